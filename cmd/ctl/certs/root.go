@@ -0,0 +1,77 @@
+package certs
+
+import (
+	"log"
+
+	"bytetrade.io/web3os/installer/cmd/ctl/options"
+	pkgcerts "bytetrade.io/web3os/installer/pkg/certs"
+	"bytetrade.io/web3os/installer/pkg/core/pipeline"
+	"bytetrade.io/web3os/installer/pkg/pipelines"
+	"github.com/spf13/cobra"
+)
+
+func NewCmdCerts() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "certs",
+		Short: "Check, renew and rotate the certificates of the cluster",
+	}
+	cmd.AddCommand(newCmdCheck())
+	cmd.AddCommand(newCmdRenew())
+	cmd.AddCommand(newCmdRotateCA())
+	return cmd
+}
+
+func newCmdCheck() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "check",
+		Short: "List the certificates of k3s or kubeadm, etcd and the registry on this node with their expiry",
+		Long:  "List the certificates of k3s or kubeadm, etcd and the registry on this node with their expiry, print them as JSON with --output json, the command fails if any of them expires within --certs-warn-days",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := pipelines.CertsCheckPipeline(); err != nil {
+				log.Fatalf("error: %v", err)
+			}
+		},
+	}
+	pipeline.AddOutputFlag(cmd.Flags(), "Output format, json to print the certificates as JSON")
+	pkgcerts.AddFlags(cmd.Flags())
+	return cmd
+}
+
+func newCmdRenew() *cobra.Command {
+	o := options.NewCertsRenewOptions()
+	cmd := &cobra.Command{
+		Use:   "renew",
+		Short: "Renew the certificates of the control plane on this master, with k3s or kubeadm",
+		Long:  "Renew the certificates of the control plane on this master, signed by the same certificate authorities, the control plane is restarted to use them, k3s is stopped meanwhile",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := pipelines.CertsRenewPipeline(o); err != nil {
+				log.Fatalf("error: %v", err)
+			}
+		},
+	}
+	o.AddFlags(cmd)
+	return cmd
+}
+
+func newCmdRotateCA() *cobra.Command {
+	o := options.NewCertsRotateCAOptions()
+	cmd := &cobra.Command{
+		Use:   "rotate-ca",
+		Short: "Replace the certificate authorities of the cluster with new ones, on its master",
+		Long: `Replace the certificate authorities of the cluster with new ones on its master, and issue again all the certificates they sign,
+the key signing the service account tokens is kept.
+
+This disrupts the cluster: the other nodes do not trust the new authorities until k3s is restarted on them,
+and the workers of a kubeadm cluster are to be deleted and added again. The former certificates of kubeadm are backed up.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := pipelines.CertsRotateCAPipeline(o); err != nil {
+				log.Fatalf("error: %v", err)
+			}
+		},
+	}
+	o.AddFlags(cmd)
+	return cmd
+}
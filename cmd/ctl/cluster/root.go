@@ -0,0 +1,12 @@
+package cluster
+
+import "github.com/spf13/cobra"
+
+func NewCmdCluster() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cluster",
+		Short: "Manage the Kubernetes cluster of Olares",
+	}
+	cmd.AddCommand(newCmdSnapshot())
+	return cmd
+}
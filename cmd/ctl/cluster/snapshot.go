@@ -0,0 +1,99 @@
+package cluster
+
+import (
+	"log"
+
+	"bytetrade.io/web3os/installer/cmd/ctl/options"
+	"bytetrade.io/web3os/installer/pkg/core/pipeline"
+	"bytetrade.io/web3os/installer/pkg/pipelines"
+	"github.com/spf13/cobra"
+)
+
+func newCmdSnapshot() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "snapshot",
+		Short: "Save and restore snapshots of the datastore of the cluster, the etcd or sqlite database of k3s or the etcd of kubeadm",
+	}
+	cmd.AddCommand(newCmdSnapshotSave())
+	cmd.AddCommand(newCmdSnapshotList())
+	cmd.AddCommand(newCmdSnapshotRestore())
+	cmd.AddCommand(newCmdSnapshotPrune())
+	return cmd
+}
+
+func newCmdSnapshotSave() *cobra.Command {
+	o := options.NewClusterSnapshotOptions()
+	cmd := &cobra.Command{
+		Use:   "save",
+		Short: "Save a snapshot of the datastore of the cluster on this master",
+		Long: `Save a snapshot of the datastore of the cluster on this master while it is running,
+then remove the former snapshots beyond --keep or older than --max-age.
+
+With --upload, the snapshots of the node are copied to the object storage of Olares, S3, OSS, COS or the managed MinIO,
+under cluster-snapshots/<node> of the prefix of the cluster, where those beyond --keep or older than --max-age are removed too.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := pipelines.ClusterSnapshotSavePipeline(o); err != nil {
+				log.Fatalf("error: %v", err)
+			}
+		},
+	}
+	o.AddFlags(cmd)
+	return cmd
+}
+
+func newCmdSnapshotList() *cobra.Command {
+	o := options.NewClusterSnapshotListOptions()
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the snapshots of the datastore kept on this node, oldest first",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := pipelines.ClusterSnapshotListPipeline(o); err != nil {
+				log.Fatalf("error: %v", err)
+			}
+		},
+	}
+	o.AddFlags(cmd)
+	pipeline.AddOutputFlag(cmd.Flags(), "Output format, json to print the snapshots as JSON")
+	return cmd
+}
+
+func newCmdSnapshotRestore() *cobra.Command {
+	o := options.NewClusterSnapshotRestoreOptions()
+	cmd := &cobra.Command{
+		Use:   "restore <name>",
+		Short: "Reset the datastore of the cluster on this master from a snapshot",
+		Long: `Reset the datastore of the cluster on this master from a snapshot, as listed by olares-cli cluster snapshot list,
+or downloaded from the object storage of Olares with --download.
+
+olaresd and the cluster are stopped meanwhile, the current datastore is moved aside and what was written since the snapshot is lost.
+The etcd of kubeadm can only be restored if it has a single member.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := pipelines.ClusterSnapshotRestorePipeline(args[0], o); err != nil {
+				log.Fatalf("error: %v", err)
+			}
+		},
+	}
+	o.AddFlags(cmd)
+	return cmd
+}
+
+func newCmdSnapshotPrune() *cobra.Command {
+	o := options.NewClusterSnapshotOptions()
+	cmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Remove the snapshots of the datastore beyond --keep or older than --max-age",
+		Long: `Remove the snapshots of the datastore kept on this node beyond --keep or older than --max-age, the most recent one is always kept,
+with --upload the snapshots in the object storage of Olares are uploaded and pruned the same way.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := pipelines.ClusterSnapshotPrunePipeline(o); err != nil {
+				log.Fatalf("error: %v", err)
+			}
+		},
+	}
+	o.AddFlags(cmd)
+	return cmd
+}
@@ -0,0 +1,145 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+
+	"bytetrade.io/web3os/installer/cmd/ctl/options"
+	"bytetrade.io/web3os/installer/pkg/config"
+	"bytetrade.io/web3os/installer/pkg/core/pipeline"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+)
+
+func NewCmdConfig() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Write, check and show the install configuration used by olares-cli install --config",
+	}
+	cmd.AddCommand(newCmdInit())
+	cmd.AddCommand(newCmdValidate())
+	cmd.AddCommand(newCmdPrintEffective())
+	return cmd
+}
+
+func newCmdInit() *cobra.Command {
+	o := options.NewConfigInitOptions()
+	cmd := &cobra.Command{
+		Use:   "init [file]",
+		Short: "Write a documented install configuration with the default settings, to stdout if no file is given",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) == 0 {
+				fmt.Print(config.Template)
+				return
+			}
+			if _, err := os.Stat(args[0]); err == nil && !o.Force {
+				log.Fatalf("error: %s already exists, use --force to overwrite it", args[0])
+			}
+			// the file is meant to hold the password of the user and the storage keys
+			if err := os.WriteFile(args[0], []byte(config.Template), 0600); err != nil {
+				log.Fatalf("error: %v", err)
+			}
+		},
+	}
+	o.AddFlags(cmd)
+	return cmd
+}
+
+type validateResult struct {
+	Path   string   `json:"path"`
+	Valid  bool     `json:"valid"`
+	Errors []string `json:"errors,omitempty"`
+}
+
+func newCmdValidate() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "validate <file>",
+		Short: "Check an install configuration, overridden by the environment variables set, print the problems found",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			result := validateResult{Path: args[0]}
+			c, err := config.Load(args[0])
+			if err != nil {
+				result.Errors = append(result.Errors, err.Error())
+			} else {
+				for _, e := range config.Validate(c) {
+					result.Errors = append(result.Errors, e.Error())
+				}
+			}
+			result.Valid = len(result.Errors) == 0
+
+			if asJSON() {
+				printJSON(result)
+			} else {
+				for _, e := range result.Errors {
+					fmt.Println("error:", e)
+				}
+				if result.Valid {
+					fmt.Printf("%s: valid\n", result.Path)
+				}
+			}
+			if !result.Valid {
+				os.Exit(1)
+			}
+		},
+	}
+	pipeline.AddOutputFlag(cmd.Flags(), "Output format, json to print the result of the checks as JSON")
+	return cmd
+}
+
+func newCmdPrintEffective() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "print-effective [file]",
+		Short: "Print the settings an install would use, from the configuration file if any, the environment variables and the defaults",
+		Long:  "Print the settings an install would use, from the configuration file if any, the environment variables and the defaults, with the secrets masked, the command line flags given to the install still take precedence over them",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var path string
+			if len(args) > 0 {
+				path = args[0]
+			}
+			c, err := config.Load(path)
+			if err != nil {
+				log.Fatalf("error: %v", err)
+			}
+			for _, e := range config.Validate(c) {
+				fmt.Fprintln(os.Stderr, "warning:", e)
+			}
+			if asJSON() {
+				printJSON(c.Redacted())
+				return
+			}
+			data, err := yaml.Marshal(c.Redacted())
+			if err != nil {
+				log.Fatalf("error: %v", err)
+			}
+			os.Stdout.Write(data)
+		},
+	}
+	pipeline.AddOutputFlag(cmd.Flags(), "Output format, json to print the settings as JSON rather than YAML")
+	return cmd
+}
+
+func printJSON(v interface{}) {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		log.Fatalf("error: %v", err)
+	}
+}
+
+func asJSON() bool {
+	switch pipeline.DefaultOptions.Output {
+	case "":
+		return false
+	case pipeline.OutputJSON:
+		return true
+	default:
+		log.Fatalf("error: --output %s is not supported by config", pipeline.DefaultOptions.Output)
+		return false
+	}
+}
@@ -0,0 +1,85 @@
+package history
+
+import (
+	"log"
+	"os"
+
+	"bytetrade.io/web3os/installer/cmd/ctl/options"
+	"bytetrade.io/web3os/installer/pkg/core/pipeline"
+	"bytetrade.io/web3os/installer/pkg/core/storage"
+	"bytetrade.io/web3os/installer/pkg/history"
+	"github.com/spf13/cobra"
+)
+
+func NewCmdHistory() *cobra.Command {
+	o := options.NewHistoryOptions()
+	cmd := &cobra.Command{
+		Use:   "history",
+		Short: "List and inspect the past runs of olares-cli on this machine",
+		Long:  "List and inspect the past runs of olares-cli recorded in the local state store, print them as JSON with --output json",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			provider := openStore(o)
+			defer provider.Close()
+			if err := history.ListRuns(os.Stdout, provider, o.Limit, asJSON()); err != nil {
+				log.Fatalf("error: %v", err)
+			}
+		},
+	}
+	o.AddFlags(cmd)
+	pipeline.AddOutputFlag(cmd.PersistentFlags(), "Output format, json to print the runs, or the inventory, as JSON")
+
+	cmd.AddCommand(newCmdShow(o))
+	cmd.AddCommand(newCmdInventory(o))
+	return cmd
+}
+
+func newCmdShow(o *options.HistoryOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "show <run-id>",
+		Short: "Show a past run with the outcome of its tasks on every host, the ID can be shortened to a unique prefix",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			provider := openStore(o)
+			defer provider.Close()
+			if err := history.ShowRun(os.Stdout, provider, args[0], asJSON()); err != nil {
+				log.Fatalf("error: %v", err)
+			}
+		},
+	}
+}
+
+func newCmdInventory(o *options.HistoryOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "inventory",
+		Short: "Show the nodes of the cluster and the versions of the components installed on them",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			provider := openStore(o)
+			defer provider.Close()
+			if err := history.ShowInventory(os.Stdout, provider, asJSON()); err != nil {
+				log.Fatalf("error: %v", err)
+			}
+		},
+	}
+}
+
+func openStore(o *options.HistoryOptions) storage.Provider {
+	provider, err := history.OpenStore(o.BaseDir)
+	if err != nil {
+		log.Fatalf("error: %v", err)
+	}
+	return provider
+}
+
+func asJSON() bool {
+	switch pipeline.DefaultOptions.Output {
+	case "":
+		return false
+	case pipeline.OutputJSON:
+		return true
+	default:
+		log.Fatalf("error: --output %s is not supported by history", pipeline.DefaultOptions.Output)
+		return false
+	}
+}
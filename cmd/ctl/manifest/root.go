@@ -0,0 +1,164 @@
+package manifest
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+
+	"bytetrade.io/web3os/installer/cmd/ctl/options"
+	"bytetrade.io/web3os/installer/pkg/core/pipeline"
+	"bytetrade.io/web3os/installer/pkg/manifest"
+	"github.com/spf13/cobra"
+)
+
+func NewCmdManifest() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "manifest",
+		Short: "Validate, convert and compare installation manifests",
+	}
+	cmd.AddCommand(newCmdValidate())
+	cmd.AddCommand(newCmdConvert())
+	cmd.AddCommand(newCmdDiff())
+	return cmd
+}
+
+type validateResult struct {
+	Path          string   `json:"path"`
+	Format        string   `json:"format"`
+	SchemaVersion int      `json:"schemaVersion,omitempty"`
+	Items         int      `json:"items"`
+	Signed        bool     `json:"signed"`
+	Errors        []string `json:"errors,omitempty"`
+}
+
+func newCmdValidate() *cobra.Command {
+	o := options.NewManifestValidateOptions()
+	cmd := &cobra.Command{
+		Use:   "validate <manifest>",
+		Short: "Check a manifest, and its detached signature if any, print the problems found",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			result := validateResult{Path: args[0]}
+			doc, err := manifest.Load(args[0])
+			if err != nil {
+				result.Errors = append(result.Errors, err.Error())
+			} else {
+				result.Format, result.SchemaVersion, result.Items, result.Signed = doc.Format, doc.SchemaVersion, len(doc.Items), doc.Signed
+				for _, e := range manifest.Validate(doc) {
+					result.Errors = append(result.Errors, e.Error())
+				}
+				if o.RequireSignature && !doc.Signed {
+					result.Errors = append(result.Errors, fmt.Sprintf("no signature found at %s", manifest.SignaturePath(args[0])))
+				}
+			}
+
+			if asJSON() {
+				printJSON(result)
+			} else {
+				for _, e := range result.Errors {
+					fmt.Println("error:", e)
+				}
+				if doc != nil {
+					fmt.Printf("%s: %d items, format %s", result.Path, result.Items, result.Format)
+					if result.SchemaVersion > 0 {
+						fmt.Printf(" (schema version %d)", result.SchemaVersion)
+					}
+					if result.Signed {
+						fmt.Print(", signature verified")
+					} else {
+						fmt.Print(", not signed")
+					}
+					fmt.Println()
+				}
+			}
+			if len(result.Errors) > 0 {
+				os.Exit(1)
+			}
+		},
+	}
+	o.AddFlags(cmd)
+	pipeline.AddOutputFlag(cmd.Flags(), "Output format, json to print the result of the checks as JSON")
+	return cmd
+}
+
+func newCmdConvert() *cobra.Command {
+	o := options.NewManifestConvertOptions()
+	cmd := &cobra.Command{
+		Use:   "convert <manifest>",
+		Short: "Convert a manifest between the legacy csv format and the structured yaml and json ones",
+		Long:  "Convert a manifest between the legacy csv format and the structured yaml and json ones, the signature of the source manifest does not apply to the converted one, which has to be signed again",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			doc, err := manifest.Load(args[0])
+			if err != nil {
+				log.Fatalf("error: %v", err)
+			}
+			data, err := manifest.Encode(doc.Items, o.Format)
+			if err != nil {
+				log.Fatalf("error: %v", err)
+			}
+			if o.Target == "" {
+				os.Stdout.Write(data)
+				return
+			}
+			if err := os.WriteFile(o.Target, data, 0644); err != nil {
+				log.Fatalf("error: %v", err)
+			}
+		},
+	}
+	o.AddFlags(cmd)
+	return cmd
+}
+
+func newCmdDiff() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "diff <old-manifest> <new-manifest>",
+		Short: "Show the items added, removed and modified between two manifests, whatever their formats",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			old, err := manifest.Load(args[0])
+			if err != nil {
+				log.Fatalf("error: %v", err)
+			}
+			new, err := manifest.Load(args[1])
+			if err != nil {
+				log.Fatalf("error: %v", err)
+			}
+			changes := manifest.Diff(old.Manifest(), new.Manifest())
+			if asJSON() {
+				if changes == nil {
+					changes = []manifest.Change{}
+				}
+				printJSON(changes)
+				return
+			}
+			for _, c := range changes {
+				fmt.Println(c)
+			}
+		},
+	}
+	pipeline.AddOutputFlag(cmd.Flags(), "Output format, json to print the changes as JSON")
+	return cmd
+}
+
+func printJSON(v interface{}) {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		log.Fatalf("error: %v", err)
+	}
+}
+
+func asJSON() bool {
+	switch pipeline.DefaultOptions.Output {
+	case "":
+		return false
+	case pipeline.OutputJSON:
+		return true
+	default:
+		log.Fatalf("error: --output %s is not supported by manifest", pipeline.DefaultOptions.Output)
+		return false
+	}
+}
@@ -2,6 +2,7 @@ package node
 
 import (
 	"bytetrade.io/web3os/installer/cmd/ctl/options"
+	"bytetrade.io/web3os/installer/pkg/config"
 	"bytetrade.io/web3os/installer/pkg/pipelines"
 	"github.com/spf13/cobra"
 	"log"
@@ -11,8 +12,11 @@ func NewCmdAddNode() *cobra.Command {
 	o := options.NewAddNodeOptions()
 	cmd := &cobra.Command{
 		Use:   "add",
-		Short: "add worker node to the cluster",
+		Short: "add worker node, or an additional master node with --role master, to the cluster",
 		Run: func(cmd *cobra.Command, args []string) {
+			if err := config.Apply(o.ConfigFile, cmd.Flags()); err != nil {
+				log.Fatal(err)
+			}
 			if err := pipelines.AddNodePipeline(o); err != nil {
 				log.Fatal(err)
 			}
@@ -1 +1,28 @@
 package node
+
+import (
+	"log"
+
+	"bytetrade.io/web3os/installer/cmd/ctl/options"
+	"bytetrade.io/web3os/installer/pkg/pipelines"
+	"github.com/spf13/cobra"
+)
+
+func NewCmdDeleteNode() *cobra.Command {
+	o := options.NewDeleteNodeOptions()
+	cmd := &cobra.Command{
+		Use:   "delete <name>",
+		Short: "delete a worker node, or an additional master node, from the cluster",
+		Long: "Cordon and drain a node, then delete it from the cluster, either from a master or from the node itself. " +
+			"A master can only be deleted from a k3s cluster with other masters left, its etcd member is removed as well. " +
+			"On the node itself, its Kubernetes components, etcd, JuiceFS mount and saved master config are also removed, so that it can be added again",
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := pipelines.DeleteNodePipeline(args[0], o); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+	o.AddFlags(cmd)
+	return cmd
+}
@@ -0,0 +1,51 @@
+package node
+
+import (
+	"log"
+
+	"bytetrade.io/web3os/installer/cmd/ctl/options"
+	"bytetrade.io/web3os/installer/pkg/core/pipeline"
+	"bytetrade.io/web3os/installer/pkg/pipelines"
+	"github.com/spf13/cobra"
+)
+
+func NewCmdListNodes() *cobra.Command {
+	o := options.NewNodeOptions()
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "list the nodes of the cluster with their roles, versions and GPU labels",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := pipelines.ListNodesPipeline(o); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+	o.AddFlags(cmd)
+	pipeline.AddOutputFlag(cmd.Flags(), "Output format, json to print the nodes as JSON")
+	return cmd
+}
+
+func NewCmdCordonNode() *cobra.Command {
+	return newCmdCordon("cordon", "mark a node as unschedulable, the pods running on it are kept", false)
+}
+
+func NewCmdUncordonNode() *cobra.Command {
+	return newCmdCordon("uncordon", "mark a node as schedulable again", true)
+}
+
+func newCmdCordon(use, short string, uncordon bool) *cobra.Command {
+	o := options.NewNodeOptions()
+	cmd := &cobra.Command{
+		Use:   use + " <name>",
+		Short: short,
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := pipelines.CordonNodePipeline(args[0], uncordon, o); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+	o.AddFlags(cmd)
+	return cmd
+}
@@ -9,5 +9,9 @@ func NewNodeCommand() *cobra.Command {
 	}
 	cmd.AddCommand(NewCmdMasterInfo())
 	cmd.AddCommand(NewCmdAddNode())
+	cmd.AddCommand(NewCmdDeleteNode())
+	cmd.AddCommand(NewCmdListNodes())
+	cmd.AddCommand(NewCmdCordonNode())
+	cmd.AddCommand(NewCmdUncordonNode())
 	return cmd
 }
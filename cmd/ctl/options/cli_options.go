@@ -1,8 +1,12 @@
 package options
 
 import (
+	"time"
+
 	"bytetrade.io/web3os/installer/pkg/common"
 	cc "bytetrade.io/web3os/installer/pkg/core/common"
+	"bytetrade.io/web3os/installer/pkg/core/pipeline"
+	"bytetrade.io/web3os/installer/pkg/manifest"
 	"bytetrade.io/web3os/installer/pkg/phase/cluster"
 	"github.com/spf13/cobra"
 )
@@ -33,7 +37,9 @@ type CliTerminusInstallOptions struct {
 	WithJuiceFS     bool
 	MiniKubeProfile string
 	BaseDir         string
+	ConfigFile      string
 	common.SwapConfig
+	pipeline.Options
 }
 
 func NewCliTerminusInstallOptions() *CliTerminusInstallOptions {
@@ -46,7 +52,9 @@ func (o *CliTerminusInstallOptions) AddFlags(cmd *cobra.Command) {
 	cmd.Flags().BoolVar(&o.WithJuiceFS, "with-juicefs", false, "Use JuiceFS as the rootfs for Olares workloads, rather than the local disk.")
 	cmd.Flags().StringVarP(&o.MiniKubeProfile, "profile", "p", "", "Set Minikube profile name, only in MacOS platform, defaults to "+common.MinikubeDefaultProfile)
 	cmd.Flags().StringVarP(&o.BaseDir, "base-dir", "b", "", "Set Olares package base dir, defaults to $HOME/"+cc.DefaultBaseDir)
+	cmd.Flags().StringVar(&o.ConfigFile, "config", "", "Read the settings from an install configuration file, see olares-cli config init, the flags and environment variables given override it")
 	(&o.SwapConfig).AddFlags(cmd.Flags())
+	(&o.Options).AddFlags(cmd.Flags())
 }
 
 type CliPrepareSystemOptions struct {
@@ -55,6 +63,10 @@ type CliPrepareSystemOptions struct {
 	RegistryMirrors string
 	BaseDir         string
 	MinikubeProfile string
+	SkipChecks      []string
+	LocalRegistry   bool
+	SkipImages      bool
+	pipeline.Options
 }
 
 func NewCliPrepareSystemOptions() *CliPrepareSystemOptions {
@@ -67,6 +79,10 @@ func (o *CliPrepareSystemOptions) AddFlags(cmd *cobra.Command) {
 	cmd.Flags().StringVarP(&o.RegistryMirrors, "registry-mirrors", "r", "", "Docker Container registry mirrors, multiple mirrors are separated by commas")
 	cmd.Flags().StringVarP(&o.BaseDir, "base-dir", "b", "", "Set Olares package base dir, defaults to $HOME/"+cc.DefaultBaseDir)
 	cmd.Flags().StringVarP(&o.MinikubeProfile, "profile", "p", "", "Set Minikube profile name, only in MacOS platform, defaults to "+common.MinikubeDefaultProfile)
+	cmd.Flags().StringSliceVar(&o.SkipChecks, "skip-check", nil, "Skip the named precheck, e.g., Swap, can be repeated or comma separated")
+	cmd.Flags().BoolVar(&o.LocalRegistry, "local-registry", false, "Push the images to a registry served on this node rather than importing them into containerd, for every node of the cluster to pull them from it, only on the master node of a k3s cluster on Linux")
+	cmd.Flags().BoolVar(&o.SkipImages, "skip-images", false, "Do not preload the images, e.g., on a worker node pulling them from the local registry of the master node")
+	(&o.Options).AddFlags(cmd.Flags())
 }
 
 type ChangeIPOptions struct {
@@ -90,8 +106,9 @@ func (o *ChangeIPOptions) AddFlags(cmd *cobra.Command) {
 }
 
 type PreCheckOptions struct {
-	Version string
-	BaseDir string
+	Version    string
+	BaseDir    string
+	SkipChecks []string
 }
 
 func NewPreCheckOptions() *PreCheckOptions {
@@ -101,6 +118,7 @@ func NewPreCheckOptions() *PreCheckOptions {
 func (o *PreCheckOptions) AddFlags(cmd *cobra.Command) {
 	cmd.Flags().StringVarP(&o.Version, "version", "v", "", "Set Olares version, e.g., 1.10.0, 1.10.0-20241109")
 	cmd.Flags().StringVarP(&o.BaseDir, "base-dir", "b", "", "Set Olares package base dir, defaults to $HOME/"+cc.DefaultBaseDir)
+	cmd.Flags().StringSliceVar(&o.SkipChecks, "skip-check", nil, "Skip the named precheck, e.g., Swap, can be repeated or comma separated")
 }
 
 type InstallStorageOptions struct {
@@ -119,8 +137,11 @@ func (o *InstallStorageOptions) AddFlags(cmd *cobra.Command) {
 
 type AddNodeOptions struct {
 	common.MasterHostConfig
-	Version string
-	BaseDir string
+	pipeline.Options
+	Version    string
+	BaseDir    string
+	ConfigFile string
+	Role       string
 }
 
 func NewAddNodeOptions() *AddNodeOptions {
@@ -130,7 +151,10 @@ func NewAddNodeOptions() *AddNodeOptions {
 func (o *AddNodeOptions) AddFlags(cmd *cobra.Command) {
 	cmd.Flags().StringVarP(&o.Version, "version", "v", "", "Set Olares version, e.g., 1.10.0, 1.10.0-20241109")
 	cmd.Flags().StringVarP(&o.BaseDir, "base-dir", "b", "", "Set Olares package base dir, defaults to $HOME/"+cc.DefaultBaseDir)
+	cmd.Flags().StringVar(&o.ConfigFile, "config", "", "Read the master host settings from an install configuration file, the flags given override it")
+	cmd.Flags().StringVar(&o.Role, "role", common.Worker, "Role of the node in the cluster, one of: worker, master. A master runs an additional control plane and etcd member, only supported for k3s")
 	(&o.MasterHostConfig).AddFlags(cmd.Flags())
+	(&o.Options).AddFlags(cmd.Flags())
 }
 
 type MasterInfoOptions struct {
@@ -147,9 +171,40 @@ func (o *MasterInfoOptions) AddFlags(cmd *cobra.Command) {
 	(&o.MasterHostConfig).AddFlags(cmd.Flags())
 }
 
+type DeleteNodeOptions struct {
+	BaseDir      string
+	DrainTimeout time.Duration
+	pipeline.Options
+}
+
+func NewDeleteNodeOptions() *DeleteNodeOptions {
+	return &DeleteNodeOptions{DrainTimeout: 5 * time.Minute}
+}
+
+func (o *DeleteNodeOptions) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&o.BaseDir, "base-dir", "b", "", "Set Olares package base dir, defaults to $HOME/"+cc.DefaultBaseDir)
+	cmd.Flags().DurationVar(&o.DrainTimeout, "drain-timeout", o.DrainTimeout, "How long to wait for the pods to be evicted from the node before giving up")
+	(&o.Options).AddFlags(cmd.Flags())
+}
+
+type NodeOptions struct {
+	BaseDir string
+}
+
+func NewNodeOptions() *NodeOptions {
+	return &NodeOptions{}
+}
+
+func (o *NodeOptions) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&o.BaseDir, "base-dir", "b", "", "Set Olares package base dir, defaults to $HOME/"+cc.DefaultBaseDir)
+}
+
 type UpgradeOptions struct {
 	Version string
 	BaseDir string
+	// NoRollback keeps the system as the failed upgrade left it, rather than restoring the snapshot taken before it
+	NoRollback bool
+	pipeline.Options
 }
 
 func NewUpgradeOptions() *UpgradeOptions {
@@ -159,4 +214,220 @@ func NewUpgradeOptions() *UpgradeOptions {
 func (o *UpgradeOptions) AddFlags(cmd *cobra.Command) {
 	cmd.Flags().StringVarP(&o.Version, "version", "v", "", "Set target Olares version to upgrade to, e.g., 1.10.0, 1.10.0-20241109")
 	cmd.Flags().StringVarP(&o.BaseDir, "base-dir", "b", "", "Set Olares package base dir, defaults to $HOME/"+cc.DefaultBaseDir)
+	cmd.Flags().BoolVar(&o.NoRollback, "no-rollback", false, "Do not restore the snapshot taken before the upgrade if it fails, e.g., to investigate the failure, it can be restored later with olares-cli upgrade rollback")
+	(&o.Options).AddFlags(cmd.Flags())
+}
+
+type UpgradePlanOptions struct {
+	Version string
+	BaseDir string
+}
+
+func NewUpgradePlanOptions() *UpgradePlanOptions {
+	return &UpgradePlanOptions{}
+}
+
+func (o *UpgradePlanOptions) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&o.Version, "version", "v", "", "Set target Olares version to plan the upgrade to, e.g., 1.10.0, 1.10.0-20241109")
+	cmd.Flags().StringVarP(&o.BaseDir, "base-dir", "b", "", "Set Olares package base dir, defaults to $HOME/"+cc.DefaultBaseDir)
+}
+
+type UpgradeRollbackOptions struct {
+	BaseDir  string
+	Snapshot string
+	pipeline.Options
+}
+
+func NewUpgradeRollbackOptions() *UpgradeRollbackOptions {
+	return &UpgradeRollbackOptions{}
+}
+
+func (o *UpgradeRollbackOptions) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&o.BaseDir, "base-dir", "b", "", "Set Olares package base dir, defaults to $HOME/"+cc.DefaultBaseDir)
+	cmd.Flags().StringVar(&o.Snapshot, "snapshot", "", "Directory of the snapshot taken before the upgrade to roll back, defaults to the latest one")
+	(&o.Options).AddFlags(cmd.Flags())
+}
+
+type UpgradeKubeOptions struct {
+	Version      string
+	BaseDir      string
+	Manifest     string
+	DrainTimeout time.Duration
+	pipeline.Options
+}
+
+func NewUpgradeKubeOptions() *UpgradeKubeOptions {
+	return &UpgradeKubeOptions{DrainTimeout: 5 * time.Minute}
+}
+
+func (o *UpgradeKubeOptions) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&o.Version, "version", "", "Kubernetes version to upgrade the node to, e.g. v1.33.3, the binaries of the manifest are to be of this version")
+	cmd.Flags().StringVarP(&o.BaseDir, "base-dir", "b", "", "Set Olares package base dir, defaults to $HOME/"+cc.DefaultBaseDir)
+	cmd.Flags().StringVar(&o.Manifest, "manifest", "", "Set installation manifest file, defaults to that of the installation package of the current version")
+	cmd.Flags().DurationVar(&o.DrainTimeout, "drain-timeout", o.DrainTimeout, "How long to wait for the pods to be evicted from the node before giving up")
+	(&o.Options).AddFlags(cmd.Flags())
+}
+
+type CertsRenewOptions struct {
+	BaseDir    string
+	Components []string
+	pipeline.Options
+}
+
+func NewCertsRenewOptions() *CertsRenewOptions {
+	return &CertsRenewOptions{}
+}
+
+func (o *CertsRenewOptions) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&o.BaseDir, "base-dir", "b", "", "Set Olares package base dir, defaults to $HOME/"+cc.DefaultBaseDir)
+	cmd.Flags().StringSliceVar(&o.Components, "component", nil, "Certificates to renew, all of them by default: "+
+		"those known to kubeadm certs renew, e.g. apiserver or admin.conf, or the services known to k3s certificate rotate, e.g. api-server or kubelet")
+	(&o.Options).AddFlags(cmd.Flags())
+}
+
+type CertsRotateCAOptions struct {
+	BaseDir string
+	pipeline.Options
+}
+
+func NewCertsRotateCAOptions() *CertsRotateCAOptions {
+	return &CertsRotateCAOptions{}
+}
+
+func (o *CertsRotateCAOptions) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&o.BaseDir, "base-dir", "b", "", "Set Olares package base dir, defaults to $HOME/"+cc.DefaultBaseDir)
+	(&o.Options).AddFlags(cmd.Flags())
+}
+
+// ClusterSnapshotOptions are those of cluster snapshot save and prune
+type ClusterSnapshotOptions struct {
+	BaseDir string
+	Keep    int
+	MaxAge  time.Duration
+	Upload  bool
+	pipeline.Options
+}
+
+func NewClusterSnapshotOptions() *ClusterSnapshotOptions {
+	return &ClusterSnapshotOptions{Keep: 5}
+}
+
+func (o *ClusterSnapshotOptions) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&o.BaseDir, "base-dir", "b", "", "Set Olares package base dir, defaults to $HOME/"+cc.DefaultBaseDir)
+	cmd.Flags().IntVar(&o.Keep, "keep", o.Keep, "Number of the most recent snapshots to keep, 0 to keep them all")
+	cmd.Flags().DurationVar(&o.MaxAge, "max-age", 0, "Remove the snapshots older than this, e.g. 168h, the most recent one is always kept, 0 to keep them whatever their age")
+	cmd.Flags().BoolVar(&o.Upload, "upload", false, "Copy the snapshots to the object storage of Olares and remove those beyond --keep or older than --max-age from it")
+	(&o.Options).AddFlags(cmd.Flags())
+}
+
+type ClusterSnapshotListOptions struct {
+	BaseDir string
+}
+
+func NewClusterSnapshotListOptions() *ClusterSnapshotListOptions {
+	return &ClusterSnapshotListOptions{}
+}
+
+func (o *ClusterSnapshotListOptions) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&o.BaseDir, "base-dir", "b", "", "Set Olares package base dir, defaults to $HOME/"+cc.DefaultBaseDir)
+}
+
+type ClusterSnapshotRestoreOptions struct {
+	BaseDir  string
+	Download bool
+	pipeline.Options
+}
+
+func NewClusterSnapshotRestoreOptions() *ClusterSnapshotRestoreOptions {
+	return &ClusterSnapshotRestoreOptions{}
+}
+
+func (o *ClusterSnapshotRestoreOptions) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&o.BaseDir, "base-dir", "b", "", "Set Olares package base dir, defaults to $HOME/"+cc.DefaultBaseDir)
+	cmd.Flags().BoolVar(&o.Download, "download", false, "Download the snapshot from the object storage of Olares if it is not kept on the node")
+	(&o.Options).AddFlags(cmd.Flags())
+}
+
+// StopOptions are those of olares-cli stop
+type StopOptions struct {
+	Timeout       time.Duration
+	CheckInterval time.Duration
+	StageTimeout  time.Duration
+	SkipDrain     bool
+}
+
+func NewStopOptions() *StopOptions {
+	return &StopOptions{Timeout: 1 * time.Minute, CheckInterval: 10 * time.Second, StageTimeout: 5 * time.Minute}
+}
+
+func (o *StopOptions) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().DurationVarP(&o.Timeout, "timeout", "t", o.Timeout, "Timeout for graceful shutdown before using SIGKILL")
+	cmd.Flags().DurationVarP(&o.CheckInterval, "check-interval", "i", o.CheckInterval, "Interval between checks for remaining processes")
+	cmd.Flags().DurationVar(&o.StageTimeout, "stage-timeout", o.StageTimeout, "Timeout of each stage waiting for the workloads to shut down, the volumes to detach and the files on JuiceFS to be closed")
+	cmd.Flags().BoolVar(&o.SkipDrain, "skip-drain", false, "Stop the services right away, without scaling down the workloads and waiting for the files on JuiceFS to be closed first")
+}
+
+// StartOptions are those of olares-cli start
+type StartOptions struct {
+	StageTimeout time.Duration
+}
+
+func NewStartOptions() *StartOptions {
+	return &StartOptions{StageTimeout: 10 * time.Minute}
+}
+
+func (o *StartOptions) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().DurationVar(&o.StageTimeout, "stage-timeout", o.StageTimeout, "Timeout of each stage waiting for the API server, and for the pods of Olares to be running")
+}
+
+type HistoryOptions struct {
+	BaseDir string
+	Limit   int
+}
+
+func NewHistoryOptions() *HistoryOptions {
+	return &HistoryOptions{Limit: 20}
+}
+
+func (o *HistoryOptions) AddFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVarP(&o.BaseDir, "base-dir", "b", "", "Set Olares package base dir, defaults to the one of the current installation or $HOME/"+cc.DefaultBaseDir)
+	cmd.Flags().IntVar(&o.Limit, "limit", o.Limit, "Maximum number of runs to list, most recent first")
+}
+
+type ManifestValidateOptions struct {
+	RequireSignature bool
+}
+
+func NewManifestValidateOptions() *ManifestValidateOptions {
+	return &ManifestValidateOptions{}
+}
+
+func (o *ManifestValidateOptions) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&o.RequireSignature, "require-signature", false, "Fail if the manifest has no detached signature next to it")
+}
+
+type ManifestConvertOptions struct {
+	Format string
+	Target string
+}
+
+func NewManifestConvertOptions() *ManifestConvertOptions {
+	return &ManifestConvertOptions{Format: manifest.FormatYAML}
+}
+
+func (o *ManifestConvertOptions) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&o.Format, "format", o.Format, "Format to convert the manifest to, one of: yaml, json, csv (the legacy format)")
+	cmd.Flags().StringVar(&o.Target, "to", "", "File to write the converted manifest to, defaults to stdout")
+}
+
+type ConfigInitOptions struct {
+	Force bool
+}
+
+func NewConfigInitOptions() *ConfigInitOptions {
+	return &ConfigInitOptions{}
+}
+
+func (o *ConfigInitOptions) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&o.Force, "force", false, "Overwrite the file if it exists")
 }
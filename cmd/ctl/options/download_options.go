@@ -29,10 +29,12 @@ type CliDownloadOptions struct {
 	Manifest       string
 	BaseDir        string
 	DownloadCdnUrl string
+	Parallel       int
+	LimitRate      string
 }
 
 func NewCliDownloadOptions() *CliDownloadOptions {
-	return &CliDownloadOptions{}
+	return &CliDownloadOptions{Parallel: 4}
 }
 
 func (o *CliDownloadOptions) AddFlags(cmd *cobra.Command) {
@@ -40,5 +42,37 @@ func (o *CliDownloadOptions) AddFlags(cmd *cobra.Command) {
 	cmd.Flags().StringVarP(&o.BaseDir, "base-dir", "b", "", "Set Olares package base dir , defaults to $HOME/"+cc.DefaultBaseDir)
 	cmd.Flags().StringVar(&o.Manifest, "manifest", "", "Set package manifest file , defaults to {base-dir}/versions/v{version}/installation.manifest")
 	cmd.Flags().StringVar(&o.KubeType, "kube", "k3s", "Set kube type, e.g., k3s or k8s")
-	cmd.Flags().StringVar(&o.DownloadCdnUrl, "download-cdn-url", "", "Set the CDN accelerated download address in the format https://example.cdn.com. If not set, the default download address will be used")
+	cmd.Flags().StringVar(&o.DownloadCdnUrl, "download-cdn-url", "", "Set the CDN accelerated download address in the format https://example.cdn.com, or a comma separated list of them tried in order when a download fails. If not set, the default download address will be used")
+	cmd.Flags().IntVar(&o.Parallel, "parallel", o.Parallel, "Number of files downloaded at the same time")
+	cmd.Flags().StringVar(&o.LimitRate, "limit-rate", "", "Cap the total download bandwidth, in bytes per second with an optional suffix, e.g., 512Ki, 10M")
+}
+
+type CliDownloadBundleOptions struct {
+	CliDownloadOptions
+	Arch   []string
+	Target string
+}
+
+func NewCliDownloadBundleOptions() *CliDownloadBundleOptions {
+	return &CliDownloadBundleOptions{CliDownloadOptions: *NewCliDownloadOptions()}
+}
+
+func (o *CliDownloadBundleOptions) AddFlags(cmd *cobra.Command) {
+	o.CliDownloadOptions.AddFlags(cmd)
+	cmd.Flags().StringSliceVar(&o.Arch, "arch", nil, "Architectures to bundle the packages of, e.g., amd64,arm64, defaults to the one of this machine")
+	cmd.Flags().StringVarP(&o.Target, "out", "o", "", "Path of the bundle to write, defaults to olares-{version}.tar.zst in the current directory")
+}
+
+type CliImportBundleOptions struct {
+	Version string
+	BaseDir string
+}
+
+func NewCliImportBundleOptions() *CliImportBundleOptions {
+	return &CliImportBundleOptions{}
+}
+
+func (o *CliImportBundleOptions) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&o.Version, "version", "v", "", "Set Olares version, e.g., 1.10.0, 1.10.0-20241109, the import fails if the bundle is for another version, defaults to the one of the bundle")
+	cmd.Flags().StringVarP(&o.BaseDir, "base-dir", "b", "", "Set Olares package base dir , defaults to $HOME/"+cc.DefaultBaseDir)
 }
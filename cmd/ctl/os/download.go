@@ -16,6 +16,8 @@ func NewCmdRootDownload() *cobra.Command {
 	rootDownloadCmd.AddCommand(NewCmdCheckDownload())
 	rootDownloadCmd.AddCommand(NewCmdDownload())
 	rootDownloadCmd.AddCommand(NewCmdDownloadWizard())
+	rootDownloadCmd.AddCommand(NewCmdDownloadBundle())
+	rootDownloadCmd.AddCommand(NewCmdImportBundle())
 
 	return rootDownloadCmd
 }
@@ -70,3 +72,39 @@ func NewCmdCheckDownload() *cobra.Command {
 	o.AddFlags(cmd)
 	return cmd
 }
+
+func NewCmdDownloadBundle() *cobra.Command {
+	o := options.NewCliDownloadBundleOptions()
+	cmd := &cobra.Command{
+		Use:   "bundle",
+		Short: "Pack the installer, the manifest and all the packages into an offline bundle for air-gapped installs",
+		Long:  "Pack the installer, the manifest and all the packages into an offline bundle for air-gapped installs, the packages missing locally are downloaded first. The installation wizard of the version must have been downloaded.",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			if err := pipelines.ExportOfflineBundle(o); err != nil {
+				log.Fatalf("error: %v", err)
+			}
+		},
+	}
+
+	o.AddFlags(cmd)
+	return cmd
+}
+
+func NewCmdImportBundle() *cobra.Command {
+	o := options.NewCliImportBundleOptions()
+	cmd := &cobra.Command{
+		Use:   "import <bundle>",
+		Short: "Verify an offline bundle and unpack it into the base dir, then check the packages as the download check does",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+
+			if err := pipelines.ImportOfflineBundle(args[0], o); err != nil {
+				log.Fatalf("error: %v", err)
+			}
+		},
+	}
+
+	o.AddFlags(cmd)
+	return cmd
+}
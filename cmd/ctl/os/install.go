@@ -4,6 +4,7 @@ import (
 	"log"
 
 	"bytetrade.io/web3os/installer/cmd/ctl/options"
+	"bytetrade.io/web3os/installer/pkg/config"
 	"bytetrade.io/web3os/installer/pkg/pipelines"
 	"github.com/spf13/cobra"
 )
@@ -24,6 +25,9 @@ func NewCmdInstallOs() *cobra.Command {
 		Use:   "install",
 		Short: "Install Olares",
 		Run: func(cmd *cobra.Command, args []string) {
+			if err := config.Apply(o.InstallOptions.ConfigFile, cmd.Flags()); err != nil {
+				log.Fatalf("error: %v", err)
+			}
 			if err := pipelines.CliInstallTerminusPipeline(o.InstallOptions); err != nil {
 				log.Fatalf("error: %v", err)
 			}
@@ -1,28 +1,42 @@
 package os
 
 import (
-	"archive/tar"
-	"bytetrade.io/web3os/installer/pkg/common"
-	"bytetrade.io/web3os/installer/pkg/core/util"
-	"compress/gzip"
+	"bytes"
+	"encoding/json"
 	"fmt"
-	"io"
 	"log"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"text/tabwriter"
 	"time"
 
+	"bytetrade.io/web3os/installer/pkg/common"
+	"bytetrade.io/web3os/installer/pkg/core/connector"
+	"bytetrade.io/web3os/installer/pkg/core/logger"
+	"bytetrade.io/web3os/installer/pkg/core/pipeline"
+	"bytetrade.io/web3os/installer/pkg/core/util"
+	daemontemplates "bytetrade.io/web3os/installer/pkg/daemon/templates"
+	"bytetrade.io/web3os/installer/pkg/history"
+	"bytetrade.io/web3os/installer/pkg/storage"
+	"bytetrade.io/web3os/installer/pkg/supportbundle"
+	"bytetrade.io/web3os/installer/version"
+	corev1 "k8s.io/api/core/v1"
+
 	"github.com/spf13/cobra"
 )
 
 // LogCollectOptions holds options for collecting logs
 type LogCollectOptions struct {
-	// Time duration to collect logs for (empty means all available logs)
+	// Start of the time window of the logs, a duration before now or a time (empty means all available logs)
 	Since string
+	// End of the time window of the logs, a duration before now or a time (empty means up to now)
+	Until string
 	// Maximum number of lines to collect per log source
 	MaxLines int
+	// Maximum size in MiB of the collected logs before compression (0 means unlimited)
+	MaxSizeMB int
 	// Output directory for collected logs
 	OutputDir string
 	// Components to collect logs from (empty means all)
@@ -33,111 +47,211 @@ type LogCollectOptions struct {
 
 var servicesToCollectLogs = []string{"k3s", "containerd", "olaresd", "kubelet", "juicefs", "redis", "minio", "etcd", "NetworkManager"}
 
+// terminusAPIGroupSuffix is that of the API groups of the custom resources of Olares
+const terminusAPIGroupSuffix = ".bytetrade.io"
+
+// maxRunsToCollect is how many of the latest runs of olares-cli are collected from the history
+const maxRunsToCollect = 100
+
 func collectLogs(options *LogCollectOptions) error {
 	if os.Getuid() != 0 {
 		return fmt.Errorf("os: please run as root")
 	}
+	window, err := supportbundle.ParseWindow(options.Since, options.Until, time.Now())
+	if err != nil {
+		return err
+	}
 	if err := os.MkdirAll(options.OutputDir, 0755); err != nil {
 		return fmt.Errorf("failed to create output directory: %v", err)
 	}
 
+	registerHostSecrets()
+
 	timestamp := time.Now().Format("20060102-150405")
 	archiveName := filepath.Join(options.OutputDir, fmt.Sprintf("olares-logs-%s.tar.gz", timestamp))
 
-	archive, err := os.Create(archiveName)
+	manifest := supportbundle.Manifest{
+		CLIVersion: version.VERSION,
+		CreatedAt:  time.Now(),
+		MaxSize:    int64(options.MaxSizeMB) * 1024 * 1024,
+	}
+	if !window.Since.IsZero() {
+		manifest.Since = &window.Since
+	}
+	if !window.Until.IsZero() {
+		manifest.Until = &window.Until
+	}
+	bw, err := supportbundle.Create(archiveName, manifest)
 	if err != nil {
-		return fmt.Errorf("failed to create archive: %v", err)
+		return err
+	}
+	if err := collectAll(bw, options, window); err != nil {
+		_ = bw.Close()
+		return err
+	}
+	if err := bw.Close(); err != nil {
+		return err
 	}
-	defer archive.Close()
 
-	gw := gzip.NewWriter(archive)
-	defer gw.Close()
+	collected := bw.Manifest()
+	fmt.Printf("logs have been collected and archived in: %s\n", archiveName)
+	if len(collected.Skipped) > 0 {
+		fmt.Printf("warning: %d item(s) left out as the logs reached --max-size-mb, see %s\n", len(collected.Skipped), supportbundle.ManifestFile)
+	}
+	if len(collected.Errors) > 0 {
+		fmt.Printf("warning: %d item(s) failed to be collected, see %s\n", len(collected.Errors), supportbundle.ManifestFile)
+	}
+	return nil
+}
 
-	tw := tar.NewWriter(gw)
-	defer tw.Close()
+// collectAll collects everything into the bundle, the most useful first as the bundle may reach its maximum size,
+// what fails to be collected is recorded in the manifest, the errors returned are those of the bundle itself
+// and of kubectl unless they are ignored
+func collectAll(bw *supportbundle.Writer, options *LogCollectOptions, window supportbundle.Window) error {
+	fmt.Println("collecting system info ...")
+	if err := collectSystemInfo(bw); err != nil {
+		return fmt.Errorf("failed to collect system info: %v", err)
+	}
 
 	// collect systemd service logs
-	if err := collectSystemdLogs(tw, options); err != nil {
+	if err := collectSystemdLogs(bw, options, window); err != nil {
 		return fmt.Errorf("failed to collect systemd logs: %v", err)
 	}
 
 	fmt.Println("collecting dmesg logs ...")
-	if err := collectDmesgLogs(tw, options); err != nil {
+	if err := collectDmesgLogs(bw, options); err != nil {
 		return fmt.Errorf("failed to collect dmesg logs: %v", err)
 	}
 
-	fmt.Println("collecting logs from kubernetes cluster...")
-	if err := collectKubernetesLogs(tw, options); err != nil {
-		return fmt.Errorf("failed to collect kubernetes logs: %v", err)
+	fmt.Println("collecting olares-cli logs and history...")
+	if err := collectOlaresCLILogs(bw, options, window); err != nil {
+		return fmt.Errorf("failed to collect OlaresCLI logs: %v", err)
 	}
 
-	fmt.Println("collecting olares-cli logs...")
-	if err := collectOlaresCLILogs(tw, options); err != nil {
-		return fmt.Errorf("failed to collect OlaresCLI logs: %v", err)
+	fmt.Println("collecting logs and state from kubernetes cluster...")
+	if err := collectKubernetesLogs(bw, options, window); err != nil {
+		return fmt.Errorf("failed to collect kubernetes logs: %v", err)
 	}
 
 	fmt.Println("collecting network configs...")
-	if err := collectNetworkConfigs(tw, options); err != nil {
+	if err := collectNetworkConfigs(bw, options); err != nil {
 		return fmt.Errorf("failed to collect network configs: %v", err)
 	}
+	return nil
+}
+
+// collectSystemInfo adds the system info to the manifest, and the release file and the env of olaresd to the bundle
+func collectSystemInfo(bw *supportbundle.Writer) (err error) {
+	func() {
+		// getting the system info panics if the local ip cannot be found
+		defer func() {
+			if r := recover(); r != nil {
+				bw.AddError("system info", fmt.Errorf("%v", r))
+			}
+		}()
+		bw.SetSystemInfo(connector.GetSystemInfo())
+	}()
+	arg := &common.Argument{}
+	if err := arg.LoadReleaseInfo(); err != nil {
+		bw.AddError(common.OlaresReleaseFile, err)
+	}
+	bw.SetOlaresVersion(arg.OlaresVersion)
 
-	fmt.Printf("logs have been collected and archived in: %s\n", archiveName)
+	for name, file := range map[string]string{
+		"olares/release":     common.OlaresReleaseFile,
+		"olares/olaresd.env": filepath.Join("/etc/systemd/system", daemontemplates.TerminusdEnv.Name()),
+	} {
+		if err := addFile(bw, name, file, nil); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
-func collectOlaresCLILogs(tw *tar.Writer, options *LogCollectOptions) error {
-	basedir, err := getBaseDir()
+// addFile adds the file to the bundle under name, see supportbundle.Writer.Add,
+// a file that is not found is skipped and one that cannot be read recorded in the manifest
+func addFile(bw *supportbundle.Writer, name, file string, keep func(line string) bool) error {
+	f, err := os.Open(file)
 	if err != nil {
-		return err
+		if !os.IsNotExist(err) {
+			bw.AddError(file, err)
+		}
+		return nil
 	}
-	cliLogDir := filepath.Join(basedir, "logs")
-	if _, err := os.Stat(cliLogDir); err != nil {
-		fmt.Printf("warning: directory %s does not exist, skipping collecting olares-cli logs\n", cliLogDir)
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		bw.AddError(file, err)
 		return nil
 	}
-	err = filepath.Walk(cliLogDir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
+	if err := bw.Add(name, file, f, info.ModTime(), keep); err != nil {
+		return fmt.Errorf("failed to write data for %s: %v", file, err)
+	}
+	return nil
+}
 
-		if info.IsDir() {
+// addDir adds the files of dir modified within the window to the bundle under name, see addFile
+func addDir(bw *supportbundle.Writer, name, dir string, window supportbundle.Window, keep func(line string) bool) error {
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			bw.AddError(path, err)
 			return nil
 		}
-
-		srcFile, err := os.Open(path)
-		if err != nil {
-			return fmt.Errorf("failed to open file %s: %v", path, err)
+		if info.IsDir() || !window.ModifiedWithin(info.ModTime()) {
+			return nil
 		}
-		defer srcFile.Close()
-
-		relPath, err := filepath.Rel(cliLogDir, path)
+		relPath, err := filepath.Rel(dir, path)
 		if err != nil {
 			return fmt.Errorf("failed to get relative path: %v", err)
 		}
-
-		header := &tar.Header{
-			Name:    filepath.Join("olares-cli", relPath),
-			Mode:    0644,
-			Size:    info.Size(),
-			ModTime: info.ModTime(),
-		}
-		if err := tw.WriteHeader(header); err != nil {
-			return fmt.Errorf("failed to write header for %s: %v", path, err)
-		}
-
-		// stream file contents to tar
-		if _, err := io.CopyN(tw, srcFile, header.Size); err != nil {
-			return fmt.Errorf("failed to write data for %s: %v", path, err)
-		}
-		return nil
+		return addFile(bw, filepath.Join(name, relPath), path, keep)
 	})
+}
+
+func collectOlaresCLILogs(bw *supportbundle.Writer, options *LogCollectOptions, window supportbundle.Window) error {
+	basedir, err := getBaseDir()
 	if err != nil {
+		return err
+	}
+	cliLogDir := filepath.Join(basedir, "logs")
+	if _, err := os.Stat(cliLogDir); err != nil {
+		fmt.Printf("warning: directory %s does not exist, skipping collecting olares-cli logs\n", cliLogDir)
+	} else if err := addDir(bw, "olares-cli", cliLogDir, window, nil); err != nil {
 		return fmt.Errorf("failed to collect olares-cli logs from %s: %v", cliLogDir, err)
 	}
+
+	provider, err := history.OpenStore(basedir)
+	if err != nil {
+		bw.AddError("history", err)
+		return nil
+	}
+	defer provider.Close()
+
+	var since int64
+	if !window.Since.IsZero() {
+		since = window.Since.UnixMilli()
+	}
+	if installLogs, err := provider.QueryInstallState(since); err != nil {
+		bw.AddError("history install_logs", err)
+	} else if err := bw.AddJSON("history/install-logs.json", "install_logs", installLogs); err != nil {
+		return err
+	}
+
+	var runs, inventory bytes.Buffer
+	if err := history.ListRuns(&runs, provider, maxRunsToCollect, true); err != nil {
+		bw.AddError("history pipeline_runs", err)
+	} else if err := bw.AddBytes("history/runs.json", "pipeline_runs", runs.Bytes()); err != nil {
+		return err
+	}
+	if err := history.ShowInventory(&inventory, provider, true); err != nil {
+		bw.AddError("history inventory", err)
+	} else if err := bw.AddBytes("history/inventory.json", "component_versions, node_inventory", inventory.Bytes()); err != nil {
+		return err
+	}
 	return nil
 }
 
-func collectSystemdLogs(tw *tar.Writer, options *LogCollectOptions) error {
+func collectSystemdLogs(bw *supportbundle.Writer, options *LogCollectOptions, window supportbundle.Window) error {
 	// Create temp directory for log files
 	tempDir, err := os.MkdirTemp("", "olares-logs-*")
 	if err != nil {
@@ -160,139 +274,83 @@ func collectSystemdLogs(tw *tar.Writer, options *LogCollectOptions) error {
 		}
 
 		fmt.Printf("collecting logs for service: %s\n", service)
-
-		// create temp file for this service's logs
-		tempFile := filepath.Join(tempDir, fmt.Sprintf("%s.log", service))
-		logFile, err := os.Create(tempFile)
-		if err != nil {
-			return fmt.Errorf("failed to create temp file for %s: %v", service, err)
-		}
-
-		args := []string{"-u", service}
-		if options.Since != "" {
-			if !strings.HasPrefix(options.Since, "-") {
-				options.Since = "-" + options.Since
-			}
-			args = append(args, "--since", options.Since)
-		}
-		if options.MaxLines > 0 {
-			args = append(args, "-n", fmt.Sprintf("%d", options.MaxLines))
+		if err := collectServiceLogs(bw, service, tempDir, options, window); err != nil {
+			return err
 		}
+	}
+	return nil
+}
 
-		if options.Since != "" && options.MaxLines > 0 {
-			// this is a journalctl bug
-			// where -S and -n combined results in the latest logs truncated
-			// rather than the old logs
-			// a -r corrects the truncate behavior
-			args = append(args, "-r")
-		}
+func collectServiceLogs(bw *supportbundle.Writer, service, tempDir string, options *LogCollectOptions, window supportbundle.Window) error {
+	// create temp file for this service's logs
+	tempFile := filepath.Join(tempDir, fmt.Sprintf("%s.log", service))
+	logFile, err := os.Create(tempFile)
+	if err != nil {
+		return fmt.Errorf("failed to create temp file for %s: %v", service, err)
+	}
 
-		// execute journalctl and write directly to temp file
-		// don't just use the command output because that's too memory-consuming
-		// the same logic goes to the os.Open and io.Copy rather than os.ReadFile
-		cmd := exec.Command("journalctl", args...)
-		cmd.Stdout = logFile
-		if err := cmd.Run(); err != nil {
-			logFile.Close()
-			return fmt.Errorf("failed to collect logs for %s: %v", service, err)
-			continue
-		}
-		logFile.Close()
+	args := append([]string{"-u", service}, window.JournalArgs()...)
+	if options.MaxLines > 0 {
+		args = append(args, "-n", fmt.Sprintf("%d", options.MaxLines))
+	}
 
-		// get file info for the tar header
-		fi, err := os.Stat(tempFile)
-		if err != nil {
-			return fmt.Errorf("failed to stat temp file for %s: %v", service, err)
-		}
+	if !window.Since.IsZero() && options.MaxLines > 0 {
+		// this is a journalctl bug
+		// where -S and -n combined results in the latest logs truncated
+		// rather than the old logs
+		// a -r corrects the truncate behavior
+		args = append(args, "-r")
+	}
 
-		logFile, err = os.Open(tempFile)
-		if err != nil {
-			return fmt.Errorf("failed to open temp file for %s: %v", service, err)
-		}
-		defer logFile.Close()
+	// execute journalctl and write directly to temp file
+	// don't just use the command output because that's too memory-consuming
+	// the same logic goes to the os.Open and io.Copy rather than os.ReadFile
+	cmd := exec.Command("journalctl", args...)
+	cmd.Stdout = logFile
+	err = cmd.Run()
+	logFile.Close()
+	if err != nil {
+		bw.AddError("journalctl "+strings.Join(args, " "), err)
+		return nil
+	}
 
-		header := &tar.Header{
-			Name:    fmt.Sprintf("%s.log", service),
-			Mode:    0644,
-			Size:    fi.Size(),
-			ModTime: time.Now(),
-		}
-		if err := tw.WriteHeader(header); err != nil {
-			return fmt.Errorf("failed to write header for %s: %v", service, err)
-		}
+	logFile, err = os.Open(tempFile)
+	if err != nil {
+		return fmt.Errorf("failed to open temp file for %s: %v", service, err)
+	}
+	defer logFile.Close()
 
-		if _, err := io.CopyN(tw, logFile, header.Size); err != nil {
-			return fmt.Errorf("failed to write logs for %s: %v", service, err)
-		}
+	if err := bw.Add(fmt.Sprintf("%s.log", service), "journalctl -u "+service, logFile, time.Now(), nil); err != nil {
+		return fmt.Errorf("failed to write logs for %s: %v", service, err)
 	}
 	return nil
 }
 
-func collectDmesgLogs(tw *tar.Writer, options *LogCollectOptions) error {
-	cmd := exec.Command("dmesg")
-	output, err := cmd.Output()
+func collectDmesgLogs(bw *supportbundle.Writer, options *LogCollectOptions) error {
+	return addCommandOutput(bw, "dmesg.log", "dmesg")
+}
+
+// addCommandOutput adds the output of the command to the bundle under name,
+// the failure of the command is recorded in the manifest
+func addCommandOutput(bw *supportbundle.Writer, name string, command string, args ...string) error {
+	source := strings.Join(append([]string{command}, args...), " ")
+	output, err := exec.Command(command, args...).Output()
 	if err != nil {
-		return err
-	}
-	header := &tar.Header{
-		Name:    "dmesg.log",
-		Mode:    0644,
-		Size:    int64(len(output)),
-		ModTime: time.Now(),
-	}
-	if err := tw.WriteHeader(header); err != nil {
-		return fmt.Errorf("failed to write dmesg header: %v", err)
+		bw.AddError(source, err)
+		return nil
 	}
-	if _, err := tw.Write(output); err != nil {
-		return fmt.Errorf("failed to write dmesg data: %v", err)
+	if err := bw.AddBytes(name, source, output); err != nil {
+		return fmt.Errorf("failed to write %s: %v", name, err)
 	}
 	return nil
 }
 
-func collectKubernetesLogs(tw *tar.Writer, options *LogCollectOptions) error {
+func collectKubernetesLogs(bw *supportbundle.Writer, options *LogCollectOptions, window supportbundle.Window) error {
 	podsLogDir := "/var/log/pods"
 	if _, err := os.Stat(podsLogDir); err != nil {
 		fmt.Printf("warning: directory %s does not exist, skipping collecting pod logs\n", podsLogDir)
-	} else {
-		err := filepath.Walk(podsLogDir, func(path string, info os.FileInfo, err error) error {
-			if err != nil {
-				return err
-			}
-
-			if info.IsDir() {
-				return nil
-			}
-
-			srcFile, err := os.Open(path)
-			if err != nil {
-				return fmt.Errorf("failed to open file %s: %v", path, err)
-			}
-			defer srcFile.Close()
-
-			relPath, err := filepath.Rel(podsLogDir, path)
-			if err != nil {
-				return fmt.Errorf("failed to get relative path: %v", err)
-			}
-
-			header := &tar.Header{
-				Name:    filepath.Join("pods", relPath),
-				Mode:    0644,
-				Size:    info.Size(),
-				ModTime: info.ModTime(),
-			}
-			if err := tw.WriteHeader(header); err != nil {
-				return fmt.Errorf("failed to write header for %s: %v", path, err)
-			}
-
-			// stream file contents to tar
-			if _, err := io.CopyN(tw, srcFile, header.Size); err != nil {
-				return fmt.Errorf("failed to write data for %s: %v", path, err)
-			}
-			return nil
-		})
-		if err != nil {
-			return fmt.Errorf("failed to collect pod logs from /var/log/pods: %v", err)
-		}
+	} else if err := addDir(bw, "pods", podsLogDir, window, window.KeepLine); err != nil {
+		return fmt.Errorf("failed to collect pod logs from /var/log/pods: %v", err)
 	}
 
 	if _, err := util.GetCommand("kubectl"); err != nil {
@@ -300,149 +358,166 @@ func collectKubernetesLogs(tw *tar.Writer, options *LogCollectOptions) error {
 		return nil
 	}
 
-	cmd := exec.Command("kubectl", "get", "pods", "--all-namespaces", "-o", "wide")
-	output, err := tryKubectlCommand(cmd, "get pods", options)
-	if err != nil && !options.IgnoreKubeErrors {
-		return err
-	}
-	if err == nil {
-		header := &tar.Header{
-			Name:    "pods-list.txt",
-			Mode:    0644,
-			Size:    int64(len(output)),
-			ModTime: time.Now(),
+	for name, args := range map[string][]string{
+		"pods-list.txt":     {"get", "pods", "--all-namespaces", "-o", "wide"},
+		"pods-describe.txt": {"describe", "pods", "--all-namespaces"},
+		"node-describe.txt": {"describe", "node"},
+	} {
+		output, err := tryKubectlCommand(bw, options, args...)
+		if err != nil {
+			return err
 		}
-		if err := tw.WriteHeader(header); err != nil {
-			return fmt.Errorf("failed to write pods list header: %v", err)
+		if output == nil {
+			continue
 		}
-		if _, err := tw.Write(output); err != nil {
-			return fmt.Errorf("failed to write pods list data: %v", err)
+		if err := bw.AddBytes(name, "kubectl "+strings.Join(args, " "), output); err != nil {
+			return fmt.Errorf("failed to write %s: %v", name, err)
 		}
 	}
 
-	cmd = exec.Command("kubectl", "describe", "pods", "--all-namespaces")
-	output, err = tryKubectlCommand(cmd, "describe pods", options)
-	if err != nil && !options.IgnoreKubeErrors {
+	if err := collectKubernetesEvents(bw, options, window); err != nil {
 		return err
 	}
-	if err == nil {
-		header := &tar.Header{
-			Name:    "pods-describe.txt",
-			Mode:    0644,
-			Size:    int64(len(output)),
-			ModTime: time.Now(),
-		}
-		if err := tw.WriteHeader(header); err != nil {
-			return fmt.Errorf("failed to write pods description header: %v", err)
-		}
-		if _, err := tw.Write(output); err != nil {
-			return fmt.Errorf("failed to write pods description data: %v", err)
+	if err := collectTerminusResources(bw, options); err != nil {
+		return err
+	}
+
+	if _, err := util.GetCommand("helm"); err == nil {
+		// the metadata of the releases, their values hold secrets and are left out
+		if err := addCommandOutput(bw, "cluster/helm-releases.json", "helm", "list", "--all-namespaces", "--all", "-o", "json"); err != nil {
+			return err
 		}
 	}
+	return nil
+}
 
-	cmd = exec.Command("kubectl", "describe", "node")
-	output, err = tryKubectlCommand(cmd, "describe node", options)
-	if err != nil && !options.IgnoreKubeErrors {
+// collectKubernetesEvents adds the events of the cluster last seen within the window
+func collectKubernetesEvents(bw *supportbundle.Writer, options *LogCollectOptions, window supportbundle.Window) error {
+	args := []string{"get", "events", "--all-namespaces", "-o", "json"}
+	output, err := tryKubectlCommand(bw, options, args...)
+	if err != nil || output == nil {
 		return err
 	}
-	if err == nil {
-		header := &tar.Header{
-			Name:    "node-describe.txt",
-			Mode:    0644,
-			Size:    int64(len(output)),
-			ModTime: time.Now(),
-		}
-		if err := tw.WriteHeader(header); err != nil {
-			return fmt.Errorf("failed to write node description header: %v", err)
-		}
-		if _, err := tw.Write(output); err != nil {
-			return fmt.Errorf("failed to write node description data: %v", err)
-		}
+	var events corev1.EventList
+	if err := json.Unmarshal(output, &events); err != nil {
+		bw.AddError("kubectl "+strings.Join(args, " "), err)
+		return nil
 	}
-
-	return nil
+	events.Items = window.FilterEvents(events.Items)
+	return bw.AddJSON(supportbundle.EventsFile, "kubectl "+strings.Join(args, " "), &events)
 }
 
-func collectNetworkConfigs(tw *tar.Writer, options *LogCollectOptions) error {
-	if _, err := util.GetCommand("ip"); err == nil {
-		cmd := exec.Command("ip", "address")
-		output, err := cmd.Output()
+// collectTerminusResources adds the custom resources of Olares, those of the API groups of bytetrade.io
+func collectTerminusResources(bw *supportbundle.Writer, options *LogCollectOptions) error {
+	output, err := tryKubectlCommand(bw, options, "api-resources", "--verbs=list", "-o", "name")
+	if err != nil || output == nil {
+		return err
+	}
+	for _, resource := range strings.Fields(string(output)) {
+		if !strings.HasSuffix(resource, terminusAPIGroupSuffix) {
+			continue
+		}
+		args := []string{"get", resource, "--all-namespaces", "-o", "yaml"}
+		crs, err := tryKubectlCommand(bw, options, args...)
 		if err != nil {
 			return err
 		}
-		header := &tar.Header{
-			Name:    "ip-address.txt",
-			Mode:    0644,
-			Size:    int64(len(output)),
-			ModTime: time.Now(),
-		}
-		if err := tw.WriteHeader(header); err != nil {
-			return fmt.Errorf("failed to write ip address header: %v", err)
+		if crs == nil {
+			continue
 		}
-		if _, err := tw.Write(output); err != nil {
-			return fmt.Errorf("failed to write ip address data: %v", err)
+		if err := bw.AddBytes(filepath.Join("cluster", "resources", resource+".yaml"), "kubectl "+strings.Join(args, " "), crs); err != nil {
+			return fmt.Errorf("failed to write %s: %v", resource, err)
 		}
+	}
+	return nil
+}
 
-		cmd = exec.Command("ip", "route")
-		output, err = cmd.Output()
-		if err != nil {
+func collectNetworkConfigs(bw *supportbundle.Writer, options *LogCollectOptions) error {
+	if _, err := util.GetCommand("ip"); err == nil {
+		if err := addCommandOutput(bw, "ip-address.txt", "ip", "address"); err != nil {
 			return err
 		}
-		header = &tar.Header{
-			Name:    "ip-route.txt",
-			Mode:    0644,
-			Size:    int64(len(output)),
-			ModTime: time.Now(),
-		}
-		if err := tw.WriteHeader(header); err != nil {
-			return fmt.Errorf("failed to write ip route header: %v", err)
-		}
-		if _, err := tw.Write(output); err != nil {
-			return fmt.Errorf("failed to write ip route data: %v", err)
+		if err := addCommandOutput(bw, "ip-route.txt", "ip", "route"); err != nil {
+			return err
 		}
 	}
 
 	if _, err := util.GetCommand("iptables-save"); err == nil {
-		cmd := exec.Command("iptables-save")
-		output, err := cmd.Output()
-		if err != nil {
+		if err := addCommandOutput(bw, "iptables.txt", "iptables-save"); err != nil {
 			return err
 		}
-		header := &tar.Header{
-			Name:    "iptables.txt",
-			Mode:    0644,
-			Size:    int64(len(output)),
-			ModTime: time.Now(),
-		}
-		if err := tw.WriteHeader(header); err != nil {
-			return fmt.Errorf("failed to write iptables header: %v", err)
-		}
-		if _, err := tw.Write(output); err != nil {
-			return fmt.Errorf("failed to write iptables data: %v", err)
-		}
 	}
 
 	if _, err := util.GetCommand("nft"); err == nil {
-		cmd := exec.Command("nft", "list", "ruleset")
-		output, err := cmd.Output()
-		if err != nil {
+		if err := addCommandOutput(bw, "nftables.txt", "nft", "list", "ruleset"); err != nil {
 			return err
 		}
-		header := &tar.Header{
-			Name:    "nftables.txt",
-			Mode:    0644,
-			Size:    int64(len(output)),
-			ModTime: time.Now(),
+	}
+
+	return nil
+}
+
+// registerHostSecrets registers the passwords and tokens kept on this host,
+// so that they are masked wherever they appear in the collected logs
+func registerHostSecrets() {
+	for file, key := range map[string]string{
+		storage.RedisConfigFile: "requirepass ",
+		storage.MinioConfigFile: "MINIO_ROOT_PASSWORD=",
+	} {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			continue
 		}
-		if err := tw.WriteHeader(header); err != nil {
-			return fmt.Errorf("failed to write nftables header: %v", err)
+		for _, line := range strings.Split(string(data), "\n") {
+			if strings.HasPrefix(line, key) {
+				logger.RegisterSecret(strings.TrimPrefix(line, key))
+			}
 		}
-		if _, err := tw.Write(output); err != nil {
-			return fmt.Errorf("failed to write nftables data: %v", err)
+	}
+	for _, file := range []string{"/var/lib/rancher/k3s/server/token", "/var/lib/rancher/k3s/server/node-token"} {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			continue
 		}
+		// K10<ca hash>::server:<password>
+		token := strings.TrimSpace(string(data))
+		logger.RegisterSecret(token, token[strings.LastIndex(token, ":")+1:])
 	}
 
-	return nil
+	// the credentials of the object storage, given at the installation and kept on the Terminus CR
+	logger.RegisterSecret(os.Getenv(common.ENV_AWS_ACCESS_KEY_ID_SETUP), os.Getenv(common.ENV_AWS_SECRET_ACCESS_KEY_SETUP),
+		os.Getenv(common.ENV_AWS_SESSION_TOKEN_SETUP), os.Getenv(common.ENV_BACKUP_SECRET))
+	for _, annotation := range []string{"s3-ak", "s3-sk", "s3-sts"} {
+		jsonpath := fmt.Sprintf("jsonpath={.metadata.annotations.bytetrade\\.io/%s}", annotation)
+		if output, err := exec.Command("kubectl", "get", "terminus", "terminus", "-o", jsonpath).Output(); err == nil {
+			logger.RegisterSecret(string(output))
+		}
+	}
+
+	// the passwords given on the command line of olares-cli, e.g., --master-ssh-password,
+	// as recorded in the collected history by the versions not masking them
+	basedir, err := getBaseDir()
+	if err != nil {
+		return
+	}
+	provider, err := history.OpenStore(basedir)
+	if err != nil {
+		return
+	}
+	defer provider.Close()
+	runs, err := provider.QueryPipelineRuns(maxRunsToCollect)
+	if err != nil {
+		return
+	}
+	for _, run := range runs {
+		args := strings.Fields(run.Args)
+		for i, arg := range logger.RedactArgs(args) {
+			if arg != args[i] {
+				name, _, _ := strings.Cut(arg, "=")
+				logger.RegisterSecret(strings.Trim(strings.TrimPrefix(args[i], name+"="), `"'`))
+			}
+		}
+	}
 }
 
 func getBaseDir() (string, error) {
@@ -461,12 +536,16 @@ func getBaseDir() (string, error) {
 	return filepath.Join(homeDir, ".olares"), nil
 }
 
-func tryKubectlCommand(cmd *exec.Cmd, description string, options *LogCollectOptions) ([]byte, error) {
-	output, err := cmd.Output()
+// tryKubectlCommand runs kubectl, its failure is recorded in the manifest if kube errors are ignored,
+// the output is then nil
+func tryKubectlCommand(bw *supportbundle.Writer, options *LogCollectOptions, args ...string) ([]byte, error) {
+	description := strings.Join(args, " ")
+	output, err := exec.Command("kubectl", args...).Output()
 	if err != nil {
 		if options.IgnoreKubeErrors {
 			fmt.Printf("warning: failed to %s: %v\n", description, err)
-			return nil, err
+			bw.AddError("kubectl "+description, err)
+			return nil, nil
 		}
 		return nil, fmt.Errorf("failed to %s: %v", description, err)
 	}
@@ -482,10 +561,92 @@ func checkServiceExists(service string) bool {
 	return cmd.Run() == nil
 }
 
+// printInspection prints what is found in a support bundle, as JSON with --output json
+func printInspection(ins *supportbundle.Inspection) error {
+	if pipeline.DefaultOptions.Output == pipeline.OutputJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(ins)
+	}
+
+	m := ins.Manifest
+	fmt.Printf("collected:    %s by olares-cli %s\n", m.CreatedAt.Local().Format(time.RFC1123), m.CLIVersion)
+	if m.OlaresVersion != "" {
+		fmt.Printf("olares:       %s\n", m.OlaresVersion)
+	}
+	if si := m.SystemInfo; si != nil && si.HostInfo != nil {
+		fmt.Printf("host:         %s, %s %s, kernel %s, %s\n", si.HostInfo.HostName, si.HostInfo.OsPlatform, si.HostInfo.OsVersion, si.HostInfo.OsKernel, si.HostInfo.OsArch)
+	}
+	if si := m.SystemInfo; si != nil && si.CpuInfo != nil && si.MemoryInfo != nil {
+		fmt.Printf("resources:    %d CPU(s), %s of memory\n", si.CpuInfo.CpuLogicalCount, util.FormatBytes(int64(si.MemoryInfo.Total)))
+	}
+	window := "all the logs"
+	if m.Since != nil || m.Until != nil {
+		window = fmt.Sprintf("%s to %s", formatBound(m.Since, "the start"), formatBound(m.Until, "the collection"))
+	}
+	fmt.Printf("window:       %s\n", window)
+	fmt.Printf("items:        %d, %s before compression\n", len(m.Items), util.FormatBytes(m.Size))
+
+	if len(m.Skipped) > 0 {
+		fmt.Printf("\n%d item(s) left out as the logs reached their maximum size of %s:\n", len(m.Skipped), util.FormatBytes(m.MaxSize))
+		for _, item := range m.Skipped {
+			fmt.Printf("  %s\n", item.Name)
+		}
+	}
+	if len(m.Errors) > 0 {
+		fmt.Printf("\n%d item(s) failed to be collected:\n", len(m.Errors))
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+		for _, e := range m.Errors {
+			fmt.Fprintf(w, "  %s\t%s\n", e.Source, e.Error)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+	if len(ins.ErrorLines) > 0 {
+		fmt.Println("\nlogs with the most errors:")
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+		fmt.Fprintln(w, "  LOG\tERRORS\tLAST")
+		for _, c := range ins.ErrorLines {
+			fmt.Fprintf(w, "  %s\t%d\t%s\n", c.Name, c.Count, truncate(c.Example, 120))
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+	if len(ins.WarningEvents) > 0 {
+		fmt.Println("\nmost frequent warning events:")
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+		fmt.Fprintln(w, "  REASON\tCOUNT\tLAST")
+		for _, c := range ins.WarningEvents {
+			fmt.Fprintf(w, "  %s\t%d\t%s\n", c.Name, c.Count, truncate(c.Example, 120))
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func formatBound(t *time.Time, none string) string {
+	if t == nil {
+		return none
+	}
+	return t.Local().Format(time.RFC1123)
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
+
 func NewCmdLogs() *cobra.Command {
 	options := &LogCollectOptions{
 		Since:            "7d",
 		MaxLines:         3000,
+		MaxSizeMB:        1024,
 		OutputDir:        "./olares-logs",
 		IgnoreKubeErrors: false,
 	}
@@ -500,11 +661,16 @@ func NewCmdLogs() *cobra.Command {
 - Redis logs
 - MinIO logs
 - etcd logs
-- Olaresd logs
-- olares-cli logs
+- Olaresd logs and env
+- olares-cli logs and history of the installation
 - network configurations
 - Kubernetes pod info and logs
-- Kubernetes node info`,
+- Kubernetes node info, events, custom resources of Olares and Helm releases
+
+The passwords, keys and tokens found in the collected logs are masked.
+The bundle holds a manifest.json listing what has been collected, what failed to be, and the system info,
+read it along with a summary of the errors found with olares-cli logs inspect.`,
+		Args: cobra.NoArgs,
 		Run: func(cmd *cobra.Command, args []string) {
 			if err := collectLogs(options); err != nil {
 				log.Fatalf("error: %v", err)
@@ -512,11 +678,35 @@ func NewCmdLogs() *cobra.Command {
 		},
 	}
 
-	cmd.Flags().StringVar(&options.Since, "since", options.Since, "Only return logs newer than a relative duration like 5s, 2m, or 3h, to limit the log file")
+	cmd.Flags().StringVar(&options.Since, "since", options.Since, "Only collect logs newer than a relative duration like 7d, 2m, or 3h, or than a time like \"2024-06-10 08:00\", empty for all of them")
+	cmd.Flags().StringVar(&options.Until, "until", options.Until, "Only collect logs older than a relative duration or a time, as --since, empty for up to now")
 	cmd.Flags().IntVar(&options.MaxLines, "max-lines", options.MaxLines, "Maximum number of lines to collect per log source, to limit the log file size")
+	cmd.Flags().IntVar(&options.MaxSizeMB, "max-size-mb", options.MaxSizeMB, "Maximum size in MiB of the collected logs before compression, the ones beyond are truncated or left out, 0 for no limit")
 	cmd.Flags().StringVar(&options.OutputDir, "output-dir", options.OutputDir, "Directory to store collected logs, will be created if not existing")
 	cmd.Flags().StringSliceVar(&options.Components, "components", nil, "Specific components (systemd service) to collect logs from (comma-separated). If empty, collects from all Olares-related components that can be found")
 	cmd.Flags().BoolVar(&options.IgnoreKubeErrors, "ignore-kube-errors", options.IgnoreKubeErrors, "Continue collecting logs even if kubectl commands fail, e.g., when kube-apiserver is not reachable")
 
+	cmd.AddCommand(newCmdLogsInspect())
+	return cmd
+}
+
+func newCmdLogsInspect() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "inspect <bundle>",
+		Short: "Summarize a bundle collected by olares-cli logs, offline",
+		Long: `Summarize a bundle collected by olares-cli logs, offline: the system it was collected on, what it holds and failed to collect,
+the logs with the most errors and the most frequent warning events of Kubernetes. Print it as JSON with --output json.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			ins, err := supportbundle.Inspect(args[0])
+			if err != nil {
+				log.Fatalf("error: %v", err)
+			}
+			if err := printInspection(ins); err != nil {
+				log.Fatalf("error: %v", err)
+			}
+		},
+	}
+	pipeline.AddOutputFlag(cmd.Flags(), "Output format, json to print the summary as JSON")
 	return cmd
 }
@@ -2,6 +2,7 @@ package os
 
 import (
 	"bytetrade.io/web3os/installer/cmd/ctl/options"
+	"bytetrade.io/web3os/installer/pkg/core/pipeline"
 	"bytetrade.io/web3os/installer/pkg/pipelines"
 	"github.com/spf13/cobra"
 	"log"
@@ -19,5 +20,6 @@ func NewCmdPrecheck() *cobra.Command {
 		},
 	}
 	o.AddFlags(cmd)
+	pipeline.AddOutputFlag(cmd.Flags(), "Output format, json to print the result of the checks as JSON"+dryRunOutputs)
 	return cmd
 }
@@ -16,16 +16,28 @@ import (
 
 func NewCmdRelease() *cobra.Command {
 	var (
-		baseDir             string
-		version             string
-		cdn                 string
-		ignoreMissingImages bool
-		extract             bool
+		baseDir               string
+		version               string
+		cdn                   string
+		ignoreMissingImages   bool
+		allowMissingChecksums bool
+		signingKeyFile        string
+		extract               bool
 	)
 
 	cmd := &cobra.Command{
 		Use:   "release",
 		Short: "Build release based on a local Olares repository",
+		Long: `Build release based on a local Olares repository.
+
+The build is reproducible: the entries of the install wizard tarball are sorted, owned by root,
+and dated $SOURCE_DATE_EPOCH, or the time of the last commit of the repository if unset.
+Next to the tarball are written an SPDX SBOM of the images and the binaries of its manifest,
+and the SHA-256 sums of both, to be checked with sha256sum -c.
+With --signing-key-file, the manifest in the tarball and the sums are signed,
+the signature of the sums is written next to them with a .sig suffix.
+The installer trusts the key of the official releases, to install a release signed with another key,
+point $MANIFEST_PUBLIC_KEYS_FILE at a file holding its base64 encoded public key.`,
 		Run: func(cmd *cobra.Command, args []string) {
 			cwd, err := os.Getwd()
 			if err != nil {
@@ -53,12 +65,21 @@ func NewCmdRelease() *cobra.Command {
 				time.Sleep(1 * time.Second)
 			}
 
-			wizardFile, err := builder.NewBuilder(cwd, version, cdn, ignoreMissingImages).Build()
+			artifacts, err := builder.NewBuilder(cwd, version, builder.Options{
+				CDNURL:                cdn,
+				IgnoreMissingImages:   ignoreMissingImages,
+				AllowMissingChecksums: allowMissingChecksums,
+				SigningKeyFile:        signingKeyFile,
+			}).Build()
 			if err != nil {
 				fmt.Printf("failed to build release: %s\n", err)
 				os.Exit(1)
 			}
-			fmt.Printf("\nsuccessfully built release\nversion: %s\n package: %s\n", version, wizardFile)
+			wizardFile := artifacts.Package
+			fmt.Printf("\nsuccessfully built release\nversion: %s\n package: %s\n sbom: %s\n sums: %s\n", version, wizardFile, artifacts.SBOM, artifacts.Sums)
+			if artifacts.Signature != "" {
+				fmt.Printf(" signature: %s\n", artifacts.Signature)
+			}
 			if extract {
 				dest := filepath.Join(baseDir, "versions", "v"+version)
 				if err := os.MkdirAll(dest, 0755); err != nil {
@@ -77,7 +98,9 @@ func NewCmdRelease() *cobra.Command {
 	cmd.Flags().StringVarP(&baseDir, "base-dir", "b", "", "base directory of Olares, where this release will be extracted to as a new version if --extract/-e is not disabled, defaults to $HOME/"+common.DefaultBaseDir)
 	cmd.Flags().StringVarP(&version, "version", "v", "", "version of this release, defaults to 0.0.0-local-dev-{yyyymmddhhmmss}")
 	cmd.Flags().StringVar(&cdn, "download-cdn-url", common.DownloadUrl, "CDN used for downloading checksums of dependencies and images")
-	cmd.Flags().BoolVar(&ignoreMissingImages, "ignore-missing-images", true, "ignore missing images when downloading cheksums from CDN, only disable this if no new image is added, or the build may fail because the image is not uploaded to the CDN yet")
+	cmd.Flags().BoolVar(&ignoreMissingImages, "ignore-missing-images", false, "leave the images whose checksum is missing on the CDN out of the manifest rather than failing the build, e.g. when a new image is not uploaded to the CDN yet")
+	cmd.Flags().BoolVar(&allowMissingChecksums, "allow-missing-checksums", false, "write the dependencies and the arm64 images whose checksum is missing on the CDN with an empty checksum rather than failing the build, the installer then does not verify them")
+	cmd.Flags().StringVar(&signingKeyFile, "signing-key-file", "", "file holding the base64 encoded ed25519 private key to sign the manifest and the sums with")
 	cmd.Flags().BoolVarP(&extract, "extract", "e", true, "extract this release to --base-dir after build, this can be disabled if only the release file itself is needed")
 
 	return cmd
@@ -19,6 +19,7 @@ func NewOSCommands() []*cobra.Command {
 		NewCmdChangeIP(),
 		NewCmdRelease(),
 		NewCmdPrintInfo(),
+		NewCmdStatus(),
 		NewCmdBackup(),
 		NewCmdLogs(),
 		NewCmdStart(),
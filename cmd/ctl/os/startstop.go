@@ -1,41 +1,60 @@
 package os
 
 import (
-	"time"
+	"log"
 
+	"bytetrade.io/web3os/installer/cmd/ctl/options"
+	"bytetrade.io/web3os/installer/pkg/core/pipeline"
 	"bytetrade.io/web3os/installer/pkg/pipelines"
 	"github.com/spf13/cobra"
-	"log"
 )
 
+// dryRunOutputs are the output formats supported by every pipeline, see pipeline.AddGlobalFlags
+const dryRunOutputs = ", or tree or json for the plan printed by --dry-run, events to print the progress events of the run as NDJSON"
+
 func NewCmdStart() *cobra.Command {
+	o := options.NewStartOptions()
 	cmd := &cobra.Command{
 		Use:   "start",
 		Short: "Start the Olares OS",
+		Long: `Start the Olares OS: its services in the reverse order they are stopped, then, once the API server is ready,
+the node cordoned and the workloads scaled down by olares-cli stop are uncordoned and scaled up again,
+the system ones before those of the users, and the pods of Olares are waited for to be running.
+The workloads are only scaled up by the only node of the cluster or by its last control plane node to start,
+on the other nodes the evicted pods are scheduled again once the node is uncordoned.
+
+Each stage waits for at most --stage-timeout, the result of the stages is printed at the end, as JSON with --output json.`,
 		Run: func(cmd *cobra.Command, args []string) {
-			if err := pipelines.StartOlares(); err != nil {
+			if err := pipelines.StartOlares(o); err != nil {
 				log.Fatalf("error: %v", err)
 			}
 		},
 	}
+	o.AddFlags(cmd)
+	pipeline.AddOutputFlag(cmd.Flags(), "Output format, json to print the result of the stages as JSON"+dryRunOutputs)
 	return cmd
 }
 
 func NewCmdStop() *cobra.Command {
-	var (
-		timeout       time.Duration
-		checkInterval time.Duration
-	)
+	o := options.NewStopOptions()
 	cmd := &cobra.Command{
 		Use:   "stop",
 		Short: "Stop the Olares OS",
+		Long: `Stop the Olares OS gracefully: the node is cordoned, the workloads of the users and then those of the system are scaled down
+through the API server, for the applications and the databases to shut down cleanly, and waited for along with the detaching of the volumes.
+JuiceFS is then synced and its open files waited for to be closed, before the services are stopped in the order they depend on each other.
+
+Each stage waits for at most --stage-timeout, the result of the stages is printed at the end, as JSON with --output json.
+The workloads of the whole cluster are only scaled down on its only node or on its last control plane node ready,
+on the other nodes only the pods of the node are evicted, to be scheduled on the other nodes.
+The workloads are not scaled down if the API server is not ready, or with --skip-drain.`,
 		Run: func(cmd *cobra.Command, args []string) {
-			if err := pipelines.StopOlares(timeout, checkInterval); err != nil {
+			if err := pipelines.StopOlares(o); err != nil {
 				log.Fatalf("error: %v", err)
 			}
 		},
 	}
-	cmd.Flags().DurationVarP(&timeout, "timeout", "t", 1*time.Minute, "Timeout for graceful shutdown before using SIGKILL")
-	cmd.Flags().DurationVarP(&checkInterval, "check-interval", "i", 10*time.Second, "Interval between checks for remaining processes")
+	o.AddFlags(cmd)
+	pipeline.AddOutputFlag(cmd.Flags(), "Output format, json to print the result of the stages as JSON"+dryRunOutputs)
 	return cmd
 }
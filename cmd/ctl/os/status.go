@@ -0,0 +1,28 @@
+package os
+
+import (
+	"log"
+
+	"bytetrade.io/web3os/installer/pkg/core/pipeline"
+	"bytetrade.io/web3os/installer/pkg/pipelines"
+	"github.com/spf13/cobra"
+)
+
+func NewCmdStatus() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Print the health of Olares on this node",
+		Long: `Print the health of Olares on this node: its release file and installation phase, its systemd services,
+the JuiceFS mount, the API server, the nodes, the pods of the system and of the users, and the expiry of the certificates.
+
+Print it as JSON with --output json. The command exits non-zero if any of them is degraded, for it to be used by monitoring.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := pipelines.StatusPipeline(); err != nil {
+				log.Fatalf("error: %v", err)
+			}
+		},
+	}
+	pipeline.AddOutputFlag(cmd.Flags(), "Output format, json to print the health report as JSON")
+	return cmd
+}
@@ -4,6 +4,7 @@ import (
 	"log"
 
 	"bytetrade.io/web3os/installer/cmd/ctl/options"
+	"bytetrade.io/web3os/installer/pkg/core/pipeline"
 	"bytetrade.io/web3os/installer/pkg/pipelines"
 	"github.com/spf13/cobra"
 )
@@ -23,6 +24,7 @@ func NewCmdUpgradeOs() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "upgrade",
 		Short: "Upgrade Olares to a newer version",
+		Long:  "Upgrade Olares to a newer version, a snapshot of the system is taken beforehand and restored if the upgrade fails, along with the cluster datastore, unless --no-rollback is given",
 		Run: func(cmd *cobra.Command, args []string) {
 			if err := pipelines.UpgradeOlaresPipeline(o.UpgradeOptions); err != nil {
 				log.Fatalf("error: %v", err)
@@ -31,6 +33,9 @@ func NewCmdUpgradeOs() *cobra.Command {
 	}
 	o.UpgradeOptions.AddFlags(cmd)
 	cmd.AddCommand(NewCmdUpgradePrecheck())
+	cmd.AddCommand(NewCmdUpgradePlan())
+	cmd.AddCommand(NewCmdUpgradeRollback())
+	cmd.AddCommand(NewCmdUpgradeKube())
 	return cmd
 }
 
@@ -46,3 +51,57 @@ func NewCmdUpgradePrecheck() *cobra.Command {
 	}
 	return cmd
 }
+
+func NewCmdUpgradePlan() *cobra.Command {
+	o := options.NewUpgradePlanOptions()
+	cmd := &cobra.Command{
+		Use:   "plan",
+		Short: "Show what upgrading Olares to a version would do and what blocks it",
+		Long:  "Show the upgrade tasks to run, the versions of the components, charts and images that change, the size of the files still to download, and the conditions blocking the upgrade, the command fails if there is any",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := pipelines.UpgradePlanPipeline(o); err != nil {
+				log.Fatalf("error: %v", err)
+			}
+		},
+	}
+	o.AddFlags(cmd)
+	pipeline.AddOutputFlag(cmd.Flags(), "Output format, json to print the plan as JSON")
+	return cmd
+}
+
+func NewCmdUpgradeRollback() *cobra.Command {
+	o := options.NewUpgradeRollbackOptions()
+	cmd := &cobra.Command{
+		Use:   "rollback",
+		Short: "Roll Olares back to the version it was upgraded from",
+		Long:  "Roll Olares back to the version it was upgraded from with the snapshot taken before the upgrade: the release file, the revisions of the helm releases, the charts of app-service and the olaresd binary are restored, the cluster datastore is not",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := pipelines.UpgradeRollbackPipeline(o); err != nil {
+				log.Fatalf("error: %v", err)
+			}
+		},
+	}
+	o.AddFlags(cmd)
+	return cmd
+}
+
+func NewCmdUpgradeKube() *cobra.Command {
+	o := options.NewUpgradeKubeOptions()
+	cmd := &cobra.Command{
+		Use:   "kube",
+		Short: "Upgrade Kubernetes and containerd of the node in place",
+		Long: `Upgrade k3s, or kubeadm and kubelet, and containerd of the node to a version, with the binaries of the installation manifest:
+the node is cordoned and drained, its binaries are replaced, and it is made schedulable again once Ready at the new version.
+
+Run it on every node of the cluster, one node at a time, the masters first and then the workers,
+the version skew between the nodes is checked before anything is done, and nothing is done on a node already at the version.
+An interrupted upgrade is continued with --resume.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := pipelines.UpgradeKubePipeline(o); err != nil {
+				log.Fatalf("error: %v", err)
+			}
+		},
+	}
+	o.AddFlags(cmd)
+	return cmd
+}
@@ -1,26 +1,98 @@
 package ctl
 
 import (
+	"bytetrade.io/web3os/installer/cmd/ctl/certs"
+	"bytetrade.io/web3os/installer/cmd/ctl/cluster"
+	"bytetrade.io/web3os/installer/cmd/ctl/config"
 	"bytetrade.io/web3os/installer/cmd/ctl/gpu"
+	"bytetrade.io/web3os/installer/cmd/ctl/history"
+	"bytetrade.io/web3os/installer/cmd/ctl/manifest"
 	"bytetrade.io/web3os/installer/cmd/ctl/node"
 	"bytetrade.io/web3os/installer/cmd/ctl/os"
 	"bytetrade.io/web3os/installer/cmd/ctl/osinfo"
+	pkgcerts "bytetrade.io/web3os/installer/pkg/certs"
+	"bytetrade.io/web3os/installer/pkg/core/pipeline"
 	"bytetrade.io/web3os/installer/version"
 	"github.com/spf13/cobra"
 )
 
+// warnExpiringCerts are the commands warning about the certificates of the node about to expire before they run,
+// those bringing Olares up or reporting its health
+var warnExpiringCerts = map[string]bool{
+	"olares-cli install": true,
+	"olares-cli upgrade": true,
+	"olares-cli start":   true,
+	"olares-cli status":  true,
+}
+
+// pipelineCommands are the commands running a pipeline, the others reject the switches of pipeline.AddGlobalFlags
+var pipelineCommands = map[string]bool{
+	"olares-cli install":                  true,
+	"olares-cli install storage":          true,
+	"olares-cli uninstall":                true,
+	"olares-cli prepare":                  true,
+	"olares-cli precheck":                 true,
+	"olares-cli start":                    true,
+	"olares-cli stop":                     true,
+	"olares-cli change-ip":                true,
+	"olares-cli download component":       true,
+	"olares-cli download wizard":          true,
+	"olares-cli download check":           true,
+	"olares-cli download bundle":          true,
+	"olares-cli download import":          true,
+	"olares-cli node add":                 true,
+	"olares-cli node delete":              true,
+	"olares-cli node cordon":              true,
+	"olares-cli node uncordon":            true,
+	"olares-cli node masterinfo":          true,
+	"olares-cli gpu install":              true,
+	"olares-cli gpu uninstall":            true,
+	"olares-cli gpu upgrade":              true,
+	"olares-cli gpu enable":               true,
+	"olares-cli gpu disable":              true,
+	"olares-cli certs renew":              true,
+	"olares-cli certs rotate-ca":          true,
+	"olares-cli cluster snapshot save":    true,
+	"olares-cli cluster snapshot restore": true,
+	"olares-cli cluster snapshot prune":   true,
+	"olares-cli upgrade":                  true,
+	"olares-cli upgrade kube":             true,
+	"olares-cli upgrade precheck":         true,
+	"olares-cli upgrade rollback":         true,
+}
+
 func NewDefaultCommand() *cobra.Command {
 	cmds := &cobra.Command{
 		Use:               "olares-cli",
 		Short:             "Olares Installer",
 		CompletionOptions: cobra.CompletionOptions{DisableDefaultCmd: true},
 		Version:           version.VERSION,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			// the flags of the command itself, e.g., an --output of its own, shadow the inherited ones
+			if !pipelineCommands[cmd.CommandPath()] {
+				if err := pipeline.RejectGlobalFlags(cmd.InheritedFlags()); err != nil {
+					return err
+				}
+			}
+			pipeline.PrepareOutput()
+			if warnExpiringCerts[cmd.CommandPath()] {
+				pkgcerts.WarnExpiring(cmd.ErrOrStderr())
+			}
+			return nil
+		},
 	}
 
+	pipeline.AddGlobalFlags(cmds.PersistentFlags())
+
 	cmds.AddCommand(osinfo.NewCmdInfo())
 	cmds.AddCommand(os.NewOSCommands()...)
 	cmds.AddCommand(node.NewNodeCommand())
 	cmds.AddCommand(gpu.NewCmdGpu())
+	cmds.AddCommand(history.NewCmdHistory())
+	cmds.AddCommand(manifest.NewCmdManifest())
+	cmds.AddCommand(config.NewCmdConfig())
+	cmds.AddCommand(certs.NewCmdCerts())
+	cmds.AddCommand(cluster.NewCmdCluster())
 
 	return cmds
 }
@@ -118,9 +118,10 @@ func (c *CheckFileExistModule) Init() {
 	c.Desc = "Check file if is existed"
 
 	check := &task.LocalTask{
-		Name:   "CheckExist",
-		Desc:   "Check output file if existed",
-		Action: &CheckFile{FileName: c.FileName},
+		Name:      "CheckExist",
+		AlwaysRun: true,
+		Desc:      "Check output file if existed",
+		Action:    &CheckFile{FileName: c.FileName},
 	}
 
 	c.Tasks = []task.Interface{
@@ -0,0 +1,185 @@
+package download
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"bytetrade.io/web3os/installer/pkg/bundle"
+	"bytetrade.io/web3os/installer/pkg/common"
+	cc "bytetrade.io/web3os/installer/pkg/core/common"
+	"bytetrade.io/web3os/installer/pkg/core/connector"
+	"bytetrade.io/web3os/installer/pkg/core/logger"
+	"bytetrade.io/web3os/installer/pkg/core/util"
+	"bytetrade.io/web3os/installer/pkg/manifest"
+	"bytetrade.io/web3os/installer/version"
+)
+
+const (
+	manifestFile = "installation.manifest"
+	// bundleCacheDir keeps the packages of the other architectures downloaded for a bundle,
+	// so that an interrupted export resumes
+	bundleCacheDir = "bundle-cache"
+	// bundleImportDir is where a bundle is unpacked and verified before being moved into place
+	bundleImportDir = ".bundle-import"
+)
+
+// ExportBundle downloads whatever is missing for each architecture
+// and packs the installer directory, the manifest and the packages into an offline bundle
+type ExportBundle struct {
+	common.KubeAction
+	Manifest  string
+	BaseDir   string
+	Arches    []string
+	Target    string
+	Mirrors   []string
+	Parallel  int
+	RateLimit int64
+}
+
+func (e *ExportBundle) Execute(runtime connector.Runtime) error {
+	doc, err := manifest.Load(e.Manifest)
+	if err != nil {
+		return err
+	}
+
+	dirs := make(map[string]string)
+	for _, arch := range e.Arches {
+		d := &PackageDownload{
+			KubeAction: e.KubeAction,
+			Manifest:   e.Manifest,
+			BaseDir:    e.BaseDir,
+			Mirrors:    e.Mirrors,
+			Parallel:   e.Parallel,
+			RateLimit:  e.RateLimit,
+		}
+		if arch != runtime.GetSystemInfo().GetOsArch() {
+			d.Arch = arch
+			d.BaseDir = filepath.Join(e.BaseDir, bundleCacheDir, arch)
+		}
+		logger.Infof("collecting the %s packages ...", arch)
+		if err := d.Execute(runtime); err != nil {
+			return errors.Wrapf(err, "failed to collect the %s packages", arch)
+		}
+		dirs[arch] = d.packageDir(runtime)
+	}
+
+	w, err := bundle.Create(e.Target, bundle.Info{
+		Version:   e.KubeConf.Arg.OlaresVersion,
+		Arches:    e.Arches,
+		Manifest:  filepath.ToSlash(filepath.Join(bundle.InstallerDir, manifestFile)),
+		CreatedAt: time.Now().UTC(),
+		CreatedBy: "olares-cli " + version.VERSION,
+	})
+	if err != nil {
+		return err
+	}
+	if err := e.write(w, runtime, doc, dirs); err != nil {
+		w.Abort()
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	if fi, err := os.Stat(e.Target); err == nil {
+		logger.Infof("bundle %s written, %d items for %v, %.1f MiB", e.Target, len(doc.Items), e.Arches, float64(fi.Size())/(1<<20))
+	}
+	return nil
+}
+
+func (e *ExportBundle) write(w *bundle.Writer, runtime connector.Runtime, doc *manifest.Document, dirs map[string]string) error {
+	logger.Info("packing the installer ...")
+	// the manifest is added on its own as it may have been given from elsewhere,
+	// the logs and work files of the installer are left out
+	if err := w.AddDir(bundle.InstallerDir, runtime.GetInstallerDir(),
+		cc.Cli, manifestFile, manifest.SignaturePath(manifestFile)); err != nil {
+		return errors.Wrap(err, "failed to pack the installer")
+	}
+	if err := w.AddFile(filepath.ToSlash(filepath.Join(bundle.InstallerDir, manifestFile)), e.Manifest); err != nil {
+		return err
+	}
+	if util.IsExist(manifest.SignaturePath(e.Manifest)) {
+		if err := w.AddFile(filepath.ToSlash(filepath.Join(bundle.InstallerDir, manifest.SignaturePath(manifestFile))), manifest.SignaturePath(e.Manifest)); err != nil {
+			return err
+		}
+	}
+
+	for _, arch := range e.Arches {
+		logger.Infof("packing the %s packages ...", arch)
+		for _, item := range doc.Items {
+			if err := w.AddFile(bundle.PackagePath(arch, item.Path, item.Filename), getDownloadTargetPath(item, dirs[arch])); err != nil {
+				return errors.Wrapf(err, "failed to pack %s for %s", item.FileID, arch)
+			}
+		}
+	}
+	return nil
+}
+
+// ImportBundle verifies an offline bundle and moves its installer directory
+// and the packages of the host architecture into the base directory
+type ImportBundle struct {
+	common.KubeAction
+	Bundle  string
+	BaseDir string
+}
+
+func (i *ImportBundle) Execute(runtime connector.Runtime) error {
+	staging := filepath.Join(i.BaseDir, bundleImportDir)
+	if err := os.RemoveAll(staging); err != nil {
+		return err
+	}
+	defer os.RemoveAll(staging)
+
+	logger.Infof("unpacking and verifying %s ...", i.Bundle)
+	info, err := bundle.Extract(i.Bundle, staging)
+	if err != nil {
+		return err
+	}
+	if info.Version != i.KubeConf.Arg.OlaresVersion {
+		return fmt.Errorf("the bundle is for Olares %s, not %s", info.Version, i.KubeConf.Arg.OlaresVersion)
+	}
+	arch := runtime.GetSystemInfo().GetOsArch()
+	hasArch := false
+	for _, a := range info.Arches {
+		hasArch = hasArch || a == arch
+	}
+	if !hasArch {
+		return fmt.Errorf("the bundle holds the packages for %v, not for %s", info.Arches, arch)
+	}
+
+	if err := moveTree(filepath.Join(staging, bundle.InstallerDir), runtime.GetInstallerDir()); err != nil {
+		return errors.Wrap(err, "failed to import the installer")
+	}
+	packageDir := (&PackageDownload{KubeAction: i.KubeAction, BaseDir: i.BaseDir}).packageDir(runtime)
+	if err := moveTree(filepath.Join(staging, bundle.PackagesDir, arch), packageDir); err != nil {
+		return errors.Wrap(err, "failed to import the packages")
+	}
+	logger.Infof("bundle of Olares %s imported into %s", info.Version, i.BaseDir)
+	return nil
+}
+
+// moveTree moves the files under src to the same paths under dst, replacing the existing ones
+func moveTree(src, dst string) error {
+	return filepath.Walk(src, func(p string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		if err := os.Rename(p, target); err != nil {
+			// the packages may live on another file system, e.g., on the Windows side of WSL
+			return util.MoveFile(p, target)
+		}
+		return nil
+	})
+}
@@ -7,18 +7,27 @@ import (
 
 type PackageDownloadModule struct {
 	common.KubeModule
-	Manifest       string
-	BaseDir        string
-	DownloadCdnUrl string
+	Manifest string
+	BaseDir  string
+	// Mirrors are the CDN addresses to download from, in order of preference
+	Mirrors   []string
+	Parallel  int
+	RateLimit int64
 }
 
 func (i *PackageDownloadModule) Init() {
 	i.Name = "PackageDownloadModule"
 
 	download := &task.LocalTask{
-		Name:   i.Name,
-		Desc:   i.Desc,
-		Action: &PackageDownload{Manifest: i.Manifest, BaseDir: i.BaseDir, DownloadCdnUrl: i.DownloadCdnUrl},
+		Name: i.Name,
+		Desc: i.Desc,
+		Action: &PackageDownload{
+			Manifest:  i.Manifest,
+			BaseDir:   i.BaseDir,
+			Mirrors:   i.Mirrors,
+			Parallel:  i.Parallel,
+			RateLimit: i.RateLimit,
+		},
 	}
 
 	i.Tasks = []task.Interface{
@@ -45,3 +54,56 @@ func (i *CheckDownloadModule) Init() {
 		check,
 	}
 }
+
+type BundleExportModule struct {
+	common.KubeModule
+	Manifest  string
+	BaseDir   string
+	Arches    []string
+	Target    string
+	Mirrors   []string
+	Parallel  int
+	RateLimit int64
+}
+
+func (i *BundleExportModule) Init() {
+	i.Name = "BundleExportModule"
+
+	export := &task.LocalTask{
+		Name: i.Name,
+		Desc: i.Desc,
+		Action: &ExportBundle{
+			Manifest:  i.Manifest,
+			BaseDir:   i.BaseDir,
+			Arches:    i.Arches,
+			Target:    i.Target,
+			Mirrors:   i.Mirrors,
+			Parallel:  i.Parallel,
+			RateLimit: i.RateLimit,
+		},
+	}
+
+	i.Tasks = []task.Interface{
+		export,
+	}
+}
+
+type BundleImportModule struct {
+	common.KubeModule
+	Bundle  string
+	BaseDir string
+}
+
+func (i *BundleImportModule) Init() {
+	i.Name = "BundleImportModule"
+
+	imp := &task.LocalTask{
+		Name:   i.Name,
+		Desc:   i.Desc,
+		Action: &ImportBundle{Bundle: i.Bundle, BaseDir: i.BaseDir},
+	}
+
+	i.Tasks = []task.Interface{
+		imp,
+	}
+}
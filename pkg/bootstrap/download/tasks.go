@@ -1,12 +1,14 @@
 package download
 
 import (
-	"bufio"
 	"fmt"
 	"os"
 	"path"
+	"sort"
 	"strings"
+	"sync"
 
+	"github.com/cavaliergopher/grab/v3"
 	"github.com/pkg/errors"
 
 	cc "bytetrade.io/web3os/installer/pkg/core/common"
@@ -14,6 +16,7 @@ import (
 	"bytetrade.io/web3os/installer/pkg/common"
 	"bytetrade.io/web3os/installer/pkg/core/connector"
 	"bytetrade.io/web3os/installer/pkg/core/logger"
+	"bytetrade.io/web3os/installer/pkg/core/util"
 	"bytetrade.io/web3os/installer/pkg/files"
 	"bytetrade.io/web3os/installer/pkg/manifest"
 	"bytetrade.io/web3os/installer/pkg/utils"
@@ -21,11 +24,25 @@ import (
 
 type PackageDownload struct {
 	common.KubeAction
-	Manifest       string
-	BaseDir        string
-	DownloadCdnUrl string
-	existingItems  []*manifest.ManifestItem
-	missingItems   []*manifest.ManifestItem
+	Manifest string
+	BaseDir  string
+	// Mirrors are the CDN addresses to download from, in order of preference
+	Mirrors []string
+	// Parallel is the number of files downloaded at the same time
+	Parallel int
+	// RateLimit caps the total bandwidth in bytes per second, 0 means unlimited
+	RateLimit int64
+	// Arch is the architecture to download the packages of, into BaseDir as is,
+	// the packages of the host are downloaded by default
+	Arch          string
+	existingItems []*manifest.ManifestItem
+	missingItems  []*manifest.ManifestItem
+}
+
+// downloadFailure is a file that could not be downloaded from any mirror
+type downloadFailure struct {
+	item *manifest.ManifestItem
+	err  error
 }
 
 type CheckDownload struct {
@@ -33,13 +50,7 @@ type CheckDownload struct {
 }
 
 func (d *PackageDownload) Execute(runtime connector.Runtime) error {
-	baseDir := d.BaseDir
-	if runtime.GetSystemInfo().IsWsl() {
-		var wslPackageDir = d.KubeConf.Arg.GetWslUserPath()
-		if wslPackageDir != "" {
-			baseDir = path.Join(wslPackageDir, cc.DefaultBaseDir)
-		}
-	}
+	baseDir := d.packageDir(runtime)
 	logger.Info("checking local cache ...")
 	err := d.CheckLocalCache(runtime)
 	if err != nil {
@@ -47,48 +58,96 @@ func (d *PackageDownload) Execute(runtime connector.Runtime) error {
 	}
 	if len(d.missingItems) == 0 {
 		logger.Info("all files are already downloaded and is the expected version")
-	} else {
-		logger.Infof("%d out of %d files need to be downloaded", len(d.missingItems), len(d.missingItems)+len(d.existingItems))
+		return nil
+	}
+	logger.Infof("%d out of %d files need to be downloaded", len(d.missingItems), len(d.missingItems)+len(d.existingItems))
+
+	parallel := d.Parallel
+	if parallel < 1 {
+		parallel = 1
+	}
+	if parallel > len(d.missingItems) {
+		parallel = len(d.missingItems)
+	}
+	var limiter grab.RateLimiter
+	if d.RateLimit > 0 {
+		limiter = files.NewLimiter(d.RateLimit)
 	}
+
+	var (
+		mu       sync.Mutex
+		failures []downloadFailure
+		next     = make(chan int)
+		wg       sync.WaitGroup
+	)
+	for w := 0; w < parallel; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				if err := d.downloadItem(runtime, baseDir, i, parallel > 1, limiter); err != nil {
+					logger.Errorf("failed to download %s: %v", d.missingItems[i].FileID, err)
+					mu.Lock()
+					failures = append(failures, downloadFailure{item: d.missingItems[i], err: err})
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	// no new download is started once interrupted, the running ones are aborted on the second signal
+	stop := runtime.GetContext()
+feed:
 	for i := range d.missingItems {
-		err = d.downloadItem(runtime, baseDir, i)
-		if err != nil {
-			logger.Fatal(err)
+		select {
+		case next <- i:
+		case <-stop.Done():
+			break feed
 		}
 	}
+	close(next)
+	wg.Wait()
 
-	return nil
+	if err := stop.Err(); err != nil {
+		return connector.ErrInterrupted
+	}
+	return failureReport(failures, len(d.missingItems))
+}
+
+// failureReport aggregates the files that failed to download into a single error,
+// so that one bad file does not hide the others
+func failureReport(failures []downloadFailure, total int) error {
+	if len(failures) == 0 {
+		return nil
+	}
+	sort.Slice(failures, func(i, j int) bool { return failures[i].item.FileID < failures[j].item.FileID })
+	var b strings.Builder
+	fmt.Fprintf(&b, "failed to download %d out of %d files:", len(failures), total)
+	for _, f := range failures {
+		fmt.Fprintf(&b, "\n  %s (%s/%s): %v", f.item.FileID, f.item.Path, f.item.Filename, f.err)
+	}
+	b.WriteString("\nrun the download again to resume the failed files")
+	return errors.New(b.String())
 }
 
 // CheckLocalCache compares the items in the manifest file
-// against the local files by MD5 checksum
+// against the local files by checksum
 // and filters out the existing and missing items
 func (d *PackageDownload) CheckLocalCache(runtime connector.Runtime) error {
 	if d.Manifest == "" {
 		return errors.New("manifest path is empty")
 	}
-	baseDir := d.BaseDir
+	baseDir := d.packageDir(runtime)
 
-	if runtime.GetSystemInfo().IsWsl() {
-		var wslPackageDir = d.KubeConf.Arg.GetWslUserPath()
-		if wslPackageDir != "" {
-			baseDir = path.Join(wslPackageDir, cc.DefaultBaseDir)
-		}
-	}
-
-	f, err := os.Open(d.Manifest)
+	doc, err := manifest.Load(d.Manifest)
 	if err != nil {
-		return errors.Wrap(err, "unable to open manifest")
+		return err
+	}
+	if !doc.Signed {
+		logger.Warnf("manifest %s is not signed, its content cannot be authenticated", d.Manifest)
 	}
 
-	scanner := bufio.NewScanner(f)
-	for scanner.Scan() {
-		line := scanner.Text()
-		if line == "" || strings.HasPrefix(line, "#") {
-			continue
-		}
-		item := must(manifest.ReadItem(line))
-		if must(isRealExists(runtime, item, baseDir)) {
+	for _, item := range doc.Items {
+		if must(isRealExists(runtime, item, d.arch(runtime), baseDir)) {
 			d.existingItems = append(d.existingItems, item)
 		} else {
 			d.missingItems = append(d.missingItems, item)
@@ -97,6 +156,23 @@ func (d *PackageDownload) CheckLocalCache(runtime connector.Runtime) error {
 	return nil
 }
 
+// packageDir is where the packages are kept, on WSL the host packages are kept on the Windows side
+func (d *PackageDownload) packageDir(runtime connector.Runtime) string {
+	if d.Arch == "" && runtime.GetSystemInfo().IsWsl() {
+		if wslPackageDir := d.KubeConf.Arg.GetWslUserPath(); wslPackageDir != "" {
+			return path.Join(wslPackageDir, cc.DefaultBaseDir)
+		}
+	}
+	return d.BaseDir
+}
+
+func (d *PackageDownload) arch(runtime connector.Runtime) string {
+	if d.Arch != "" {
+		return d.Arch
+	}
+	return runtime.GetSystemInfo().GetOsArch()
+}
+
 func (d *CheckDownload) Execute(runtime connector.Runtime) error {
 	if err := d.CheckLocalCache(runtime); err != nil {
 		return err
@@ -112,8 +188,7 @@ func (d *CheckDownload) Execute(runtime connector.Runtime) error {
 }
 
 // if the file exists and the checksum passed
-func isRealExists(runtime connector.Runtime, item *manifest.ManifestItem, baseDir string) (bool, error) {
-	arch := runtime.GetSystemInfo().GetOsArch()
+func isRealExists(runtime connector.Runtime, item *manifest.ManifestItem, arch, baseDir string) (bool, error) {
 	targetPath := getDownloadTargetPath(item, baseDir)
 	exists, err := runtime.GetRunner().FileExist(targetPath)
 	if err != nil {
@@ -123,32 +198,58 @@ func isRealExists(runtime connector.Runtime, item *manifest.ManifestItem, baseDi
 		return false, nil
 	}
 
-	checksum := utils.LocalMd5Sum(targetPath)
+	url := item.GetItemUrlForHost(arch)
 	// FIXME: run in remote
-	return checksum == item.GetItemUrlForHost(arch).Checksum, nil
+	if url.Size > 0 {
+		if info, err := os.Stat(targetPath); err != nil || info.Size() != url.Size {
+			return false, nil
+		}
+	}
+	if url.SHA256 != "" {
+		checksum, err := util.Sha256sum(targetPath)
+		if err != nil {
+			return false, err
+		}
+		return checksum == url.SHA256, nil
+	}
+	return utils.LocalMd5Sum(targetPath) == url.Checksum, nil
 }
 
-func (d *PackageDownload) downloadItem(runtime connector.Runtime, baseDir string, index int) error {
-	arch := runtime.GetSystemInfo().GetOsArch()
+func (d *PackageDownload) downloadItem(runtime connector.Runtime, baseDir string, index int, concurrent bool, limiter grab.RateLimiter) error {
+	arch := d.arch(runtime)
 	os := runtime.GetSystemInfo().GetOsType()
 	item := d.missingItems[index]
 	url := item.GetItemUrlForHost(arch)
 
 	component := new(files.KubeBinary)
 	component.ID = item.Filename
-	component.Arch = runtime.GetSystemInfo().GetOsArch()
+	component.Arch = arch
 	component.BaseDir = getDownloadTargetBasePath(item, baseDir)
-	component.Url = fmt.Sprintf("%s/%s", d.DownloadCdnUrl, strings.TrimPrefix(url.Url, "/"))
+	for i, mirror := range d.Mirrors {
+		u := fmt.Sprintf("%s/%s", mirror, strings.TrimPrefix(url.Url, "/"))
+		if i == 0 {
+			component.Url = u
+		} else {
+			component.MirrorUrls = append(component.MirrorUrls, u)
+		}
+	}
+	component.Size = url.Size
+	component.NoProgressBar = concurrent
+	component.Context = runtime.GetExecContext()
+	component.RateLimiter = limiter
 	component.FileName = item.Filename
-	component.CheckMd5Sum = true
-	component.Md5sum = url.Checksum
-	component.Os = os
-
-	downloadPath := component.Path()
-	if utils.IsExist(downloadPath) {
-		_, _ = runtime.GetRunner().SudoCmd(fmt.Sprintf("rm -rf %s", downloadPath), false, false)
+	// the SHA-256 is preferred when the manifest carries it
+	if url.SHA256 != "" {
+		component.CheckSum = true
+		component.Sha256sum = url.SHA256
+	} else {
+		component.CheckMd5Sum = true
+		component.Md5sum = url.Checksum
 	}
+	component.Os = os
 
+	// a file left over by a previous run is kept so that the download resumes from where it stopped,
+	// it is removed by the checksum check if it turns out to be corrupted
 	if !utils.IsExist(component.BaseDir) {
 		if err := component.CreateBaseDir(); err != nil {
 			return err
@@ -164,6 +265,9 @@ func (d *PackageDownload) downloadItem(runtime connector.Runtime, baseDir string
 	if err := component.Download(); err != nil {
 		return fmt.Errorf("Failed to download %s binary: %s error: %w ", component.ID, component.Url, err)
 	}
+	if concurrent {
+		logger.Infof("downloaded %s", item.FileID)
+	}
 
 	return nil
 }
@@ -286,6 +286,27 @@ func (c *ClearOSEnvironmentModule) Init() {
 	}
 }
 
+// UninstallETCDModule removes the etcd of the local host,
+// once its member is removed from the etcd cluster
+type UninstallETCDModule struct {
+	common.KubeModule
+}
+
+func (u *UninstallETCDModule) Init() {
+	u.Name = "UninstallETCDModule"
+
+	uninstallETCD := &task.LocalTask{
+		Name:    "UninstallETCD",
+		Desc:    "Uninstall etcd",
+		Prepare: new(EtcdTypeIsKubeKey),
+		Action:  new(UninstallETCD),
+	}
+
+	u.Tasks = []task.Interface{
+		uninstallETCD,
+	}
+}
+
 type RepositoryOnlineModule struct {
 	common.KubeModule
 	Skip bool
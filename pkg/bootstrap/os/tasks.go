@@ -32,6 +32,7 @@ import (
 	"bytetrade.io/web3os/installer/pkg/bootstrap/os/repository"
 	"bytetrade.io/web3os/installer/pkg/bootstrap/os/templates"
 	"bytetrade.io/web3os/installer/pkg/common"
+	"bytetrade.io/web3os/installer/pkg/core/cache"
 	cc "bytetrade.io/web3os/installer/pkg/core/common"
 	"bytetrade.io/web3os/installer/pkg/core/connector"
 	"bytetrade.io/web3os/installer/pkg/core/logger"
@@ -603,6 +604,7 @@ func (d *DaemonReload) Execute(runtime connector.Runtime) error {
 
 type GetOSData struct {
 	common.KubeAction
+	cache.Filler
 }
 
 func (g *GetOSData) Execute(runtime connector.Runtime) error {
@@ -621,6 +623,7 @@ func (g *GetOSData) Execute(runtime connector.Runtime) error {
 
 type SyncRepositoryFile struct {
 	common.KubeAction
+	cache.Filler
 }
 
 func (s *SyncRepositoryFile) Execute(runtime connector.Runtime) error {
@@ -668,6 +671,7 @@ func (m *MountISO) Execute(runtime connector.Runtime) error {
 
 type NewRepoClient struct {
 	common.KubeAction
+	cache.Filler
 }
 
 func (n *NewRepoClient) Execute(runtime connector.Runtime) error {
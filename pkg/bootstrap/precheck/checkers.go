@@ -0,0 +1,440 @@
+package precheck
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shirou/gopsutil/v4/disk"
+
+	"bytetrade.io/web3os/installer/pkg/common"
+	cc "bytetrade.io/web3os/installer/pkg/core/common"
+	"bytetrade.io/web3os/installer/pkg/core/connector"
+	"bytetrade.io/web3os/installer/pkg/core/util"
+)
+
+const (
+	minCpuCores         = 2
+	recommendedCpuCores = 4
+
+	// the total memory reported by the kernel is a bit less than the installed one,
+	// these stand for machines of 4 GB and 8 GB
+	minMemory         = 3 << 30
+	recommendedMemory = 7 << 30
+
+	minInotifyWatches   = 524288
+	minInotifyInstances = 524288
+
+	maxTimeSkew = time.Minute
+)
+
+type CpuCheck struct{}
+
+func (t *CpuCheck) Name() string {
+	return "CPU"
+}
+
+func (t *CpuCheck) Check(runtime connector.Runtime) error {
+	cores := runtime.GetSystemInfo().GetCpuLogicalCount()
+	switch {
+	case cores == 0:
+		return notice("the number of CPU cores cannot be detected")
+	case cores < minCpuCores:
+		return failed(fmt.Sprintf("use a machine with at least %d CPU cores, %d are recommended", minCpuCores, recommendedCpuCores),
+			"%d CPU cores found, Olares requires at least %d", cores, minCpuCores)
+	case cores < recommendedCpuCores:
+		return warning("", "%d CPU cores found, %d are recommended for Olares to run smoothly", cores, recommendedCpuCores)
+	}
+	return nil
+}
+
+type MemoryCheck struct{}
+
+func (t *MemoryCheck) Name() string {
+	return "Memory"
+}
+
+func (t *MemoryCheck) Check(runtime connector.Runtime) error {
+	total := runtime.GetSystemInfo().GetTotalMemory()
+	switch {
+	case total == 0:
+		return notice("the total memory cannot be detected")
+	case total < minMemory:
+		return failed("use a machine with at least 4 GB of memory, 8 GB are recommended",
+			"%s of memory found, Olares requires at least 4 GB", util.FormatBytes(int64(total)))
+	case total < recommendedMemory:
+		return warning("", "%s of memory found, 8 GB are recommended for Olares to run smoothly", util.FormatBytes(int64(total)))
+	}
+	return nil
+}
+
+// DiskSpaceCheck checks the free space where the packages are downloaded to
+// and where the container images and the data of the cluster are kept
+type DiskSpaceCheck struct{}
+
+func (t *DiskSpaceCheck) Name() string {
+	return "DiskSpace"
+}
+
+func (t *DiskSpaceCheck) Check(runtime connector.Runtime) error {
+	if !runtime.GetSystemInfo().IsLinux() {
+		return nil
+	}
+	paths := []struct {
+		path             string
+		min, recommended uint64
+	}{
+		{runtime.GetBaseDir(), 10 << 30, 30 << 30},
+		{"/var/lib", 20 << 30, 50 << 30},
+	}
+	var warnings []string
+	for _, p := range paths {
+		free, err := freeSpace(p.path)
+		if err != nil {
+			return notice("the free space of %s cannot be detected: %v", p.path, err)
+		}
+		if free < p.min {
+			return failed("free up some space, or mount a larger disk there",
+				"%s free on %s, Olares requires at least %s", util.FormatBytes(int64(free)), p.path, util.FormatBytes(int64(p.min)))
+		}
+		if free < p.recommended {
+			warnings = append(warnings, fmt.Sprintf("%s free on %s, %s are recommended", util.FormatBytes(int64(free)), p.path, util.FormatBytes(int64(p.recommended))))
+		}
+	}
+	if len(warnings) > 0 {
+		return warning("free up some space, or mount a larger disk there", "%s", strings.Join(warnings, ", "))
+	}
+	return nil
+}
+
+// freeSpace returns the space available to the unprivileged users on the file system of path,
+// or of its closest existing parent if it does not exist yet
+func freeSpace(path string) (uint64, error) {
+	for !util.IsExist(path) && filepath.Dir(path) != path {
+		path = filepath.Dir(path)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	usage, err := disk.UsageWithContext(ctx, path)
+	if err != nil {
+		return 0, err
+	}
+	return usage.Free, nil
+}
+
+type KernelModulesCheck struct{}
+
+func (t *KernelModulesCheck) Name() string {
+	return "KernelModules"
+}
+
+func (t *KernelModulesCheck) Check(runtime connector.Runtime) error {
+	// the kernel of WSL has got them built in without publishing its modules
+	if !runtime.GetSystemInfo().IsLinux() || runtime.GetSystemInfo().IsWsl() {
+		return nil
+	}
+	release, err := os.ReadFile("/proc/sys/kernel/osrelease")
+	if err != nil {
+		return notice("the kernel release cannot be detected: %v", err)
+	}
+	var missing []string
+	for _, name := range []string{"br_netfilter", "overlay"} {
+		if !kernelModuleAvailable(name, strings.TrimSpace(string(release))) {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return failed("install the extra modules package of the kernel, e.g., linux-modules-extra-$(uname -r) on Ubuntu",
+			"kernel module %v required by Kubernetes is not available", missing)
+	}
+	return nil
+}
+
+// kernelModuleAvailable reports whether the module is loaded, built in or can be loaded
+func kernelModuleAvailable(name, release string) bool {
+	if util.IsExist(filepath.Join("/sys/module", name)) {
+		return true
+	}
+	for _, f := range []string{"modules.builtin", "modules.dep"} {
+		data, err := os.ReadFile(filepath.Join("/lib/modules", release, f))
+		if err != nil {
+			continue
+		}
+		if bytes.Contains(data, []byte("/"+name+".ko")) {
+			return true
+		}
+	}
+	return false
+}
+
+type CgroupCheck struct{}
+
+func (t *CgroupCheck) Name() string {
+	return "Cgroups"
+}
+
+func (t *CgroupCheck) Check(runtime connector.Runtime) error {
+	systemInfo := runtime.GetSystemInfo()
+	if !systemInfo.IsLinux() {
+		return nil
+	}
+	data, err := os.ReadFile("/sys/fs/cgroup/cgroup.controllers")
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return notice("the cgroup controllers cannot be detected: %v", err)
+		}
+		if !systemInfo.CgroupCpuEnabled() || !systemInfo.CgroupMemoryEnabled() {
+			return failed("enable them on the kernel command line, e.g., with cgroup_enable=cpuset cgroup_enable=memory cgroup_memory=1 on Raspberry Pi",
+				"the cpu and memory cgroups are required by Kubernetes, but are not enabled")
+		}
+		return notice("cgroup v1 is in use, cgroup v2 is recommended")
+	}
+	missing := missingControllers(string(data), "cpuset", "cpu", "memory", "pids")
+	if len(missing) > 0 {
+		return failed("enable them on the kernel command line, e.g., with cgroup_enable=cpuset cgroup_enable=memory cgroup_memory=1 on Raspberry Pi",
+			"cgroup v2 controller %v required by Kubernetes is not enabled", missing)
+	}
+	return nil
+}
+
+func missingControllers(enabled string, required ...string) []string {
+	have := make(map[string]bool)
+	for _, c := range strings.Fields(enabled) {
+		have[c] = true
+	}
+	var missing []string
+	for _, c := range required {
+		if !have[c] {
+			missing = append(missing, c)
+		}
+	}
+	return missing
+}
+
+type SecurityModulesCheck struct{}
+
+func (t *SecurityModulesCheck) Name() string {
+	return "SecurityModules"
+}
+
+func (t *SecurityModulesCheck) Check(runtime connector.Runtime) error {
+	if !runtime.GetSystemInfo().IsLinux() {
+		return nil
+	}
+	if enforce, err := os.ReadFile("/sys/fs/selinux/enforce"); err == nil && strings.TrimSpace(string(enforce)) == "1" {
+		return warning("run `setenforce 0` and set SELINUX=permissive in /etc/selinux/config",
+			"SELinux is enforcing, which may deny the access of the containers to the host paths used by Olares")
+	}
+	if enabled, err := os.ReadFile("/sys/module/apparmor/parameters/enabled"); err == nil && strings.TrimSpace(string(enabled)) == "Y" {
+		return notice("AppArmor is enabled, the containers will run with the default profile of containerd")
+	}
+	return nil
+}
+
+type InotifyCheck struct{}
+
+func (t *InotifyCheck) Name() string {
+	return "Inotify"
+}
+
+func (t *InotifyCheck) Check(runtime connector.Runtime) error {
+	if !runtime.GetSystemInfo().IsLinux() {
+		return nil
+	}
+	watches, err := readSysctl("fs/inotify/max_user_watches")
+	if err != nil {
+		return notice("the inotify limits cannot be read: %v", err)
+	}
+	if watches < minInotifyWatches {
+		return warning(fmt.Sprintf("add fs.inotify.max_user_watches = %d to /etc/sysctl.conf and run `sysctl -p`", minInotifyWatches),
+			"fs.inotify.max_user_watches is %d, the apps watching files may fail below %d", watches, minInotifyWatches)
+	}
+	if instances, err := readSysctl("fs/inotify/max_user_instances"); err == nil && instances < minInotifyInstances {
+		return notice("fs.inotify.max_user_instances is %d, it will be raised to %d during the installation", instances, minInotifyInstances)
+	}
+	return nil
+}
+
+func readSysctl(name string) (int64, error) {
+	data, err := os.ReadFile(filepath.Join("/proc/sys", name))
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+}
+
+type SwapCheck struct{}
+
+func (t *SwapCheck) Name() string {
+	return "Swap"
+}
+
+func (t *SwapCheck) Check(runtime connector.Runtime) error {
+	if !runtime.GetSystemInfo().IsLinux() {
+		return nil
+	}
+	arg := runtime.(*common.KubeRuntime).Arg
+	data, err := os.ReadFile("/proc/swaps")
+	if err != nil {
+		return notice("the swap devices cannot be detected: %v", err)
+	}
+	// the first line is the header
+	swapOn := len(strings.Split(strings.TrimSpace(string(data)), "\n")) > 1
+	switch {
+	case swapOn && !arg.EnablePodSwap:
+		return notice("swap is on, but only the system services will use it, set --enable-pod-swap to let the pods use it too")
+	case !swapOn && arg.EnablePodSwap && !arg.EnableZRAM:
+		return warning("set up a swap file or device, or set --enable-zram to use a ZRAM device",
+			"--enable-pod-swap is set, but no swap is on")
+	}
+	return nil
+}
+
+// downloadUrl is the address the packages are downloaded from, used to check the network
+func downloadUrl(runtime connector.Runtime) string {
+	if u := runtime.(*common.KubeRuntime).Arg.DownloadCdnUrl; u != "" {
+		return u
+	}
+	return cc.DownloadUrl
+}
+
+type DNSCheck struct{}
+
+func (t *DNSCheck) Name() string {
+	return "DNS"
+}
+
+func (t *DNSCheck) Check(runtime connector.Runtime) error {
+	u, err := url.Parse(downloadUrl(runtime))
+	if err != nil || u.Hostname() == "" {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(runtime.GetContext(), 5*time.Second)
+	defer cancel()
+	if _, err := net.DefaultResolver.LookupHost(ctx, u.Hostname()); err != nil {
+		return warning("check the nameservers in /etc/resolv.conf, this can be ignored for an offline installation",
+			"failed to resolve %s: %v", u.Hostname(), err)
+	}
+	return nil
+}
+
+// TimeSkewCheck compares the system clock with the one of the download server,
+// as the certificates and tokens issued by Olares are sensitive to it
+type TimeSkewCheck struct{}
+
+func (t *TimeSkewCheck) Name() string {
+	return "TimeSkew"
+}
+
+func (t *TimeSkewCheck) Check(runtime connector.Runtime) error {
+	ctx, cancel := context.WithTimeout(runtime.GetContext(), 5*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, downloadUrl(runtime), nil)
+	if err != nil {
+		return nil
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return notice("the time skew cannot be checked: %v", err)
+	}
+	resp.Body.Close()
+	remote, err := http.ParseTime(resp.Header.Get("Date"))
+	if err != nil {
+		return notice("the time skew cannot be checked, %s sends no valid date", req.URL.Host)
+	}
+	skew := time.Since(remote)
+	if skew < 0 {
+		skew = -skew
+	}
+	if skew > maxTimeSkew {
+		return warning("enable the time synchronization, e.g., with `timedatectl set-ntp true`",
+			"the system clock is %s off", skew.Round(time.Second))
+	}
+	return nil
+}
+
+// CIDRCheck checks that the pod and service networks of the cluster do not clash
+// with the networks the host is attached to
+type CIDRCheck struct{}
+
+func (t *CIDRCheck) Name() string {
+	return "CIDR"
+}
+
+// the interfaces created by the network plugins of a previous installation
+var clusterInterfacePrefixes = []string{"cni", "flannel", "cali", "vxlan.calico", "tunl", "kube-ipvs", "cilium", "lo"}
+
+func (t *CIDRCheck) Check(runtime connector.Runtime) error {
+	if !runtime.GetSystemInfo().IsLinux() {
+		return nil
+	}
+	cluster := runtime.(*common.KubeRuntime).Cluster
+	if cluster == nil {
+		return nil
+	}
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return notice("the network interfaces cannot be listed: %v", err)
+	}
+	hostNets := make(map[string][]*net.IPNet)
+	for _, iface := range ifaces {
+		if hasAnyPrefix(iface.Name, clusterInterfacePrefixes) {
+			continue
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			if ipNet, ok := addr.(*net.IPNet); ok {
+				hostNets[iface.Name] = append(hostNets[iface.Name], ipNet)
+			}
+		}
+	}
+
+	var clashes []string
+	for _, c := range []struct{ name, cidr string }{
+		{"pod", cluster.Network.KubePodsCIDR},
+		{"service", cluster.Network.KubeServiceCIDR},
+	} {
+		for _, cidr := range strings.Split(c.cidr, ",") {
+			_, clusterNet, err := net.ParseCIDR(strings.TrimSpace(cidr))
+			if err != nil {
+				continue
+			}
+			for iface, nets := range hostNets {
+				for _, n := range nets {
+					if networksOverlap(clusterNet, n) {
+						clashes = append(clashes, fmt.Sprintf("the %s network %s with %s on %s", c.name, clusterNet, n, iface))
+					}
+				}
+			}
+		}
+	}
+	if len(clashes) > 0 {
+		return failed("attach the host to networks not clashing with the pod and service networks of Olares",
+			"clash of %s", strings.Join(clashes, ", "))
+	}
+	return nil
+}
+
+func networksOverlap(a, b *net.IPNet) bool {
+	return a.Contains(b.IP.Mask(b.Mask)) || b.Contains(a.IP.Mask(a.Mask))
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
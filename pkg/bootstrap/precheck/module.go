@@ -17,6 +17,7 @@
 package precheck
 
 import (
+	"io"
 	"time"
 
 	"bytetrade.io/web3os/installer/pkg/common"
@@ -73,6 +74,8 @@ type GetKubeVersionModule struct {
 type RunPrechecksModule struct {
 	common.KubeModule
 	manifest.ManifestModule
+	// Report receives the JSON report of the checks if set
+	Report io.Writer
 }
 
 func (m *RunPrechecksModule) Init() {
@@ -84,11 +87,24 @@ func (m *RunPrechecksModule) Init() {
 		new(RequiredPortsCheck),
 		new(ConflictingContainerdCheck),
 		new(CudaChecker),
+		new(CpuCheck),
+		new(MemoryCheck),
+		new(DiskSpaceCheck),
+		new(KernelModulesCheck),
+		new(CgroupCheck),
+		new(SecurityModulesCheck),
+		new(InotifyCheck),
+		new(SwapCheck),
+		new(DNSCheck),
+		new(TimeSkewCheck),
+		new(CIDRCheck),
 	}
 	runPreChecks := &task.LocalTask{
-		Name: "RunPrechecks",
+		Name:      "RunPrechecks",
+		AlwaysRun: true,
 		Action: &RunChecks{
 			Checkers: checkers,
+			Report:   m.Report,
 		},
 	}
 
@@ -0,0 +1,110 @@
+package precheck
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Severity tells how a problem found by a check affects the installation,
+// only the problems of SeverityFail stop it
+type Severity string
+
+const (
+	SeverityFail Severity = "fail"
+	SeverityWarn Severity = "warn"
+	SeverityInfo Severity = "info"
+)
+
+// the status of a check in the report is either the severity of its problem or one of these
+const (
+	StatusPass = "pass"
+	StatusSkip = "skip"
+)
+
+// CheckError is returned by a Checker to give the severity of the problem it found
+// and a hint on how to fix it, any other error is a failure without hint
+type CheckError struct {
+	Severity Severity
+	Message  string
+	Hint     string
+}
+
+func (e *CheckError) Error() string {
+	return e.Message
+}
+
+func failed(hint, format string, args ...any) error {
+	return &CheckError{Severity: SeverityFail, Message: fmt.Sprintf(format, args...), Hint: hint}
+}
+
+func warning(hint, format string, args ...any) error {
+	return &CheckError{Severity: SeverityWarn, Message: fmt.Sprintf(format, args...), Hint: hint}
+}
+
+func notice(format string, args ...any) error {
+	return &CheckError{Severity: SeverityInfo, Message: fmt.Sprintf(format, args...)}
+}
+
+type CheckResult struct {
+	Name    string `json:"name"`
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+	Hint    string `json:"hint,omitempty"`
+}
+
+func newCheckResult(name string, err error) CheckResult {
+	r := CheckResult{Name: name, Status: StatusPass}
+	if err == nil {
+		return r
+	}
+	var ce *CheckError
+	if errors.As(err, &ce) {
+		r.Status, r.Message, r.Hint = string(ce.Severity), ce.Message, ce.Hint
+	} else {
+		r.Status, r.Message = string(SeverityFail), err.Error()
+	}
+	return r
+}
+
+func (r CheckResult) String() string {
+	s := fmt.Sprintf("[%s] %s", r.Name, r.Message)
+	if r.Hint != "" {
+		s += "\n    hint: " + r.Hint
+	}
+	return s
+}
+
+// Report is what the prechecks print with --output json
+type Report struct {
+	Passed bool          `json:"passed"`
+	Checks []CheckResult `json:"checks"`
+}
+
+func (r *Report) results(status string) []CheckResult {
+	var res []CheckResult
+	for _, c := range r.Checks {
+		if c.Status == status {
+			res = append(res, c)
+		}
+	}
+	return res
+}
+
+func (r *Report) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+func joinResults(results []CheckResult) string {
+	var b strings.Builder
+	for _, r := range results {
+		b.WriteString(r.String())
+		b.WriteString("\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,89 @@
+package precheck
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net"
+	"path/filepath"
+	"testing"
+
+	"bytetrade.io/web3os/installer/pkg/common"
+	"bytetrade.io/web3os/installer/pkg/core/connector"
+	"bytetrade.io/web3os/installer/pkg/core/logger"
+)
+
+type fakeCheck struct {
+	name string
+	err  error
+}
+
+func (c *fakeCheck) Name() string                          { return c.name }
+func (c *fakeCheck) Check(runtime connector.Runtime) error { return c.err }
+
+func TestRunChecks(t *testing.T) {
+	dir := t.TempDir()
+	logger.InitLog(dir, filepath.Join(dir, "test.log"), true)
+
+	run := func(skip []string, checkers ...Checker) (*Report, error) {
+		var out bytes.Buffer
+		a := &RunChecks{Checkers: checkers, Report: &out}
+		a.KubeConf = &common.KubeConf{Arg: &common.Argument{SkipChecks: skip}}
+		err := a.Execute(nil)
+		report := &Report{}
+		if jerr := json.Unmarshal(out.Bytes(), report); jerr != nil {
+			t.Fatal(jerr)
+		}
+		return report, err
+	}
+
+	report, err := run(nil,
+		&fakeCheck{name: "Passing"},
+		&fakeCheck{name: "Swap", err: warning("turn it off", "swap is on")},
+		&fakeCheck{name: "AppArmor", err: notice("AppArmor is enabled")},
+	)
+	if err != nil || !report.Passed {
+		t.Fatalf("warnings and notices must not fail the checks: %v", err)
+	}
+	if got := report.Checks[1]; got.Status != string(SeverityWarn) || got.Hint != "turn it off" {
+		t.Errorf("unexpected result %+v", got)
+	}
+
+	failing := []Checker{
+		&fakeCheck{name: "Ports", err: failed("stop them", "port [80] cannot be bound")},
+		&fakeCheck{name: "Legacy", err: errors.New("plain error")},
+	}
+	report, err = run(nil, failing...)
+	if err == nil || report.Passed {
+		t.Fatal("expected the checks to fail")
+	}
+	if got := report.Checks[1].Status; got != string(SeverityFail) {
+		t.Errorf("a plain error should fail the check, got %s", got)
+	}
+
+	report, err = run([]string{"ports", "Legacy"}, failing...)
+	if err != nil || !report.Passed {
+		t.Fatalf("skipped checks must not fail: %v", err)
+	}
+	for _, c := range report.Checks {
+		if c.Status != StatusSkip {
+			t.Errorf("%s should have been skipped", c.Name)
+		}
+	}
+}
+
+func TestNetworksOverlap(t *testing.T) {
+	_, pods, _ := net.ParseCIDR("10.233.64.0/18")
+	for cidr, want := range map[string]bool{
+		"192.168.1.10/24": false,
+		"10.233.70.5/24":  true,
+		"10.0.0.4/8":      true,
+		"10.233.0.1/18":   false,
+	} {
+		ip, n, _ := net.ParseCIDR(cidr)
+		n.IP = ip
+		if got := networksOverlap(pods, n); got != want {
+			t.Errorf("%s: got %v", cidr, got)
+		}
+	}
+}
@@ -18,10 +18,10 @@ package precheck
 
 import (
 	"bufio"
-	"bytes"
 	"context"
 	"fmt"
 	"github.com/Masterminds/semver/v3"
+	"io"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/sets"
@@ -32,8 +32,10 @@ import (
 	"strings"
 	"time"
 
+	"bytetrade.io/web3os/installer/pkg/certs"
 	"bytetrade.io/web3os/installer/pkg/common"
 	"bytetrade.io/web3os/installer/pkg/core/action"
+	"bytetrade.io/web3os/installer/pkg/core/cache"
 	"bytetrade.io/web3os/installer/pkg/core/connector"
 	"bytetrade.io/web3os/installer/pkg/core/logger"
 	"bytetrade.io/web3os/installer/pkg/core/util"
@@ -45,28 +47,60 @@ import (
 	kclient "k8s.io/client-go/kubernetes"
 )
 
+// RunChecks runs the checkers and reports what they found,
+// it fails only if a check not skipped has found a problem of SeverityFail
 type RunChecks struct {
 	common.KubeAction
 	Checkers []Checker
+	// Report receives the JSON report of the checks if set
+	Report io.Writer
 }
 
+// Checker checks one thing of the system, it returns a *CheckError
+// to give the severity of the problem found and a hint on how to fix it
 type Checker interface {
 	Name() string
 	Check(runtime connector.Runtime) error
 }
 
 func (t *RunChecks) Execute(runtime connector.Runtime) error {
-	var errBuffer bytes.Buffer
+	skip := make(map[string]bool)
+	for _, name := range t.KubeConf.Arg.SkipChecks {
+		skip[strings.ToLower(name)] = true
+	}
+
+	report := &Report{Passed: true}
 	for _, checker := range t.Checkers {
-		if err := checker.Check(runtime); err != nil {
-			errBuffer.WriteString(
-				fmt.Sprintf("[%s] %v\n", checker.Name(), err),
-			)
+		name := checker.Name()
+		if skip[strings.ToLower(name)] {
+			delete(skip, strings.ToLower(name))
+			logger.Infof("[%s] skipped", name)
+			report.Checks = append(report.Checks, CheckResult{Name: name, Status: StatusSkip})
+			continue
+		}
+		r := newCheckResult(name, checker.Check(runtime))
+		switch r.Status {
+		case string(SeverityFail):
+			report.Passed = false
+		case string(SeverityWarn):
+			logger.Warnf("%s", r)
+		case string(SeverityInfo):
+			logger.Infof("%s", r)
+		}
+		report.Checks = append(report.Checks, r)
+	}
+	for name := range skip {
+		logger.Warnf("no check named %q to skip", name)
+	}
+
+	if t.Report != nil {
+		if err := report.Write(t.Report); err != nil {
+			return err
 		}
 	}
-	if errBuffer.Len() > 0 {
-		logger.Errorf("Some checks have failed:\n%s", errBuffer.String())
-		os.Exit(1)
+	if !report.Passed {
+		logger.Errorf("Some checks have failed:\n%s", joinResults(report.results(string(SeverityFail))))
+		return errors.New("the system does not meet the requirements of Olares, fix the failed checks or skip them with --skip-check")
 	}
 	return nil
 }
@@ -102,7 +136,8 @@ func (t *RequiredPortsCheck) Check(runtime connector.Runtime) error {
 		defer l.Close()
 	}
 	if len(unbindablePorts) > 0 {
-		return fmt.Errorf("port %v required by Olares cannot be bound", unbindablePorts)
+		return failed("stop the services listening on them, they can be found with `ss -ltnp`",
+			"port %v required by Olares cannot be bound", unbindablePorts)
 	}
 	return nil
 }
@@ -123,11 +158,13 @@ func (t *ConflictingContainerdCheck) Check(runtime connector.Runtime) error {
 	}
 	containerdBin, err := util.GetCommand("containerd")
 	if err == nil && containerdBin != "" {
-		return fmt.Errorf("found existing containerd binary: %s, a containerd managed by Olares is required to ensure normal function", containerdBin)
+		return failed("uninstall the existing containerd, and docker if it comes along with it",
+			"found existing containerd binary: %s, a containerd managed by Olares is required to ensure normal function", containerdBin)
 	}
 	containerdSocket := "/run/containerd/containerd.sock"
 	if util.IsExist(containerdSocket) {
-		return fmt.Errorf("found existing containerd socket: %s, a containerd managed by Olares is required to ensure normal function", containerdSocket)
+		return failed("stop and uninstall the existing containerd, and docker if it comes along with it",
+			"found existing containerd socket: %s, a containerd managed by Olares is required to ensure normal function", containerdSocket)
 	}
 	return nil
 }
@@ -291,6 +328,7 @@ func (h *GreetingsTask) Execute(runtime connector.Runtime) error {
 
 type NodePreCheck struct {
 	common.KubeAction
+	cache.Filler
 }
 
 func (n *NodePreCheck) Execute(runtime connector.Runtime) error {
@@ -405,6 +443,7 @@ func (g *GetKubeConfig) Execute(runtime connector.Runtime) error {
 
 type GetAllNodesK8sVersion struct {
 	common.KubeAction
+	cache.Filler
 }
 
 func (g *GetAllNodesK8sVersion) Execute(runtime connector.Runtime) error {
@@ -431,6 +470,7 @@ func (g *GetAllNodesK8sVersion) Execute(runtime connector.Runtime) error {
 
 type CalculateMinK8sVersion struct {
 	common.KubeAction
+	cache.Filler
 }
 
 func (g *CalculateMinK8sVersion) Execute(runtime connector.Runtime) error {
@@ -459,6 +499,7 @@ func (g *CalculateMinK8sVersion) Execute(runtime connector.Runtime) error {
 
 type CheckDesiredK8sVersion struct {
 	common.KubeAction
+	cache.Filler
 }
 
 func (k *CheckDesiredK8sVersion) Execute(_ connector.Runtime) error {
@@ -472,6 +513,7 @@ func (k *CheckDesiredK8sVersion) Execute(_ connector.Runtime) error {
 
 type KsVersionCheck struct {
 	common.KubeAction
+	cache.Filler
 }
 
 func (k *KsVersionCheck) Execute(runtime connector.Runtime) error {
@@ -549,6 +591,7 @@ func (d *DependencyCheck) Execute(_ connector.Runtime) error {
 
 type GetKubernetesNodesStatus struct {
 	common.KubeAction
+	cache.Filler
 }
 
 func (g *GetKubernetesNodesStatus) Execute(runtime connector.Runtime) error {
@@ -568,6 +611,7 @@ func (g *GetKubernetesNodesStatus) Execute(runtime connector.Runtime) error {
 
 type GetStorageKeyTask struct {
 	common.KubeAction
+	cache.Filler
 }
 
 func (t *GetStorageKeyTask) Execute(runtime connector.Runtime) error {
@@ -615,6 +659,7 @@ func (t *GetStorageKeyTask) Execute(runtime connector.Runtime) error {
 		storageClusterId = stdout
 	}
 
+	logger.RegisterSecret(storageAccessKey, storageSecretKey, storageToken)
 	t.PipelineCache.Set(common.CacheAccessKey, storageAccessKey)
 	t.PipelineCache.Set(common.CacheSecretKey, storageSecretKey)
 	t.PipelineCache.Set(common.CacheToken, storageToken)
@@ -693,3 +738,27 @@ func (t *CudaCheckTask) Execute(runtime connector.Runtime) error {
 		return ErrCudaInstalled
 	}
 }
+
+// CertsExpiryCheck warns about the certificates of the node expired or expiring within WarnDays
+type CertsExpiryCheck struct {
+	WarnDays int
+}
+
+func (t *CertsExpiryCheck) Name() string {
+	return "CertsExpiry"
+}
+
+func (t *CertsExpiryCheck) Check(runtime connector.Runtime) error {
+	found := certs.ScanCerts()
+	if certs.CheckExpiry(found, time.Duration(t.WarnDays)*24*time.Hour, time.Now()) == 0 {
+		return nil
+	}
+	// sorted by expiry
+	first := found[0]
+	if first.Status == certs.ExpiryExpired {
+		return failed("renew them with olares-cli certs renew, see olares-cli certs check",
+			"certificate %s of %s expired on %s", first.Path, first.Component, first.Expires())
+	}
+	return warning("renew them with olares-cli certs renew, see olares-cli certs check",
+		"certificate %s of %s expires on %s, within %d days", first.Path, first.Component, first.Expires(), t.WarnDays)
+}
@@ -24,6 +24,7 @@ import (
 	"strings"
 
 	"bytetrade.io/web3os/installer/pkg/common"
+	"bytetrade.io/web3os/installer/pkg/core/cache"
 	"bytetrade.io/web3os/installer/pkg/core/connector"
 	"bytetrade.io/web3os/installer/pkg/utils/certs"
 	"github.com/pkg/errors"
@@ -96,6 +97,7 @@ func (f *FetchCerts) Execute(runtime connector.Runtime) error {
 
 type GenerateCerts struct {
 	common.KubeAction
+	cache.Filler
 }
 
 func (g *GenerateCerts) Execute(runtime connector.Runtime) error {
@@ -0,0 +1,165 @@
+package registry
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"bytetrade.io/web3os/installer/pkg/common"
+	"bytetrade.io/web3os/installer/pkg/core/cache"
+	"bytetrade.io/web3os/installer/pkg/core/connector"
+	"bytetrade.io/web3os/installer/pkg/core/logger"
+	"bytetrade.io/web3os/installer/pkg/manifest"
+	"bytetrade.io/web3os/installer/pkg/utils"
+	"bytetrade.io/web3os/installer/pkg/utils/certs"
+	"github.com/pkg/errors"
+	certutil "k8s.io/client-go/util/cert"
+	netutils "k8s.io/utils/net"
+)
+
+const (
+	// LocalRegistryPort is the port the local registry of Olares listens on
+	LocalRegistryPort = 5000
+	// LocalRegistryConfigFile is the configuration of the registry service,
+	// it exists on the node serving the local registry
+	LocalRegistryConfigFile = "/etc/kubekey/registry/config.yaml"
+	// LocalRegistryDataDir is where the local registry stores the images
+	LocalRegistryDataDir = "/olares/data/registry"
+)
+
+// LocalRegistryCAFile is the CA certificate of the local registry, on every node pulling from it
+var LocalRegistryCAFile = filepath.Join(common.RegistryCertDir, "ca.crt")
+
+// LocalRegistryAddress returns the address of the local registry served on the host
+func LocalRegistryAddress(host string) string {
+	return net.JoinHostPort(host, strconv.Itoa(LocalRegistryPort))
+}
+
+type InstallLocalRegistryBinary struct {
+	common.KubeAction
+	manifest.ManifestAction
+}
+
+func (g *InstallLocalRegistryBinary) Execute(runtime connector.Runtime) error {
+	if err := utils.ResetTmpDir(runtime); err != nil {
+		return err
+	}
+
+	binary, err := g.Manifest.Get("registry")
+	if err != nil {
+		return fmt.Errorf("get registry binary info failed, the local registry needs a manifest shipping it: %w", err)
+	}
+
+	dst := filepath.Join(common.TmpDir, binary.Filename)
+	if err := runtime.GetRunner().Scp(binary.FilePath(g.BaseDir), dst); err != nil {
+		return errors.Wrap(errors.WithStack(err), "sync registry tar.gz failed")
+	}
+
+	installCmd := fmt.Sprintf("tar -zxf %s -C %s registry && install -m 0755 %s /usr/local/bin/registry",
+		dst, common.TmpDir, filepath.Join(common.TmpDir, "registry"))
+	if _, err := runtime.GetRunner().SudoCmd(installCmd, false, false); err != nil {
+		return errors.Wrap(errors.WithStack(err), "install registry binary failed")
+	}
+	return nil
+}
+
+// GenerateLocalRegistryCerts generates the CA and the server certificate of the local registry,
+// the existing ones being kept, and installs them on the node serving it
+type GenerateLocalRegistryCerts struct {
+	common.KubeAction
+}
+
+func (g *GenerateLocalRegistryCerts) Execute(runtime connector.Runtime) error {
+	host := runtime.RemoteHost()
+	pkiPath := filepath.Join(runtime.GetWorkDir(), "pki", "registry")
+
+	altNames := &certutil.AltNames{
+		DNSNames: []string{"localhost", host.GetName()},
+		IPs:      []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback, netutils.ParseIPSloppy(host.GetInternalAddress())},
+	}
+	ca := KubekeyCertRegistryCA()
+	if err := certs.GenerateCA(ca, pkiPath, g.KubeConf); err != nil {
+		return errors.Wrap(err, "generate registry CA failed")
+	}
+	if err := certs.GenerateCerts(KubekeyCertRegistryServer(altNames), ca, pkiPath, g.KubeConf); err != nil {
+		return errors.Wrap(err, "generate registry server certificate failed")
+	}
+
+	files := map[string]string{
+		"ca.pem": filepath.Base(LocalRegistryCAFile),
+		fmt.Sprintf("%s.pem", RegistryCertificateBaseName):     fmt.Sprintf("%s.pem", RegistryCertificateBaseName),
+		fmt.Sprintf("%s-key.pem", RegistryCertificateBaseName): fmt.Sprintf("%s-key.pem", RegistryCertificateBaseName),
+	}
+	for src, dst := range files {
+		if err := runtime.GetRunner().SudoScp(filepath.Join(pkiPath, src), filepath.Join(common.RegistryCertDir, dst)); err != nil {
+			return errors.Wrap(errors.WithStack(err), "scp registry certs file failed")
+		}
+	}
+	return nil
+}
+
+type StartLocalRegistry struct {
+	common.KubeAction
+}
+
+func (s *StartLocalRegistry) Execute(runtime connector.Runtime) error {
+	cmd := fmt.Sprintf("mkdir -p %s && systemctl daemon-reload && systemctl enable registry && systemctl restart registry", LocalRegistryDataDir)
+	if _, err := runtime.GetRunner().SudoCmd(cmd, false, false); err != nil {
+		return errors.Wrap(errors.WithStack(err), "start registry service failed")
+	}
+	return nil
+}
+
+// CheckLocalRegistry checks the local registry served on this node answers, to be retried while it starts
+type CheckLocalRegistry struct {
+	common.KubeAction
+}
+
+func (c *CheckLocalRegistry) Execute(runtime connector.Runtime) error {
+	ca, err := os.ReadFile(LocalRegistryCAFile)
+	if err != nil {
+		return err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return fmt.Errorf("invalid CA certificate %s", LocalRegistryCAFile)
+	}
+	client := &http.Client{
+		Timeout:   10 * time.Second,
+		Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}},
+	}
+	resp, err := client.Get(fmt.Sprintf("https://%s/v2/", LocalRegistryAddress("127.0.0.1")))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("local registry answered %s", resp.Status)
+	}
+	logger.Infof("local registry is serving at %s", LocalRegistryAddress(runtime.GetLocalHost().GetInternalAddress()))
+	return nil
+}
+
+// FetchLocalRegistryCA fetches the CA certificate of the local registry from the node serving it,
+// for this node to trust it, and caches its address for this node to pull from it
+type FetchLocalRegistryCA struct {
+	common.KubeAction
+	cache.Filler
+}
+
+func (f *FetchLocalRegistryCA) Execute(runtime connector.Runtime) error {
+	if err := os.MkdirAll(filepath.Dir(LocalRegistryCAFile), 0755); err != nil {
+		return err
+	}
+	if err := runtime.GetRunner().Fetch(LocalRegistryCAFile, LocalRegistryCAFile, false, true); err != nil {
+		return errors.Wrapf(err, "fetch %s of the local registry failed", LocalRegistryCAFile)
+	}
+	f.PipelineCache.Set(common.LocalRegistry, LocalRegistryAddress(runtime.RemoteHost().GetInternalAddress()))
+	return nil
+}
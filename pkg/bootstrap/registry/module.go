@@ -19,6 +19,7 @@ package registry
 import (
 	"fmt"
 	"path/filepath"
+	"time"
 
 	"bytetrade.io/web3os/installer/pkg/bootstrap/registry/templates"
 	"bytetrade.io/web3os/installer/pkg/common"
@@ -28,6 +29,8 @@ import (
 	"bytetrade.io/web3os/installer/pkg/core/prepare"
 	"bytetrade.io/web3os/installer/pkg/core/task"
 	"bytetrade.io/web3os/installer/pkg/core/util"
+	"bytetrade.io/web3os/installer/pkg/images"
+	"bytetrade.io/web3os/installer/pkg/manifest"
 )
 
 type RegistryCertsModule struct {
@@ -293,3 +296,156 @@ func InstallHarbor(i *InstallRegistryModule) []task.Interface {
 		startHarbor,
 	}
 }
+
+// LocalRegistryModule serves the images of the manifest from a registry on the master,
+// pushed to it once rather than imported into containerd on every node, the nodes pulling them from it when needed
+// as it is set as the mirror of their registries, see k3s.GenerateK3sRegistryConfig
+type LocalRegistryModule struct {
+	common.KubeModule
+	manifest.ManifestModule
+	Skip bool
+}
+
+func (l *LocalRegistryModule) IsSkip() bool {
+	return l.Skip
+}
+
+func (l *LocalRegistryModule) Init() {
+	l.Name = "LocalRegistryModule"
+	l.Desc = "Serve the images from a local registry"
+
+	installRegistryBinary := &task.RemoteTask{
+		Name:  "InstallLocalRegistryBinary",
+		Desc:  "Install local registry",
+		Hosts: l.Runtime.GetHostsByRole(common.Master),
+		Action: &InstallLocalRegistryBinary{
+			ManifestAction: manifest.ManifestAction{
+				Manifest: l.Manifest,
+				BaseDir:  l.BaseDir,
+			},
+		},
+		Retry: 1,
+	}
+
+	generateCerts := &task.RemoteTask{
+		Name:   "GenerateLocalRegistryCerts",
+		Desc:   "Generate local registry certs",
+		Hosts:  l.Runtime.GetHostsByRole(common.Master),
+		Action: new(GenerateLocalRegistryCerts),
+	}
+
+	generateRegistryService := &task.RemoteTask{
+		Name:  "GenerateLocalRegistryService",
+		Desc:  "Generate local registry service",
+		Hosts: l.Runtime.GetHostsByRole(common.Master),
+		Action: &action.Template{
+			Name:     "GenerateLocalRegistryService",
+			Template: templates.RegistryServiceTempl,
+			Dst:      "/etc/systemd/system/registry.service",
+		},
+	}
+
+	localRegistryConfig := func(readOnly bool) action.Action {
+		return &action.Template{
+			Name:     "GenerateLocalRegistryConfig",
+			Template: templates.LocalRegistryConfigTempl,
+			Dst:      LocalRegistryConfigFile,
+			Data: util.Data{
+				"Port":        LocalRegistryPort,
+				"DataDir":     LocalRegistryDataDir,
+				"ReadOnly":    readOnly,
+				"Certificate": fmt.Sprintf("%s.pem", RegistryCertificateBaseName),
+				"Key":         fmt.Sprintf("%s-key.pem", RegistryCertificateBaseName),
+			},
+		}
+	}
+
+	generateRegistryConfig := &task.RemoteTask{
+		Name:   "GenerateLocalRegistryConfig",
+		Desc:   "Generate local registry config",
+		Hosts:  l.Runtime.GetHostsByRole(common.Master),
+		Action: localRegistryConfig(false),
+	}
+
+	startRegistry := &task.RemoteTask{
+		Name:   "StartLocalRegistry",
+		Desc:   "Start local registry",
+		Hosts:  l.Runtime.GetHostsByRole(common.Master),
+		Action: new(StartLocalRegistry),
+	}
+
+	checkRegistry := func() task.Interface {
+		return &task.LocalTask{
+			Name:      "CheckLocalRegistry",
+			AlwaysRun: true,
+			Desc:      "Check local registry is serving",
+			Action:    new(CheckLocalRegistry),
+			Retry:     10,
+			Delay:     3 * time.Second,
+		}
+	}
+
+	pushImages := &task.LocalTask{
+		Name: "PushImages",
+		Desc: "Push images to local registry",
+		Action: &images.PushImages{
+			ManifestAction: manifest.ManifestAction{
+				Manifest: l.Manifest,
+				BaseDir:  l.BaseDir,
+			},
+			Registry: LocalRegistryAddress("127.0.0.1"),
+			CertDir:  common.RegistryCertDir,
+		},
+		Retry: 1,
+	}
+
+	// nothing is to be pushed to the registry once it serves the images to the nodes
+	setRegistryReadOnly := &task.RemoteTask{
+		Name:   "SetLocalRegistryReadOnly",
+		Desc:   "Set local registry read-only",
+		Hosts:  l.Runtime.GetHostsByRole(common.Master),
+		Action: localRegistryConfig(true),
+	}
+
+	restartRegistry := &task.RemoteTask{
+		Name:   "RestartLocalRegistry",
+		Desc:   "Restart local registry",
+		Hosts:  l.Runtime.GetHostsByRole(common.Master),
+		Action: new(StartLocalRegistry),
+	}
+
+	l.Tasks = []task.Interface{
+		installRegistryBinary,
+		generateCerts,
+		generateRegistryService,
+		generateRegistryConfig,
+		startRegistry,
+		checkRegistry(),
+		pushImages,
+		setRegistryReadOnly,
+		restartRegistry,
+		checkRegistry(),
+	}
+}
+
+// SyncLocalRegistryCAModule makes a node joining the cluster trust the local registry of the master, if it serves one
+type SyncLocalRegistryCAModule struct {
+	common.KubeModule
+}
+
+func (s *SyncLocalRegistryCAModule) Init() {
+	s.Name = "SyncLocalRegistryCA"
+
+	s.Tasks = []task.Interface{
+		&task.RemoteTask{
+			Name:  "FetchLocalRegistryCA",
+			Desc:  "Fetch the CA certificate of the local registry",
+			Hosts: s.Runtime.GetHostsByRole(common.Master),
+			Prepare: &prepare.FileExist{
+				FilePath: LocalRegistryConfigFile,
+			},
+			Action: new(FetchLocalRegistryCA),
+			Retry:  1,
+		},
+	}
+}
@@ -47,6 +47,30 @@ storage:
         rootdirectory: /mnt/registry
 http:
     addr: :443
+    tls:
+      certificate: /etc/ssl/registry/ssl/{{ .Certificate }}
+      key: /etc/ssl/registry/ssl/{{ .Key }}
+    `)))
+
+	// LocalRegistryConfigTempl defines the template of the configuration file of the local registry of Olares,
+	// which is read-only once the images are pushed to it.
+	LocalRegistryConfigTempl = template.Must(template.New("localRegistryConfig").Parse(
+		dedent.Dedent(`version: 0.1
+log:
+  fields:
+    service: registry
+storage:
+    cache:
+        blobdescriptor: inmemory
+    filesystem:
+        rootdirectory: {{ .DataDir }}
+    maintenance:
+        uploadpurging:
+            enabled: false
+        readonly:
+            enabled: {{ .ReadOnly }}
+http:
+    addr: :{{ .Port }}
     tls:
       certificate: /etc/ssl/registry/ssl/{{ .Certificate }}
       key: /etc/ssl/registry/ssl/{{ .Key }}
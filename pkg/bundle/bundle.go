@@ -0,0 +1,322 @@
+package bundle
+
+import (
+	"archive/tar"
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/pkg/errors"
+)
+
+// An offline bundle is a zstd compressed tarball laid out as:
+//
+//	bundle.json                              what the bundle holds, always the first entry
+//	installer/...                            the installer directory of the version, with the manifest
+//	packages/<arch>/<item path>/<filename>   the packages and image tarballs listed in the manifest
+//	SHA256SUMS                               the checksum of every other entry, always the last one
+const (
+	InfoFile     = "bundle.json"
+	SumsFile     = "SHA256SUMS"
+	InstallerDir = "installer"
+	PackagesDir  = "packages"
+	Extension    = ".tar.zst"
+)
+
+type Info struct {
+	Version string   `json:"version"`
+	Arches  []string `json:"arches"`
+	// Manifest is the path of the installation manifest inside the bundle
+	Manifest  string    `json:"manifest"`
+	CreatedAt time.Time `json:"createdAt"`
+	CreatedBy string    `json:"createdBy"`
+}
+
+// PackagePath returns the path of a package of the given architecture inside the bundle
+func PackagePath(arch, itemPath, filename string) string {
+	return path.Join(PackagesDir, arch, filepath.ToSlash(itemPath), filename)
+}
+
+// Writer writes a bundle to a temporary file that is renamed to its final path on Close,
+// so that an interrupted export does not leave a truncated bundle behind
+type Writer struct {
+	path string
+	f    *os.File
+	zw   *zstd.Encoder
+	tw   *tar.Writer
+	sums map[string]string
+}
+
+func Create(bundlePath string, info Info) (*Writer, error) {
+	f, err := os.Create(bundlePath + ".part")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create bundle")
+	}
+	zw, err := zstd.NewWriter(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	w := &Writer{path: bundlePath, f: f, zw: zw, tw: tar.NewWriter(zw), sums: make(map[string]string)}
+
+	data, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		w.Abort()
+		return nil, err
+	}
+	if err := w.writeEntry(InfoFile, 0644, int64(len(data)), strings.NewReader(string(data)), false); err != nil {
+		w.Abort()
+		return nil, err
+	}
+	return w, nil
+}
+
+// AddFile adds the file at src to the bundle under name
+func (w *Writer) AddFile(name, src string) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	return w.writeEntry(name, fi.Mode().Perm(), fi.Size(), f, true)
+}
+
+// AddDir adds the regular files under dir to the bundle under prefix,
+// skipping the files and subdirectories listed in skip, by their path relative to dir
+func (w *Writer) AddDir(prefix, dir string, skip ...string) error {
+	return filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		for _, s := range skip {
+			if rel != s {
+				continue
+			}
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		return w.AddFile(path.Join(prefix, filepath.ToSlash(rel)), p)
+	})
+}
+
+func (w *Writer) writeEntry(name string, mode os.FileMode, size int64, r io.Reader, sum bool) error {
+	if _, ok := w.sums[name]; ok {
+		return fmt.Errorf("duplicated bundle entry %s", name)
+	}
+	hdr := &tar.Header{Name: name, Mode: int64(mode), Size: size, ModTime: time.Now(), Typeflag: tar.TypeReg}
+	if err := w.tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	h := sha256.New()
+	if _, err := io.Copy(w.tw, io.TeeReader(r, h)); err != nil {
+		return errors.Wrapf(err, "failed to add %s to the bundle", name)
+	}
+	if sum {
+		w.sums[name] = hex.EncodeToString(h.Sum(nil))
+	}
+	return nil
+}
+
+// Close writes the checksum index and moves the bundle to its final path
+func (w *Writer) Close() error {
+	names := make([]string, 0, len(w.sums))
+	for name := range w.sums {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&b, "%s  %s\n", w.sums[name], name)
+	}
+	err := w.writeEntry(SumsFile, 0644, int64(b.Len()), strings.NewReader(b.String()), false)
+	if err == nil {
+		err = w.tw.Close()
+	}
+	if err == nil {
+		err = w.zw.Close()
+	}
+	if cerr := w.f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(w.f.Name())
+		return errors.Wrap(err, "failed to write bundle")
+	}
+	return os.Rename(w.f.Name(), w.path)
+}
+
+// Abort drops the bundle being written
+func (w *Writer) Abort() {
+	w.zw.Close()
+	w.f.Close()
+	os.Remove(w.f.Name())
+}
+
+// ReadInfo reads what the bundle holds without extracting it
+func ReadInfo(bundlePath string) (*Info, error) {
+	f, err := os.Open(bundlePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	zr, err := zstd.NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return readInfo(tar.NewReader(zr))
+}
+
+func readInfo(tr *tar.Reader) (*Info, error) {
+	hdr, err := tr.Next()
+	if err != nil {
+		return nil, errors.Wrap(err, "not an olares bundle")
+	}
+	if hdr.Name != InfoFile {
+		return nil, fmt.Errorf("not an olares bundle, %s is missing", InfoFile)
+	}
+	info := &Info{}
+	if err := json.NewDecoder(tr).Decode(info); err != nil {
+		return nil, errors.Wrapf(err, "invalid %s", InfoFile)
+	}
+	return info, nil
+}
+
+// Extract unpacks the bundle into dir and checks every file against the checksum index,
+// the caller should discard dir if an error is returned
+func Extract(bundlePath, dir string) (*Info, error) {
+	f, err := os.Open(bundlePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	zr, err := zstd.NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	tr := tar.NewReader(zr)
+
+	info, err := readInfo(tr)
+	if err != nil {
+		return nil, err
+	}
+
+	got := make(map[string]string)
+	var sums []byte
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "corrupted bundle")
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		if hdr.Name == SumsFile {
+			if sums, err = io.ReadAll(tr); err != nil {
+				return nil, errors.Wrap(err, "corrupted bundle")
+			}
+			continue
+		}
+		target, err := entryPath(dir, hdr.Name)
+		if err != nil {
+			return nil, err
+		}
+		sum, err := extractFile(tr, target, os.FileMode(hdr.Mode).Perm())
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to extract %s", hdr.Name)
+		}
+		got[hdr.Name] = sum
+	}
+
+	if sums == nil {
+		return nil, fmt.Errorf("corrupted bundle, %s is missing", SumsFile)
+	}
+	if err := verify(got, sums); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// entryPath refuses the entries that would land outside of dir
+func entryPath(dir, name string) (string, error) {
+	clean := path.Clean("/" + name)
+	if clean == "/" || clean != "/"+name {
+		return "", fmt.Errorf("invalid bundle entry %q", name)
+	}
+	return filepath.Join(dir, filepath.FromSlash(clean[1:])), nil
+}
+
+func extractFile(r io.Reader, target string, mode os.FileMode) (string, error) {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return "", err
+	}
+	f, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, mode)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(f, io.TeeReader(r, h)); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// verify checks the extracted files against the checksum index, in both directions
+func verify(got map[string]string, sums []byte) error {
+	var problems []string
+	listed := make(map[string]bool)
+	scanner := bufio.NewScanner(strings.NewReader(string(sums)))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		want, name := fields[0], fields[1]
+		listed[name] = true
+		sum, ok := got[name]
+		switch {
+		case !ok:
+			problems = append(problems, name+" is missing")
+		case sum != want:
+			problems = append(problems, name+" does not match its checksum")
+		}
+	}
+	for name := range got {
+		if !listed[name] {
+			problems = append(problems, name+" is not in "+SumsFile)
+		}
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("bundle verification failed:\n  %s", strings.Join(problems, "\n  "))
+	}
+	return nil
+}
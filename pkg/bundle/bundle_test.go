@@ -0,0 +1,125 @@
+package bundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestBundleRoundTrip(t *testing.T) {
+	src := t.TempDir()
+	installer := filepath.Join(src, "installer")
+	files := map[string]string{
+		"installer/install.sh":             "#!/bin/sh\n",
+		"installer/wizard/config.yaml":     "config\n",
+		"installer/cli/logs/install.log":   "left out\n",
+		"pkg/components/k3s":               "k3s binary\n",
+		"installer/installation.manifest":  "manifest\n",
+		"installer/installation.manifest2": "kept\n",
+	}
+	for name, content := range files {
+		p := filepath.Join(src, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	bundlePath := filepath.Join(t.TempDir(), "olares-1.12.0"+Extension)
+	w, err := Create(bundlePath, Info{Version: "1.12.0", Arches: []string{"amd64"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.AddDir(InstallerDir, installer, "cli", "installation.manifest"); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.AddFile(PackagePath("amd64", "pkg/components", "k3s"), filepath.Join(src, "pkg/components/k3s")); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := ReadInfo(bundlePath)
+	if err != nil || info.Version != "1.12.0" {
+		t.Fatalf("unexpected info %+v: %v", info, err)
+	}
+
+	dst := t.TempDir()
+	if _, err := Extract(bundlePath, dst); err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]string{
+		"installer/install.sh":              "#!/bin/sh\n",
+		"installer/installation.manifest2":  "kept\n",
+		"packages/amd64/pkg/components/k3s": "k3s binary\n",
+	} {
+		got, err := os.ReadFile(filepath.Join(dst, name))
+		if err != nil || string(got) != want {
+			t.Errorf("%s: got %q, %v", name, got, err)
+		}
+	}
+	for _, name := range []string{"installer/cli/logs/install.log", "installer/installation.manifest"} {
+		if _, err := os.Stat(filepath.Join(dst, name)); !os.IsNotExist(err) {
+			t.Errorf("%s should have been left out", name)
+		}
+	}
+	if fi, err := os.Stat(filepath.Join(dst, "installer/install.sh")); err != nil || fi.Mode().Perm()&0100 == 0 {
+		t.Errorf("install.sh lost its mode: %v", err)
+	}
+
+	// flip the content of a package, keeping the checksum index as is
+	tampered := filepath.Join(t.TempDir(), "tampered"+Extension)
+	rewrite(t, bundlePath, tampered, func(name string, data []byte) []byte {
+		if name == PackagePath("amd64", "pkg/components", "k3s") {
+			return []byte("k3s backdoor\n")
+		}
+		return data
+	})
+	if _, err := Extract(tampered, t.TempDir()); err == nil {
+		t.Error("expected a tampered bundle to be refused")
+	}
+}
+
+func rewrite(t *testing.T, src, dst string, edit func(name string, data []byte) []byte) {
+	in, err := os.Open(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer in.Close()
+	zr, err := zstd.NewReader(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+
+	var out bytes.Buffer
+	zw, _ := zstd.NewWriter(&out)
+	tr, tw := tar.NewReader(zr), tar.NewWriter(zw)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(tr)
+		data = edit(hdr.Name, data)
+		hdr.Size = int64(len(data))
+		tw.WriteHeader(hdr)
+		tw.Write(data)
+	}
+	tw.Close()
+	zw.Close()
+	if err := os.WriteFile(dst, out.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,173 @@
+package certs
+
+import (
+	"crypto/x509"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"bytetrade.io/web3os/installer/pkg/common"
+	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
+	"k8s.io/client-go/tools/clientcmd"
+	certutil "k8s.io/client-go/util/cert"
+)
+
+// DefaultExpiryWarnDays is how many days before a certificate expires it is warned about
+const DefaultExpiryWarnDays = 30
+
+// ExpiryWarnDays is the one given to olares-cli certs check, see AddFlags
+var ExpiryWarnDays = DefaultExpiryWarnDays
+
+// the statuses of a CertExpiry
+const (
+	ExpiryOK       = "ok"
+	ExpirySoon     = "expiring"
+	ExpiryExpired  = "expired"
+	expiryTimeForm = "Jan 02, 2006 15:04 MST"
+)
+
+// CertExpiry is the expiry of a certificate found on the local node
+type CertExpiry struct {
+	Component string    `json:"component"`
+	Name      string    `json:"name"`
+	Path      string    `json:"path"`
+	Authority bool      `json:"authority"`
+	NotAfter  time.Time `json:"notAfter"`
+	Residual  string    `json:"residual"`
+	// Status is set by CheckExpiry
+	Status string `json:"status"`
+}
+
+func (c *CertExpiry) Expires() string {
+	return c.NotAfter.Format(expiryTimeForm)
+}
+
+type certLocation struct {
+	component string
+	pattern   string
+}
+
+// certLocations are where the certificates of k3s, kubeadm, etcd and the registry are,
+// only those of the components installed on the node are found
+var certLocations = []certLocation{
+	{component: "kubernetes", pattern: filepath.Join(common.KubeCertDir, "*.crt")},
+	{component: "kubernetes", pattern: filepath.Join(common.KubeCertDir, "etcd", "*.crt")},
+	{component: "kubernetes", pattern: filepath.Join(common.KubeConfigDir, "*.conf")},
+	{component: "kubelet", pattern: "/var/lib/kubelet/pki/*.crt"},
+	{component: "kubelet", pattern: "/var/lib/kubelet/pki/kubelet-client-current.pem"},
+	{component: common.K3s, pattern: "/var/lib/rancher/k3s/server/tls/*.crt"},
+	{component: common.K3s, pattern: "/var/lib/rancher/k3s/server/tls/etcd/*.crt"},
+	{component: common.K3s, pattern: "/var/lib/rancher/k3s/agent/*.crt"},
+	{component: "etcd", pattern: filepath.Join(common.ETCDCertDir, "*.pem")},
+	{component: "registry", pattern: filepath.Join(common.RegistryCertDir, "*.pem")},
+}
+
+// ScanCerts reads the expiry of the certificates of the local node, sorted by expiry,
+// the files it cannot read are skipped, it must run as root to read them all
+func ScanCerts() []CertExpiry {
+	return scanCerts(certLocations)
+}
+
+func scanCerts(locations []certLocation) []CertExpiry {
+	var certs []CertExpiry
+	seen := make(map[string]bool)
+	for _, l := range locations {
+		files, _ := filepath.Glob(l.pattern)
+		for _, file := range files {
+			if seen[file] || strings.HasSuffix(file, "-key.pem") {
+				continue
+			}
+			seen[file] = true
+			cert, err := readCert(file)
+			if err != nil || cert == nil {
+				continue
+			}
+			certs = append(certs, CertExpiry{
+				Component: l.component,
+				Name:      filepath.Base(file),
+				Path:      file,
+				Authority: cert.IsCA,
+				NotAfter:  cert.NotAfter,
+				Residual:  ResidualTime(cert.NotAfter),
+			})
+		}
+	}
+	sort.SliceStable(certs, func(i, j int) bool {
+		return certs[i].NotAfter.Before(certs[j].NotAfter)
+	})
+	return certs
+}
+
+// readCert reads the first certificate of a PEM file, or the client certificate embedded in a kubeconfig,
+// it returns nil if there is none
+func readCert(file string) (*x509.Certificate, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	if strings.HasSuffix(file, ".conf") {
+		config, err := clientcmd.Load(data)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse kubeconfig %s", file)
+		}
+		data = nil
+		for _, a := range config.AuthInfos {
+			if len(a.ClientCertificateData) > 0 {
+				data = a.ClientCertificateData
+				break
+			}
+		}
+		if data == nil {
+			return nil, nil
+		}
+	}
+	certs, err := certutil.ParseCertsPEM(data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse certificate %s", file)
+	}
+	return certs[0], nil
+}
+
+// CheckExpiry sets the status of the certificates, those expiring within the given duration from now are ExpirySoon,
+// and returns how many are expired or expiring
+func CheckExpiry(certs []CertExpiry, within time.Duration, now time.Time) int {
+	n := 0
+	for i := range certs {
+		switch {
+		case !certs[i].NotAfter.After(now):
+			certs[i].Status = ExpiryExpired
+			n++
+		case certs[i].NotAfter.Before(now.Add(within)):
+			certs[i].Status = ExpirySoon
+			n++
+		default:
+			certs[i].Status = ExpiryOK
+		}
+	}
+	return n
+}
+
+// AddFlags registers the number of days before expiry the certificates are reported about to ExpiryWarnDays
+func AddFlags(fs *pflag.FlagSet) {
+	fs.IntVar(&ExpiryWarnDays, "certs-warn-days", DefaultExpiryWarnDays, "Fail if a certificate of the node expires within this many days, 0 for the expired ones only")
+}
+
+// WarnExpiring writes a warning about the certificates of the node expired or expiring within ExpiryWarnDays,
+// it is quiet if there is none or the certificates cannot be read, as when not run as root
+func WarnExpiring(w io.Writer) {
+	if ExpiryWarnDays <= 0 {
+		return
+	}
+	certs := ScanCerts()
+	n := CheckExpiry(certs, time.Duration(ExpiryWarnDays)*24*time.Hour, time.Now())
+	if n == 0 {
+		return
+	}
+	_, _ = fmt.Fprintf(w, "WARNING: %d certificate(s) of this node expire within %d days, %s of %s first on %s, see olares-cli certs check\n",
+		n, ExpiryWarnDays, certs[0].Name, certs[0].Component, certs[0].Expires())
+}
@@ -0,0 +1,51 @@
+package certs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestScanAndCheckExpiry(t *testing.T) {
+	dir := t.TempDir()
+	if err := newSelfSignedCA(filepath.Join(dir, "server-ca"), "test-ca"); err != nil {
+		t.Fatal(err)
+	}
+	// keys and files that are not certificates are skipped
+	if err := os.WriteFile(filepath.Join(dir, "broken.crt"), []byte("not a certificate"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	found := scanCerts([]certLocation{
+		{component: "k3s", pattern: filepath.Join(dir, "*.crt")},
+		{component: "k3s", pattern: filepath.Join(dir, "*.key")},
+	})
+	if len(found) != 1 {
+		t.Fatalf("found %d certificates, want 1: %+v", len(found), found)
+	}
+	c := found[0]
+	if c.Name != "server-ca.crt" || c.Component != "k3s" || !c.Authority {
+		t.Errorf("unexpected certificate %+v", c)
+	}
+
+	tests := []struct {
+		now    time.Time
+		within time.Duration
+		status string
+	}{
+		{now: time.Now(), within: 30 * 24 * time.Hour, status: ExpiryOK},
+		{now: c.NotAfter.Add(-24 * time.Hour), within: 30 * 24 * time.Hour, status: ExpirySoon},
+		{now: c.NotAfter.Add(-24 * time.Hour), within: 0, status: ExpiryOK},
+		{now: c.NotAfter, within: 0, status: ExpiryExpired},
+	}
+	for _, tt := range tests {
+		n := CheckExpiry(found, tt.within, tt.now)
+		if found[0].Status != tt.status {
+			t.Errorf("now %s within %s: status = %s, want %s", tt.now, tt.within, found[0].Status, tt.status)
+		}
+		if want := map[bool]int{true: 0, false: 1}[tt.status == ExpiryOK]; n != want {
+			t.Errorf("now %s within %s: %d expiring, want %d", tt.now, tt.within, n, want)
+		}
+	}
+}
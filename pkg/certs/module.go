@@ -18,6 +18,7 @@ package certs
 
 import (
 	"path/filepath"
+	"time"
 
 	versionutil "k8s.io/apimachinery/pkg/util/version"
 
@@ -27,6 +28,7 @@ import (
 	"bytetrade.io/web3os/installer/pkg/core/prepare"
 	"bytetrade.io/web3os/installer/pkg/core/task"
 	"bytetrade.io/web3os/installer/pkg/core/util"
+	"bytetrade.io/web3os/installer/pkg/k3s"
 	"bytetrade.io/web3os/installer/pkg/kubernetes"
 )
 
@@ -39,11 +41,12 @@ func (c *CheckCertsModule) Init() {
 	c.Desc = "Check cluster certs"
 
 	check := &task.RemoteTask{
-		Name:     "CheckClusterCerts",
-		Desc:     "Check cluster certs",
-		Hosts:    c.Runtime.GetHostsByRole(common.Master),
-		Action:   new(ListClusterCerts),
-		Parallel: true,
+		Name:      "CheckClusterCerts",
+		AlwaysRun: true,
+		Desc:      "Check cluster certs",
+		Hosts:     c.Runtime.GetHostsByRole(common.Master),
+		Action:    new(ListClusterCerts),
+		Parallel:  true,
 	}
 
 	c.Tasks = []task.Interface{
@@ -233,3 +236,87 @@ func (m *UninstallCertsFilesModule) Init() {
 		uninstall,
 	}
 }
+
+// RenewLocalCertsModule renews the certificates of the control plane on the local master, of k3s or of kubeadm,
+// all of them if no component is given
+type RenewLocalCertsModule struct {
+	common.KubeModule
+	Components []string
+}
+
+func (r *RenewLocalCertsModule) Init() {
+	r.Name = "RenewLocalCertsModule"
+	r.Desc = "Renew control-plane certs of the node"
+
+	var renew action.Action = &RenewKubeadmCerts{Components: r.Components}
+	if r.KubeConf.Cluster.Kubernetes.Type == common.K3s {
+		renew = &RenewK3sCerts{Components: r.Components}
+	}
+
+	r.Tasks = []task.Interface{
+		&task.LocalTask{
+			Name:      "CheckControlPlane",
+			AlwaysRun: true,
+			Action:    new(CheckControlPlane),
+		},
+		&task.LocalTask{
+			Name:   "RenewCerts",
+			Desc:   "Renew control-plane certs",
+			Action: renew,
+		},
+	}
+	r.Tasks = append(r.Tasks, waitAndCopyKubeConfig(r.KubeConf)...)
+}
+
+// RotateCAModule replaces the certificate authorities of the local master, of k3s or of kubeadm, with new ones,
+// every certificate they sign is issued again, the workers are to be restarted, or added again for kubeadm, to trust them
+type RotateCAModule struct {
+	common.KubeModule
+}
+
+func (r *RotateCAModule) Init() {
+	r.Name = "RotateCAModule"
+	r.Desc = "Rotate the certificate authorities of the cluster"
+
+	var rotate action.Action = new(RotateKubeadmCA)
+	if r.KubeConf.Cluster.Kubernetes.Type == common.K3s {
+		rotate = new(RotateK3sCA)
+	}
+
+	r.Tasks = []task.Interface{
+		&task.LocalTask{
+			Name:      "CheckControlPlane",
+			AlwaysRun: true,
+			Action:    new(CheckControlPlane),
+		},
+		&task.LocalTask{
+			Name:   "RotateCA",
+			Desc:   "Rotate the certificate authorities",
+			Action: rotate,
+		},
+	}
+	r.Tasks = append(r.Tasks, waitAndCopyKubeConfig(r.KubeConf)...)
+}
+
+// waitAndCopyKubeConfig waits for the API server to be ready with its new certificates
+// and copies the kubeconfig signed by them to ~/.kube/config
+func waitAndCopyKubeConfig(kubeConf *common.KubeConf) []task.Interface {
+	var copyKubeConfig action.Action = new(kubernetes.CopyKubeConfigForControlPlane)
+	if kubeConf.Cluster.Kubernetes.Type == common.K3s {
+		copyKubeConfig = new(k3s.CopyK3sKubeConfig)
+	}
+	return []task.Interface{
+		&task.LocalTask{
+			Name:   "CopyKubeConfig",
+			Action: copyKubeConfig,
+			Retry:  2,
+		},
+		&task.LocalTask{
+			Name:      "WaitKubeAPIServer",
+			AlwaysRun: true,
+			Action:    new(WaitKubeAPIServer),
+			Retry:     30,
+			Delay:     10 * time.Second,
+		},
+	}
+}
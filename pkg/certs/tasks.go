@@ -17,7 +17,11 @@
 package certs
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
 	"encoding/base64"
+	"encoding/pem"
 	"fmt"
 	"os"
 	"path"
@@ -28,8 +32,10 @@ import (
 
 	"bytetrade.io/web3os/installer/pkg/certs/templates"
 	"bytetrade.io/web3os/installer/pkg/common"
+	"bytetrade.io/web3os/installer/pkg/core/cache"
 	cc "bytetrade.io/web3os/installer/pkg/core/common"
 	"bytetrade.io/web3os/installer/pkg/core/connector"
+	"bytetrade.io/web3os/installer/pkg/core/logger"
 	"bytetrade.io/web3os/installer/pkg/core/util"
 	"bytetrade.io/web3os/installer/pkg/utils"
 	"github.com/pkg/errors"
@@ -38,6 +44,7 @@ import (
 	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 	clientcmdlatest "k8s.io/client-go/tools/clientcmd/api/latest"
 	certutil "k8s.io/client-go/util/cert"
+	"k8s.io/client-go/util/keyutil"
 )
 
 type Certificate struct {
@@ -74,6 +81,7 @@ var (
 
 type ListClusterCerts struct {
 	common.KubeAction
+	cache.Filler
 }
 
 func (l *ListClusterCerts) Execute(runtime connector.Runtime) error {
@@ -439,3 +447,194 @@ func (t *UninstallCertsFiles) Execute(runtime connector.Runtime) error {
 	}
 	return nil
 }
+
+// kubeadmCertsCmd is the kubeadm command managing the certificates, under alpha before v1.20
+func kubeadmCertsCmd(runtime connector.Runtime) (string, error) {
+	version, err := runtime.GetRunner().SudoCmd("/usr/local/bin/kubeadm version -o short", false, false)
+	if err != nil {
+		return "", errors.Wrap(errors.WithStack(err), "kubeadm get version failed")
+	}
+	v, err := versionutil.ParseSemantic(version)
+	if err != nil {
+		return "", errors.Wrap(err, "parse kubeadm version failed")
+	}
+	if v.LessThan(versionutil.MustParseSemantic("v1.20.0")) {
+		return "/usr/local/bin/kubeadm alpha certs", nil
+	}
+	return "/usr/local/bin/kubeadm certs", nil
+}
+
+// RestartControlPlanePods removes the pods of the control plane of kubeadm for kubelet to start them again,
+// as with new certificates or after the datastore is restored
+const RestartControlPlanePods = "/usr/local/bin/crictl pods --namespace kube-system --name 'kube-scheduler-*|kube-controller-manager-*|kube-apiserver-*|etcd-*' -q " +
+	"| xargs --no-run-if-empty /usr/local/bin/crictl rmp -f"
+
+// RenewKubeadmCerts renews the certificates of the control plane on the local kubeadm master, all of them if no component is given,
+// the components are the names of the certificates known to kubeadm certs renew
+type RenewKubeadmCerts struct {
+	common.KubeAction
+	Components []string
+}
+
+func (r *RenewKubeadmCerts) Execute(runtime connector.Runtime) error {
+	certsCmd, err := kubeadmCertsCmd(runtime)
+	if err != nil {
+		return err
+	}
+	components := r.Components
+	if len(components) == 0 {
+		components = []string{"all"}
+	}
+	for _, c := range components {
+		if _, err := runtime.GetRunner().SudoCmd(fmt.Sprintf("%s renew %s", certsCmd, c), false, true); err != nil {
+			return errors.Wrapf(err, "kubeadm renew the certificate of %s failed", c)
+		}
+	}
+	if _, err := runtime.GetRunner().SudoCmd(RestartControlPlanePods+" && systemctl restart kubelet", false, false); err != nil {
+		return errors.Wrap(err, "restart the control plane failed")
+	}
+	return nil
+}
+
+// RenewK3sCerts renews the certificates of the local k3s server, of all the services if no component is given,
+// the components are the services known to k3s certificate rotate, k3s is stopped meanwhile
+type RenewK3sCerts struct {
+	common.KubeAction
+	Components []string
+}
+
+func (r *RenewK3sCerts) Execute(runtime connector.Runtime) error {
+	cmd := "/usr/local/bin/k3s certificate rotate"
+	for _, c := range r.Components {
+		cmd += " --service " + c
+	}
+	if _, err := runtime.GetRunner().SudoCmd("systemctl stop k3s", false, false); err != nil {
+		return errors.Wrap(errors.WithStack(err), "stop k3s failed")
+	}
+	_, rotateErr := runtime.GetRunner().SudoCmd(cmd, false, true)
+	// k3s is to be running again whether the certificates are renewed or not
+	if _, err := runtime.GetRunner().SudoCmd("systemctl start k3s", false, false); err != nil {
+		return errors.Wrap(errors.WithStack(err), "start k3s failed")
+	}
+	if rotateErr != nil {
+		return errors.Wrap(rotateErr, "k3s renew the certificates failed")
+	}
+	return nil
+}
+
+// k3sCAs are the certificate authorities of a k3s server, the etcd ones only exist with the embedded etcd
+var k3sCAs = []string{"client-ca", "server-ca", "request-header-ca", "etcd/peer-ca", "etcd/server-ca"}
+
+const k3sTLSDir = "/var/lib/rancher/k3s/server/tls"
+
+// RotateK3sCA replaces the certificate authorities of the local k3s server with new self-signed ones,
+// the key signing the service account tokens is kept, k3s is restarted to issue all the certificates again
+type RotateK3sCA struct {
+	common.KubeAction
+}
+
+func (r *RotateK3sCA) Execute(runtime connector.Runtime) error {
+	dir := filepath.Join(filepath.Dir(k3sTLSDir), fmt.Sprintf("rotate-ca-%d", time.Now().Unix()))
+	for _, ca := range k3sCAs {
+		if !util.IsExist(filepath.Join(k3sTLSDir, ca+".crt")) {
+			continue
+		}
+		if err := newSelfSignedCA(filepath.Join(dir, ca), fmt.Sprintf("k3s-%s@%d", filepath.Base(ca), time.Now().Unix())); err != nil {
+			return err
+		}
+	}
+	if err := util.CopyFile(filepath.Join(k3sTLSDir, "service.key"), filepath.Join(dir, "service.key")); err != nil {
+		return errors.Wrap(err, "copy the service account key failed")
+	}
+
+	// the new authorities are not signed by the current ones, which k3s only accepts when forced
+	if _, err := runtime.GetRunner().SudoCmd(fmt.Sprintf("/usr/local/bin/k3s certificate rotate-ca --path=%s --force", dir), false, true); err != nil {
+		return errors.Wrap(err, "k3s rotate the certificate authorities failed")
+	}
+	if _, err := runtime.GetRunner().SudoCmd("systemctl restart k3s", false, false); err != nil {
+		return errors.Wrap(errors.WithStack(err), "restart k3s failed")
+	}
+	return nil
+}
+
+func newSelfSignedCA(path, commonName string) error {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return errors.Wrap(err, "generate the key of the certificate authority failed")
+	}
+	cert, err := certutil.NewSelfSignedCACert(certutil.Config{CommonName: commonName}, key)
+	if err != nil {
+		return errors.Wrap(err, "generate the certificate authority failed")
+	}
+	if err := certutil.WriteCert(path+".crt", pem.EncodeToMemory(&pem.Block{Type: certutil.CertificateBlockType, Bytes: cert.Raw})); err != nil {
+		return errors.Wrapf(err, "write %s.crt failed", path)
+	}
+	keyPEM, err := keyutil.MarshalPrivateKeyToPEM(key)
+	if err != nil {
+		return errors.Wrap(err, "encode the key of the certificate authority failed")
+	}
+	if err := keyutil.WriteKey(path+".key", keyPEM); err != nil {
+		return errors.Wrapf(err, "write %s.key failed", path)
+	}
+	return nil
+}
+
+// RotateKubeadmCA replaces the certificate authorities of the local kubeadm master with new ones,
+// along with all the certificates and kubeconfigs they sign, the former ones are backed up first,
+// the key signing the service account tokens and the certificates of an external etcd are kept
+type RotateKubeadmCA struct {
+	common.KubeAction
+}
+
+func (r *RotateKubeadmCA) Execute(runtime connector.Runtime) error {
+	backup := fmt.Sprintf("%s-backup-%d", common.KubeCertDir, time.Now().Unix())
+	config := filepath.Join(common.KubeConfigDir, "kubeadm-config.yaml")
+	kubeconfigs := "admin.conf controller-manager.conf scheduler.conf kubelet.conf"
+	cmds := []string{
+		fmt.Sprintf("cp -a %s %s", common.KubeCertDir, backup),
+		fmt.Sprintf("cd %s && cp -a %s %s/", common.KubeConfigDir, kubeconfigs, backup),
+		fmt.Sprintf("cd %s && find . -maxdepth 2 \\( -name '*.crt' -o -name '*.key' \\) ! -name 'sa.*' -delete", common.KubeCertDir),
+		fmt.Sprintf("cd %s && rm -f %s", common.KubeConfigDir, kubeconfigs),
+		fmt.Sprintf("/usr/local/bin/kubeadm init phase certs all --config %s", config),
+		fmt.Sprintf("/usr/local/bin/kubeadm init phase kubeconfig all --config %s", config),
+	}
+	for _, cmd := range cmds {
+		if _, err := runtime.GetRunner().SudoCmd(cmd, false, true); err != nil {
+			return errors.Wrapf(err, "rotate the certificate authorities failed, the former certificates are in %s", backup)
+		}
+	}
+	if _, err := runtime.GetRunner().SudoCmd(RestartControlPlanePods+" && systemctl restart kubelet", false, false); err != nil {
+		return errors.Wrap(err, "restart the control plane failed")
+	}
+	logger.Infof("the former certificates and kubeconfigs are backed up to %s", backup)
+	return nil
+}
+
+// WaitKubeAPIServer waits for the API server to be ready again after its certificates are changed
+type WaitKubeAPIServer struct {
+	common.KubeAction
+}
+
+func (w *WaitKubeAPIServer) Execute(runtime connector.Runtime) error {
+	if _, err := runtime.GetRunner().SudoCmd("/usr/local/bin/kubectl get --raw=/readyz", false, false); err != nil {
+		return errors.Wrap(err, "the API server is not ready yet")
+	}
+	return nil
+}
+
+// CheckControlPlane checks that the local node is a master, whose certificates are managed by the commands,
+// those of the workers are issued by the masters
+type CheckControlPlane struct {
+	common.KubeAction
+}
+
+func (c *CheckControlPlane) Execute(runtime connector.Runtime) error {
+	ca := filepath.Join(common.KubeCertDir, "ca.key")
+	if c.KubeConf.Cluster.Kubernetes.Type == common.K3s {
+		ca = filepath.Join(k3sTLSDir, "server-ca.key")
+	}
+	if !util.IsExist(ca) {
+		return errors.Errorf("%s is not found, the certificates are managed on the masters", ca)
+	}
+	return nil
+}
@@ -114,8 +114,12 @@ const (
 	// KubernetesModule
 	ClusterStatus = "clusterStatus"
 	ClusterExist  = "clusterExist"
+	// ClusterCreated is set once the run has started to create the cluster, for it to be reset on a rollback
+	ClusterCreated = "clusterCreated"
 
 	MasterInfo = "masterInfo"
+	// LocalRegistry is the address of the local registry of the cluster the node pulls the images from
+	LocalRegistry = "localRegistry"
 
 	// CertsModule
 	Certificate   = "certificate"
@@ -269,6 +273,9 @@ const (
 
 	CacheUpgradeUsers     = "upgrade_users"
 	CacheUpgradeAdminUser = "upgrade_admin_user"
+	CacheUpgradeSnapshot  = "upgrade_snapshot"
+
+	CacheNodeUpgradeNeeded = "node_upgrade_needed"
 
 	CacheWindowsDistroStoreLocation     = "windows_distro_store_location"
 	CacheWindowsDistroStoreLocationNums = "windows_distro_store_location_nums"
@@ -286,6 +293,8 @@ const (
 	ENV_PUBLICLY_ACCESSIBLE          = "PUBLICLY_ACCESSIBLE"
 	ENV_KUBE_TYPE                    = "KUBE_TYPE"
 	ENV_REGISTRY_MIRRORS             = "REGISTRY_MIRRORS"
+	ENV_LOCAL_REGISTRY               = "LOCAL_REGISTRY"
+	ENV_MANIFEST_PUBLIC_KEYS_FILE    = "MANIFEST_PUBLIC_KEYS_FILE"
 	ENV_NVIDIA_CONTAINER_REPO_MIRROR = "NVIDIA_CONTAINER_REPO_MIRROR"
 	ENV_DOWNLOAD_CDN_URL             = "DOWNLOAD_CDN_URL"
 	ENV_STORAGE                      = "STORAGE"
@@ -314,6 +323,9 @@ const (
 	ENV_DISABLE_HOST_IP_PROMPT      = "DISABLE_HOST_IP_PROMPT"
 	ENV_AUTO_ADD_FIREWALL_RULES     = "AUTO_ADD_FIREWALL_RULES"
 	ENV_TERMINUS_OS_DOMAINNAME      = "TERMINUS_OS_DOMAINNAME"
+	ENV_TERMINUS_OS_USERNAME        = "TERMINUS_OS_USERNAME"
+	ENV_TERMINUS_OS_EMAIL           = "TERMINUS_OS_EMAIL"
+	ENV_TERMINUS_OS_PASSWORD        = "TERMINUS_OS_PASSWORD"
 	ENV_DEFAULT_WSL_DISTRO_LOCATION = "DEFAULT_WSL_DISTRO_LOCATION" // If set to 1, the default WSL distro storage will be used.
 
 	ENV_CONTAINER      = "container"
@@ -82,12 +82,27 @@ type Argument struct {
 	ExtraAddon      string `json:"extra_addon"` // addon yaml config
 	RegistryMirrors string `json:"registry_mirrors"`
 	DownloadCdnUrl  string `json:"download_cdn_url"`
+	// DownloadMirrors are the CDN addresses to download the packages from, in order of preference,
+	// DownloadCdnUrl being the first one
+	DownloadMirrors   []string `json:"download_mirrors"`
+	DownloadParallel  int      `json:"download_parallel"`
+	DownloadRateLimit int64    `json:"download_rate_limit"` // bytes per second, 0 means unlimited
+
+	// SkipChecks are the names of the prechecks not to run
+	SkipChecks []string `json:"skip_checks"`
+
+	// LocalRegistry serves the images from a registry on this node, pushed to it in the prepare phase
+	// rather than imported into containerd, for the nodes of the cluster to pull them when needed
+	LocalRegistry bool `json:"local_registry"`
 
 	// Swap config
 	*SwapConfig
 
 	// master node ssh config
 	*MasterHostConfig
+	// JoinAsMaster adds the current node to the cluster of the master host
+	// as an additional control plane node, rather than as a worker
+	JoinAsMaster bool `json:"join_as_master"`
 
 	LocalSSHPort int `json:"-"`
 
@@ -286,6 +301,7 @@ func NewArgument() *Argument {
 		SwapConfig:             &SwapConfig{},
 	}
 	arg.IsCloudInstance, _ = strconv.ParseBool(os.Getenv(ENV_TERMINUS_IS_CLOUD_VERSION))
+	arg.LocalRegistry, _ = strconv.ParseBool(os.Getenv(ENV_LOCAL_REGISTRY))
 	arg.PublicNetworkInfo.PubliclyAccessible, _ = strconv.ParseBool(os.Getenv(ENV_PUBLICLY_ACCESSIBLE))
 	arg.IsOlaresInContainer = os.Getenv("CONTAINER_MODE") == "oic"
 
@@ -357,12 +373,36 @@ func (a *Argument) GetWslUserPath() string {
 	return res
 }
 
+// SetDownloadCdnUrl takes a comma separated list of CDN addresses, the first one being preferred
 func (a *Argument) SetDownloadCdnUrl(downloadCdnUrl string) {
-	u := strings.TrimSuffix(downloadCdnUrl, "/")
-	if u == "" {
-		u = common.DownloadUrl
+	a.DownloadMirrors = nil
+	for _, u := range strings.Split(downloadCdnUrl, ",") {
+		u = strings.TrimSuffix(strings.TrimSpace(u), "/")
+		if u != "" {
+			a.DownloadMirrors = append(a.DownloadMirrors, u)
+		}
+	}
+	if len(a.DownloadMirrors) == 0 {
+		a.DownloadMirrors = []string{common.DownloadUrl}
+	}
+	a.DownloadCdnUrl = a.DownloadMirrors[0]
+}
+
+func (a *Argument) SetDownloadLimits(parallel int, rateLimit int64) {
+	if parallel < 1 {
+		parallel = 1
+	}
+	a.DownloadParallel = parallel
+	a.DownloadRateLimit = rateLimit
+}
+
+func (a *Argument) SetSkipChecks(names []string) {
+	a.SkipChecks = nil
+	for _, name := range names {
+		if name = strings.TrimSpace(name); name != "" {
+			a.SkipChecks = append(a.SkipChecks, name)
+		}
 	}
-	a.DownloadCdnUrl = u
 }
 
 func (a *Argument) SetTokenMaxAge() {
@@ -397,6 +437,19 @@ func (a *Argument) SetRegistryMirrors(registryMirrors string) {
 	a.RegistryMirrors = registryMirrors
 }
 
+// SetLocalRegistry enables the local registry, which is otherwise enabled by $LOCAL_REGISTRY
+func (a *Argument) SetLocalRegistry(localRegistry bool) {
+	if localRegistry {
+		a.LocalRegistry = true
+	}
+}
+
+// SetSkipPullImages skips preloading the images into the container runtime,
+// for them to be pulled when needed, e.g., from the local registry of the master
+func (a *Argument) SetSkipPullImages(skip bool) {
+	a.SkipPullImages = skip
+}
+
 func (a *Argument) SetDeleteCache(deleteCache bool) {
 	a.DeleteCache = deleteCache
 }
@@ -407,6 +460,9 @@ func (a *Argument) SetDeleteCRI(deleteCRI bool) {
 
 func (a *Argument) SetStorage(storage *Storage) {
 	a.Storage = storage
+	if storage != nil {
+		logger.RegisterSecret(storage.StorageAccessKey, storage.StorageSecretKey, storage.StorageToken)
+	}
 }
 
 func (a *Argument) SetMinikubeProfile(profile string) {
@@ -536,6 +592,7 @@ func (a *Argument) SetMasterHostOverride(config MasterHostConfig) {
 		a.MasterNodeName = "master"
 	}
 	if config.MasterSSHPassword != "" {
+		logger.RegisterSecret(config.MasterSSHPassword)
 		a.MasterSSHPassword = config.MasterSSHPassword
 	}
 	if config.MasterSSHUser != "" {
@@ -549,6 +606,20 @@ func (a *Argument) SetMasterHostOverride(config MasterHostConfig) {
 	}
 }
 
+// SetJoinRole sets the role the current node joins the cluster of the master host with,
+// either worker or master
+func (a *Argument) SetJoinRole(role string) error {
+	switch role {
+	case "", Worker:
+		a.JoinAsMaster = false
+	case Master:
+		a.JoinAsMaster = true
+	default:
+		return fmt.Errorf("invalid node role %q, must be one of: %s, %s", role, Worker, Master)
+	}
+	return nil
+}
+
 func (a *Argument) LoadMasterHostConfigIfAny() error {
 	if a.BaseDir == "" {
 		return errors.New("basedir unset")
@@ -576,6 +647,10 @@ func NewKubeRuntime(flag string, arg Argument) (*KubeRuntime, error) {
 
 	base := connector.NewBaseRuntime(cluster.Name, connector.NewDialer(),
 		arg.Debug, arg.IgnoreErr, arg.Provider, arg.BaseDir, arg.OlaresVersion, arg.ConsoleLogFileName, arg.ConsoleLogTruncate, arg.SystemInfo)
+	if arg.Provider == nil {
+		arg.Provider = openStateStore(base.GetBaseDir())
+		base.SetStorage(arg.Provider)
+	}
 
 	clusterSpec := &cluster.Spec
 	defaultCluster, roleGroups := clusterSpec.SetDefaultClusterSpec(arg.InCluster, arg.SystemInfo.IsDarwin())
@@ -613,6 +688,22 @@ func NewKubeRuntime(flag string, arg Argument) (*KubeRuntime, error) {
 	return r, nil
 }
 
+// openStateStore opens the local state database under the base directory,
+// a nil provider is returned if it can not be used, e.g., in a build without cgo,
+// so that the pipelines still run, just without recording their progress
+func openStateStore(baseDir string) storage.Provider {
+	provider := storage.NewSQLiteProvider(baseDir)
+	if err := provider.Ping(); err != nil {
+		logger.Debugf("[runtime] state store unavailable: %v", err)
+		return nil
+	}
+	if err := provider.StartupCheck(); err != nil {
+		logger.Warnf("[runtime] failed to initialize the state store: %v", err)
+		return nil
+	}
+	return provider
+}
+
 // Copy is used to create a copy for Runtime.
 func (k *KubeRuntime) Copy() connector.Runtime {
 	runtime := *k
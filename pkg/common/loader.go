@@ -247,6 +247,12 @@ func (d *DefaultLoader) Load() (*kubekeyapiv1alpha2.Cluster, error) {
 			Worker:   {d.arg.MasterNodeName, hostname},
 			Registry: {d.arg.MasterNodeName},
 		}
+		if d.arg.JoinAsMaster {
+			// the existing master is kept first,
+			// as the tasks working on the first master only expect a running one
+			allInOne.Spec.RoleGroups[Master] = append(allInOne.Spec.RoleGroups[Master], hostname)
+			allInOne.Spec.RoleGroups[ETCD] = append(allInOne.Spec.RoleGroups[ETCD], hostname)
+		}
 	}
 
 	if ver := normalizedBuildVersion(d.KubernetesVersion); ver != "" {
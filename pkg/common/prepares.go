@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strconv"
 
+	"bytetrade.io/web3os/installer/pkg/core/cache"
 	"bytetrade.io/web3os/installer/pkg/core/connector"
 	"bytetrade.io/web3os/installer/pkg/core/prepare"
 	"bytetrade.io/web3os/installer/pkg/core/util"
@@ -30,6 +31,7 @@ func (p *Stop) PreCheck(runtime connector.Runtime) (bool, error) {
 
 type GetCommandKubectl struct {
 	prepare.BasePrepare
+	cache.Filler
 }
 
 func (p *GetCommandKubectl) PreCheck(runtime connector.Runtime) (bool, error) {
@@ -46,6 +48,7 @@ func (p *GetCommandKubectl) PreCheck(runtime connector.Runtime) (bool, error) {
 
 type GetMasterNum struct {
 	prepare.BasePrepare
+	cache.Filler
 }
 
 func (p *GetMasterNum) PreCheck(runtime connector.Runtime) (bool, error) {
@@ -69,6 +72,7 @@ func (p *GetMasterNum) PreCheck(runtime connector.Runtime) (bool, error) {
 
 type GetNodeNum struct {
 	prepare.BasePrepare
+	cache.Filler
 }
 
 func (p *GetNodeNum) PreCheck(runtime connector.Runtime) (bool, error) {
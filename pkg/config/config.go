@@ -0,0 +1,370 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"bytetrade.io/web3os/installer/pkg/common"
+	"bytetrade.io/web3os/installer/pkg/core/logger"
+	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
+	"sigs.k8s.io/yaml"
+)
+
+// SchemaVersion is the latest version of the install configuration format this installer understands
+const SchemaVersion = 1
+
+// Config is the declarative form of the settings of an installation,
+// which are otherwise given by the command line flags, the environment variables and the interactive prompts,
+// the precedence being: flags, then environment variables, then the configuration file
+type Config struct {
+	SchemaVersion int `json:"schemaVersion"`
+
+	Version            string `json:"version,omitempty"`
+	KubeType           string `json:"kubeType,omitempty"`
+	BaseDir            string `json:"baseDir,omitempty"`
+	WithJuiceFS        bool   `json:"withJuiceFS,omitempty"`
+	RegistryMirrors    string `json:"registryMirrors,omitempty"`
+	DownloadCdnUrl     string `json:"downloadCdnUrl,omitempty"`
+	HostIP             string `json:"hostIP,omitempty"`
+	PubliclyAccessible bool   `json:"publiclyAccessible,omitempty"`
+	LocalRegistry      bool   `json:"localRegistry,omitempty"`
+	// ManifestPublicKeysFile holds public keys, one per line, trusted for the install wizard manifest along with the embedded one,
+	// a signed manifest is then required
+	ManifestPublicKeysFile string `json:"manifestPublicKeysFile,omitempty"`
+
+	User       User       `json:"user"`
+	Storage    Storage    `json:"storage"`
+	GPU        GPU        `json:"gpu"`
+	Cloudflare Cloudflare `json:"cloudflare"`
+	Frp        Frp        `json:"frp"`
+	Swap       Swap       `json:"swap"`
+	MasterHost MasterHost `json:"masterHost"`
+}
+
+type User struct {
+	DomainName string `json:"domainName,omitempty"`
+	UserName   string `json:"userName,omitempty"`
+	Email      string `json:"email,omitempty"`
+	Password   string `json:"password,omitempty"`
+}
+
+type Storage struct {
+	Type                string `json:"type,omitempty"`
+	Bucket              string `json:"bucket,omitempty"`
+	Prefix              string `json:"prefix,omitempty"`
+	AccessKey           string `json:"accessKey,omitempty"`
+	SecretKey           string `json:"secretKey,omitempty"`
+	Token               string `json:"token,omitempty"`
+	ClusterId           string `json:"clusterId,omitempty"`
+	SyncSecret          string `json:"syncSecret,omitempty"`
+	BackupClusterBucket string `json:"backupClusterBucket,omitempty"`
+}
+
+type GPU struct {
+	Enable *bool `json:"enable,omitempty"`
+}
+
+type Cloudflare struct {
+	Enable *bool `json:"enable,omitempty"`
+}
+
+type Frp struct {
+	Enable     bool   `json:"enable,omitempty"`
+	Server     string `json:"server,omitempty"`
+	Port       int    `json:"port,omitempty"`
+	AuthMethod string `json:"authMethod,omitempty"`
+	AuthToken  string `json:"authToken,omitempty"`
+}
+
+type Swap struct {
+	EnablePodSwap    bool   `json:"enablePodSwap,omitempty"`
+	Swappiness       int    `json:"swappiness,omitempty"`
+	EnableZRAM       bool   `json:"enableZRAM,omitempty"`
+	ZRAMSize         string `json:"zramSize,omitempty"`
+	ZRAMSwapPriority int    `json:"zramSwapPriority,omitempty"`
+}
+
+type MasterHost struct {
+	Host              string `json:"host,omitempty"`
+	NodeName          string `json:"nodeName,omitempty"`
+	SSHUser           string `json:"sshUser,omitempty"`
+	SSHPassword       string `json:"sshPassword,omitempty"`
+	SSHPrivateKeyPath string `json:"sshPrivateKeyPath,omitempty"`
+	SSHPort           int    `json:"sshPort,omitempty"`
+	// Role is the role the node joins the master with, worker or master
+	Role string `json:"role,omitempty"`
+}
+
+// Load reads the configuration file at path, overrides it with the environment variables set
+// and fills in the defaults, an empty path gives the configuration of the environment variables alone,
+// the result is to be checked with Validate
+func Load(path string) (*Config, error) {
+	c := &Config{SchemaVersion: SchemaVersion}
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to read config")
+		}
+		if c, err = Parse(data); err != nil {
+			return nil, errors.Wrapf(err, "invalid config %s", path)
+		}
+	}
+	if err := c.applyEnv(); err != nil {
+		return nil, err
+	}
+	c.setDefaults()
+	for _, s := range c.secretFields() {
+		logger.RegisterSecret(*s)
+	}
+	return c, nil
+}
+
+// Parse decodes a configuration in YAML or JSON, the unknown and duplicated fields are rejected
+func Parse(data []byte) (*Config, error) {
+	c := &Config{}
+	if err := yaml.UnmarshalStrict(data, c); err != nil {
+		return nil, err
+	}
+	switch {
+	case c.SchemaVersion == 0:
+		return nil, errors.New("schemaVersion is not set")
+	case c.SchemaVersion > SchemaVersion:
+		return nil, fmt.Errorf("schemaVersion %d is not supported by this installer, the latest one being %d, please upgrade olares-cli", c.SchemaVersion, SchemaVersion)
+	case c.SchemaVersion < 0:
+		return nil, fmt.Errorf("invalid schemaVersion %d", c.SchemaVersion)
+	}
+	return c, nil
+}
+
+func (c *Config) setDefaults() {
+	if c.KubeType == "" {
+		c.KubeType = common.K3s
+	}
+	if c.Storage.Type == "" {
+		c.Storage.Type = common.ManagedMinIO
+	}
+	if c.GPU.Enable == nil {
+		c.GPU.Enable = boolPtr(true)
+	}
+	// as done by the installer, the Cloudflare tunnel is not used if the host is reachable otherwise
+	if c.Cloudflare.Enable == nil || c.PubliclyAccessible || c.Frp.Enable {
+		c.Cloudflare.Enable = boolPtr(!c.PubliclyAccessible && !c.Frp.Enable)
+	}
+}
+
+// envVar binds a setting to the environment variable that the installer reads it from
+type envVar struct {
+	name string
+	// one of *string, *bool, **bool and *int
+	value interface{}
+}
+
+// envVars are the settings that are still read from the environment by the installer,
+// they are exported by Export so that the existing code paths, and the installer run in WSL, see them
+func (c *Config) envVars() []envVar {
+	return []envVar{
+		{common.ENV_REGISTRY_MIRRORS, &c.RegistryMirrors},
+		{common.ENV_DOWNLOAD_CDN_URL, &c.DownloadCdnUrl},
+		{common.ENV_HOST_IP, &c.HostIP},
+		{common.ENV_PUBLICLY_ACCESSIBLE, &c.PubliclyAccessible},
+		{common.ENV_LOCAL_REGISTRY, &c.LocalRegistry},
+		{common.ENV_MANIFEST_PUBLIC_KEYS_FILE, &c.ManifestPublicKeysFile},
+
+		{common.ENV_TERMINUS_OS_DOMAINNAME, &c.User.DomainName},
+		{common.ENV_TERMINUS_OS_USERNAME, &c.User.UserName},
+		{common.ENV_TERMINUS_OS_EMAIL, &c.User.Email},
+		{common.ENV_TERMINUS_OS_PASSWORD, &c.User.Password},
+
+		{common.ENV_STORAGE, &c.Storage.Type},
+		{common.ENV_S3_BUCKET, &c.Storage.Bucket},
+		{common.ENV_BACKUP_KEY_PREFIX, &c.Storage.Prefix},
+		{common.ENV_AWS_ACCESS_KEY_ID_SETUP, &c.Storage.AccessKey},
+		{common.ENV_AWS_SECRET_ACCESS_KEY_SETUP, &c.Storage.SecretKey},
+		{common.ENV_AWS_SESSION_TOKEN_SETUP, &c.Storage.Token},
+		{common.ENV_CLUSTER_ID, &c.Storage.ClusterId},
+		{common.ENV_BACKUP_SECRET, &c.Storage.SyncSecret},
+		{common.ENV_BACKUP_CLUSTER_BUCKET, &c.Storage.BackupClusterBucket},
+
+		{common.ENV_LOCAL_GPU_ENABLE, &c.GPU.Enable},
+		{common.ENV_CLOUDFLARE_ENABLE, &c.Cloudflare.Enable},
+
+		{common.ENV_FRP_ENABLE, &c.Frp.Enable},
+		{common.ENV_FRP_SERVER, &c.Frp.Server},
+		{common.ENV_FRP_PORT, &c.Frp.Port},
+		{common.ENV_FRP_AUTH_METHOD, &c.Frp.AuthMethod},
+		{common.ENV_FRP_AUTH_TOKEN, &c.Frp.AuthToken},
+
+		{common.ENV_OLARES_VERSION, &c.Version},
+		{common.ENV_OLARES_BASE_DIR, &c.BaseDir},
+	}
+}
+
+func (c *Config) applyEnv() error {
+	for _, e := range c.envVars() {
+		s, ok := os.LookupEnv(e.name)
+		if !ok || s == "" {
+			continue
+		}
+		switch v := e.value.(type) {
+		case *string:
+			*v = s
+		case *bool, **bool:
+			b, err := strconv.ParseBool(s)
+			if err != nil {
+				return fmt.Errorf("invalid value %q of env %s, expecting 1 or 0", s, e.name)
+			}
+			if p, ok := v.(*bool); ok {
+				*p = b
+			} else {
+				*v.(**bool) = &b
+			}
+		case *int:
+			i, err := strconv.Atoi(s)
+			if err != nil {
+				return fmt.Errorf("invalid value %q of env %s, expecting a number", s, e.name)
+			}
+			*v = i
+		}
+	}
+	return nil
+}
+
+// Export sets the environment variables of the settings that are read from the environment,
+// the ones already set are left as is, as they have precedence over the configuration file anyway
+func (c *Config) Export() error {
+	for _, e := range c.envVars() {
+		if os.Getenv(e.name) != "" {
+			continue
+		}
+		var s string
+		switch v := e.value.(type) {
+		case *string:
+			s = *v
+		case *bool:
+			s = boolEnv(*v)
+		case **bool:
+			if *v != nil {
+				s = boolEnv(**v)
+			}
+		case *int:
+			if *v != 0 {
+				s = strconv.Itoa(*v)
+			}
+		}
+		if s == "" {
+			continue
+		}
+		if err := os.Setenv(e.name, s); err != nil {
+			return errors.Wrapf(err, "failed to set env %s", e.name)
+		}
+	}
+	return nil
+}
+
+// flags are the settings given by the command line flags, by flag name,
+// only the ones set in the configuration are returned
+func (c *Config) flags() map[string]string {
+	flags := make(map[string]string)
+	set := func(name, value string) {
+		if value != "" {
+			flags[name] = value
+		}
+	}
+	setBool := func(name string, value bool) {
+		if value {
+			flags[name] = strconv.FormatBool(value)
+		}
+	}
+	setInt := func(name string, value int) {
+		if value != 0 {
+			flags[name] = strconv.Itoa(value)
+		}
+	}
+	set("version", c.Version)
+	set("kube", c.KubeType)
+	set("base-dir", c.BaseDir)
+	setBool("with-juicefs", c.WithJuiceFS)
+
+	setBool("enable-pod-swap", c.Swap.EnablePodSwap)
+	setInt("swappiness", c.Swap.Swappiness)
+	setBool("enable-zram", c.Swap.EnableZRAM)
+	set("zram-size", c.Swap.ZRAMSize)
+	setInt("zram-swap-priority", c.Swap.ZRAMSwapPriority)
+
+	set("master-host", c.MasterHost.Host)
+	set("master-node-name", c.MasterHost.NodeName)
+	set("master-ssh-user", c.MasterHost.SSHUser)
+	set("master-ssh-password", c.MasterHost.SSHPassword)
+	set("master-ssh-private-key-path", c.MasterHost.SSHPrivateKeyPath)
+	setInt("master-ssh-port", c.MasterHost.SSHPort)
+	set("role", c.MasterHost.Role)
+	return flags
+}
+
+// SetFlags sets the flags of fs from the configuration,
+// except for the ones given on the command line and the ones the command does not have
+func (c *Config) SetFlags(fs *pflag.FlagSet) error {
+	for name, value := range c.flags() {
+		f := fs.Lookup(name)
+		if f == nil || f.Changed {
+			continue
+		}
+		if err := fs.Set(name, value); err != nil {
+			return errors.Wrapf(err, "invalid value of --%s in config", name)
+		}
+	}
+	return nil
+}
+
+// Apply loads and validates the configuration file at path, then applies it to the command,
+// nothing is done if path is empty
+func Apply(path string, fs *pflag.FlagSet) error {
+	if path == "" {
+		return nil
+	}
+	c, err := Load(path)
+	if err != nil {
+		return err
+	}
+	if errs := Validate(c); len(errs) > 0 {
+		return fmt.Errorf("invalid config %s: %v", path, joinErrors(errs))
+	}
+	if err := c.Export(); err != nil {
+		return err
+	}
+	return c.SetFlags(fs)
+}
+
+// secretFields are the settings masked in the logs and in what is shown to the user
+func (c *Config) secretFields() []*string {
+	return []*string{
+		&c.User.Password,
+		&c.Storage.AccessKey, &c.Storage.SecretKey, &c.Storage.Token, &c.Storage.SyncSecret,
+		&c.Frp.AuthToken,
+		&c.MasterHost.SSHPassword,
+	}
+}
+
+// Redacted returns a copy of the configuration with the secrets masked, to be shown to the user
+func (c *Config) Redacted() *Config {
+	r := *c
+	for _, s := range r.secretFields() {
+		if *s != "" {
+			*s = logger.Redacted
+		}
+	}
+	return &r
+}
+
+func boolEnv(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
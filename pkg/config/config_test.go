@@ -0,0 +1,76 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"bytetrade.io/web3os/installer/pkg/common"
+	"bytetrade.io/web3os/installer/pkg/core/logger"
+	"github.com/spf13/pflag"
+)
+
+func TestTemplate(t *testing.T) {
+	c, err := Parse([]byte(Template))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if errs := Validate(c); len(errs) > 0 {
+		t.Fatalf("the template is invalid: %v", errs)
+	}
+}
+
+func TestParse(t *testing.T) {
+	for name, data := range map[string]string{
+		"unknown field":  "schemaVersion: 1\nuser:\n  name: alice\n",
+		"no version":     "kubeType: k3s\n",
+		"future version": "schemaVersion: 99\n",
+	} {
+		if _, err := Parse([]byte(data)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "olares.yaml")
+	data := "schemaVersion: 1\nkubeType: k8s\nuser:\n  userName: alice\nfrp:\n  authToken: frp-token\nswap:\n  swappiness: 10\n"
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(common.ENV_TERMINUS_OS_USERNAME, "bob")
+	t.Setenv(common.ENV_LOCAL_GPU_ENABLE, "0")
+
+	c, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.User.UserName != "bob" {
+		t.Errorf("the env should override the file, got user %s", c.User.UserName)
+	}
+	if c.GPU.Enable == nil || *c.GPU.Enable {
+		t.Error("the GPU should be disabled by the env")
+	}
+	if c.Storage.Type != common.ManagedMinIO {
+		t.Errorf("unexpected default storage %s", c.Storage.Type)
+	}
+	if got := logger.Redact("token=frp-token"); got != "token="+logger.Redacted {
+		t.Errorf("the secrets should be registered, got %s", got)
+	}
+	if c.Redacted().Frp.AuthToken != logger.Redacted || c.Frp.AuthToken != "frp-token" {
+		t.Error("only the copy should be redacted")
+	}
+
+	fs := pflag.NewFlagSet("install", pflag.ContinueOnError)
+	kube := fs.String("kube", "k3s", "")
+	swappiness := fs.Int("swappiness", 0, "")
+	if err := fs.Parse([]string{"--swappiness", "60"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SetFlags(fs); err != nil {
+		t.Fatal(err)
+	}
+	if *kube != "k8s" || *swappiness != 60 {
+		t.Errorf("the flags given should be kept and the others set, got --kube %s --swappiness %d", *kube, *swappiness)
+	}
+}
@@ -0,0 +1,103 @@
+package config
+
+// Template is the configuration written by olares-cli config init,
+// every setting is documented along with the environment variable or the flag overriding it
+const Template = `# Olares install configuration, use it with: olares-cli install --config <file>
+# the command line flags and the environment variables, given in brackets, override the settings of this file
+schemaVersion: 1
+
+# Olares version to install, e.g., 1.10.0 [--version, OLARES_VERSION]
+version: ""
+# k3s or k8s [--kube]
+kubeType: k3s
+# Olares package base dir, defaults to $HOME/.olares [--base-dir, OLARES_BASE_DIR]
+baseDir: ""
+# use JuiceFS as the rootfs for Olares workloads, rather than the local disk [--with-juicefs]
+withJuiceFS: false
+# container registry mirrors, separated by commas [REGISTRY_MIRRORS]
+registryMirrors: ""
+# CDN to download the packages from [DOWNLOAD_CDN_URL]
+downloadCdnUrl: ""
+# IP address of this host, detected if empty [HOST_IP]
+hostIP: ""
+# whether this host can be reached from the internet directly [PUBLICLY_ACCESSIBLE]
+publiclyAccessible: false
+# serve the images from a registry on the master node rather than importing them on every node [--local-registry, LOCAL_REGISTRY]
+localRegistry: false
+# file of base64 encoded ed25519 public keys, one per line, trusted for the manifest of the install wizard
+# along with the key of the official releases, a signed manifest is then required [MANIFEST_PUBLIC_KEYS_FILE]
+manifestPublicKeysFile: ""
+
+user:
+  # prompted for if empty, defaults to olares.com [TERMINUS_OS_DOMAINNAME]
+  domainName: ""
+  # the Olares ID registered in the LarePass app, prompted for if empty [TERMINUS_OS_USERNAME]
+  userName: ""
+  # defaults to <userName>@<domainName> [TERMINUS_OS_EMAIL]
+  email: ""
+  # the encrypted password of 32 characters, generated if empty [TERMINUS_OS_PASSWORD]
+  password: ""
+
+storage:
+  # one of managed-minio, minio, s3, oss, cos [STORAGE]
+  type: managed-minio
+  # [S3_BUCKET]
+  bucket: ""
+  # [BACKUP_KEY_PREFIX]
+  prefix: ""
+  # [AWS_ACCESS_KEY_ID_SETUP]
+  accessKey: ""
+  # [AWS_SECRET_ACCESS_KEY_SETUP]
+  secretKey: ""
+  # [AWS_SESSION_TOKEN_SETUP]
+  token: ""
+
+gpu:
+  # [LOCAL_GPU_ENABLE]
+  enable: true
+
+cloudflare:
+  # the Cloudflare tunnel, not used if publiclyAccessible or frp is enabled [CLOUDFLARE_ENABLE]
+  enable: true
+
+frp:
+  # [FRP_ENABLE]
+  enable: false
+  # [FRP_SERVER]
+  server: ""
+  # [FRP_PORT]
+  port: 0
+  # [FRP_AUTH_METHOD]
+  authMethod: ""
+  # [FRP_AUTH_TOKEN]
+  authToken: ""
+
+swap:
+  # only pods of the BestEffort QOS group can use swap [--enable-pod-swap]
+  enablePodSwap: false
+  # the current value is kept if 0 [--swappiness]
+  swappiness: 0
+  # [--enable-zram]
+  enableZRAM: false
+  # defaults to half of the total RAM [--zram-size]
+  zramSize: ""
+  # defaults to 100 [--zram-swap-priority]
+  zramSwapPriority: 0
+
+# the master node to join, used by olares-cli node add
+masterHost:
+  # [--master-host]
+  host: ""
+  # [--master-node-name]
+  nodeName: ""
+  # defaults to root [--master-ssh-user]
+  sshUser: ""
+  # [--master-ssh-password]
+  sshPassword: ""
+  # defaults to ~/.ssh/id_rsa [--master-ssh-private-key-path]
+  sshPrivateKeyPath: ""
+  # defaults to 22 [--master-ssh-port]
+  sshPort: 0
+  # worker or master, defaults to worker [--role]
+  role: ""
+`
@@ -0,0 +1,102 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/mail"
+	"strings"
+
+	"bytetrade.io/web3os/installer/pkg/common"
+	"bytetrade.io/web3os/installer/pkg/utils"
+)
+
+var (
+	kubeTypes    = []string{common.K3s, common.K8s}
+	storageTypes = []string{common.ManagedMinIO, common.MinIO, common.S3, common.OSS, common.COS}
+	nodeRoles    = []string{common.Worker, common.Master}
+)
+
+// Validate checks the whole configuration and returns all the problems found rather than the first one
+func Validate(c *Config) []error {
+	var errs []error
+	fail := func(field, format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf("%s: %s", field, fmt.Sprintf(format, args...)))
+	}
+
+	if c.KubeType != "" && !oneOf(c.KubeType, kubeTypes) {
+		fail("kubeType", "%q is not one of %s", c.KubeType, strings.Join(kubeTypes, ", "))
+	}
+	if c.HostIP != "" && net.ParseIP(c.HostIP) == nil {
+		fail("hostIP", "%q is not an IP address", c.HostIP)
+	}
+
+	if c.User.DomainName != "" && !utils.IsValidDomain(c.User.DomainName) {
+		fail("user.domainName", "%q is not a valid domain name", c.User.DomainName)
+	}
+	if c.User.UserName != "" {
+		if err := utils.ValidateUserName(strings.Split(c.User.UserName, "@")[0]); err != nil {
+			fail("user.userName", "%v", err)
+		}
+	}
+	if c.User.Email != "" {
+		if _, err := mail.ParseAddress(c.User.Email); err != nil {
+			fail("user.email", "%v", err)
+		}
+	}
+	if c.User.Password != "" && len(c.User.Password) != 32 {
+		fail("user.password", "the length should be 32")
+	}
+
+	if c.Storage.Type != "" && !oneOf(c.Storage.Type, storageTypes) {
+		fail("storage.type", "%q is not one of %s", c.Storage.Type, strings.Join(storageTypes, ", "))
+	}
+	if (c.Storage.AccessKey == "") != (c.Storage.SecretKey == "") {
+		fail("storage", "accessKey and secretKey are to be set together")
+	}
+
+	if c.Frp.Enable && c.Frp.Server == "" {
+		fail("frp.server", "required when frp is enabled")
+	}
+	if c.Frp.Port < 0 || c.Frp.Port > 65535 {
+		fail("frp.port", "%d is not a valid port", c.Frp.Port)
+	}
+
+	if c.Swap.Swappiness < 0 || c.Swap.Swappiness > 200 {
+		fail("swap.swappiness", "%d is not between 0 and 200", c.Swap.Swappiness)
+	}
+	if c.Swap.ZRAMSwapPriority < -1 || c.Swap.ZRAMSwapPriority > 32767 {
+		fail("swap.zramSwapPriority", "%d is not between -1 and 32767", c.Swap.ZRAMSwapPriority)
+	}
+	swap := &common.SwapConfig{ZRAMSize: c.Swap.ZRAMSize}
+	if err := swap.Validate(); err != nil {
+		fail("swap.zramSize", "%v", err)
+	}
+
+	if c.MasterHost.SSHPort < 0 || c.MasterHost.SSHPort > 65535 {
+		fail("masterHost.sshPort", "%d is not a valid port", c.MasterHost.SSHPort)
+	}
+	if c.MasterHost.SSHUser != "" && c.MasterHost.SSHUser != "root" && c.MasterHost.SSHPassword == "" {
+		fail("masterHost.sshPassword", "required for a non-root user in order to execute sudo command")
+	}
+	if c.MasterHost.Role != "" && !oneOf(c.MasterHost.Role, nodeRoles) {
+		fail("masterHost.role", "%q is not one of %s", c.MasterHost.Role, strings.Join(nodeRoles, ", "))
+	}
+	return errs
+}
+
+func oneOf(s string, values []string) bool {
+	for _, v := range values {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+func joinErrors(errs []error) string {
+	msgs := make([]string, 0, len(errs))
+	for _, e := range errs {
+		msgs = append(msgs, e.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
@@ -199,6 +199,19 @@ func (e *EnableContainerd) Execute(runtime connector.Runtime) error {
 	return nil
 }
 
+// StopContainerd stops containerd for its binaries to be replaced,
+// the containers keep running under their shims
+type StopContainerd struct {
+	common.KubeAction
+}
+
+func (s *StopContainerd) Execute(runtime connector.Runtime) error {
+	if _, err := runtime.GetRunner().SudoCmd("systemctl stop containerd", false, false); err != nil {
+		return errors.Wrap(errors.WithStack(err), "stop containerd failed")
+	}
+	return nil
+}
+
 type DisableContainerd struct {
 	common.KubeAction
 }
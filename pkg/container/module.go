@@ -403,3 +403,54 @@ func (m *KillContainerdProcessModule) Init() {
 	}
 
 }
+
+// UpgradeContainerdModule replaces the containerd, runc and crictl binaries of the local node with those of the manifest,
+// while the Kubernetes service of the node is stopped
+type UpgradeContainerdModule struct {
+	common.KubeModule
+	manifest.ManifestModule
+}
+
+func (m *UpgradeContainerdModule) Init() {
+	m.Name = "UpgradeContainerd"
+	m.Desc = "Upgrade containerd"
+
+	manifestAction := manifest.ManifestAction{
+		BaseDir:  m.BaseDir,
+		Manifest: m.Manifest,
+	}
+
+	stop := &task.LocalTask{
+		Name:    "StopContainerd",
+		Prepare: new(kubernetes.NodeNeedsUpgrade),
+		Action:  new(StopContainerd),
+	}
+
+	syncContainerd := &task.LocalTask{
+		Name:    "SyncContainerd",
+		Prepare: new(kubernetes.NodeNeedsUpgrade),
+		Action:  &SyncContainerd{ManifestAction: manifestAction},
+		Retry:   2,
+	}
+
+	syncCrictl := &task.LocalTask{
+		Name:    "SyncCrictlBinaries",
+		Prepare: new(kubernetes.NodeNeedsUpgrade),
+		Action:  &SyncCrictlBinaries{ManifestAction: manifestAction},
+		Retry:   2,
+	}
+
+	start := &task.LocalTask{
+		Name:    "StartContainerd",
+		Prepare: new(kubernetes.NodeNeedsUpgrade),
+		Action:  &EnableContainerd{ManifestAction: manifestAction},
+		Retry:   2,
+	}
+
+	m.Tasks = []task.Interface{
+		stop,
+		syncContainerd,
+		syncCrictl,
+		start,
+	}
+}
@@ -83,3 +83,20 @@ func (c *Cache) GetMustBool(k string) (bool, bool) {
 	}
 	return res, ok
 }
+
+// Filler is embedded by the actions and the prepares setting the pipeline, the module or the host cache
+// for the tasks that follow, the tasks running them are run again when a run is resumed,
+// as the cache of the previous run is lost along with it
+type Filler struct{}
+
+func (Filler) fillsCache() {}
+
+type filler interface {
+	fillsCache()
+}
+
+// Fills reports whether v, an action or a prepare, sets the cache, see Filler
+func Fills(v interface{}) bool {
+	_, ok := v.(filler)
+	return ok
+}
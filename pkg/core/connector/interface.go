@@ -27,6 +27,7 @@ import (
 
 type Connection interface {
 	Exec(cmd string, host Host) (stdout string, code int, err error)
+	ExecContext(ctx context.Context, cmd string, host Host) (stdout string, code int, err error)
 	PExec(cmd string, stdin io.Reader, stdout io.Writer, stderr io.Writer, host Host) (code int, err error)
 	Fetch(local, remote string, host Host) error
 	Scp(local, remote string, host Host) error
@@ -70,6 +71,9 @@ type Runtime interface {
 	SetConnector(c Connector)
 	SetStorage(s storage.Provider)
 	GetStorage() storage.Provider
+	GetContext() context.Context
+	GetExecContext() context.Context
+	SetContext(ctx, execCtx context.Context)
 	RemoteHost() Host
 	Copy() Runtime
 	GetSystemInfo() Systems
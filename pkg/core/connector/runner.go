@@ -32,14 +32,27 @@ type Runner struct {
 	Debug bool
 	Host  Host
 	Index int
+	// Ctx kills the commands run by the runner once done, see Runtime.GetExecContext
+	Ctx context.Context
+}
+
+func (r *Runner) context() context.Context {
+	if r.Ctx == nil {
+		return context.Background()
+	}
+	return r.Ctx
 }
 
 func (r *Runner) Exec(cmd string, printOutput, printLine bool) (string, int, error) {
+	return r.exec(r.context(), cmd, printOutput, printLine)
+}
+
+func (r *Runner) exec(ctx context.Context, cmd string, printOutput, printLine bool) (string, int, error) {
 	if r.Conn == nil {
-		return r.Host.ExecExt(cmd, printOutput, printLine)
+		return r.Host.Exec(ctx, cmd, printOutput, printLine)
 	}
 
-	stdout, code, err := r.Conn.Exec(cmd, r.Host)
+	stdout, code, err := r.Conn.ExecContext(ctx, cmd, r.Host)
 	logger.Debugf("command: [%s]\n%s", r.Host.GetName(), cmd)
 	if stdout != "" {
 		logger.Debugf("stdout: [%s]\n%s", r.Host.GetName(), stdout)
@@ -51,7 +64,7 @@ func (r *Runner) Exec(cmd string, printOutput, printLine bool) (string, int, err
 
 	if printOutput {
 		if stdout != "" {
-			fmt.Printf("stdout: [%s]\n%s\n", r.Host.GetName(), stdout)
+			fmt.Printf("stdout: [%s]\n%s\n", r.Host.GetName(), logger.Redact(stdout))
 		}
 	}
 	return stdout, code, err
@@ -69,7 +82,8 @@ func (r *Runner) CmdContext(ctx context.Context, cmd string, printOutput bool, p
 	if r.Conn == nil {
 		return r.Host.CmdExtWithContext(ctx, cmd, printOutput, printLine)
 	}
-	return r.Cmd(cmd, printOutput, printLine)
+	stdout, _, err := r.exec(ctx, cmd, printOutput, printLine)
+	return stdout, err
 }
 
 func (r *Runner) SudoCmd(cmd string, printOutput, printLine bool) (string, error) {
@@ -17,6 +17,7 @@
 package connector
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/user"
@@ -50,8 +51,14 @@ type BaseRuntime struct {
 	olaresVersion   string
 	systemInfo      Systems
 	k8sClient       *kubernetes.Clientset
+	ctx             context.Context
+	execCtx         context.Context
 }
 
+// ErrInterrupted is the error of the work that has not been started, or has been aborted,
+// because the run has been interrupted
+var ErrInterrupted = errors.New("interrupted")
+
 func NewBaseRuntime(name string, connector Connector, verbose bool, ignoreErr bool, sqlProvider storage.Provider, baseDir string, olaresVersion string, consoleLogFileName string, consoleLogTruncate bool, systemInfo Systems) BaseRuntime {
 	base := BaseRuntime{
 		ObjName:         name,
@@ -114,6 +121,27 @@ func (b *BaseRuntime) SetStorage(s storage.Provider) {
 	b.storage = s
 }
 
+// GetContext is done once the run has been interrupted, no new task should be started past that point
+func (b *BaseRuntime) GetContext() context.Context {
+	if b.ctx == nil {
+		return context.Background()
+	}
+	return b.ctx
+}
+
+// GetExecContext is done once the commands still running are to be killed
+func (b *BaseRuntime) GetExecContext() context.Context {
+	if b.execCtx == nil {
+		return context.Background()
+	}
+	return b.execCtx
+}
+
+func (b *BaseRuntime) SetContext(ctx, execCtx context.Context) {
+	b.ctx = ctx
+	b.execCtx = execCtx
+}
+
 func (b *BaseRuntime) GetConnector() Connector {
 	return b.connector
 }
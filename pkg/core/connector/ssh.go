@@ -325,12 +325,28 @@ func (c *connection) PExec(cmd string, stdin io.Reader, stdout io.Writer, stderr
 }
 
 func (c *connection) Exec(cmd string, host Host) (stdout string, code int, err error) {
+	return c.ExecContext(context.Background(), cmd, host)
+}
+
+// ExecContext runs the command like Exec, the command is killed and its session closed once ctx is done
+func (c *connection) ExecContext(ctx context.Context, cmd string, host Host) (stdout string, code int, err error) {
 	sess, err := c.session()
 	if err != nil {
 		return "", 1, errors.Wrap(err, "failed to get SSH session")
 	}
 	defer sess.Close()
 
+	finished := make(chan struct{})
+	defer close(finished)
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = sess.Signal(ssh.SIGKILL)
+			sess.Close()
+		case <-finished:
+		}
+	}()
+
 	exitCode := 0
 
 	in, _ := sess.StdinPipe()
@@ -390,6 +406,9 @@ func (c *connection) Exec(cmd string, host Host) (stdout string, code int, err e
 			exitCode = exitErr.ExitStatus()
 		}
 	}
+	if ctx.Err() != nil {
+		err = errors.Wrap(ctx.Err(), "command aborted")
+	}
 	outStr := strings.TrimPrefix(string(output), trimPrefix)
 
 	// preserve original error
@@ -99,6 +99,7 @@ type Systems interface {
 	GetFsType() string
 	GetDefaultZfsPrefixName() string
 	GetTotalMemory() uint64
+	GetCpuLogicalCount() int
 
 	Print()
 	String() string
@@ -263,11 +264,11 @@ func (s *SystemInfo) GetDebianVersionCode() string {
 }
 
 func (s *SystemInfo) CgroupCpuEnabled() bool {
-	return s.CgroupInfo.CpuEnabled >= 1
+	return s.CgroupInfo != nil && s.CgroupInfo.CpuEnabled >= 1
 }
 
 func (s *SystemInfo) CgroupMemoryEnabled() bool {
-	return s.CgroupInfo.MemoryEnabled >= 1
+	return s.CgroupInfo != nil && s.CgroupInfo.MemoryEnabled >= 1
 }
 
 func (s *SystemInfo) GetFsType() string {
@@ -282,6 +283,10 @@ func (s *SystemInfo) GetTotalMemory() uint64 {
 	return s.MemoryInfo.Total
 }
 
+func (s *SystemInfo) GetCpuLogicalCount() int {
+	return s.CpuInfo.CpuLogicalCount
+}
+
 func (s *SystemInfo) GetPkgManager() string {
 	return s.PkgManager
 }
@@ -0,0 +1,252 @@
+package event
+
+import (
+	"sync"
+	"time"
+
+	"bytetrade.io/web3os/installer/pkg/core/cache"
+	"bytetrade.io/web3os/installer/pkg/core/logger"
+	"bytetrade.io/web3os/installer/pkg/model"
+)
+
+const cacheKey = "PipelineEvents"
+
+const (
+	PipelineStart  = "pipeline.start"
+	PipelineEnd    = "pipeline.end"
+	ModuleStart    = "module.start"
+	ModuleSkip     = "module.skip"
+	ModuleEnd      = "module.end"
+	ModuleRollback = "module.rollback"
+	TaskStart      = "task.start"
+	TaskRetry      = "task.retry"
+	TaskHostResult = "task.host"
+	TaskRollback   = "task.rollback"
+	TaskFinish     = "task.finish"
+)
+
+const (
+	StatusSuccess = "success"
+	StatusFailed  = "failed"
+	StatusSkipped = "skipped"
+	// StatusInterrupted is the status of a run stopped by a signal
+	StatusInterrupted = "interrupted"
+)
+
+// Sink receives every event of a pipeline run
+type Sink interface {
+	Write(e model.PipelineEvent) error
+	Close() error
+}
+
+// Emitter stamps the progress events of a pipeline run and fans them out to the sinks.
+// Modules run one after another, so the emitter keeps track of the current module and task
+// to compute the percentage, the way the journal does.
+// All methods are safe to call on a nil *Emitter, which emits nothing.
+type Emitter struct {
+	pipeline    string
+	runID       string
+	moduleCount int
+	sinks       []Sink
+
+	mu          sync.Mutex
+	moduleIndex int
+	module      string
+	taskIndex   int
+	taskCount   int
+}
+
+// NewEmitter returns nil if there is no sink to emit to
+func NewEmitter(pipeline, runID string, moduleCount int, sinks ...Sink) *Emitter {
+	var s []Sink
+	for _, sink := range sinks {
+		if sink != nil {
+			s = append(s, sink)
+		}
+	}
+	if len(s) == 0 {
+		return nil
+	}
+	return &Emitter{
+		pipeline:    pipeline,
+		runID:       runID,
+		moduleCount: moduleCount,
+		sinks:       s,
+	}
+}
+
+// FromCache returns the emitter attached to the pipeline cache, if any.
+func FromCache(c *cache.Cache) *Emitter {
+	if c == nil {
+		return nil
+	}
+	v, ok := c.Get(cacheKey)
+	if !ok {
+		return nil
+	}
+	e, _ := v.(*Emitter)
+	return e
+}
+
+// Attach makes the emitter available to the modules and tasks through the pipeline cache.
+func (e *Emitter) Attach(c *cache.Cache) {
+	if e == nil {
+		return
+	}
+	c.Set(cacheKey, e)
+}
+
+func (e *Emitter) PipelineStarted() {
+	e.emit(model.PipelineEvent{Type: PipelineStart}, false)
+}
+
+// PipelineEnded emits the last event of the run and closes the sinks
+func (e *Emitter) PipelineEnded(err error) {
+	e.end(status(err), err)
+}
+
+// PipelineInterrupted emits the last event of a run stopped by a signal and closes the sinks
+func (e *Emitter) PipelineInterrupted(err error) {
+	e.end(StatusInterrupted, err)
+}
+
+func (e *Emitter) end(st string, err error) {
+	if e == nil {
+		return
+	}
+	ev := model.PipelineEvent{Type: PipelineEnd, Status: st, Error: errString(err)}
+	if err == nil {
+		e.mu.Lock()
+		e.moduleIndex, e.taskIndex, e.taskCount = e.moduleCount, 0, 0
+		e.mu.Unlock()
+	}
+	e.emit(ev, false)
+
+	for _, s := range e.sinks {
+		if err := s.Close(); err != nil {
+			logger.Debugf("failed to close event sink: %v", err)
+		}
+	}
+}
+
+// BeginModule sets the module the following events belong to
+func (e *Emitter) BeginModule(index int, name string) {
+	if e == nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.moduleIndex = index
+	e.module = name
+	e.taskIndex, e.taskCount = 0, 0
+}
+
+// BeginTask sets the position of the task about to run within the current module
+func (e *Emitter) BeginTask(index, count int) {
+	if e == nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.taskIndex, e.taskCount = index, count
+}
+
+func (e *Emitter) ModuleStarted() {
+	e.emit(model.PipelineEvent{Type: ModuleStart}, false)
+}
+
+func (e *Emitter) ModuleSkipped(index int, name string) {
+	e.BeginModule(index, name)
+	e.emit(model.PipelineEvent{Type: ModuleSkip, Status: StatusSkipped}, false)
+}
+
+func (e *Emitter) ModuleEnded(err error) {
+	e.emit(model.PipelineEvent{Type: ModuleEnd, Status: status(err), Error: errString(err)}, err == nil)
+}
+
+// ModuleRolledBack emits the outcome of the rollback of the current module,
+// status is one of the Status* constants, skipped meaning the module has nothing to revert with
+func (e *Emitter) ModuleRolledBack(status string, err error) {
+	e.emit(model.PipelineEvent{Type: ModuleRollback, Status: status, Error: errString(err)}, false)
+}
+
+func (e *Emitter) TaskStarted(task string) {
+	e.emit(model.PipelineEvent{Type: TaskStart, Task: task}, false)
+}
+
+func (e *Emitter) TaskRetried(task, host string, attempt int, err error) {
+	e.emit(model.PipelineEvent{Type: TaskRetry, Task: task, Host: host, Attempt: attempt, Error: errString(err)}, false)
+}
+
+// HostResult emits the outcome of the task on one host, status is one of the Status* constants
+func (e *Emitter) HostResult(task, host, status string, err error) {
+	e.emit(model.PipelineEvent{Type: TaskHostResult, Task: task, Host: host, Status: status, Error: errString(err)}, false)
+}
+
+func (e *Emitter) TaskRolledBack(task, host string, err error) {
+	e.emit(model.PipelineEvent{Type: TaskRollback, Task: task, Host: host, Status: status(err), Error: errString(err)}, false)
+}
+
+func (e *Emitter) TaskFinished(task string, err error) {
+	e.emit(model.PipelineEvent{Type: TaskFinish, Task: task, Status: status(err), Error: errString(err)}, err == nil)
+}
+
+// emit stamps the event with the run and the current progress,
+// done counts the current task, or the current module if no task is running, as completed
+func (e *Emitter) emit(ev model.PipelineEvent, done bool) {
+	if e == nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	ev.Time = time.Now()
+	ev.RunID = e.runID
+	ev.Pipeline = e.pipeline
+	if ev.Type != PipelineStart && ev.Type != PipelineEnd {
+		ev.Module = e.module
+	}
+	ev.Percent = e.percent(ev.Type, done)
+
+	for _, s := range e.sinks {
+		if err := s.Write(ev); err != nil {
+			logger.Debugf("failed to write %s event: %v", ev.Type, err)
+		}
+	}
+}
+
+func (e *Emitter) percent(t string, done bool) int {
+	if e.moduleCount <= 0 {
+		return 0
+	}
+	progress := float64(e.moduleIndex)
+	switch {
+	case t == ModuleSkip, t == ModuleEnd && done:
+		progress++
+	case e.taskCount > 0:
+		tasks := float64(e.taskIndex)
+		if done {
+			tasks++
+		}
+		progress += tasks / float64(e.taskCount)
+	}
+	p := int(progress * 100 / float64(e.moduleCount))
+	if p > 100 {
+		p = 100
+	}
+	return p
+}
+
+func status(err error) string {
+	if err != nil {
+		return StatusFailed
+	}
+	return StatusSuccess
+}
+
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
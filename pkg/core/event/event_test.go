@@ -0,0 +1,89 @@
+package event
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"bytetrade.io/web3os/installer/pkg/core/logger"
+	"bytetrade.io/web3os/installer/pkg/model"
+)
+
+func TestEmitter(t *testing.T) {
+	dir := t.TempDir()
+	logger.InitLog(dir, filepath.Join(dir, "test.log"), true)
+
+	socket, err := ListenSocket(filepath.Join(dir, "events.sock"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, err := net.Dial("unix", filepath.Join(dir, "events.sock"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	var out bytes.Buffer
+	e := NewEmitter("Install", "run-1", 2, NewWriterSink(&out), socket)
+	// wait for the client to be registered by the accept loop
+	for {
+		socket.(*socketSink).mu.Lock()
+		n := len(socket.(*socketSink).clients)
+		socket.(*socketSink).mu.Unlock()
+		if n > 0 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	e.PipelineStarted()
+	e.BeginModule(0, "Download")
+	e.ModuleStarted()
+	e.BeginTask(0, 2)
+	e.TaskStarted("DownloadBinaries")
+	e.HostResult("DownloadBinaries", "node1", StatusSuccess, nil)
+	e.TaskFinished("DownloadBinaries", nil)
+	e.BeginTask(1, 2)
+	e.TaskStarted("Extract")
+	e.TaskFinished("Extract", nil)
+	e.ModuleEnded(nil)
+	e.ModuleSkipped(1, "Upgrade")
+	e.PipelineEnded(nil)
+
+	expected := []struct {
+		typ     string
+		percent int
+	}{
+		{PipelineStart, 0},
+		{ModuleStart, 0},
+		{TaskStart, 0},
+		{TaskHostResult, 0},
+		{TaskFinish, 25},
+		{TaskStart, 25},
+		{TaskFinish, 50},
+		{ModuleEnd, 50},
+		{ModuleSkip, 100},
+		{PipelineEnd, 100},
+	}
+
+	check := func(name string, scanner *bufio.Scanner) {
+		for i, want := range expected {
+			if !scanner.Scan() {
+				t.Fatalf("%s: missing event %d, %v", name, i, scanner.Err())
+			}
+			var got model.PipelineEvent
+			if err := json.Unmarshal(scanner.Bytes(), &got); err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			if got.Type != want.typ || got.Percent != want.percent || got.RunID != "run-1" {
+				t.Errorf("%s: event %d is %s %d%%, expected %s %d%%", name, i, got.Type, got.Percent, want.typ, want.percent)
+			}
+		}
+	}
+	check("stdout", bufio.NewScanner(&out))
+	check("socket", bufio.NewScanner(conn))
+}
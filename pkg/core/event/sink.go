@@ -0,0 +1,126 @@
+package event
+
+import (
+	"encoding/json"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"bytetrade.io/web3os/installer/pkg/core/logger"
+	"bytetrade.io/web3os/installer/pkg/core/storage"
+	"bytetrade.io/web3os/installer/pkg/model"
+)
+
+// writerSink writes the events as NDJSON, one JSON object per line
+type writerSink struct {
+	enc *json.Encoder
+}
+
+func NewWriterSink(w io.Writer) Sink {
+	return &writerSink{enc: json.NewEncoder(w)}
+}
+
+func (s *writerSink) Write(e model.PipelineEvent) error {
+	return s.enc.Encode(e)
+}
+
+func (s *writerSink) Close() error {
+	return nil
+}
+
+// storageSink persists the events through the storage provider
+type storageSink struct {
+	provider storage.Provider
+}
+
+// NewStorageSink returns nil if there is no storage provider
+func NewStorageSink(provider storage.Provider) Sink {
+	if provider == nil {
+		return nil
+	}
+	return &storageSink{provider: provider}
+}
+
+func (s *storageSink) Write(e model.PipelineEvent) error {
+	return s.provider.SavePipelineEvent(e)
+}
+
+func (s *storageSink) Close() error {
+	return nil
+}
+
+const socketWriteTimeout = 2 * time.Second
+
+// socketSink serves the events as NDJSON to every client connected to a unix socket,
+// clients only receive the events emitted after they connected
+type socketSink struct {
+	path     string
+	listener net.Listener
+
+	mu      sync.Mutex
+	clients map[net.Conn]*json.Encoder
+}
+
+// ListenSocket listens on the unix socket at path, replacing any stale socket file
+func ListenSocket(path string) (Sink, error) {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return nil, errors.Wrapf(err, "failed to remove stale event socket %s", path)
+	}
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to listen on event socket %s", path)
+	}
+	s := &socketSink{
+		path:     path,
+		listener: l,
+		clients:  make(map[net.Conn]*json.Encoder),
+	}
+	go s.accept()
+	return s, nil
+}
+
+func (s *socketSink) accept() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				logger.Debugf("event socket %s stopped accepting: %v", s.path, err)
+			}
+			return
+		}
+		s.mu.Lock()
+		s.clients[conn] = json.NewEncoder(conn)
+		s.mu.Unlock()
+	}
+}
+
+func (s *socketSink) Write(e model.PipelineEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for conn, enc := range s.clients {
+		// a stalled client must not hold up the pipeline
+		conn.SetWriteDeadline(time.Now().Add(socketWriteTimeout))
+		if err := enc.Encode(e); err != nil {
+			logger.Debugf("event socket client %s disconnected: %v", conn.RemoteAddr(), err)
+			conn.Close()
+			delete(s.clients, conn)
+		}
+	}
+	return nil
+}
+
+func (s *socketSink) Close() error {
+	err := s.listener.Close()
+	s.mu.Lock()
+	for conn := range s.clients {
+		conn.Close()
+		delete(s.clients, conn)
+	}
+	s.mu.Unlock()
+	// the listener removes the socket file itself on close
+	return err
+}
@@ -0,0 +1,305 @@
+package journal
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"bytetrade.io/web3os/installer/pkg/core/cache"
+	"bytetrade.io/web3os/installer/pkg/core/ending"
+	"bytetrade.io/web3os/installer/pkg/core/logger"
+	"bytetrade.io/web3os/installer/pkg/core/storage"
+	"bytetrade.io/web3os/installer/pkg/model"
+	"bytetrade.io/web3os/installer/version"
+)
+
+const cacheKey = "PipelineJournal"
+
+// Journal records the completion of every task on every host of a pipeline run
+// in the state store, so that an interrupted run can later be resumed
+// without repeating the work that has already succeeded.
+// All methods are safe to call on a nil *Journal, which records nothing.
+type Journal struct {
+	provider storage.Provider
+	run      model.PipelineRun
+	resuming bool
+
+	mu          sync.Mutex
+	done        map[string]struct{}
+	values      map[string]string
+	moduleIndex int
+	// moduleKey is the name of the module, suffixed by its occurrence if it runs several times in the pipeline
+	moduleKey   string
+	moduleSeen  map[string]int
+	force       bool
+	rolledBack  bool
+	interrupted bool
+	finished    bool
+}
+
+// Open starts a new run of the named pipeline, or continues the latest run
+// if resume is set and that run did not succeed.
+// A nil Journal is returned if no state store is available and resuming was not asked for.
+func Open(provider storage.Provider, name string, resume bool) (*Journal, error) {
+	if provider == nil {
+		if resume {
+			return nil, errors.New("the local state store is unavailable, unable to resume from the previous run")
+		}
+		return nil, nil
+	}
+
+	j := &Journal{
+		provider:   provider,
+		done:       make(map[string]struct{}),
+		values:     make(map[string]string),
+		moduleSeen: make(map[string]int),
+		run: model.PipelineRun{
+			ID:        uuid.NewString(),
+			Name:      name,
+			Status:    model.PipelineRunRunning,
+			StartedAt: time.Now(),
+			Version:   version.VERSION,
+		},
+	}
+	j.run.Command, j.run.Args = commandLine(os.Args[1:])
+
+	if resume {
+		last, err := provider.QueryLatestPipelineRun(name)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case last == nil:
+			logger.Infof("[Job] [%s] no previous run found, starting from the beginning", name)
+		case last.Status == model.PipelineRunSucceeded:
+			logger.Infof("[Job] [%s] the previous run succeeded, starting from the beginning", name)
+		case last.Status == model.PipelineRunRolledBack:
+			logger.Infof("[Job] [%s] the previous run has been rolled back, starting from the beginning", name)
+		default:
+			checkpoints, err := provider.QueryTaskCheckpoints(last.ID)
+			if err != nil {
+				return nil, err
+			}
+			for _, c := range checkpoints {
+				if c.Status == ending.SUCCESS.String() {
+					j.done[key(c.Module, c.Task, c.Host)] = struct{}{}
+				}
+			}
+			values, err := provider.QueryPipelineRunValues(last.ID)
+			if err != nil {
+				return nil, err
+			}
+			for _, v := range values {
+				j.values[v.Name] = v.Value
+			}
+			j.run.ID = last.ID
+			j.run.StartedAt = last.StartedAt
+			j.resuming = true
+			logger.Infof("[Job] [%s] resuming run %s, %d task(s) already completed", name, last.ID, len(j.done))
+		}
+	}
+
+	if err := provider.SavePipelineRun(j.run); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// FromCache returns the journal attached to the pipeline cache, if any.
+func FromCache(c *cache.Cache) *Journal {
+	if c == nil {
+		return nil
+	}
+	v, ok := c.Get(cacheKey)
+	if !ok {
+		return nil
+	}
+	j, _ := v.(*Journal)
+	return j
+}
+
+// Attach makes the journal available to the modules through the pipeline cache.
+func (j *Journal) Attach(c *cache.Cache) {
+	if j == nil {
+		return
+	}
+	c.Set(cacheKey, j)
+}
+
+func (j *Journal) RunID() string {
+	if j == nil {
+		return ""
+	}
+	return j.run.ID
+}
+
+// Resuming reports whether any task may be skipped in this run.
+func (j *Journal) Resuming() bool {
+	if j == nil {
+		return false
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.resuming && !j.force
+}
+
+// BeginModule sets the module the following tasks belong to,
+// force makes the module ignore the checkpoints of the previous run.
+func (j *Journal) BeginModule(index int, name string, force bool) {
+	if j == nil {
+		return
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.moduleIndex = index
+	j.moduleSeen[name]++
+	j.moduleKey = Occurrence(name, j.moduleSeen[name])
+	j.force = force
+}
+
+// Occurrence names the nth module, or task, of the given name, the checkpoints being matched by name,
+// rather than by position, for the modules and the tasks added or removed between the runs not to shift them
+func Occurrence(name string, n int) string {
+	if n <= 1 {
+		return name
+	}
+	return fmt.Sprintf("%s#%d", name, n)
+}
+
+// Done reports whether the task of the current module, named as by Occurrence, has already succeeded on the host.
+func (j *Journal) Done(task string, host string) bool {
+	if j == nil {
+		return false
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if !j.resuming || j.force {
+		return false
+	}
+	_, ok := j.done[key(j.moduleKey, task, host)]
+	return ok
+}
+
+// Record saves the result of the task of the current module, named as by Occurrence, on the host.
+func (j *Journal) Record(taskIndex int, task, host string, status ending.ResultStatus) {
+	if j == nil {
+		return
+	}
+	j.mu.Lock()
+	checkpoint := model.TaskCheckpoint{
+		RunID:       j.run.ID,
+		ModuleIndex: j.moduleIndex,
+		Module:      j.moduleKey,
+		TaskIndex:   taskIndex,
+		Task:        task,
+		Host:        host,
+		Status:      status.String(),
+		UpdatedAt:   time.Now(),
+	}
+	j.mu.Unlock()
+
+	if err := j.provider.SaveTaskCheckpoint(checkpoint); err != nil {
+		logger.Warnf("failed to record checkpoint: %v", err)
+	}
+}
+
+// Set saves a value of the run, found again by Value when the run is resumed.
+func (j *Journal) Set(name, value string) {
+	if j == nil {
+		return
+	}
+	j.mu.Lock()
+	j.values[name] = value
+	j.mu.Unlock()
+
+	if err := j.provider.SavePipelineRunValue(model.PipelineRunValue{
+		RunID: j.run.ID, Name: name, Value: value, UpdatedAt: time.Now(),
+	}); err != nil {
+		logger.Warnf("failed to record value %s of run %s: %v", name, j.run.ID, err)
+	}
+}
+
+// Value returns the value saved by Set in this run, or in the run resumed.
+func (j *Journal) Value(name string) (string, bool) {
+	if j == nil {
+		return "", false
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	v, ok := j.values[name]
+	return v, ok
+}
+
+// RolledBack marks the run as rolled back, so that it will not be resumed
+// as the completed tasks may have been reverted.
+func (j *Journal) RolledBack() {
+	if j == nil {
+		return
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.rolledBack = true
+}
+
+// Interrupted marks the run as stopped by a signal rather than failed.
+func (j *Journal) Interrupted() {
+	if j == nil {
+		return
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.interrupted = true
+}
+
+// Finish marks the run as succeeded, or failed if err is not nil.
+// Only the first call records anything, the end of a run may be reported
+// both by the pipeline and by a forced exit.
+func (j *Journal) Finish(err error) {
+	if j == nil {
+		return
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.finished {
+		return
+	}
+	j.finished = true
+
+	now := time.Now()
+	j.run.EndedAt = &now
+	j.run.Status = model.PipelineRunSucceeded
+	if err != nil {
+		j.run.Status = model.PipelineRunFailed
+		j.run.Error = err.Error()
+	}
+	if j.interrupted {
+		j.run.Status = model.PipelineRunInterrupted
+	}
+	if j.rolledBack {
+		j.run.Status = model.PipelineRunRolledBack
+	}
+	if err := j.provider.SavePipelineRun(j.run); err != nil {
+		logger.Warnf("failed to record the end of run %s: %v", j.run.ID, err)
+	}
+}
+
+// commandLine splits the arguments of the program into the (sub)command, e.g., "node add",
+// and the flags and arguments that follow it
+func commandLine(args []string) (command, rest string) {
+	// the values of the secret flags, e.g., --master-ssh-password, are not to be shown by olares-cli history
+	args = logger.RedactArgs(args)
+	i := 0
+	for i < len(args) && !strings.HasPrefix(args[i], "-") {
+		i++
+	}
+	return strings.Join(args[:i], " "), logger.Scrub(strings.Join(args[i:], " "))
+}
+
+func key(module, task, host string) string {
+	return module + "/" + task + "/" + host
+}
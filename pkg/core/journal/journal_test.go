@@ -0,0 +1,182 @@
+package journal
+
+import (
+	"path/filepath"
+	"testing"
+
+	"bytetrade.io/web3os/installer/pkg/core/ending"
+	"bytetrade.io/web3os/installer/pkg/core/logger"
+	"bytetrade.io/web3os/installer/pkg/core/storage"
+	"bytetrade.io/web3os/installer/pkg/model"
+	"github.com/pkg/errors"
+)
+
+func TestResume(t *testing.T) {
+	dir := t.TempDir()
+	logger.InitLog(dir, filepath.Join(dir, "test.log"), true)
+	provider := storage.NewSQLiteProvider(dir)
+	if err := provider.StartupCheck(); err != nil {
+		t.Skipf("state store unavailable: %v", err)
+	}
+
+	first, err := Open(provider, "Install", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first.BeginModule(0, "Download", false)
+	first.Record(0, "DownloadBinaries", "node1", ending.SUCCESS)
+	first.Record(1, "Extract", "node1", ending.FAILED)
+	first.Set("snapshot", "/var/snapshots/1")
+	first.Finish(errors.New("extract failed"))
+
+	second, err := Open(provider, "Install", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.RunID() != first.RunID() {
+		t.Fatalf("expected to resume run %s, got %s", first.RunID(), second.RunID())
+	}
+	if v, _ := second.Value("snapshot"); v != "/var/snapshots/1" {
+		t.Errorf("expected the value of the resumed run, got %q", v)
+	}
+	second.BeginModule(0, "Download", false)
+	if !second.Done("DownloadBinaries", "node1") {
+		t.Error("succeeded task should be skipped")
+	}
+	if second.Done("Extract", "node1") {
+		t.Error("failed task should run again")
+	}
+	second.BeginModule(0, "Download", true)
+	if second.Done("DownloadBinaries", "node1") {
+		t.Error("forced module should run again")
+	}
+	second.Finish(nil)
+
+	third, err := Open(provider, "Install", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if third.RunID() == first.RunID() || third.Resuming() {
+		t.Error("a succeeded run should not be resumed")
+	}
+	if _, ok := third.Value("snapshot"); ok {
+		t.Error("a new run should not see the values of a previous one")
+	}
+}
+
+func TestRolledBackRunIsNotResumed(t *testing.T) {
+	dir := t.TempDir()
+	logger.InitLog(dir, filepath.Join(dir, "test.log"), true)
+	provider := storage.NewSQLiteProvider(dir)
+	if err := provider.StartupCheck(); err != nil {
+		t.Skipf("state store unavailable: %v", err)
+	}
+
+	first, err := Open(provider, "Install", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first.BeginModule(0, "InitCluster", false)
+	first.Record(0, "GenerateK3sService", "node1", ending.SUCCESS)
+	first.RolledBack()
+	first.Finish(errors.New("init cluster failed"))
+
+	second, err := Open(provider, "Install", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.RunID() == first.RunID() || second.Resuming() {
+		t.Error("a rolled back run should not be resumed")
+	}
+}
+
+func TestInterruptedRunIsResumed(t *testing.T) {
+	dir := t.TempDir()
+	logger.InitLog(dir, filepath.Join(dir, "test.log"), true)
+	provider := storage.NewSQLiteProvider(dir)
+	if err := provider.StartupCheck(); err != nil {
+		t.Skipf("state store unavailable: %v", err)
+	}
+
+	first, err := Open(provider, "Install", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first.BeginModule(0, "LoadImages", false)
+	first.Record(0, "LoadImages", "node1", ending.SUCCESS)
+	first.Interrupted()
+	first.Finish(errors.New("interrupted"))
+	// a forced exit reporting the end again must not overwrite the record
+	first.Finish(nil)
+
+	last, err := provider.QueryLatestPipelineRun("Install")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last.Status != model.PipelineRunInterrupted {
+		t.Errorf("run status is %s, expected %s", last.Status, model.PipelineRunInterrupted)
+	}
+
+	second, err := Open(provider, "Install", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second.BeginModule(0, "LoadImages", false)
+	if second.RunID() != first.RunID() || !second.Done("LoadImages", "node1") {
+		t.Error("an interrupted run should be resumed")
+	}
+}
+
+func TestCheckpointsMatchedByName(t *testing.T) {
+	dir := t.TempDir()
+	logger.InitLog(dir, filepath.Join(dir, "test.log"), true)
+	provider := storage.NewSQLiteProvider(dir)
+	if err := provider.StartupCheck(); err != nil {
+		t.Skipf("state store unavailable: %v", err)
+	}
+
+	first, err := Open(provider, "Upgrade", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first.BeginModule(0, "Status", false)
+	first.Record(0, "GetClusterStatus", "node1", ending.SUCCESS)
+	first.BeginModule(1, "Upgrade", false)
+	first.Record(0, "SaveSnapshot", "node1", ending.SUCCESS)
+	first.BeginModule(2, "Status", false)
+	first.Record(0, "GetClusterStatus", "node1", ending.FAILED)
+	first.Finish(errors.New("status failed"))
+
+	// a module added in front of the others, and the tasks of a module depending on the versions, do not shift the checkpoints
+	second, err := Open(provider, "Upgrade", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second.BeginModule(0, "Precheck", false)
+	if second.Done("SaveSnapshot", "node1") {
+		t.Error("a task of another module should not be skipped")
+	}
+	second.BeginModule(1, "Status", false)
+	if !second.Done("GetClusterStatus", "node1") {
+		t.Error("the first occurrence of a module should match its checkpoints")
+	}
+	second.BeginModule(2, "Upgrade", false)
+	if !second.Done("SaveSnapshot", "node1") || second.Done(Occurrence("SaveSnapshot", 2), "node1") {
+		t.Error("the tasks should be matched by name and occurrence")
+	}
+	second.BeginModule(3, "Status", false)
+	if second.Done("GetClusterStatus", "node1") {
+		t.Error("the second occurrence of a module should match its own checkpoints")
+	}
+}
+
+func TestCommandLine(t *testing.T) {
+	command, args := commandLine([]string{"node", "add", "--master-host", "10.0.0.1", "-b", "/opt/olares"})
+	if command != "node add" || args != "--master-host 10.0.0.1 -b /opt/olares" {
+		t.Errorf("got command %q and args %q", command, args)
+	}
+	_, args = commandLine([]string{"node", "add", "--master-ssh-password", "hunter22", "--master-ssh-user=olares", "--master-ssh-password=hunter22"})
+	if args != "--master-ssh-password ****** --master-ssh-user=olares --master-ssh-password=******" {
+		t.Errorf("got args %q, want the passwords masked", args)
+	}
+}
@@ -2,6 +2,7 @@ package logger
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"path"
 	"time"
@@ -15,6 +16,15 @@ var logger *zap.SugaredLogger
 
 var FatalMessagePrefix = "[FATAL] "
 
+// consoleOutput is where the console log is printed,
+// stdout is left to machine-readable output when that is asked for
+var consoleOutput io.Writer = os.Stdout
+
+// SetConsoleOutput changes where the console log is printed, it must be called before InitLog
+func SetConsoleOutput(w io.Writer) {
+	consoleOutput = w
+}
+
 func InitLog(jsonLogDir, consoleLogFilePath string, consoleLogTruncate bool) {
 	for _, logDir := range []string{jsonLogDir, path.Dir(consoleLogFilePath)} {
 		found, err := isDirExist(logDir)
@@ -79,12 +89,13 @@ func InitLog(jsonLogDir, consoleLogFilePath string, consoleLogTruncate bool) {
 		EncodeCaller:   zapcore.ShortCallerEncoder,
 	}
 
-	consoleDebugging := zapcore.Lock(os.Stdout)
+	consoleDebugging := zapcore.Lock(zapcore.AddSync(consoleOutput))
 
+	// every sink masks the secrets registered with RegisterSecret
 	core := zapcore.NewTee(
-		zapcore.NewCore(zapcore.NewConsoleEncoder(consoleEncoderConfig), consoleDebugging, consolePriority),
-		zapcore.NewCore(zapcore.NewConsoleEncoder(consoleEncoderConfig), zapcore.AddSync(consoleLogFile), consolePriority),
-		zapcore.NewCore(zapcore.NewJSONEncoder(fileEncoder), zapcore.AddSync(jsonLogFile), jsonLogFilePriority),
+		zapcore.NewCore(zapcore.NewConsoleEncoder(consoleEncoderConfig), redactingSyncer{consoleDebugging}, consolePriority),
+		zapcore.NewCore(zapcore.NewConsoleEncoder(consoleEncoderConfig), redactingSyncer{zapcore.AddSync(consoleLogFile)}, consolePriority),
+		zapcore.NewCore(zapcore.NewJSONEncoder(fileEncoder), redactingSyncer{zapcore.AddSync(jsonLogFile)}, jsonLogFilePriority),
 	)
 	logger = zap.New(core, zap.AddCaller(), zap.AddCallerSkip(1), zap.AddStacktrace(zapcore.FatalLevel)).Sugar()
 }
@@ -0,0 +1,124 @@
+package logger
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// Redacted replaces the registered secrets in whatever is logged
+const Redacted = "******"
+
+// the shorter values are not masked, as they would mask much more than the secret
+const minSecretLength = 4
+
+var secrets = struct {
+	sync.RWMutex
+	values   map[string]struct{}
+	replacer *strings.Replacer
+}{values: make(map[string]struct{})}
+
+// RegisterSecret masks the values in every log entry, in the console log and in the command echo
+// from now on, it is to be called as soon as a password, key or token is known
+func RegisterSecret(values ...string) {
+	secrets.Lock()
+	defer secrets.Unlock()
+	for _, v := range values {
+		if v = strings.TrimSpace(v); len(v) >= minSecretLength {
+			secrets.values[v] = struct{}{}
+			// as found in the entries of the JSON log
+			secrets.values[jsonEscaper.Replace(v)] = struct{}{}
+		}
+	}
+
+	// the longest ones first, so that a secret containing another one is masked as a whole
+	sorted := make([]string, 0, len(secrets.values))
+	for v := range secrets.values {
+		sorted = append(sorted, v)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if len(sorted[i]) != len(sorted[j]) {
+			return len(sorted[i]) > len(sorted[j])
+		}
+		return sorted[i] < sorted[j]
+	})
+	pairs := make([]string, 0, 2*len(sorted))
+	for _, v := range sorted {
+		pairs = append(pairs, v, Redacted)
+	}
+	secrets.replacer = strings.NewReplacer(pairs...)
+}
+
+var jsonEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+// Redact masks the registered secrets in s
+func Redact(s string) string {
+	secrets.RLock()
+	r := secrets.replacer
+	secrets.RUnlock()
+	if r == nil {
+		return s
+	}
+	return r.Replace(s)
+}
+
+// secretFlags are the names of the command line flags whose value is a secret,
+// e.g., of juicefs, ossutil or k3s, and the --*password flags of olares-cli
+const secretFlags = `[a-z0-9-]*password|access-key|secret-key|session-token|access-key-id|access-key-secret|sts-token|secret-id|token`
+
+var secretFlag = regexp.MustCompile(`^--(?:` + secretFlags + `)$`)
+
+// secretPatterns match the secrets known to appear in the logs of Olares and of its components,
+// the second group is masked, the others are kept
+var secretPatterns = []*regexp.Regexp{
+	// credentials given on a command line
+	regexp.MustCompile(`(--(?:` + secretFlags + `)[= ]+)("[^"]*"|'[^']*'|[^\s"']+)`),
+	// the password of a URL, e.g., redis://:password@host
+	regexp.MustCompile(`([a-z][a-z0-9+.-]*://[^:/@\s]*:)([^@/\s]+)(@)`),
+	// key-value pairs of configuration files and structured logs, e.g., the bytetrade.io/s3-sk annotation of the Terminus CR
+	regexp.MustCompile(`(?i)((?:password|passwd|secret_?key|s3[-_]sk|s3[-_]sts)["']?\s*[:=]\s*["']?|requirepass\s+)([^\s"',;}]+)`),
+}
+
+// RedactArgs returns the arguments of a command line with the values of the secret flags masked,
+// given either as --flag value or as --flag=value
+func RedactArgs(args []string) []string {
+	redacted := make([]string, len(args))
+	copy(redacted, args)
+	for i, arg := range redacted {
+		name, _, hasValue := strings.Cut(arg, "=")
+		if !secretFlag.MatchString(name) {
+			continue
+		}
+		if hasValue {
+			redacted[i] = name + "=" + Redacted
+		} else if i+1 < len(redacted) {
+			redacted[i+1] = Redacted
+		}
+	}
+	return redacted
+}
+
+// Scrub masks the registered secrets in s, along with the values that look like secrets,
+// it is meant for the logs written before the secrets were registered or by other processes
+func Scrub(s string) string {
+	s = Redact(s)
+	for _, p := range secretPatterns {
+		s = p.ReplaceAllString(s, "${1}"+Redacted+"${3}")
+	}
+	return s
+}
+
+// redactingSyncer masks the registered secrets in what is written to a sink of the logger
+type redactingSyncer struct {
+	zapcore.WriteSyncer
+}
+
+func (w redactingSyncer) Write(p []byte) (int, error) {
+	if _, err := w.WriteSyncer.Write([]byte(Redact(string(p)))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,48 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRedact(t *testing.T) {
+	dir := t.TempDir()
+	consoleLog := filepath.Join(dir, "install.log")
+	InitLog(dir, consoleLog, true)
+
+	RegisterSecret("s3cr3t", `pa"ss`, "ab")
+	Infof("format with %s and %s, keep %s", "s3cr3t", `pa"ss`, "ab")
+	Infow("structured", "sk", `pa"ss`)
+	Sync()
+
+	entries, _ := filepath.Glob(filepath.Join(dir, "*.log"))
+	for _, f := range entries {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(string(data), "s3cr3t") || strings.Contains(string(data), `pa\"ss`) || strings.Contains(string(data), `pa"ss`) {
+			t.Errorf("%s holds a secret:\n%s", f, data)
+		}
+		if !strings.Contains(string(data), "keep ab") {
+			t.Errorf("%s lost a value too short to be a secret:\n%s", f, data)
+		}
+	}
+
+	for in, want := range map[string]string{
+		"juicefs format redis://:hunter2@10.0.0.2:6379/1 --secret-key abc --token='x y'": "juicefs format redis://:******@10.0.0.2:6379/1 --secret-key ****** --token=******",
+		"MINIO_ROOT_PASSWORD=hunter2":            "MINIO_ROOT_PASSWORD=******",
+		`{"password": "hunter2", "user": "bob"}`: `{"password": "******", "user": "bob"}`,
+		"requirepass hunter2":                    "requirepass ******",
+		"redis password not found":               "redis password not found",
+		"bytetrade.io/s3-sk: abc/def":            "bytetrade.io/s3-sk: ******",
+		`"bytetrade.io/s3-sts": "tok"`:           `"bytetrade.io/s3-sts": "******"`,
+		"join --master-ssh-password hunter22":    "join --master-ssh-password ******",
+	} {
+		if got := Scrub(in); got != want {
+			t.Errorf("Scrub(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package module
+
+import (
+	"github.com/pkg/errors"
+
+	"bytetrade.io/web3os/installer/pkg/core/connector"
+	"bytetrade.io/web3os/installer/pkg/core/logger"
+)
+
+// ErrRollbackUnsupported is returned by the Rollback of a module that has nothing to revert with
+var ErrRollbackUnsupported = errors.New("rollback is not supported by the module")
+
+// Rollbacker is implemented by the modules able to revert what they did.
+// When a pipeline fails and rolling back is asked for,
+// Rollback is called on the failed module and then on every completed one, most recent first,
+// so it must cope with the module having been only partially run.
+type Rollbacker interface {
+	Rollback() error
+}
+
+// Rollback runs the RollbackTasks of the module, all of them are run even if some fail
+func (b *BaseTaskModule) Rollback() error {
+	if len(b.RollbackTasks) == 0 {
+		return ErrRollbackUnsupported
+	}
+
+	var errs []error
+	for i := range b.RollbackTasks {
+		t := b.RollbackTasks[i]
+		t.Init(b.Runtime.(connector.Runtime), b.ModuleCache, b.PipelineCache)
+		res := t.Execute()
+		for _, ac := range res.ActionResults {
+			logger.Infof("[R] %s: %s %s", ac.Host.GetName(), t.GetName(), ac.Status.String())
+		}
+		if res.IsFailed() {
+			errs = append(errs, errors.Wrapf(res.CombineErr(), "%s failed", t.GetName()))
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Wrapf(combine(errs), "Module[%s] rollback failed", b.Name)
+	}
+	return nil
+}
+
+func combine(errs []error) error {
+	msg := errs[0].Error()
+	for _, err := range errs[1:] {
+		msg += "; " + err.Error()
+	}
+	return errors.New(msg)
+}
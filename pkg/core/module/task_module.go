@@ -19,6 +19,8 @@ package module
 import (
 	"bytetrade.io/web3os/installer/pkg/core/connector"
 	"bytetrade.io/web3os/installer/pkg/core/ending"
+	"bytetrade.io/web3os/installer/pkg/core/event"
+	"bytetrade.io/web3os/installer/pkg/core/journal"
 	"bytetrade.io/web3os/installer/pkg/core/logger"
 	"bytetrade.io/web3os/installer/pkg/core/task"
 	"bytetrade.io/web3os/installer/pkg/core/util"
@@ -28,6 +30,8 @@ import (
 type BaseTaskModule struct {
 	BaseModule
 	Tasks []task.Interface
+	// RollbackTasks revert what the Tasks did, see Rollbacker
+	RollbackTasks []task.Interface
 }
 
 func (b *BaseTaskModule) Init() {
@@ -45,14 +49,28 @@ func (b *BaseTaskModule) GetTasks() []task.Interface {
 }
 
 func (b *BaseTaskModule) Run(result *ending.ModuleResult) {
+	jn := journal.FromCache(b.PipelineCache)
+	ev := event.FromCache(b.PipelineCache)
+	seen := make(map[string]int, len(b.Tasks))
 	for i := range b.Tasks {
 		t := b.Tasks[i]
+		seen[t.GetName()]++
+		name := journal.Occurrence(t.GetName(), seen[t.GetName()])
+		if b.Runtime.(connector.Runtime).GetContext().Err() != nil {
+			result.ErrResult(errors.Wrapf(connector.ErrInterrupted, "Module[%s] stopped before %s", b.Name, t.GetName()))
+			return
+		}
 		t.Init(b.Runtime.(connector.Runtime), b.ModuleCache, b.PipelineCache)
+		ev.BeginTask(i, len(b.Tasks))
+		if b.skipCheckpointed(jn, name, t) {
+			continue
+		}
 
 		// logger.Infof("[A] %s: %s", b.Name, t.GetDesc())
 		res := t.Execute()
 		for j := range res.ActionResults {
 			ac := res.ActionResults[j]
+			jn.Record(i, name, ac.Host.GetName(), ac.Status)
 			// logger.Infof("[Module] %s: %s %s", ac.Host.GetName(), b.Name, ac.Status.String())
 			elapsed := ac.EndTime.Sub(ac.StartTime)
 			// logger.Infof("[Module] %s: %s %s", ac.Host.GetName(), b.Name, ac.Status.String())
@@ -83,3 +101,33 @@ func (b *BaseTaskModule) Run(result *ending.ModuleResult) {
 	}
 	result.NormalResult()
 }
+
+// skipCheckpointed drops the hosts on which the task already succeeded in the run being resumed,
+// and reports whether there is nothing left to do for the task,
+// the tasks filling the cache or only reading the state are run again, see task.RunsOnResume.
+func (b *BaseTaskModule) skipCheckpointed(jn *journal.Journal, name string, t task.Interface) bool {
+	if !jn.Resuming() || task.RunsOnResume(t) {
+		return false
+	}
+
+	switch tt := t.(type) {
+	case *task.RemoteTask:
+		hosts := make([]connector.Host, 0, len(tt.Hosts))
+		for _, host := range tt.Hosts {
+			if host != nil && jn.Done(name, host.GetName()) {
+				logger.Infof("[A] %s: %s skipped (completed in previous run)", host.GetName(), t.GetName())
+				continue
+			}
+			hosts = append(hosts, host)
+		}
+		tt.Hosts = hosts
+		return len(hosts) == 0
+	case *task.LocalTask:
+		host := b.Runtime.GetLocalHost()
+		if jn.Done(name, host.GetName()) {
+			logger.Infof("[A] %s: %s skipped (completed in previous run)", host.GetName(), t.GetName())
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,158 @@
+package pipeline
+
+import (
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
+
+	"bytetrade.io/web3os/installer/pkg/core/logger"
+	"bytetrade.io/web3os/installer/pkg/core/module"
+	"bytetrade.io/web3os/installer/pkg/core/task"
+)
+
+// Options are the execution switches shared by the pipeline commands
+type Options struct {
+	// Resume skips the tasks that already succeeded in the previous, unfinished run
+	Resume bool
+	// FromModule forces the pipeline to run again from the named module,
+	// modules before it are treated as with Resume
+	FromModule string
+
+	// DryRun prints the plan of the pipeline instead of executing it
+	DryRun bool
+	// Output selects the format of what the pipeline prints, see the Output* constants
+	Output string
+	// EventsSocket is the path of a unix socket serving the progress events of the run
+	EventsSocket string
+	// MaxParallelHosts caps the number of hosts the tasks of the pipeline work on at the same time
+	MaxParallelHosts int
+	// RollbackOnFailure rolls back the failed module and the completed ones, see module.Rollbacker
+	RollbackOnFailure bool
+	// JSONReport is set by the pipelines printing a JSON report of their own,
+	// which is what --output json stands for when not in a dry run
+	JSONReport bool
+}
+
+const (
+	OutputTree = "tree"
+	OutputJSON = "json"
+	// OutputEvents prints the progress events of the run to stdout as NDJSON, and nothing else
+	OutputEvents = "events"
+)
+
+// DefaultOptions holds the switches given on the command line for every pipeline,
+// they apply to the pipelines that do not set them explicitly
+var DefaultOptions Options
+
+// AddGlobalFlags registers the switches shared by every pipeline command to DefaultOptions
+func AddGlobalFlags(fs *pflag.FlagSet) {
+	fs.BoolVar(&DefaultOptions.DryRun, "dry-run", false, "Print the modules and tasks the command would run, with their target hosts and rendered templates, their secrets masked, without executing any of them")
+	fs.StringVar(&DefaultOptions.Output, "output", "", "Output format, tree or json for the plan printed by --dry-run, events to print only the progress events of the run as NDJSON, the console log is printed to stderr for json and events, and everything else too for events")
+	fs.StringVar(&DefaultOptions.EventsSocket, "events-socket", "", "Path of a unix socket to serve the progress events of the run as NDJSON on")
+	fs.BoolVar(&DefaultOptions.RollbackOnFailure, "rollback-on-failure", false, "On failure, roll back the failed module and then the completed ones in reverse order, for the modules supporting it, and report what was reverted")
+	fs.IntVar(&DefaultOptions.MaxParallelHosts, "max-parallel-hosts", task.DefaultCon, "Maximum number of hosts the tasks work on at the same time, across all the running tasks")
+}
+
+// RejectGlobalFlags fails if any of the switches registered by AddGlobalFlags is set in fs,
+// the flags inherited by a command not running a pipeline, which would silently ignore them
+func RejectGlobalFlags(fs *pflag.FlagSet) error {
+	for _, name := range []string{"dry-run", "output", "events-socket", "rollback-on-failure", "max-parallel-hosts"} {
+		if f := fs.Lookup(name); f != nil && f.Changed {
+			return errors.Errorf("--%s is only supported by the commands running a pipeline", name)
+		}
+	}
+	return nil
+}
+
+// AddOutputFlag registers --output to DefaultOptions.Output on a command printing a report of its own,
+// with usage telling the formats it supports, it takes the place of the one registered by AddGlobalFlags
+func AddOutputFlag(fs *pflag.FlagSet, usage string) {
+	fs.StringVar(&DefaultOptions.Output, "output", "", usage)
+}
+
+// stdout is the standard output of the program, os.Stdout is redirected to stderr with --output events, see PrepareOutput
+var stdout = os.Stdout
+
+// PrepareOutput moves the console log to stderr if stdout is reserved to the output asked for by --output,
+// it must be called before the logger is initialized along with the runtime.
+// With --output events, os.Stdout itself is redirected to stderr for the run, for whatever the tasks, and the commands they run,
+// print not to mix with the events, which are written to the standard output kept aside
+func PrepareOutput() {
+	if DefaultOptions.MachineReadable() {
+		logger.SetConsoleOutput(os.Stderr)
+	}
+	if DefaultOptions.Output == OutputEvents {
+		os.Stdout = os.Stderr
+	}
+}
+
+// MachineReadable reports whether stdout is reserved to the output asked for by --output
+func (o *Options) MachineReadable() bool {
+	return o.Output == OutputJSON || o.Output == OutputEvents
+}
+
+func (o *Options) AddFlags(fs *pflag.FlagSet) {
+	fs.BoolVar(&o.Resume, "resume", false, "Resume the previous unfinished run, skipping the tasks that have already succeeded")
+	fs.StringVar(&o.FromModule, "from-module", "", "Resume the previous run but force every module starting from the given one to run again, implies --resume")
+}
+
+func (o *Options) validate() error {
+	switch o.Output {
+	case "":
+	case OutputJSON:
+		if !o.DryRun && !o.JSONReport {
+			return errors.Errorf("--output %s is only supported with --dry-run", o.Output)
+		}
+	case OutputTree:
+		if !o.DryRun {
+			return errors.Errorf("--output %s is only supported with --dry-run", o.Output)
+		}
+	case OutputEvents:
+		if o.DryRun {
+			return errors.Errorf("--output %s is not supported with --dry-run", o.Output)
+		}
+	default:
+		return errors.Errorf("unsupported output format %q", o.Output)
+	}
+	if o.MaxParallelHosts < 0 {
+		return errors.Errorf("invalid --max-parallel-hosts %d", o.MaxParallelHosts)
+	}
+	return nil
+}
+
+// withDefaults fills in the switches not set on the options from DefaultOptions
+func (o Options) withDefaults() Options {
+	o.DryRun = o.DryRun || DefaultOptions.DryRun
+	o.RollbackOnFailure = o.RollbackOnFailure || DefaultOptions.RollbackOnFailure
+	if o.Output == "" {
+		o.Output = DefaultOptions.Output
+	}
+	if o.EventsSocket == "" {
+		o.EventsSocket = DefaultOptions.EventsSocket
+	}
+	if o.MaxParallelHosts <= 0 {
+		o.MaxParallelHosts = DefaultOptions.MaxParallelHosts
+	}
+	return o
+}
+
+func (o *Options) resuming() bool {
+	return o.Resume || o.FromModule != ""
+}
+
+// matchModule reports whether the module is the one referred to by name,
+// either by its name or by its type name, e.g. InstallOsSystem or InstallOsSystemModule
+func matchModule(m module.Module, name string) bool {
+	return strings.EqualFold(m.GetName(), name) || strings.EqualFold(moduleTypeName(m), name)
+}
+
+func moduleTypeName(m module.Module) string {
+	t := reflect.TypeOf(m)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Name()
+}
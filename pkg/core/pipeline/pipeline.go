@@ -17,16 +17,20 @@
 package pipeline
 
 import (
-	"os"
 	"sync"
 	"time"
 
 	"bytetrade.io/web3os/installer/pkg/core/cache"
 	"bytetrade.io/web3os/installer/pkg/core/connector"
 	"bytetrade.io/web3os/installer/pkg/core/ending"
+	"bytetrade.io/web3os/installer/pkg/core/event"
+	"bytetrade.io/web3os/installer/pkg/core/journal"
 	"bytetrade.io/web3os/installer/pkg/core/logger"
 	"bytetrade.io/web3os/installer/pkg/core/module"
+	"bytetrade.io/web3os/installer/pkg/core/task"
 	"bytetrade.io/web3os/installer/pkg/core/util"
+	"bytetrade.io/web3os/installer/pkg/signals"
+	"github.com/google/uuid"
 	"github.com/pkg/errors"
 )
 
@@ -46,6 +50,22 @@ type Pipeline struct {
 	PipelineCache   *cache.Cache
 	ModuleCachePool sync.Pool
 	ModulePostHooks []module.PostHookInterface
+	Options
+
+	// RollbackReport is set once the pipeline has failed and been rolled back
+	RollbackReport *RollbackReport
+
+	journal           *journal.Journal
+	events            *event.Emitter
+	completed         []completedModule
+	fromModuleReached bool
+	finishOnce        sync.Once
+
+	mu sync.Mutex
+	// goroutineErr is the failure of a module running in the background
+	goroutineErr error
+	// background tracks the modules running in the background, waited for once the last module is done
+	background sync.WaitGroup
 }
 
 func (p *Pipeline) Init() error {
@@ -60,18 +80,81 @@ func (p *Pipeline) Init() error {
 
 	p.StartAt = time.Now()
 
+	j, err := journal.Open(p.Runtime.GetStorage(), p.Name, p.resuming())
+	if err != nil {
+		return err
+	}
+	j.Attach(p.PipelineCache)
+	p.journal = j
+
+	e, err := p.newEmitter()
+	if err != nil {
+		return err
+	}
+	e.Attach(p.PipelineCache)
+	p.events = e
+
+	task.NewWorkerPool(p.MaxParallelHosts).Attach(p.PipelineCache)
+
 	return nil
 }
 
+func (p *Pipeline) newEmitter() (*event.Emitter, error) {
+	var sinks []event.Sink
+	if p.Output == OutputEvents {
+		sinks = append(sinks, event.NewWriterSink(stdout))
+	}
+	if p.EventsSocket != "" {
+		s, err := event.ListenSocket(p.EventsSocket)
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, s)
+	}
+	sinks = append(sinks, event.NewStorageSink(p.Runtime.GetStorage()))
+
+	runID := p.journal.RunID()
+	if runID == "" {
+		runID = uuid.NewString()
+	}
+	return event.NewEmitter(p.Name, runID, len(p.Modules), sinks...), nil
+}
+
 func (p *Pipeline) Start() error {
+	p.Options = p.Options.withDefaults()
+	if err := p.Options.validate(); err != nil {
+		return err
+	}
+	if p.DryRun {
+		return p.printPlan()
+	}
+
+	// the first signal stops the pipeline before the next task, the second one kills the running commands
+	p.Runtime.SetContext(signals.GracefulShutdown())
+
 	logger.Infof("[Job] [%s] start ...", p.Name)
 	if err := p.Init(); err != nil {
 		logger.Errorf("[Job] %s execute failed %v", p.Name, err)
 		return errors.Wrapf(err, "Job %s execute failed", p.Name)
 	}
+	removeHook := signals.OnForceExit(func() { p.finish(connector.ErrInterrupted) })
+	defer removeHook()
+
+	p.events.PipelineStarted()
 	for i := range p.Modules {
+		if err := p.stopped(); err != nil {
+			logger.Errorf("[Job] [%s] execute failed %v", p.Name, err)
+			p.releaseCompleted()
+			p.finish(err)
+			return err
+		}
 		m := p.Modules[i]
 		if m.IsSkip() {
+			name := m.GetName()
+			if name == "" {
+				name = moduleTypeName(m)
+			}
+			p.events.ModuleSkipped(i, name)
 			continue
 		}
 
@@ -80,6 +163,12 @@ func (p *Pipeline) Start() error {
 		m.AutoAssert()
 		m.Init()
 		logger.Infof("[Module] %s", m.GetName())
+		name := m.GetName()
+		if name == "" {
+			name = moduleTypeName(m)
+		}
+		p.journal.BeginModule(i, name, p.forceRun(m))
+		p.events.BeginModule(i, m.GetName())
 		for j := range p.ModulePostHooks {
 			m.AppendPostHook(p.ModulePostHooks[j])
 		}
@@ -88,15 +177,29 @@ func (p *Pipeline) Start() error {
 		if res.IsFailed() {
 			logger.Errorf("[Job] [%s] execute failed %v", p.Name, err)
 			// return errors.Wrapf(res.CombineResult, "Pipeline[%s] execute failed", p.Name)
+			p.rollback(i, m, moduleCache)
+			p.finish(res.CombineResult)
 			return res.CombineResult
 		}
 		if err != nil {
 			logger.Errorf("[Job] [%s] execute failed %v", p.Name, err)
 			// return errors.Wrapf(err, "Job[%s] execute failed", p.Name)
+			p.rollback(i, m, moduleCache)
+			p.finish(err)
 			return err
 		}
-		p.releaseModuleCache(moduleCache)
+		p.moduleDone(i, m, moduleCache)
 	}
+	// the modules running in the background are part of the run, which only ends once they are done
+	p.background.Wait()
+	// the last module may have been interrupted, or one running in the background may have failed meanwhile
+	if err := p.stopped(); err != nil {
+		logger.Errorf("[Job] [%s] execute failed %v", p.Name, err)
+		p.releaseCompleted()
+		p.finish(err)
+		return err
+	}
+	p.releaseCompleted()
 	p.releasePipelineCache()
 
 	// close ssh connect
@@ -104,20 +207,63 @@ func (p *Pipeline) Start() error {
 		p.Runtime.GetConnector().Close(host)
 	}
 
+	if p.FromModule != "" && !p.fromModuleReached {
+		logger.Warnf("[Job] [%s] no module matches --from-module %s", p.Name, p.FromModule)
+	}
+
 	if p.SpecHosts != len(p.Runtime.GetAllHosts()) {
 		logger.Errorf("[Job] %s execute failed: there are some error in your spec hosts", p.Name)
-		return errors.Errorf("[Job] %s execute failed: there are some error in your spec hosts", p.Name)
+		err := errors.Errorf("[Job] %s execute failed: there are some error in your spec hosts", p.Name)
+		p.finish(err)
+		return err
 	}
+	p.finish(nil)
 	logger.Infof("[Job] %s execute successfully!!! (%s)", p.Name, p.since())
 	logger.Sync()
 
 	return nil
 }
 
+// finish records the end of the run in the journal and emits the last event, only once
+func (p *Pipeline) finish(err error) {
+	p.finishOnce.Do(func() {
+		if err == nil || p.Runtime.GetContext().Err() == nil {
+			p.journal.Finish(err)
+			p.events.PipelineEnded(err)
+			return
+		}
+		p.journal.Interrupted()
+		p.journal.Finish(err)
+		p.events.PipelineInterrupted(err)
+		if p.journal != nil {
+			logger.Warnf("[Job] [%s] interrupted, run it again with --resume to continue", p.Name)
+		}
+	})
+}
+
+// stopped returns the reason not to run the next module:
+// the run has been interrupted, or a module running in the background has failed
+func (p *Pipeline) stopped() error {
+	if p.Runtime.GetContext().Err() != nil {
+		return errors.Wrapf(connector.ErrInterrupted, "Pipeline[%s] stopped", p.Name)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.goroutineErr
+}
+
 func (p *Pipeline) RunModule(m module.Module) *ending.ModuleResult {
 	m.Slogan()
 
 	result := ending.NewModuleResult()
+	p.events.ModuleStarted()
+	defer func() {
+		if result.IsFailed() {
+			p.events.ModuleEnded(result.CombineResult)
+		} else {
+			p.events.ModuleEnded(nil)
+		}
+	}()
 	for {
 		switch m.Is() {
 		case module.TaskModuleType:
@@ -127,10 +273,19 @@ func (p *Pipeline) RunModule(m module.Module) *ending.ModuleResult {
 			}
 
 		case module.GoroutineModuleType:
+			// the failure of a background module fails the pipeline before its next module
+			p.background.Add(1)
 			go func() {
-				m.Run(result)
-				if result.IsFailed() {
-					os.Exit(1)
+				defer p.background.Done()
+				res := ending.NewModuleResult()
+				m.Run(res)
+				if res.IsFailed() {
+					logger.Errorf("[Module] %s failed in the background: %v", m.GetName(), res.CombineResult)
+					p.mu.Lock()
+					if p.goroutineErr == nil {
+						p.goroutineErr = res.CombineResult
+					}
+					p.mu.Unlock()
 				}
 			}()
 		default:
@@ -152,6 +307,18 @@ func (p *Pipeline) RunModule(m module.Module) *ending.ModuleResult {
 	return result
 }
 
+// forceRun reports whether the module is, or comes after, the one requested by --from-module,
+// in which case it runs regardless of the checkpoints of the previous run
+func (p *Pipeline) forceRun(m module.Module) bool {
+	if p.FromModule == "" {
+		return false
+	}
+	if !p.fromModuleReached && matchModule(m, p.FromModule) {
+		p.fromModuleReached = true
+	}
+	return p.fromModuleReached
+}
+
 func (p *Pipeline) newModuleCache() *cache.Cache {
 	moduleCache, ok := p.ModuleCachePool.Get().(*cache.Cache)
 	if ok {
@@ -0,0 +1,181 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"bytetrade.io/web3os/installer/pkg/core/cache"
+	"bytetrade.io/web3os/installer/pkg/core/module"
+	"bytetrade.io/web3os/installer/pkg/core/task"
+)
+
+// Plan is the ordered list of modules and tasks a pipeline would run
+type Plan struct {
+	Pipeline string       `json:"pipeline"`
+	Modules  []ModulePlan `json:"modules"`
+}
+
+type ModulePlan struct {
+	Name  string      `json:"name"`
+	Type  string      `json:"type"`
+	Kind  string      `json:"kind,omitempty"`
+	Skip  bool        `json:"skip,omitempty"`
+	Tasks []task.Plan `json:"tasks,omitempty"`
+}
+
+// Plan initializes every module and task of the pipeline and evaluates the side effect free prepares,
+// without executing any action
+func (p *Pipeline) Plan() *Plan {
+	if p.PipelineCache == nil {
+		p.PipelineCache = cache.NewCache()
+	}
+
+	plan := &Plan{Pipeline: p.Name}
+	for i := range p.Modules {
+		m := p.Modules[i]
+		mp := ModulePlan{Name: m.GetName(), Type: moduleTypeName(m)}
+		if m.IsSkip() {
+			mp.Skip = true
+			plan.Modules = append(plan.Modules, mp)
+			continue
+		}
+
+		moduleCache := p.newModuleCache()
+		m.Default(p.Runtime, p.PipelineCache, moduleCache)
+		m.AutoAssert()
+		m.Init()
+		mp.Name = m.GetName()
+		mp.Kind = m.Is()
+		if tm, ok := m.(module.TaskModule); ok {
+			for _, t := range tm.GetTasks() {
+				t.Init(p.Runtime, moduleCache, p.PipelineCache)
+				if planner, ok := t.(task.Planner); ok {
+					mp.Tasks = append(mp.Tasks, *planner.Plan())
+				} else {
+					mp.Tasks = append(mp.Tasks, task.Plan{Name: t.GetName(), Desc: t.GetDesc()})
+				}
+			}
+		}
+		p.releaseModuleCache(moduleCache)
+		plan.Modules = append(plan.Modules, mp)
+	}
+	p.releasePipelineCache()
+
+	for _, host := range p.Runtime.GetAllHosts() {
+		p.Runtime.GetConnector().Close(host)
+	}
+	return plan
+}
+
+func (p *Pipeline) printPlan() error {
+	switch p.Output {
+	case "", OutputTree:
+		p.Plan().PrintTree(os.Stdout)
+		return nil
+	case OutputJSON:
+		return p.Plan().PrintJSON(os.Stdout)
+	default:
+		return errors.Errorf("unsupported dry-run output format %q", p.Output)
+	}
+}
+
+func (plan *Plan) PrintJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(plan)
+}
+
+func (plan *Plan) PrintTree(w io.Writer) {
+	fmt.Fprintf(w, "Pipeline %s\n", plan.Pipeline)
+	for i, m := range plan.Modules {
+		prefix, indent := branch(i == len(plan.Modules)-1)
+		switch {
+		case m.Skip:
+			fmt.Fprintf(w, "%s[%d] %s (skipped)\n", prefix, i+1, moduleLabel(m))
+			continue
+		case m.Kind != module.TaskModuleType:
+			fmt.Fprintf(w, "%s[%d] %s (%s, tasks are decided at runtime)\n", prefix, i+1, moduleLabel(m), m.Kind)
+			continue
+		}
+		fmt.Fprintf(w, "%s[%d] %s\n", prefix, i+1, moduleLabel(m))
+
+		for j, t := range m.Tasks {
+			tPrefix, tIndent := branch(j == len(m.Tasks)-1)
+			fmt.Fprintf(w, "%s%s%s\n", indent, tPrefix, taskLabel(t))
+
+			var lines []string
+			if t.Error != "" {
+				lines = append(lines, "error: "+t.Error)
+			}
+			if t.Action != "" {
+				lines = append(lines, "action: "+t.Action)
+			}
+			if t.Prepare != "" {
+				lines = append(lines, "prepare: "+t.Prepare)
+			}
+			for _, h := range t.Hosts {
+				line := fmt.Sprintf("host %s (%s): %s", h.Name, h.Address, h.Prepare)
+				if h.Error != "" {
+					line += ", " + h.Error
+				}
+				lines = append(lines, line)
+			}
+			for _, tp := range t.Templates {
+				if tp.Error != "" {
+					lines = append(lines, fmt.Sprintf("template %s -> %s: render failed, %s", tp.Name, tp.Dst, tp.Error))
+					continue
+				}
+				lines = append(lines, fmt.Sprintf("template %s -> %s (%d lines)", tp.Name, tp.Dst, strings.Count(tp.Content, "\n")+1))
+			}
+			for k, line := range lines {
+				lPrefix, _ := branch(k == len(lines)-1)
+				fmt.Fprintf(w, "%s%s%s%s\n", indent, tIndent, lPrefix, line)
+			}
+		}
+	}
+}
+
+func moduleLabel(m ModulePlan) string {
+	if m.Name == "" || m.Name == m.Type {
+		return m.Type
+	}
+	return fmt.Sprintf("%s [%s]", m.Name, m.Type)
+}
+
+func taskLabel(t task.Plan) string {
+	var attrs []string
+	if t.Kind != "" {
+		attrs = append(attrs, t.Kind)
+	}
+	if t.Parallel {
+		attrs = append(attrs, "parallel")
+	}
+	if t.Concurrency > 0 {
+		attrs = append(attrs, fmt.Sprintf("concurrency=%g", t.Concurrency))
+	}
+	if t.Retry > 0 {
+		attrs = append(attrs, fmt.Sprintf("retry=%d", t.Retry), "delay="+t.Delay)
+	}
+	if t.Timeout != "" {
+		attrs = append(attrs, "timeout="+t.Timeout)
+	}
+	if t.IgnoreError {
+		attrs = append(attrs, "ignore errors")
+	}
+	if len(attrs) == 0 {
+		return t.Name
+	}
+	return fmt.Sprintf("%s (%s)", t.Name, strings.Join(attrs, ", "))
+}
+
+func branch(last bool) (prefix, indent string) {
+	if last {
+		return "└── ", "    "
+	}
+	return "├── ", "│   "
+}
@@ -0,0 +1,135 @@
+package pipeline
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"bytetrade.io/web3os/installer/pkg/core/cache"
+	"bytetrade.io/web3os/installer/pkg/core/event"
+	"bytetrade.io/web3os/installer/pkg/core/logger"
+	"bytetrade.io/web3os/installer/pkg/core/module"
+)
+
+const (
+	RollbackReverted    = "reverted"
+	RollbackFailed      = "failed"
+	RollbackUnsupported = "not supported"
+)
+
+// RollbackReport tells what the compensation pass after a failure did to each module that had run,
+// most recent first
+type RollbackReport struct {
+	Pipeline string          `json:"pipeline"`
+	Entries  []RollbackEntry `json:"entries"`
+}
+
+type RollbackEntry struct {
+	Module string `json:"module"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Reverted reports whether any module has been rolled back,
+// in which case the checkpoints of the run no longer reflect the state of the machines
+func (r *RollbackReport) Reverted() bool {
+	for _, e := range r.Entries {
+		if e.Status == RollbackReverted {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *RollbackReport) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "rollback of %s:", r.Pipeline)
+	for _, e := range r.Entries {
+		fmt.Fprintf(&b, "\n  %-40s %s", e.Module, e.Status)
+		if e.Error != "" {
+			fmt.Fprintf(&b, ", %s", e.Error)
+		}
+	}
+	return b.String()
+}
+
+// completedModule is a module that has run, kept with its module cache
+// so that it can be rolled back if a later module fails
+type completedModule struct {
+	index  int
+	module module.Module
+	cache  *cache.Cache
+}
+
+// moduleDone keeps track of the module that has just run successfully
+// if rolling back on failure is asked for, releasing its cache otherwise
+func (p *Pipeline) moduleDone(index int, m module.Module, moduleCache *cache.Cache) {
+	if !p.RollbackOnFailure {
+		p.releaseModuleCache(moduleCache)
+		return
+	}
+	cm := completedModule{index: index, module: m}
+	if _, ok := m.(module.Rollbacker); ok {
+		cm.cache = moduleCache
+	} else {
+		p.releaseModuleCache(moduleCache)
+	}
+	p.completed = append(p.completed, cm)
+}
+
+// rollback runs the compensation pass after the module at index has failed:
+// the failed module and then every completed one are rolled back, most recent first
+func (p *Pipeline) rollback(index int, failed module.Module, moduleCache *cache.Cache) {
+	if !p.RollbackOnFailure {
+		return
+	}
+	if p.Runtime.GetContext().Err() != nil {
+		logger.Infof("[Job] [%s] interrupted, not rolling back", p.Name)
+		p.releaseCompleted()
+		return
+	}
+	logger.Infof("[Job] [%s] rolling back ...", p.Name)
+
+	modules := append(p.completed, completedModule{index: index, module: failed, cache: moduleCache})
+	p.completed = nil
+	report := &RollbackReport{Pipeline: p.Name}
+	for i := len(modules) - 1; i >= 0; i-- {
+		cm := modules[i]
+		entry := RollbackEntry{Module: cm.module.GetName(), Status: RollbackUnsupported}
+		if rb, ok := cm.module.(module.Rollbacker); ok {
+			logger.Infof("[Rollback] %s", entry.Module)
+			p.events.BeginModule(cm.index, entry.Module)
+			err := rb.Rollback()
+			switch {
+			case errors.Is(err, module.ErrRollbackUnsupported):
+				p.events.ModuleRolledBack(event.StatusSkipped, nil)
+			case err != nil:
+				entry.Status, entry.Error = RollbackFailed, err.Error()
+				p.events.ModuleRolledBack(event.StatusFailed, err)
+			default:
+				entry.Status = RollbackReverted
+				p.events.ModuleRolledBack(event.StatusSuccess, nil)
+			}
+		}
+		if cm.cache != nil {
+			p.releaseModuleCache(cm.cache)
+		}
+		report.Entries = append(report.Entries, entry)
+	}
+
+	if report.Reverted() {
+		p.journal.RolledBack()
+	}
+	p.RollbackReport = report
+	logger.Infof("[Job] [%s] %s", p.Name, report)
+}
+
+func (p *Pipeline) releaseCompleted() {
+	for _, cm := range p.completed {
+		if cm.cache != nil {
+			p.releaseModuleCache(cm.cache)
+		}
+	}
+	p.completed = nil
+}
@@ -64,3 +64,12 @@ func (p *PrepareCollection) AutoAssert(runtime connector.Runtime) {
 		v.AutoAssert(runtime)
 	}
 }
+
+func (p *PrepareCollection) HasSideEffects() bool {
+	for _, v := range *p {
+		if HasSideEffects(v) {
+			return true
+		}
+	}
+	return false
+}
@@ -15,3 +15,7 @@ func (p *InitialDelay) PreCheck(runtime connector.Runtime) (bool, error) {
 	time.Sleep(p.Duration)
 	return true, nil
 }
+
+func (p *InitialDelay) HasSideEffects() bool {
+	return true
+}
@@ -28,3 +28,8 @@ type FastPrepare struct {
 func (b *FastPrepare) PreCheck(runtime connector.Runtime) (bool, error) {
 	return b.Inject(runtime)
 }
+
+// HasSideEffects is always true as the injected function can not be inspected
+func (b *FastPrepare) HasSideEffects() bool {
+	return true
+}
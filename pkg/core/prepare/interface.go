@@ -26,3 +26,17 @@ type Prepare interface {
 	Init(cache *cache.Cache, rootCache *cache.Cache)
 	AutoAssert(runtime connector.Runtime)
 }
+
+// SideEffector is implemented by the prepares whose PreCheck does more than inspect the host,
+// such prepares are not evaluated when planning a pipeline in dry-run mode
+type SideEffector interface {
+	HasSideEffects() bool
+}
+
+// HasSideEffects reports whether evaluating the prepare could change anything
+func HasSideEffects(p Prepare) bool {
+	if s, ok := p.(SideEffector); ok {
+		return s.HasSideEffects()
+	}
+	return false
+}
@@ -5,6 +5,14 @@ import "regexp"
 const (
 	tableInstallConfig = "install_config"
 	tableInstallLogs   = "install_logs"
+
+	tablePipelineRuns        = "pipeline_runs"
+	tablePipelineCheckpoints = "pipeline_task_checkpoints"
+	tablePipelineEvents      = "pipeline_events"
+	tablePipelineRunCommands = "pipeline_run_commands"
+	tablePipelineRunValues   = "pipeline_run_values"
+	tableComponentVersions   = "component_versions"
+	tableNodeInventory       = "node_inventory"
 )
 
 const (
@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS pipeline_runs (
+    id VARCHAR(64) NOT NULL PRIMARY KEY,
+    name VARCHAR(120) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    error TEXT NOT NULL DEFAULT '',
+    started_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ended_at TIMESTAMP NULL
+);
+
+CREATE INDEX IF NOT EXISTS pipeline_runs_name_idx ON pipeline_runs (name, started_at);
+
+CREATE TABLE IF NOT EXISTS pipeline_checkpoints (
+    id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+    run_id VARCHAR(64) NOT NULL,
+    module_index INTEGER NOT NULL,
+    module VARCHAR(120) NOT NULL,
+    task_index INTEGER NOT NULL,
+    task VARCHAR(120) NOT NULL,
+    host VARCHAR(120) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (run_id, module_index, task_index, host)
+);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS pipeline_events;
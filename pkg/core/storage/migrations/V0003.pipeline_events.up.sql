@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS pipeline_events (
+    id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+    run_id VARCHAR(64) NOT NULL,
+    type VARCHAR(40) NOT NULL,
+    pipeline VARCHAR(120) NOT NULL,
+    module VARCHAR(120) NOT NULL DEFAULT '',
+    task VARCHAR(120) NOT NULL DEFAULT '',
+    host VARCHAR(120) NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL DEFAULT '',
+    attempt INTEGER NOT NULL DEFAULT 0,
+    percent INTEGER NOT NULL DEFAULT 0,
+    error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS pipeline_events_run_idx ON pipeline_events (run_id, id);
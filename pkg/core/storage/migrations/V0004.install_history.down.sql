@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS node_inventory;
+DROP TABLE IF EXISTS component_versions;
+DROP TABLE IF EXISTS pipeline_run_commands;
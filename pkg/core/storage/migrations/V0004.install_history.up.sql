@@ -0,0 +1,30 @@
+-- the command line of a run is kept apart from pipeline_runs:
+-- the migrations are applied again on every startup and SQLite can not add a column only if it is missing
+CREATE TABLE IF NOT EXISTS pipeline_run_commands (
+    run_id VARCHAR(64) NOT NULL PRIMARY KEY,
+    command VARCHAR(120) NOT NULL DEFAULT '',
+    args TEXT NOT NULL DEFAULT '',
+    version VARCHAR(64) NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS component_versions (
+    id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+    host VARCHAR(120) NOT NULL,
+    component VARCHAR(64) NOT NULL,
+    version VARCHAR(120) NOT NULL,
+    run_id VARCHAR(64) NOT NULL DEFAULT '',
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (host, component)
+);
+
+CREATE TABLE IF NOT EXISTS node_inventory (
+    name VARCHAR(120) NOT NULL PRIMARY KEY,
+    address VARCHAR(64) NOT NULL DEFAULT '',
+    internal_address VARCHAR(64) NOT NULL DEFAULT '',
+    roles VARCHAR(120) NOT NULL DEFAULT '',
+    arch VARCHAR(20) NOT NULL DEFAULT '',
+    os VARCHAR(20) NOT NULL DEFAULT '',
+    run_id VARCHAR(64) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
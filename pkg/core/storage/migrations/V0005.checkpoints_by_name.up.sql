@@ -0,0 +1,20 @@
+-- the checkpoints are matched by the names of the module and of the task rather than by their positions,
+-- for the modules and the tasks added or removed between the runs not to make a run skip the wrong tasks,
+-- pipeline_checkpoints, created again by V0002 on every startup, is moved here and dropped
+CREATE TABLE IF NOT EXISTS pipeline_task_checkpoints (
+    id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+    run_id VARCHAR(64) NOT NULL,
+    module_index INTEGER NOT NULL,
+    module VARCHAR(120) NOT NULL,
+    task_index INTEGER NOT NULL,
+    task VARCHAR(120) NOT NULL,
+    host VARCHAR(120) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (run_id, module, task, host)
+);
+
+INSERT OR IGNORE INTO pipeline_task_checkpoints (run_id, module_index, module, task_index, task, host, status, updated_at)
+    SELECT run_id, module_index, module, task_index, task, host, status, updated_at FROM pipeline_checkpoints ORDER BY id DESC;
+
+DROP TABLE IF EXISTS pipeline_checkpoints;
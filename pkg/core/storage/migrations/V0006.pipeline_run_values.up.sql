@@ -0,0 +1,8 @@
+-- the values a run saves for itself to find again when it is resumed, e.g., the snapshot taken before an upgrade
+CREATE TABLE IF NOT EXISTS pipeline_run_values (
+    run_id VARCHAR(64) NOT NULL,
+    name VARCHAR(64) NOT NULL,
+    value TEXT NOT NULL DEFAULT '',
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (run_id, name)
+);
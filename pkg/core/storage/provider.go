@@ -15,4 +15,22 @@ type Provider interface {
 	SaveInstallConfig(config model.InstallModelReq) (err error)
 	SaveInstallLog(msg string, state string, percent int64) (err error)
 	QueryInstallState(tspan int64) (data []model.InstallState, err error)
+
+	SavePipelineRun(run model.PipelineRun) (err error)
+	QueryLatestPipelineRun(name string) (run *model.PipelineRun, err error)
+	SaveTaskCheckpoint(checkpoint model.TaskCheckpoint) (err error)
+	QueryTaskCheckpoints(runID string) (data []model.TaskCheckpoint, err error)
+	QueryPipelineRuns(limit int) (data []model.PipelineRun, err error)
+	QueryPipelineRun(id string) (run *model.PipelineRun, err error)
+	SavePipelineRunValue(value model.PipelineRunValue) (err error)
+	QueryPipelineRunValues(runID string) (data []model.PipelineRunValue, err error)
+
+	SavePipelineEvent(event model.PipelineEvent) (err error)
+	QueryPipelineEvents(runID string) (data []model.PipelineEvent, err error)
+
+	SaveComponentVersion(component model.ComponentVersion) (err error)
+	QueryComponentVersions() (data []model.ComponentVersion, err error)
+	SaveNode(node model.Node) (err error)
+	QueryNodes() (data []model.Node, err error)
+	DeleteNode(name string) (err error)
 }
@@ -30,6 +30,27 @@ func NewSQLProvider(name, dataSourceName string) (provider SQLProvider) {
 		sqlInsertInstallConfig: fmt.Sprintf(queryFmtInsertInstallConfig, tableInstallConfig),
 		sqlInsertInstallLog:    fmt.Sprintf(queryFmtInsertInstallLog, tableInstallLogs),
 		sqlQueryInstallState:   fmt.Sprintf(queryFmtQueryInstallState, tableInstallLogs),
+
+		sqlUpsertPipelineRun:        fmt.Sprintf(queryFmtUpsertPipelineRun, tablePipelineRuns),
+		sqlSelectLatestPipelineRun:  fmt.Sprintf(queryFmtSelectLatestPipelineRun, tablePipelineRuns),
+		sqlUpsertTaskCheckpoint:     fmt.Sprintf(queryFmtUpsertTaskCheckpoint, tablePipelineCheckpoints),
+		sqlSelectTaskCheckpoints:    fmt.Sprintf(queryFmtSelectTaskCheckpoints, tablePipelineCheckpoints),
+		sqlInsertPipelineRunCommand: fmt.Sprintf(queryFmtInsertPipelineRunCommand, tablePipelineRunCommands),
+		sqlSelectPipelineRuns:       fmt.Sprintf(queryFmtSelectPipelineRuns, tablePipelineRuns, tablePipelineRunCommands),
+		sqlSelectPipelineRun:        fmt.Sprintf(queryFmtSelectPipelineRun, tablePipelineRuns, tablePipelineRunCommands),
+
+		sqlUpsertPipelineRunValue:  fmt.Sprintf(queryFmtUpsertPipelineRunValue, tablePipelineRunValues),
+		sqlSelectPipelineRunValues: fmt.Sprintf(queryFmtSelectPipelineRunValues, tablePipelineRunValues),
+
+		sqlInsertPipelineEvent:  fmt.Sprintf(queryFmtInsertPipelineEvent, tablePipelineEvents),
+		sqlSelectPipelineEvents: fmt.Sprintf(queryFmtSelectPipelineEvents, tablePipelineEvents),
+
+		sqlUpsertComponentVersion:  fmt.Sprintf(queryFmtUpsertComponentVersion, tableComponentVersions),
+		sqlSelectComponentVersions: fmt.Sprintf(queryFmtSelectComponentVersions, tableComponentVersions),
+		sqlDeleteComponentVersions: fmt.Sprintf(queryFmtDeleteComponentVersions, tableComponentVersions),
+		sqlUpsertNode:              fmt.Sprintf(queryFmtUpsertNode, tableNodeInventory),
+		sqlSelectNodes:             fmt.Sprintf(queryFmtSelectNodes, tableNodeInventory),
+		sqlDeleteNode:              fmt.Sprintf(queryFmtDeleteNode, tableNodeInventory),
 	}
 
 	return provider
@@ -50,6 +71,33 @@ type SQLProvider struct {
 	sqlInsertInstallLog    string
 	sqlQueryInstallState   string
 
+	// Table: pipeline_runs, pipeline_task_checkpoints
+	sqlUpsertPipelineRun       string
+	sqlSelectLatestPipelineRun string
+	sqlUpsertTaskCheckpoint    string
+	sqlSelectTaskCheckpoints   string
+
+	// Table: pipeline_run_commands
+	sqlInsertPipelineRunCommand string
+	sqlSelectPipelineRuns       string
+	sqlSelectPipelineRun        string
+
+	// Table: pipeline_run_values
+	sqlUpsertPipelineRunValue  string
+	sqlSelectPipelineRunValues string
+
+	// Table: pipeline_events
+	sqlInsertPipelineEvent  string
+	sqlSelectPipelineEvents string
+
+	// Table: component_versions, node_inventory
+	sqlUpsertComponentVersion  string
+	sqlSelectComponentVersions string
+	sqlDeleteComponentVersions string
+	sqlUpsertNode              string
+	sqlSelectNodes             string
+	sqlDeleteNode              string
+
 	// Utility.
 	sqlSelectExistingTables string
 
@@ -163,3 +211,208 @@ func (p *SQLProvider) QueryInstallState(tspan int64) (data []model.InstallState,
 
 	return data, nil
 }
+
+func (p *SQLProvider) SavePipelineRun(run model.PipelineRun) (err error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	if _, err = p.db.ExecContext(ctx, p.sqlUpsertPipelineRun,
+		run.ID, run.Name, run.Status, run.Error, run.StartedAt, run.EndedAt); err != nil {
+		return fmt.Errorf("error saving pipeline run %s (%s): %w", run.ID, run.Name, err)
+	}
+	// a resumed run keeps the command line it was started with
+	if _, err = p.db.ExecContext(ctx, p.sqlInsertPipelineRunCommand,
+		run.ID, run.Command, run.Args, run.Version); err != nil {
+		return fmt.Errorf("error saving the command of pipeline run %s (%s): %w", run.ID, run.Name, err)
+	}
+	return nil
+}
+
+func (p *SQLProvider) QueryPipelineRuns(limit int) (data []model.PipelineRun, err error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	data = make([]model.PipelineRun, 0, limit)
+	if err = p.db.SelectContext(ctx, &data, p.sqlSelectPipelineRuns, limit); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return data, fmt.Errorf("error querying pipeline runs: %w", err)
+	}
+	return data, nil
+}
+
+// QueryPipelineRun returns the run whose ID is, or starts with, id,
+// an error is returned if the prefix is ambiguous
+func (p *SQLProvider) QueryPipelineRun(id string) (run *model.PipelineRun, err error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var data []model.PipelineRun
+	if err = p.db.SelectContext(ctx, &data, p.sqlSelectPipelineRun, id); err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("error querying pipeline run %s: %w", id, err)
+	}
+	switch {
+	case len(data) == 0:
+		return nil, nil
+	case len(data) > 1 && data[0].ID != id:
+		return nil, fmt.Errorf("run ID %s is ambiguous, %d runs match", id, len(data))
+	}
+	return &data[0], nil
+}
+
+func (p *SQLProvider) QueryLatestPipelineRun(name string) (run *model.PipelineRun, err error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	run = &model.PipelineRun{}
+	if err = p.db.GetContext(ctx, run, p.sqlSelectLatestPipelineRun, name); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error querying latest run of pipeline %s: %w", name, err)
+	}
+	return run, nil
+}
+
+func (p *SQLProvider) SaveTaskCheckpoint(checkpoint model.TaskCheckpoint) (err error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	if _, err = p.db.ExecContext(ctx, p.sqlUpsertTaskCheckpoint,
+		checkpoint.RunID, checkpoint.ModuleIndex, checkpoint.Module, checkpoint.TaskIndex, checkpoint.Task,
+		checkpoint.Host, checkpoint.Status, checkpoint.UpdatedAt); err != nil {
+		return fmt.Errorf("error saving checkpoint %s/%s on %s: %w", checkpoint.Module, checkpoint.Task, checkpoint.Host, err)
+	}
+	return nil
+}
+
+func (p *SQLProvider) QueryTaskCheckpoints(runID string) (data []model.TaskCheckpoint, err error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	data = make([]model.TaskCheckpoint, 0, 32)
+	if err = p.db.SelectContext(ctx, &data, p.sqlSelectTaskCheckpoints, runID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return data, err
+	}
+	return data, nil
+}
+
+func (p *SQLProvider) SavePipelineRunValue(value model.PipelineRunValue) (err error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	if _, err = p.db.ExecContext(ctx, p.sqlUpsertPipelineRunValue,
+		value.RunID, value.Name, value.Value, value.UpdatedAt); err != nil {
+		return fmt.Errorf("error saving value %s of pipeline run %s: %w", value.Name, value.RunID, err)
+	}
+	return nil
+}
+
+func (p *SQLProvider) QueryPipelineRunValues(runID string) (data []model.PipelineRunValue, err error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	data = make([]model.PipelineRunValue, 0, 4)
+	if err = p.db.SelectContext(ctx, &data, p.sqlSelectPipelineRunValues, runID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return data, err
+	}
+	return data, nil
+}
+
+func (p *SQLProvider) SavePipelineEvent(event model.PipelineEvent) (err error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	if _, err = p.db.ExecContext(ctx, p.sqlInsertPipelineEvent,
+		event.RunID, event.Type, event.Pipeline, event.Module, event.Task, event.Host,
+		event.Status, event.Attempt, event.Percent, event.Error, event.Time); err != nil {
+		return fmt.Errorf("error saving %s event of run %s: %w", event.Type, event.RunID, err)
+	}
+	return nil
+}
+
+func (p *SQLProvider) QueryPipelineEvents(runID string) (data []model.PipelineEvent, err error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	data = make([]model.PipelineEvent, 0, 128)
+	if err = p.db.SelectContext(ctx, &data, p.sqlSelectPipelineEvents, runID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return data, err
+	}
+	return data, nil
+}
+
+func (p *SQLProvider) SaveComponentVersion(component model.ComponentVersion) (err error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	if _, err = p.db.ExecContext(ctx, p.sqlUpsertComponentVersion,
+		component.Host, component.Component, component.Version, component.RunID, component.UpdatedAt); err != nil {
+		return fmt.Errorf("error saving version of %s on %s: %w", component.Component, component.Host, err)
+	}
+	return nil
+}
+
+func (p *SQLProvider) QueryComponentVersions() (data []model.ComponentVersion, err error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	data = make([]model.ComponentVersion, 0, 16)
+	if err = p.db.SelectContext(ctx, &data, p.sqlSelectComponentVersions); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return data, err
+	}
+	return data, nil
+}
+
+func (p *SQLProvider) SaveNode(node model.Node) (err error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	if _, err = p.db.ExecContext(ctx, p.sqlUpsertNode,
+		node.Name, node.Address, node.InternalAddress, node.Roles, node.Arch, node.Os, node.RunID,
+		node.CreatedAt, node.UpdatedAt); err != nil {
+		return fmt.Errorf("error saving node %s: %w", node.Name, err)
+	}
+	return nil
+}
+
+func (p *SQLProvider) QueryNodes() (data []model.Node, err error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	data = make([]model.Node, 0, 8)
+	if err = p.db.SelectContext(ctx, &data, p.sqlSelectNodes); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return data, err
+	}
+	return data, nil
+}
+
+// DeleteNode removes the node from the inventory along with the versions of the components on it
+func (p *SQLProvider) DeleteNode(name string) (err error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	if _, err = p.db.ExecContext(ctx, p.sqlDeleteComponentVersions, name); err != nil {
+		return fmt.Errorf("error deleting component versions of node %s: %w", name, err)
+	}
+	if _, err = p.db.ExecContext(ctx, p.sqlDeleteNode, name); err != nil {
+		return fmt.Errorf("error deleting node %s: %w", name, err)
+	}
+	return nil
+}
@@ -3,6 +3,7 @@ package storage
 import (
 	"database/sql"
 	"encoding/base64"
+	"path"
 
 	"github.com/mattn/go-sqlite3"
 )
@@ -25,6 +26,11 @@ func NewSQLiteProvider(dbPath string) (provider *SQLiteProvider) {
 	return provider
 }
 
+// SQLiteDataSource is the path of the database file of the SQLite provider opened on dbPath
+func SQLiteDataSource(dbPath string) string {
+	return path.Join(dbPath, providerDataSourceName)
+}
+
 func sqlite3BLOBToTEXTBase64(data []byte) (b64 string) {
 	return base64.StdEncoding.EncodeToString(data)
 }
@@ -12,6 +12,77 @@ const (
 		SELECT message, state, percent, created_at FROM %s WHERE created_at >= ? ORDER BY id, created_at;`
 )
 
+// Table: pipeline_runs, pipeline_task_checkpoints
+const (
+	queryFmtUpsertPipelineRun = `
+		INSERT INTO %s (id, name, status, error, started_at, ended_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET status = excluded.status, error = excluded.error, ended_at = excluded.ended_at;`
+	queryFmtSelectLatestPipelineRun = `
+		SELECT id, name, status, error, started_at, ended_at FROM %s WHERE name = ? ORDER BY started_at DESC LIMIT 1;`
+	queryFmtInsertPipelineRunCommand = `
+		INSERT INTO %s (run_id, command, args, version)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT (run_id) DO NOTHING;`
+	queryFmtSelectPipelineRuns = `
+		SELECT r.id, r.name, r.status, r.error, r.started_at, r.ended_at,
+			COALESCE(c.command, '') AS command, COALESCE(c.args, '') AS args, COALESCE(c.version, '') AS version
+		FROM %s r LEFT JOIN %s c ON c.run_id = r.id
+		ORDER BY r.started_at DESC LIMIT ?;`
+	queryFmtSelectPipelineRun = `
+		SELECT r.id, r.name, r.status, r.error, r.started_at, r.ended_at,
+			COALESCE(c.command, '') AS command, COALESCE(c.args, '') AS args, COALESCE(c.version, '') AS version
+		FROM %s r LEFT JOIN %s c ON c.run_id = r.id
+		WHERE r.id LIKE ? || '%%'
+		ORDER BY r.started_at DESC;`
+	queryFmtUpsertTaskCheckpoint = `
+		INSERT INTO %s (run_id, module_index, module, task_index, task, host, status, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (run_id, module, task, host) DO UPDATE SET module_index = excluded.module_index, task_index = excluded.task_index, status = excluded.status, updated_at = excluded.updated_at;`
+	queryFmtSelectTaskCheckpoints = `
+		SELECT run_id, module_index, module, task_index, task, host, status, updated_at FROM %s WHERE run_id = ? ORDER BY module_index, task_index, host;`
+)
+
+// Table: pipeline_run_values
+const (
+	queryFmtUpsertPipelineRunValue = `
+		INSERT INTO %s (run_id, name, value, updated_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT (run_id, name) DO UPDATE SET value = excluded.value, updated_at = excluded.updated_at;`
+	queryFmtSelectPipelineRunValues = `
+		SELECT run_id, name, value, updated_at FROM %s WHERE run_id = ? ORDER BY name;`
+)
+
+// Table: pipeline_events
+const (
+	queryFmtInsertPipelineEvent = `
+		INSERT INTO %s (run_id, type, pipeline, module, task, host, status, attempt, percent, error, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);`
+	queryFmtSelectPipelineEvents = `
+		SELECT run_id, type, pipeline, module, task, host, status, attempt, percent, error, created_at FROM %s WHERE run_id = ? ORDER BY id;`
+)
+
+// Table: component_versions, node_inventory
+const (
+	queryFmtUpsertComponentVersion = `
+		INSERT INTO %s (host, component, version, run_id, updated_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (host, component) DO UPDATE SET version = excluded.version, run_id = excluded.run_id, updated_at = excluded.updated_at;`
+	queryFmtSelectComponentVersions = `
+		SELECT host, component, version, run_id, updated_at FROM %s ORDER BY host, component;`
+	queryFmtDeleteComponentVersions = `
+		DELETE FROM %s WHERE host = ?;`
+	queryFmtUpsertNode = `
+		INSERT INTO %s (name, address, internal_address, roles, arch, os, run_id, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (name) DO UPDATE SET address = excluded.address, internal_address = excluded.internal_address,
+			roles = excluded.roles, arch = excluded.arch, os = excluded.os, run_id = excluded.run_id, updated_at = excluded.updated_at;`
+	queryFmtSelectNodes = `
+		SELECT name, address, internal_address, roles, arch, os, run_id, created_at, updated_at FROM %s ORDER BY name;`
+	queryFmtDeleteNode = `
+		DELETE FROM %s WHERE name = ?;`
+)
+
 const (
 	querySQLiteSelectExistingTables = `
 		SELECT name
@@ -0,0 +1,132 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"bytetrade.io/web3os/installer/pkg/core/logger"
+	"bytetrade.io/web3os/installer/pkg/model"
+)
+
+func openTestProvider(t *testing.T, dir string) *SQLiteProvider {
+	provider := NewSQLiteProvider(dir)
+	if err := provider.StartupCheck(); err != nil {
+		t.Skipf("state store unavailable: %v", err)
+	}
+	return provider
+}
+
+func TestInstallHistory(t *testing.T) {
+	dir := t.TempDir()
+	logger.InitLog(dir, filepath.Join(dir, "test.log"), true)
+	provider := openTestProvider(t, dir)
+
+	// a run recorded before V0004 has no command line
+	started := time.Now().Add(-time.Hour)
+	if _, err := provider.db.Exec("INSERT INTO pipeline_runs (id, name, status, error, started_at) VALUES (?, ?, ?, ?, ?)",
+		"0ld-run", "Install", model.PipelineRunSucceeded, "", started); err != nil {
+		t.Fatal(err)
+	}
+	run := model.PipelineRun{
+		ID: "a1b2c3", Name: "Upgrade", Status: model.PipelineRunRunning, StartedAt: time.Now(),
+		Command: "upgrade", Args: "--version 1.12.0", Version: "1.12.0",
+	}
+	if err := provider.SavePipelineRun(run); err != nil {
+		t.Fatal(err)
+	}
+	// a resumed run keeps the command line it was started with
+	run.Status, run.Args = model.PipelineRunSucceeded, "--resume"
+	if err := provider.SavePipelineRun(run); err != nil {
+		t.Fatal(err)
+	}
+
+	// the migrations are applied again on every startup
+	provider.Close()
+	provider = openTestProvider(t, dir)
+	defer provider.Close()
+
+	runs, err := provider.QueryPipelineRuns(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 2 {
+		t.Fatalf("expected 2 runs, got %d", len(runs))
+	}
+	if runs[0].ID != "a1b2c3" || runs[0].Status != model.PipelineRunSucceeded || runs[0].Command != "upgrade" || runs[0].Args != "--version 1.12.0" {
+		t.Errorf("unexpected latest run %+v", runs[0])
+	}
+	if runs[1].ID != "0ld-run" || runs[1].Command != "" {
+		t.Errorf("unexpected run recorded before the command lines %+v", runs[1])
+	}
+
+	if r, err := provider.QueryPipelineRun("a1b"); err != nil || r == nil || r.ID != "a1b2c3" {
+		t.Errorf("expected run a1b2c3 by its prefix, got %+v, %v", r, err)
+	}
+	if r, err := provider.QueryPipelineRun("ffff"); err != nil || r != nil {
+		t.Errorf("expected no run, got %+v, %v", r, err)
+	}
+	if err := provider.SavePipelineRun(model.PipelineRun{ID: "a1c", Name: "Install", StartedAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := provider.QueryPipelineRun("a1"); err == nil {
+		t.Error("expected an ambiguous prefix to fail")
+	}
+}
+
+func TestInventory(t *testing.T) {
+	dir := t.TempDir()
+	logger.InitLog(dir, filepath.Join(dir, "test.log"), true)
+	provider := openTestProvider(t, dir)
+	defer provider.Close()
+
+	now := time.Now()
+	for _, c := range []model.ComponentVersion{
+		{Host: "node1", Component: "k3s", Version: "v1.32.5+k3s1", RunID: "run-1", UpdatedAt: now},
+		{Host: "node1", Component: "containerd", Version: "v1.7.27", RunID: "run-1", UpdatedAt: now},
+		{Host: "node2", Component: "k3s", Version: "v1.32.5+k3s1", RunID: "run-1", UpdatedAt: now},
+		// the version found by a later run replaces the former one
+		{Host: "node1", Component: "k3s", Version: "v1.33.3+k3s1", RunID: "run-2", UpdatedAt: now},
+	} {
+		if err := provider.SaveComponentVersion(c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, n := range []model.Node{
+		{Name: "node1", Address: "192.168.1.10", Roles: "master,worker", RunID: "run-1", CreatedAt: now, UpdatedAt: now},
+		{Name: "node2", Address: "192.168.1.11", Roles: "worker", RunID: "run-1", CreatedAt: now, UpdatedAt: now},
+		{Name: "node2", Address: "192.168.1.12", Roles: "worker", RunID: "run-2", CreatedAt: now, UpdatedAt: now},
+	} {
+		if err := provider.SaveNode(n); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	versions, err := provider.QueryComponentVersions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 3 {
+		t.Fatalf("expected 3 component versions, got %d", len(versions))
+	}
+	if v := versions[1]; v.Host != "node1" || v.Component != "k3s" || v.Version != "v1.33.3+k3s1" || v.RunID != "run-2" {
+		t.Errorf("unexpected version of k3s on node1 %+v", v)
+	}
+	nodes, err := provider.QueryNodes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nodes) != 2 || nodes[1].Address != "192.168.1.12" || nodes[1].RunID != "run-2" {
+		t.Errorf("unexpected nodes %+v", nodes)
+	}
+
+	if err := provider.DeleteNode("node1"); err != nil {
+		t.Fatal(err)
+	}
+	if nodes, _ := provider.QueryNodes(); len(nodes) != 1 || nodes[0].Name != "node2" {
+		t.Errorf("expected node2 alone, got %+v", nodes)
+	}
+	if versions, _ := provider.QueryComponentVersions(); len(versions) != 1 || versions[0].Host != "node2" {
+		t.Errorf("expected the versions of node2 alone, got %+v", versions)
+	}
+}
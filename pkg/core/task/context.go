@@ -0,0 +1,34 @@
+package task
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"bytetrade.io/web3os/installer/pkg/core/connector"
+	"bytetrade.io/web3os/installer/pkg/core/util"
+)
+
+// waitRetry waits for the delay before the next attempt of a task,
+// it reports false as soon as the run is interrupted, in which case the task is not to be retried
+func waitRetry(ctx context.Context, delay time.Duration) bool {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// timeoutErr tells why the context of the task on a host is done,
+// either the timeout has expired or the run has been aborted
+func timeoutErr(ctx context.Context, what string, timeout time.Duration) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("%s timeout, Timeout=%s", what, util.ShortDur(timeout))
+	}
+	return fmt.Errorf("%s aborted: %w", what, connector.ErrInterrupted)
+}
@@ -27,10 +27,10 @@ import (
 	"bytetrade.io/web3os/installer/pkg/core/cache"
 	"bytetrade.io/web3os/installer/pkg/core/connector"
 	"bytetrade.io/web3os/installer/pkg/core/ending"
+	"bytetrade.io/web3os/installer/pkg/core/event"
 	"bytetrade.io/web3os/installer/pkg/core/logger"
 	"bytetrade.io/web3os/installer/pkg/core/prepare"
 	"bytetrade.io/web3os/installer/pkg/core/rollback"
-	"bytetrade.io/web3os/installer/pkg/core/util"
 )
 
 type LocalTask struct {
@@ -43,6 +43,8 @@ type LocalTask struct {
 	Retry    int
 	Delay    time.Duration
 	Timeout  time.Duration
+	// AlwaysRun runs the task again when a run is resumed even if it succeeded, see RunsOnResume
+	AlwaysRun bool
 
 	PipelineCache *cache.Cache
 	ModuleCache   *cache.Cache
@@ -96,7 +98,10 @@ func (l *LocalTask) Default() {
 }
 
 func (l *LocalTask) Execute() *ending.TaskResult {
+	ev := event.FromCache(l.PipelineCache)
+	ev.TaskStarted(l.Name)
 	if l.TaskResult.IsFailed() {
+		ev.TaskFinished(l.Name, l.TaskResult.CombineErr())
 		return l.TaskResult
 	}
 
@@ -106,15 +111,17 @@ func (l *LocalTask) Execute() *ending.TaskResult {
 
 	if l.TaskResult.IsFailed() {
 		l.TaskResult.ErrResult()
+		ev.TaskFinished(l.Name, l.TaskResult.CombineErr())
 		return l.TaskResult
 	}
 
 	l.TaskResult.NormalResult()
+	ev.TaskFinished(l.Name, nil)
 	return l.TaskResult
 }
 
 func (l *LocalTask) RunWithTimeout(runtime connector.Runtime, host connector.Host) {
-	ctx, cancel := context.WithTimeout(context.Background(), l.Timeout)
+	ctx, cancel := context.WithTimeout(runtime.GetExecContext(), l.Timeout)
 	defer cancel()
 
 	resCh := make(chan error)
@@ -122,10 +129,13 @@ func (l *LocalTask) RunWithTimeout(runtime connector.Runtime, host connector.Hos
 	go l.Run(runtime, host, resCh)
 	select {
 	case <-ctx.Done():
-		l.TaskResult.AppendErr(host, fmt.Errorf("execute task timeout, Timeout=%s", util.ShortDur(l.Timeout)))
+		err := timeoutErr(ctx, "execute task", l.Timeout)
+		l.TaskResult.AppendErr(host, err)
+		event.FromCache(l.PipelineCache).HostResult(l.Name, host.GetName(), event.StatusFailed, err)
 	case e := <-resCh:
 		if e != nil {
 			l.TaskResult.AppendErr(host, e)
+			event.FromCache(l.PipelineCache).HostResult(l.Name, host.GetName(), event.StatusFailed, e)
 		}
 	}
 }
@@ -141,6 +151,7 @@ func (l *LocalTask) Run(runtime connector.Runtime, host connector.Host, resCh ch
 		Conn: nil,
 		//Debug: runtime.Arg.Debug,
 		Host: host,
+		Ctx:  runtime.GetExecContext(),
 	})
 
 	l.Prepare.Init(l.ModuleCache, l.PipelineCache)
@@ -151,6 +162,7 @@ func (l *LocalTask) Run(runtime connector.Runtime, host connector.Host, resCh ch
 			return
 		} else {
 			l.TaskResult.AppendSkip(host)
+			event.FromCache(l.PipelineCache).HostResult(l.Name, host.GetName(), event.StatusSkipped, nil)
 			return
 		}
 	}
@@ -162,6 +174,7 @@ func (l *LocalTask) Run(runtime connector.Runtime, host connector.Host, resCh ch
 		return
 	}
 	l.TaskResult.AppendSuccess(host)
+	event.FromCache(l.PipelineCache).HostResult(l.Name, host.GetName(), event.StatusSuccess, nil)
 }
 
 func (l *LocalTask) WhenWithRetry(runtime connector.Runtime, host connector.Host) (bool, error) {
@@ -175,8 +188,11 @@ func (l *LocalTask) WhenWithRetry(runtime connector.Runtime, host connector.Host
 				err = errors.New(err.Error() + e.Error())
 				continue
 			}
+			if !waitRetry(runtime.GetContext(), l.Delay) {
+				err = errors.Wrap(e, "pre-check not retried as the run has been interrupted")
+				break
+			}
 			logger.Infof("retry: [%s]", host.GetName())
-			time.Sleep(l.Delay)
 			continue
 		} else {
 			err = nil
@@ -213,8 +229,12 @@ func (l *LocalTask) ExecuteWithRetry(runtime connector.Runtime, host connector.H
 				err = errors.New(err.Error() + e.Error())
 				continue
 			}
+			if !waitRetry(runtime.GetContext(), l.Delay) {
+				err = errors.Wrapf(e, "%s not retried as the run has been interrupted", l.Name)
+				break
+			}
 			logger.Infof("Local retry: [%s] %s", host.GetName(), l.Name)
-			time.Sleep(l.Delay)
+			event.FromCache(l.PipelineCache).TaskRetried(l.Name, host.GetName(), i+1, e)
 			continue
 		} else {
 			err = nil
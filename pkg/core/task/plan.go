@@ -0,0 +1,189 @@
+package task
+
+import (
+	"reflect"
+	"strings"
+
+	"bytetrade.io/web3os/installer/pkg/core/action"
+	"bytetrade.io/web3os/installer/pkg/core/cache"
+	"bytetrade.io/web3os/installer/pkg/core/connector"
+	"bytetrade.io/web3os/installer/pkg/core/logger"
+	"bytetrade.io/web3os/installer/pkg/core/prepare"
+	"bytetrade.io/web3os/installer/pkg/core/util"
+)
+
+const (
+	KindRemote = "remote"
+	KindLocal  = "local"
+
+	PrepareRun          = "run"
+	PrepareSkip         = "skip"
+	PrepareUnevaluated  = "unevaluated"
+	PrepareSideEffected = "not evaluated, has side effects"
+)
+
+// Planner is implemented by the tasks that can describe what they would do
+// without executing their action
+type Planner interface {
+	Plan() *Plan
+}
+
+// Plan describes a task as it would be executed
+type Plan struct {
+	Name        string         `json:"name"`
+	Desc        string         `json:"desc,omitempty"`
+	Kind        string         `json:"kind"`
+	Action      string         `json:"action,omitempty"`
+	Prepare     string         `json:"prepare,omitempty"`
+	Parallel    bool           `json:"parallel"`
+	Retry       int            `json:"retry"`
+	Delay       string         `json:"delay"`
+	Timeout     string         `json:"timeout"`
+	Concurrency float64        `json:"concurrency,omitempty"`
+	IgnoreError bool           `json:"ignoreError,omitempty"`
+	Rollback    string         `json:"rollback,omitempty"`
+	Hosts       []HostPlan     `json:"hosts"`
+	Templates   []TemplatePlan `json:"templates,omitempty"`
+	Error       string         `json:"error,omitempty"`
+}
+
+// HostPlan is the outcome of the prepare of a task on one host
+type HostPlan struct {
+	Name    string `json:"name"`
+	Address string `json:"address"`
+	Prepare string `json:"prepare"`
+	Error   string `json:"error,omitempty"`
+}
+
+// TemplatePlan is a rendered action.Template and its destination, with its secrets masked
+type TemplatePlan struct {
+	Name    string `json:"name"`
+	Dst     string `json:"dst"`
+	Content string `json:"content,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+func (t *RemoteTask) Plan() *Plan {
+	p := &Plan{
+		Name:        t.Name,
+		Desc:        t.Desc,
+		Kind:        KindRemote,
+		Action:      typeName(t.Action),
+		Prepare:     prepareName(t.Prepare),
+		Parallel:    t.Parallel,
+		Retry:       t.Retry,
+		Delay:       util.ShortDur(t.Delay),
+		Timeout:     util.ShortDur(t.Timeout),
+		Concurrency: t.Concurrency,
+		IgnoreError: t.IgnoreError,
+		Rollback:    typeName(t.Rollback),
+		Templates:   planTemplates(t.Action),
+	}
+	if t.TaskResult.IsFailed() {
+		p.Error = t.TaskResult.CombineErr().Error()
+		return p
+	}
+
+	for i, host := range t.Hosts {
+		if host == nil || t.Runtime.HostIsDeprecated(host) {
+			continue
+		}
+		selfRuntime := t.Runtime.Copy()
+		hp := HostPlan{Name: host.GetName(), Address: host.GetAddress()}
+		if err := t.ConfigureSelfRuntime(selfRuntime, host, i); err != nil {
+			hp.Prepare, hp.Error = PrepareUnevaluated, err.Error()
+		} else {
+			hp.Prepare, hp.Error = planPrepare(t.Prepare, selfRuntime, t.ModuleCache, t.PipelineCache, t.When)
+		}
+		p.Hosts = append(p.Hosts, hp)
+	}
+	return p
+}
+
+func (l *LocalTask) Plan() *Plan {
+	p := &Plan{
+		Name:        l.Name,
+		Desc:        l.Desc,
+		Kind:        KindLocal,
+		Action:      typeName(l.Action),
+		Prepare:     prepareName(l.Prepare),
+		Retry:       l.Retry,
+		Delay:       util.ShortDur(l.Delay),
+		Timeout:     util.ShortDur(l.Timeout),
+		IgnoreError: l.IgnoreError,
+		Rollback:    typeName(l.Rollback),
+		Templates:   planTemplates(l.Action),
+	}
+	if l.TaskResult.IsFailed() {
+		p.Error = l.TaskResult.CombineErr().Error()
+		return p
+	}
+
+	host := l.Runtime.GetLocalHost()
+	selfRuntime := l.Runtime.Copy()
+	selfRuntime.SetRunner(&connector.Runner{Host: host})
+	hp := HostPlan{Name: host.GetName(), Address: host.GetAddress()}
+	hp.Prepare, hp.Error = planPrepare(l.Prepare, selfRuntime, l.ModuleCache, l.PipelineCache, l.When)
+	p.Hosts = append(p.Hosts, hp)
+	return p
+}
+
+// planPrepare evaluates the prepare of the task against the runtime configured for one host,
+// unless doing so could change anything
+func planPrepare(p prepare.Prepare, runtime connector.Runtime, moduleCache, pipelineCache *cache.Cache,
+	when func(runtime connector.Runtime) (bool, error)) (string, string) {
+	if prepare.HasSideEffects(p) {
+		return PrepareSideEffected, ""
+	}
+	p.Init(moduleCache, pipelineCache)
+	p.AutoAssert(runtime)
+	ok, err := when(runtime)
+	if err != nil {
+		return PrepareUnevaluated, err.Error()
+	}
+	if !ok {
+		return PrepareSkip, ""
+	}
+	return PrepareRun, ""
+}
+
+func planTemplates(a action.Action) []TemplatePlan {
+	tmpl, ok := a.(*action.Template)
+	if !ok || tmpl.Template == nil {
+		return nil
+	}
+	tp := TemplatePlan{Name: tmpl.Template.Name(), Dst: tmpl.Dst}
+	content, err := util.Render(tmpl.Template, tmpl.Data)
+	if err != nil {
+		tp.Error = err.Error()
+	} else {
+		// the rendered configuration files hold passwords, e.g., those of Redis and MinIO
+		tp.Content = logger.Scrub(content)
+	}
+	return []TemplatePlan{tp}
+}
+
+func prepareName(p prepare.Prepare) string {
+	if c, ok := p.(*prepare.PrepareCollection); ok {
+		var names []string
+		for _, v := range *c {
+			names = append(names, typeName(v))
+		}
+		return strings.Join(names, ",")
+	}
+	if _, ok := p.(*prepare.BasePrepare); ok {
+		return ""
+	}
+	return typeName(p)
+}
+
+func typeName(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Name()
+}
@@ -28,6 +28,7 @@ import (
 	"bytetrade.io/web3os/installer/pkg/core/cache"
 	"bytetrade.io/web3os/installer/pkg/core/connector"
 	"bytetrade.io/web3os/installer/pkg/core/ending"
+	"bytetrade.io/web3os/installer/pkg/core/event"
 	"bytetrade.io/web3os/installer/pkg/core/logger"
 	"bytetrade.io/web3os/installer/pkg/core/prepare"
 	"bytetrade.io/web3os/installer/pkg/core/rollback"
@@ -46,6 +47,8 @@ type RemoteTask struct {
 	Delay       time.Duration
 	Timeout     time.Duration
 	Concurrency float64
+	// AlwaysRun runs the task again when a run is resumed even if it succeeded, see RunsOnResume
+	AlwaysRun bool
 
 	PipelineCache *cache.Cache
 	ModuleCache   *cache.Cache
@@ -71,13 +74,16 @@ func (t *RemoteTask) Init(runtime connector.Runtime, moduleCache *cache.Cache, p
 }
 
 func (t *RemoteTask) Execute() *ending.TaskResult {
+	ev := event.FromCache(t.PipelineCache)
+	ev.TaskStarted(t.Name)
 	if t.TaskResult.IsFailed() {
+		ev.TaskFinished(t.Name, t.TaskResult.CombineErr())
 		return t.TaskResult
 	}
-	routinePool := make(chan struct{}, DefaultCon)
+	routinePool := make(chan struct{}, t.calculateConcurrency())
 	defer close(routinePool)
 
-	ctx, cancel := context.WithTimeout(context.Background(), t.Timeout)
+	ctx, cancel := context.WithTimeout(t.Runtime.GetExecContext(), t.Timeout)
 	defer cancel()
 	wg := &sync.WaitGroup{}
 	for i := range t.Hosts {
@@ -97,31 +103,47 @@ func (t *RemoteTask) Execute() *ending.TaskResult {
 
 	if t.TaskResult.IsFailed() {
 		t.TaskResult.ErrResult()
+		ev.TaskFinished(t.Name, t.TaskResult.CombineErr())
 		return t.TaskResult
 	}
 
 	t.TaskResult.NormalResult()
+	ev.TaskFinished(t.Name, nil)
 	return t.TaskResult
 }
 
 func (t *RemoteTask) RunWithTimeout(ctx context.Context, runtime connector.Runtime, host connector.Host, index int,
 	wg *sync.WaitGroup, pool chan struct{}) {
 	pool <- struct{}{}
+	workers := WorkerPoolFromCache(t.PipelineCache)
+	workers.Acquire()
+	defer func() {
+		workers.Release()
+		<-pool
+		wg.Done()
+	}()
+
+	// the hosts still waiting for their turn are not started once the run is interrupted
+	if runtime.GetContext().Err() != nil {
+		t.TaskResult.AppendErr(host, connector.ErrInterrupted)
+		event.FromCache(t.PipelineCache).HostResult(t.Name, host.GetName(), event.StatusFailed, connector.ErrInterrupted)
+		return
+	}
 
 	resCh := make(chan error)
 	go t.Run(runtime, host, index, resCh)
 
 	select {
 	case <-ctx.Done():
-		t.TaskResult.AppendErr(host, fmt.Errorf("execute task timeout, Timeout=%s", util.ShortDur(t.Timeout)))
+		err := timeoutErr(ctx, "execute task", t.Timeout)
+		t.TaskResult.AppendErr(host, err)
+		event.FromCache(t.PipelineCache).HostResult(t.Name, host.GetName(), event.StatusFailed, err)
 	case e := <-resCh:
 		if e != nil {
 			t.TaskResult.AppendErr(host, e)
+			event.FromCache(t.PipelineCache).HostResult(t.Name, host.GetName(), event.StatusFailed, e)
 		}
 	}
-
-	<-pool
-	wg.Done()
 }
 
 func (t *RemoteTask) Run(runtime connector.Runtime, host connector.Host, index int, resCh chan error) {
@@ -146,6 +168,7 @@ func (t *RemoteTask) Run(runtime connector.Runtime, host connector.Host, index i
 			return
 		} else {
 			t.TaskResult.AppendSkip(host)
+			event.FromCache(t.PipelineCache).HostResult(t.Name, host.GetName(), event.StatusSkipped, nil)
 			return
 		}
 	}
@@ -158,6 +181,7 @@ func (t *RemoteTask) Run(runtime connector.Runtime, host connector.Host, index i
 	}
 
 	t.TaskResult.AppendSuccess(host)
+	event.FromCache(t.PipelineCache).HostResult(t.Name, host.GetName(), event.StatusSuccess, nil)
 	return
 }
 
@@ -175,6 +199,7 @@ func (t *RemoteTask) ConfigureSelfRuntime(runtime connector.Runtime, host connec
 		//Debug: runtime.Arg.Debug,
 		Host:  host,
 		Index: index,
+		Ctx:   runtime.GetExecContext(),
 	}
 	runtime.SetRunner(r)
 	return nil
@@ -203,8 +228,11 @@ func (t *RemoteTask) WhenWithRetry(runtime connector.Runtime) (bool, error) {
 				err = errors.New(err.Error() + e.Error())
 				continue
 			}
+			if !waitRetry(runtime.GetContext(), t.Delay) {
+				err = errors.Wrap(e, "pre-check not retried as the run has been interrupted")
+				break
+			}
 			logger.Infof("retry: [%s]", runtime.RemoteHost().GetName())
-			time.Sleep(t.Delay)
 			continue
 		} else {
 			err = nil
@@ -227,8 +255,12 @@ func (t *RemoteTask) ExecuteWithRetry(runtime connector.Runtime) error {
 				err = errors.New(err.Error() + e.Error())
 				continue
 			}
+			if !waitRetry(runtime.GetContext(), t.Delay) {
+				err = errors.Wrapf(e, "%s not retried as the run has been interrupted", t.Name)
+				break
+			}
 			logger.Debugf("Remote retry: [%s] %s", runtime.RemoteHost().GetName(), t.Name)
-			time.Sleep(t.Delay)
+			event.FromCache(t.PipelineCache).TaskRetried(t.Name, runtime.RemoteHost().GetName(), i+1, e)
 			continue
 		} else {
 			err = nil
@@ -246,9 +278,9 @@ func (t *RemoteTask) ExecuteRollback() {
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), t.Timeout)
+	ctx, cancel := context.WithTimeout(t.Runtime.GetExecContext(), t.Timeout)
 	defer cancel()
-	routinePool := make(chan struct{}, DefaultCon)
+	routinePool := make(chan struct{}, t.calculateConcurrency())
 	defer close(routinePool)
 
 	rwg := &sync.WaitGroup{}
@@ -258,12 +290,12 @@ func (t *RemoteTask) ExecuteRollback() {
 		}
 		selfRuntime := t.Runtime.Copy()
 
+		rwg.Add(1)
 		if t.Parallel {
 			go t.RollbackWithTimeout(ctx, selfRuntime, ar.Host, i, ar, rwg, routinePool)
 		} else {
 			t.RollbackWithTimeout(ctx, selfRuntime, ar.Host, i, ar, rwg, routinePool)
 		}
-		rwg.Add(1)
 	}
 	rwg.Wait()
 }
@@ -272,21 +304,27 @@ func (t *RemoteTask) RollbackWithTimeout(ctx context.Context, runtime connector.
 	result *ending.ActionResult, wg *sync.WaitGroup, pool chan struct{}) {
 
 	pool <- struct{}{}
+	workers := WorkerPoolFromCache(t.PipelineCache)
+	workers.Acquire()
 
 	resCh := make(chan error)
 	go t.RunRollback(runtime, host, index, result, resCh)
 
+	var err error
 	select {
 	case <-ctx.Done():
+		err = timeoutErr(ctx, "rollback", t.Timeout)
 		logger.Warnf("rollback-failed: [%s]", runtime.RemoteHost().GetName())
-		logger.Errorf("%s execute task timeout, Timeout=%d", runtime.RemoteHost().GetName(), t.Timeout)
-	case e := <-resCh:
-		if e != nil {
+		logger.Errorf("%s %s", runtime.RemoteHost().GetName(), err.Error())
+	case err = <-resCh:
+		if err != nil {
 			logger.Warnf("rollback-failed: [%s]", runtime.RemoteHost().GetName())
-			logger.Errorf("%s %s", runtime.RemoteHost().GetName(), e.Error())
+			logger.Errorf("%s %s", runtime.RemoteHost().GetName(), err.Error())
 		}
 	}
+	event.FromCache(t.PipelineCache).TaskRolledBack(t.Name, host.GetName(), err)
 
+	workers.Release()
 	<-pool
 	wg.Done()
 }
@@ -343,6 +381,8 @@ func (t *RemoteTask) Default() {
 	}
 }
 
+// calculateConcurrency is the number of hosts the task works on at the same time,
+// Concurrency being the fraction of the hosts of the task
 func (t *RemoteTask) calculateConcurrency() int {
 	num := t.Concurrency * float64(len(t.Hosts))
 	res := int(util.Round(num, 0))
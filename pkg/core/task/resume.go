@@ -0,0 +1,36 @@
+package task
+
+import (
+	"bytetrade.io/web3os/installer/pkg/core/action"
+	"bytetrade.io/web3os/installer/pkg/core/cache"
+	"bytetrade.io/web3os/installer/pkg/core/prepare"
+)
+
+// RunsOnResume reports whether the task is run again when a run is resumed even if it succeeded:
+// it is set AlwaysRun, as the tasks only reading the state of the hosts are,
+// or its action or one of its prepares sets the cache, see cache.Filler
+func RunsOnResume(t Interface) bool {
+	switch tt := t.(type) {
+	case *RemoteTask:
+		return tt.AlwaysRun || fillsCache(tt.Action, tt.Prepare)
+	case *LocalTask:
+		return tt.AlwaysRun || fillsCache(tt.Action, tt.Prepare)
+	}
+	return false
+}
+
+func fillsCache(a action.Action, p prepare.Prepare) bool {
+	return cache.Fills(a) || prepareFillsCache(p)
+}
+
+func prepareFillsCache(p prepare.Prepare) bool {
+	if c, ok := p.(*prepare.PrepareCollection); ok {
+		for _, v := range *c {
+			if prepareFillsCache(v) {
+				return true
+			}
+		}
+		return false
+	}
+	return cache.Fills(p)
+}
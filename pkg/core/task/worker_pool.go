@@ -0,0 +1,49 @@
+package task
+
+import (
+	"bytetrade.io/web3os/installer/pkg/core/cache"
+)
+
+const workerPoolCacheKey = "PipelineWorkerPool"
+
+// WorkerPool caps the number of hosts the tasks of a pipeline work on at the same time,
+// on top of the concurrency of each task.
+// A nil WorkerPool puts no cap.
+type WorkerPool chan struct{}
+
+func NewWorkerPool(size int) WorkerPool {
+	if size <= 0 {
+		size = DefaultCon
+	}
+	return make(WorkerPool, size)
+}
+
+// WorkerPoolFromCache returns the worker pool attached to the pipeline cache, if any.
+func WorkerPoolFromCache(c *cache.Cache) WorkerPool {
+	if c == nil {
+		return nil
+	}
+	v, ok := c.Get(workerPoolCacheKey)
+	if !ok {
+		return nil
+	}
+	p, _ := v.(WorkerPool)
+	return p
+}
+
+// Attach makes the worker pool available to the tasks through the pipeline cache.
+func (p WorkerPool) Attach(c *cache.Cache) {
+	c.Set(workerPoolCacheKey, p)
+}
+
+func (p WorkerPool) Acquire() {
+	if p != nil {
+		p <- struct{}{}
+	}
+}
+
+func (p WorkerPool) Release() {
+	if p != nil {
+		<-p
+	}
+}
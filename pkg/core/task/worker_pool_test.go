@@ -0,0 +1,142 @@
+package task
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"bytetrade.io/web3os/installer/pkg/core/action"
+	"bytetrade.io/web3os/installer/pkg/core/cache"
+	"bytetrade.io/web3os/installer/pkg/core/connector"
+	"bytetrade.io/web3os/installer/pkg/core/logger"
+)
+
+// testRuntime implements the methods of connector.Runtime a RemoteTask calls on the local host, the others panic
+type testRuntime struct {
+	connector.Runtime
+	host connector.Host
+}
+
+func (r *testRuntime) GetContext() context.Context          { return context.Background() }
+func (r *testRuntime) GetExecContext() context.Context      { return context.Background() }
+func (r *testRuntime) Copy() connector.Runtime              { return &testRuntime{} }
+func (r *testRuntime) HostIsDeprecated(connector.Host) bool { return false }
+func (r *testRuntime) GetSystemInfo() connector.Systems     { return testSystems{} }
+func (r *testRuntime) SetRunner(runner *connector.Runner)   { r.host = runner.Host }
+func (r *testRuntime) RemoteHost() connector.Host           { return r.host }
+
+type testSystems struct {
+	connector.Systems
+}
+
+func (testSystems) GetLocalIp() string { return "127.0.0.1" }
+
+// inFlight counts the hosts worked on at the same time
+type inFlight struct {
+	mu      sync.Mutex
+	current int
+	max     int
+}
+
+type countAction struct {
+	action.BaseAction
+	count *inFlight
+}
+
+func (a *countAction) Execute(runtime connector.Runtime) error {
+	a.count.mu.Lock()
+	a.count.current++
+	if a.count.current > a.count.max {
+		a.count.max = a.count.current
+	}
+	a.count.mu.Unlock()
+
+	time.Sleep(30 * time.Millisecond)
+
+	a.count.mu.Lock()
+	a.count.current--
+	a.count.mu.Unlock()
+	return nil
+}
+
+func newCountTask(name string, hosts int, concurrency float64, count *inFlight, pipelineCache *cache.Cache) *RemoteTask {
+	t := &RemoteTask{
+		Name:        name,
+		Action:      &countAction{count: count},
+		Parallel:    true,
+		Concurrency: concurrency,
+	}
+	for i := 0; i < hosts; i++ {
+		h := connector.NewHost()
+		h.SetName(fmt.Sprintf("node%d", i))
+		h.SetAddress("127.0.0.1")
+		h.SetInternalAddress("127.0.0.1")
+		t.Hosts = append(t.Hosts, h)
+	}
+	t.Init(&testRuntime{}, cache.NewCache(), pipelineCache)
+	return t
+}
+
+func TestCalculateConcurrency(t *testing.T) {
+	for _, c := range []struct {
+		concurrency float64
+		hosts       int
+		expected    int
+	}{
+		{1, 8, 8},
+		{0.5, 8, 4},
+		{0.25, 8, 2},
+		{0.1, 3, 1},
+		{0, 3, 3},
+	} {
+		task := newCountTask("Count", c.hosts, c.concurrency, &inFlight{}, cache.NewCache())
+		if n := task.calculateConcurrency(); n != c.expected {
+			t.Errorf("concurrency %v of %d hosts: expected %d hosts at a time, got %d", c.concurrency, c.hosts, c.expected, n)
+		}
+	}
+}
+
+func TestTaskConcurrency(t *testing.T) {
+	dir := t.TempDir()
+	logger.InitLog(dir, filepath.Join(dir, "test.log"), true)
+
+	count := &inFlight{}
+	task := newCountTask("Count", 8, 0.25, count, cache.NewCache())
+	if res := task.Execute(); res.IsFailed() {
+		t.Fatal(res.CombineErr())
+	}
+	if count.max != 2 {
+		t.Errorf("expected 2 hosts at a time, got %d", count.max)
+	}
+}
+
+func TestWorkerPoolCapsConcurrentTasks(t *testing.T) {
+	dir := t.TempDir()
+	logger.InitLog(dir, filepath.Join(dir, "test.log"), true)
+
+	pipelineCache := cache.NewCache()
+	NewWorkerPool(3).Attach(pipelineCache)
+	count := &inFlight{}
+	tasks := []*RemoteTask{
+		newCountTask("First", 6, 1, count, pipelineCache),
+		newCountTask("Second", 6, 1, count, pipelineCache),
+	}
+
+	wg := &sync.WaitGroup{}
+	for _, task := range tasks {
+		wg.Add(1)
+		go func(task *RemoteTask) {
+			defer wg.Done()
+			if res := task.Execute(); res.IsFailed() {
+				t.Error(res.CombineErr())
+			}
+		}(task)
+	}
+	wg.Wait()
+	if count.max != 3 {
+		t.Errorf("expected 3 hosts at a time across the tasks, got %d", count.max)
+	}
+}
@@ -17,6 +17,7 @@ func ExecWithContext(ctx context.Context, name string, printOutput bool, printLi
 
 	logger.Debugf("[exec] try to exec CMD: %s", name)
 	cmd := exec.CommandContext(ctx, "/bin/bash", "-c", name)
+	setProcessGroup(cmd)
 	out, err := cmd.StdoutPipe()
 	if err != nil {
 		return "", exitCode, err
@@ -44,7 +45,7 @@ func ExecWithContext(ctx context.Context, name string, printOutput bool, printLi
 			}
 
 			if printLine && line != "" {
-				fmt.Println(strings.TrimSuffix(line, "\n"))
+				fmt.Println(logger.Redact(strings.TrimSuffix(line, "\n")))
 			}
 			outputBuffer.WriteString(line)
 
@@ -52,7 +53,7 @@ func ExecWithContext(ctx context.Context, name string, printOutput bool, printLi
 		}
 
 		if printLine && line != "" {
-			fmt.Println(strings.TrimSuffix(line, "\n"))
+			fmt.Println(logger.Redact(strings.TrimSuffix(line, "\n")))
 		}
 
 		outputBuffer.WriteString(line)
@@ -70,11 +71,11 @@ func ExecWithContext(ctx context.Context, name string, printOutput bool, printLi
 	res = strings.TrimSpace(res)
 
 	if printOutput {
-		fmt.Printf("[exec] CMD: %s, OUTPUT: \n%s\n", cmd.String(), res)
+		fmt.Printf("[exec] CMD: %s, OUTPUT: \n%s\n", logger.Redact(cmd.String()), logger.Redact(res))
 	}
 
 	logger.Debugf("[exec] CMD: %s, OUTPUT: %s", cmd.String(), res)
-	return res, exitCode, errors.Wrapf(err, "Failed to exec command: %s \n%s", cmd, res)
+	return res, exitCode, errors.Wrapf(err, "Failed to exec command: %s \n%s", logger.Redact(cmd.String()), logger.Redact(res))
 }
 
 func Exec(ctx context.Context, name string, printOutput bool, printLine bool) (stdout string, code int, err error) {
@@ -82,6 +83,7 @@ func Exec(ctx context.Context, name string, printOutput bool, printLine bool) (s
 
 	logger.Debugf("[exec] try to exec CMD: %s", name)
 	cmd := exec.CommandContext(ctx, "/bin/bash", "-c", name)
+	setProcessGroup(cmd)
 	out, err := cmd.StdoutPipe()
 	if err != nil {
 		return "", exitCode, err
@@ -108,7 +110,7 @@ func Exec(ctx context.Context, name string, printOutput bool, printLine bool) (s
 			}
 
 			if printLine && line != "" {
-				fmt.Println(strings.TrimSuffix(line, "\n"))
+				fmt.Println(logger.Redact(strings.TrimSuffix(line, "\n")))
 			}
 			outputBuffer.WriteString(line)
 
@@ -116,7 +118,7 @@ func Exec(ctx context.Context, name string, printOutput bool, printLine bool) (s
 		}
 
 		if printLine && line != "" {
-			fmt.Println(strings.TrimSuffix(line, "\n"))
+			fmt.Println(logger.Redact(strings.TrimSuffix(line, "\n")))
 		}
 
 		outputBuffer.WriteString(line)
@@ -134,9 +136,9 @@ func Exec(ctx context.Context, name string, printOutput bool, printLine bool) (s
 	res = strings.TrimSpace(res)
 
 	if printOutput {
-		fmt.Printf("[exec] CMD: %s, OUTPUT: \n%s\n", cmd.String(), res)
+		fmt.Printf("[exec] CMD: %s, OUTPUT: \n%s\n", logger.Redact(cmd.String()), logger.Redact(res))
 	}
 
 	logger.Debugf("[exec] CMD: %s, OUTPUT: %s", cmd.String(), res)
-	return res, exitCode, errors.Wrapf(err, "Failed to exec command: %s \n%s", cmd, res)
+	return res, exitCode, errors.Wrapf(err, "Failed to exec command: %s \n%s", logger.Redact(cmd.String()), logger.Redact(res))
 }
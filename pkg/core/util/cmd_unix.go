@@ -0,0 +1,25 @@
+//go:build !windows
+
+package util
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+// setProcessGroup runs the command in a process group of its own, so that a Ctrl-C on the terminal
+// is left to the installer to handle instead of killing the command half way,
+// and makes the cancellation of its context kill the whole group rather than the shell alone.
+// Commands run by a non-root user stay in the foreground group, sudo may need the terminal to ask for a password.
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.WaitDelay = 5 * time.Second
+	if os.Geteuid() != 0 {
+		return
+	}
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
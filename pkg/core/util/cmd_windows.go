@@ -0,0 +1,10 @@
+package util
+
+import (
+	"os/exec"
+	"time"
+)
+
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.WaitDelay = 5 * time.Second
+}
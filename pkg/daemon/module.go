@@ -48,6 +48,12 @@ func (m *ReplaceOlaresdBinaryModule) Init() {
 	m.Name = "ReplaceOlaresdBinaryModule"
 	m.Desc = "Replace olaresd"
 
+	backup := &task.LocalTask{
+		Name:   "BackupOlaresdBinary",
+		Desc:   "Backup olaresd binary",
+		Action: new(BackupOlaresdBinary),
+	}
+
 	replace := &task.LocalTask{
 		Name: "ReplaceOlaresdBinary",
 		Desc: "Replace olaresd binary",
@@ -76,6 +82,7 @@ func (m *ReplaceOlaresdBinaryModule) Init() {
 	}
 
 	m.Tasks = []task.Interface{
+		backup,
 		replace,
 		updateEnv,
 		restart,
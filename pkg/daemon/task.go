@@ -12,6 +12,7 @@ import (
 	"bytetrade.io/web3os/installer/pkg/daemon/templates"
 	"bytetrade.io/web3os/installer/pkg/manifest"
 	"bytetrade.io/web3os/installer/pkg/utils"
+	"github.com/joho/godotenv"
 	"github.com/pkg/errors"
 )
 
@@ -44,6 +45,40 @@ func (g *InstallTerminusdBinary) Execute(runtime connector.Runtime) error {
 	return nil
 }
 
+// OlaresdBackupPath is where the olaresd binary installed for a version of Olares is kept
+// once replaced by that of another version, so that an upgrade can be rolled back
+func OlaresdBackupPath(baseDir, version string) string {
+	return filepath.Join(baseDir, "upgrade", "olaresd", "olaresd-"+version)
+}
+
+// InstalledOlaresdVersion returns the version of Olares olaresd has been installed for, as set in its env file
+func InstalledOlaresdVersion() (string, error) {
+	env, err := godotenv.Read(filepath.Join("/etc/systemd/system/", templates.TerminusdEnv.Name()))
+	if err != nil {
+		return "", err
+	}
+	return env["INSTALLED_VERSION"], nil
+}
+
+// BackupOlaresdBinary keeps the installed olaresd binary before it is replaced by that of another version,
+// see OlaresdBackupPath
+type BackupOlaresdBinary struct {
+	common.KubeAction
+}
+
+func (b *BackupOlaresdBinary) Execute(runtime connector.Runtime) error {
+	installed, err := InstalledOlaresdVersion()
+	// olaresd is not installed, or already replaced by a previous run
+	if err != nil || installed == "" || installed == b.KubeConf.Arg.OlaresVersion || !util.IsExist("/usr/local/bin/olaresd") {
+		return nil
+	}
+	dst := OlaresdBackupPath(runtime.GetBaseDir(), installed)
+	if _, err := runtime.GetRunner().SudoCmd(fmt.Sprintf("mkdir -p %s && cp -f /usr/local/bin/olaresd %s", filepath.Dir(dst), dst), false, false); err != nil {
+		return errors.Wrap(errors.WithStack(err), "backup olaresd binary failed")
+	}
+	return nil
+}
+
 type UpdateOlaresdServiceEnv struct {
 	common.KubeAction
 }
@@ -0,0 +1,133 @@
+package datastore
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"bytetrade.io/web3os/installer/pkg/common"
+	"bytetrade.io/web3os/installer/pkg/core/connector"
+	"bytetrade.io/web3os/installer/pkg/core/logger"
+	"bytetrade.io/web3os/installer/pkg/core/util"
+	"github.com/joho/godotenv"
+	"github.com/pkg/errors"
+)
+
+// the datastores of the cluster: the etcd installed by KubeKey, for kubeadm and multi-master k3s clusters,
+// or the embedded etcd or sqlite database of k3s
+const (
+	TypeEtcd      = "etcd"
+	TypeK3sEtcd   = "k3s-etcd"
+	TypeK3sSqlite = "k3s-sqlite"
+
+	K3sDatastoreDir = "/var/lib/rancher/k3s/server/db"
+	EtcdEnvFile     = "/etc/etcd.env"
+
+	etcdSnapshotFile = "etcd-snapshot.db"
+	sqliteFile       = "state.db"
+)
+
+func etcdAdminCert(hostName string) (string, string) {
+	return filepath.Join(common.ETCDCertDir, fmt.Sprintf("admin-%s.pem", hostName)),
+		filepath.Join(common.ETCDCertDir, fmt.Sprintf("admin-%s-key.pem", hostName))
+}
+
+// Detect finds the type of the datastore of the cluster on the local node, it is empty if there is none
+func Detect(hostName string) string {
+	cert, _ := etcdAdminCert(hostName)
+	switch {
+	case util.IsExist(cert):
+		return TypeEtcd
+	case util.IsExist(filepath.Join(K3sDatastoreDir, "etcd")):
+		return TypeK3sEtcd
+	case util.IsExist(filepath.Join(K3sDatastoreDir, sqliteFile)):
+		return TypeK3sSqlite
+	}
+	return ""
+}
+
+// Save saves the datastore of the cluster to dir while the cluster is running,
+// name is that of the snapshot of the embedded etcd of k3s, which k3s suffixes,
+// it returns the file saved, relative to dir, and the type of the datastore, both empty if there is none
+func Save(runtime connector.Runtime, dir, name string) (string, string, error) {
+	host := runtime.RemoteHost().GetName()
+	switch Detect(host) {
+	case TypeEtcd:
+		cert, key := etcdAdminCert(host)
+		cmd := fmt.Sprintf("export ETCDCTL_API=3;"+
+			"%s/etcdctl --endpoints=https://127.0.0.1:2379 --cacert=%s/ca.pem --cert=%s --key=%s snapshot save %s",
+			common.BinDir, common.ETCDCertDir, cert, key, filepath.Join(dir, etcdSnapshotFile))
+		if _, err := runtime.GetRunner().SudoCmd(cmd, false, false); err != nil {
+			return "", "", errors.Wrap(errors.WithStack(err), "failed to save the etcd snapshot")
+		}
+		return etcdSnapshotFile, TypeEtcd, nil
+	case TypeK3sEtcd:
+		cmd := fmt.Sprintf("%s/k3s etcd-snapshot save --dir %s --name %s", common.BinDir, dir, name)
+		if _, err := runtime.GetRunner().SudoCmd(cmd, false, false); err != nil {
+			return "", "", errors.Wrap(errors.WithStack(err), "failed to save the k3s etcd snapshot")
+		}
+		// k3s suffixes the name of the snapshot with the node name and the time
+		matches, _ := filepath.Glob(filepath.Join(dir, name+"*"))
+		if len(matches) == 0 {
+			return "", "", errors.Errorf("no k3s etcd snapshot found in %s", dir)
+		}
+		return filepath.Base(matches[0]), TypeK3sEtcd, nil
+	case TypeK3sSqlite:
+		var cmd string
+		if _, err := runtime.GetRunner().SudoCmd("command -v sqlite3", false, false); err == nil {
+			// the online backup of sqlite is consistent while k3s writes to the database
+			cmd = fmt.Sprintf("sqlite3 %s '.backup %s'", filepath.Join(K3sDatastoreDir, sqliteFile), filepath.Join(dir, sqliteFile))
+		} else {
+			// a copy of the database and of its write-ahead log is only consistent once k3s has stopped writing to them
+			logger.Warnf("sqlite3 is not installed, k3s is stopped while its database is copied")
+			// k3s is started again whether the copy succeeded or not, the command fails if it did not,
+			// it has no $ for the outer shell of SudoPrefix, which quotes it in double quotes, not to expand it
+			cmd = fmt.Sprintf("if systemctl stop k3s && cp -a %s/%s* %s; then systemctl start k3s; else systemctl start k3s; false; fi",
+				K3sDatastoreDir, sqliteFile, dir)
+		}
+		if _, err := runtime.GetRunner().SudoCmd(cmd, false, false); err != nil {
+			return "", "", errors.Wrap(errors.WithStack(err), "failed to save the k3s database")
+		}
+		return sqliteFile, TypeK3sSqlite, nil
+	}
+	return "", "", nil
+}
+
+// Restore resets the datastore of the cluster from the file saved by Save in dir, the current data is moved aside,
+// k3s, or etcd and kubelet, are to be stopped, the etcd installed by KubeKey can only be restored if it has a single member
+func Restore(runtime connector.Runtime, datastoreType, dir, file string) error {
+	suffix := fmt.Sprintf("bak-%d", time.Now().Unix())
+	var cmd string
+	switch datastoreType {
+	case TypeEtcd:
+		env, err := godotenv.Read(EtcdEnvFile)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read %s", EtcdEnvFile)
+		}
+		if strings.Contains(env["ETCD_INITIAL_CLUSTER"], ",") {
+			return errors.Errorf("etcd has several members, %s, restore the snapshot on all of them with etcdutl", env["ETCD_INITIAL_CLUSTER"])
+		}
+		dataDir := env["ETCD_DATA_DIR"]
+		if dataDir == "" {
+			dataDir = "/var/lib/etcd"
+		}
+		cmd = fmt.Sprintf("mv %s %s.%s && export ETCDCTL_API=3 && %s/etcdctl snapshot restore %s "+
+			"--name %s --initial-cluster %s --initial-cluster-token %s --initial-advertise-peer-urls %s --data-dir %s",
+			dataDir, dataDir, suffix, common.BinDir, filepath.Join(dir, file),
+			env["ETCD_NAME"], env["ETCD_INITIAL_CLUSTER"], env["ETCD_INITIAL_CLUSTER_TOKEN"], env["ETCD_INITIAL_ADVERTISE_PEER_URLS"], dataDir)
+	case TypeK3sEtcd:
+		// k3s moves the current data aside itself
+		cmd = fmt.Sprintf("%s/k3s server --cluster-reset --cluster-reset-restore-path=%s", common.BinDir, filepath.Join(dir, file))
+	case TypeK3sSqlite:
+		backup := filepath.Join(K3sDatastoreDir, suffix)
+		cmd = fmt.Sprintf("mkdir -p %s && mv %s/%s* %s/ && cp -a %s/%s* %s/",
+			backup, K3sDatastoreDir, sqliteFile, backup, dir, sqliteFile, K3sDatastoreDir)
+	default:
+		return errors.Errorf("unknown datastore type %q", datastoreType)
+	}
+	if _, err := runtime.GetRunner().SudoCmd(cmd, false, true); err != nil {
+		return errors.Wrapf(errors.WithStack(err), "failed to restore the %s datastore", datastoreType)
+	}
+	return nil
+}
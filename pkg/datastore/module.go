@@ -0,0 +1,137 @@
+package datastore
+
+import (
+	"time"
+
+	"bytetrade.io/web3os/installer/pkg/certs"
+	"bytetrade.io/web3os/installer/pkg/common"
+	"bytetrade.io/web3os/installer/pkg/core/task"
+	"bytetrade.io/web3os/installer/pkg/terminus"
+)
+
+// SaveSnapshotModule takes a snapshot of the datastore of the cluster and prunes the former ones,
+// the snapshots are mirrored to the object storage of Olares if Upload is set
+type SaveSnapshotModule struct {
+	common.KubeModule
+	Keep   int
+	MaxAge time.Duration
+	Upload bool
+}
+
+func (m *SaveSnapshotModule) Init() {
+	m.Name = "SaveSnapshotModule"
+	m.Desc = "Save a snapshot of the datastore of the cluster"
+
+	m.Tasks = []task.Interface{
+		&task.LocalTask{
+			Name:   "SaveSnapshot",
+			Desc:   "Save a snapshot of the datastore",
+			Action: new(SaveSnapshot),
+		},
+	}
+	m.Tasks = append(m.Tasks, pruneTasks(m.Keep, m.MaxAge, m.Upload)...)
+}
+
+// PruneSnapshotsModule removes the snapshots beyond the retention, from the object storage of Olares too if Upload is set
+type PruneSnapshotsModule struct {
+	common.KubeModule
+	Keep   int
+	MaxAge time.Duration
+	Upload bool
+}
+
+func (m *PruneSnapshotsModule) Init() {
+	m.Name = "PruneSnapshotsModule"
+	m.Desc = "Prune the snapshots of the datastore of the cluster"
+	m.Tasks = pruneTasks(m.Keep, m.MaxAge, m.Upload)
+}
+
+func pruneTasks(keep int, maxAge time.Duration, upload bool) []task.Interface {
+	tasks := []task.Interface{
+		&task.LocalTask{
+			Name:   "PruneSnapshots",
+			Desc:   "Prune the former snapshots",
+			Action: &PruneSnapshots{Keep: keep, MaxAge: maxAge},
+		},
+	}
+	if upload {
+		tasks = append(tasks, &task.LocalTask{
+			Name:   "UploadSnapshots",
+			Desc:   "Upload the snapshots to the object storage",
+			Action: &UploadSnapshots{Keep: keep, MaxAge: maxAge},
+			Retry:  3,
+			Delay:  5 * time.Second,
+		})
+	}
+	return tasks
+}
+
+// RestoreSnapshotModule resets the datastore of the cluster from the snapshot SnapshotName,
+// downloaded from the object storage of Olares if Download is set and it is not kept on the node,
+// olaresd and the cluster are stopped meanwhile
+type RestoreSnapshotModule struct {
+	common.KubeModule
+	SnapshotName string
+	Download     bool
+}
+
+func (m *RestoreSnapshotModule) Init() {
+	m.Name = "RestoreSnapshotModule"
+	m.Desc = "Restore the datastore of the cluster from a snapshot"
+
+	if m.Download {
+		m.Tasks = append(m.Tasks, &task.LocalTask{
+			Name:   "DownloadSnapshot",
+			Desc:   "Download the snapshot from the object storage",
+			Action: &DownloadSnapshot{Name: m.SnapshotName},
+			Retry:  3,
+			Delay:  5 * time.Second,
+		})
+	}
+	m.Tasks = append(m.Tasks,
+		&task.LocalTask{
+			Name:      "CheckSnapshot",
+			AlwaysRun: true,
+			Desc:      "Check the snapshot",
+			Action:    &CheckSnapshot{Name: m.SnapshotName},
+		},
+		&task.LocalTask{
+			Name: "StopOlaresd",
+			Desc: "Stop olaresd",
+			Action: &terminus.SystemctlCommand{
+				Command:   "stop",
+				UnitNames: []string{"olaresd"},
+			},
+		},
+		&task.LocalTask{
+			Name:   "StopCluster",
+			Desc:   "Stop the cluster",
+			Action: new(StopCluster),
+		},
+		&task.LocalTask{
+			Name:   "RestoreSnapshot",
+			Desc:   "Restore the datastore",
+			Action: &RestoreSnapshot{Name: m.SnapshotName},
+		},
+		&task.LocalTask{
+			Name:   "StartCluster",
+			Desc:   "Start the cluster",
+			Action: new(StartCluster),
+		},
+		&task.LocalTask{
+			Name:      "WaitKubeAPIServer",
+			AlwaysRun: true,
+			Action:    new(certs.WaitKubeAPIServer),
+			Retry:     30,
+			Delay:     10 * time.Second,
+		},
+		&task.LocalTask{
+			Name: "StartOlaresd",
+			Desc: "Start olaresd",
+			Action: &terminus.SystemctlCommand{
+				Command:   "start",
+				UnitNames: []string{"olaresd"},
+			},
+		},
+	)
+}
@@ -0,0 +1,146 @@
+package datastore
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"bytetrade.io/web3os/installer/pkg/core/connector"
+	"github.com/pkg/errors"
+)
+
+const (
+	snapshotFile = "snapshot.json"
+	// snapshotNameForm names the snapshots after their creation time, so that they sort by it
+	snapshotNameForm = "20060102150405"
+)
+
+// Snapshot is a snapshot of the datastore of the cluster taken on demand,
+// it is kept as snapshot.json in its directory along with the saved datastore
+type Snapshot struct {
+	Name string `json:"name"`
+	// Dir is the directory of the snapshot, it is not saved
+	Dir       string    `json:"-"`
+	CreatedAt time.Time `json:"createdAt"`
+	Node      string    `json:"node"`
+	Type      string    `json:"type"`
+	// File is the file, relative to Dir, the datastore has been saved to
+	File string `json:"file"`
+	Size int64  `json:"size"`
+}
+
+// SnapshotsDir is where the snapshots of the datastore are kept, one directory each
+func SnapshotsDir(baseDir string) string {
+	return filepath.Join(baseDir, "cluster", "snapshots")
+}
+
+// TakeSnapshot saves the datastore of the cluster to a new snapshot in SnapshotsDir
+func TakeSnapshot(runtime connector.Runtime) (*Snapshot, error) {
+	now := time.Now()
+	s := &Snapshot{
+		Name:      now.Format(snapshotNameForm),
+		CreatedAt: now,
+		Node:      runtime.RemoteHost().GetName(),
+	}
+	s.Dir = filepath.Join(SnapshotsDir(runtime.GetBaseDir()), s.Name)
+	// the datastore holds the secrets of the cluster
+	if err := os.MkdirAll(s.Dir, 0700); err != nil {
+		return nil, errors.Wrapf(err, "failed to create %s", s.Dir)
+	}
+
+	var err error
+	if s.File, s.Type, err = Save(runtime, s.Dir, "snapshot"); err != nil {
+		_ = os.RemoveAll(s.Dir)
+		return nil, err
+	}
+	if s.File == "" {
+		_ = os.RemoveAll(s.Dir)
+		return nil, errors.New("no datastore of the cluster found on this node, snapshots are taken on a master")
+	}
+	if info, err := os.Stat(filepath.Join(s.Dir, s.File)); err == nil {
+		s.Size = info.Size()
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(s.Dir, snapshotFile), data, 0600); err != nil {
+		return nil, errors.Wrap(err, "failed to save the snapshot")
+	}
+	return s, nil
+}
+
+// LoadSnapshot loads the snapshot of the given name from SnapshotsDir
+func LoadSnapshot(baseDir, name string) (*Snapshot, error) {
+	return loadSnapshot(filepath.Join(SnapshotsDir(baseDir), name))
+}
+
+func loadSnapshot(dir string) (*Snapshot, error) {
+	data, err := os.ReadFile(filepath.Join(dir, snapshotFile))
+	if err != nil {
+		return nil, err
+	}
+	s := &Snapshot{Dir: dir}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse %s", filepath.Join(dir, snapshotFile))
+	}
+	return s, nil
+}
+
+// ListSnapshots lists the snapshots kept on the node, oldest first,
+// those interrupted while being taken have no snapshot.json and are skipped
+func ListSnapshots(baseDir string) ([]*Snapshot, error) {
+	return listSnapshots(SnapshotsDir(baseDir))
+}
+
+// listSnapshots lists the snapshots in dir, one directory each, oldest first
+func listSnapshots(dir string) ([]*Snapshot, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var snapshots []*Snapshot
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		if s, err := loadSnapshot(filepath.Join(dir, e.Name())); err == nil {
+			snapshots = append(snapshots, s)
+		}
+	}
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].CreatedAt.Before(snapshots[j].CreatedAt)
+	})
+	return snapshots, nil
+}
+
+// ExpiredSnapshots selects the snapshots, sorted oldest first, beyond the most recent keep ones or older than maxAge,
+// a zero keep or maxAge does not limit them, the most recent snapshot is never selected
+func ExpiredSnapshots(snapshots []*Snapshot, keep int, maxAge time.Duration, now time.Time) []*Snapshot {
+	var expired []*Snapshot
+	for i, s := range snapshots {
+		if i == len(snapshots)-1 {
+			break
+		}
+		if (keep > 0 && i < len(snapshots)-keep) || (maxAge > 0 && now.Sub(s.CreatedAt) > maxAge) {
+			expired = append(expired, s)
+		}
+	}
+	return expired
+}
+
+// Age is how long ago the snapshot was taken, rounded to the second
+func (s *Snapshot) Age(now time.Time) string {
+	return now.Sub(s.CreatedAt).Round(time.Second).String()
+}
+
+func (s *Snapshot) String() string {
+	return fmt.Sprintf("%s (%s of %s)", s.Name, s.Type, s.Node)
+}
@@ -0,0 +1,43 @@
+package datastore
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestExpiredSnapshots(t *testing.T) {
+	now := time.Date(2024, 6, 10, 0, 0, 0, 0, time.UTC)
+	var snapshots []*Snapshot
+	for _, days := range []int{10, 5, 3, 1} {
+		created := now.AddDate(0, 0, -days)
+		snapshots = append(snapshots, &Snapshot{Name: created.Format(snapshotNameForm), CreatedAt: created})
+	}
+	names := func(ss []*Snapshot) []string {
+		var n []string
+		for _, s := range ss {
+			n = append(n, s.Name)
+		}
+		return n
+	}
+
+	tests := []struct {
+		name   string
+		keep   int
+		maxAge time.Duration
+		want   []string
+	}{
+		{name: "unlimited", want: nil},
+		{name: "keep 2", keep: 2, want: names(snapshots[:2])},
+		{name: "keep more than there are", keep: 10, want: nil},
+		{name: "max age 4 days", maxAge: 4 * 24 * time.Hour, want: names(snapshots[:2])},
+		{name: "keep 3 or max age 4 days", keep: 3, maxAge: 4 * 24 * time.Hour, want: names(snapshots[:2])},
+		{name: "the latest is kept", maxAge: time.Hour, want: names(snapshots[:3])},
+	}
+	for _, tt := range tests {
+		got := names(ExpiredSnapshots(snapshots, tt.keep, tt.maxAge, now))
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: expired %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
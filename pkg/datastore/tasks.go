@@ -0,0 +1,228 @@
+package datastore
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"bytetrade.io/web3os/installer/pkg/certs"
+	"bytetrade.io/web3os/installer/pkg/common"
+	"bytetrade.io/web3os/installer/pkg/core/connector"
+	"bytetrade.io/web3os/installer/pkg/core/logger"
+	"bytetrade.io/web3os/installer/pkg/core/util"
+	"bytetrade.io/web3os/installer/pkg/storage"
+	"github.com/pkg/errors"
+)
+
+// remoteSnapshotsDir is where the snapshots of a node are uploaded to under the prefix of the cluster in the object storage
+func remoteSnapshotsDir(node string) string {
+	return filepath.Join("cluster-snapshots", node)
+}
+
+func remoteSnapshots(runtime connector.Runtime, s *common.Storage, dir string) (*storage.ObjectStore, error) {
+	if s == nil {
+		return nil, errors.New("no object storage of Olares is configured")
+	}
+	return storage.NewObjectStore(s, runtime.GetSystemInfo().GetLocalIp(), filepath.Join(remoteSnapshotsDir(runtime.RemoteHost().GetName()), dir))
+}
+
+// SaveSnapshot takes a snapshot of the datastore of the cluster
+type SaveSnapshot struct {
+	common.KubeAction
+}
+
+func (a *SaveSnapshot) Execute(runtime connector.Runtime) error {
+	s, err := TakeSnapshot(runtime)
+	if err != nil {
+		return err
+	}
+	logger.Infof("snapshot %s of the %s datastore saved to %s", s.Name, s.Type, filepath.Join(s.Dir, s.File))
+	return nil
+}
+
+// PruneSnapshots removes the snapshots beyond the most recent Keep ones or older than MaxAge, see ExpiredSnapshots
+type PruneSnapshots struct {
+	common.KubeAction
+	Keep   int
+	MaxAge time.Duration
+}
+
+func (a *PruneSnapshots) Execute(runtime connector.Runtime) error {
+	snapshots, err := ListSnapshots(runtime.GetBaseDir())
+	if err != nil {
+		return errors.Wrap(err, "failed to list the snapshots")
+	}
+	for _, s := range ExpiredSnapshots(snapshots, a.Keep, a.MaxAge, time.Now()) {
+		logger.Infof("removing snapshot %s", s)
+		if err := os.RemoveAll(s.Dir); err != nil {
+			return errors.Wrapf(err, "failed to remove snapshot %s", s.Name)
+		}
+	}
+	return nil
+}
+
+// UploadSnapshots copies the snapshots of the node to the object storage of Olares,
+// where those beyond the most recent Keep ones or older than MaxAge are then removed, see ExpiredSnapshots,
+// the snapshots removed from the node are kept in the object storage until they expire there too
+type UploadSnapshots struct {
+	common.KubeAction
+	Keep   int
+	MaxAge time.Duration
+}
+
+func (a *UploadSnapshots) Execute(runtime connector.Runtime) error {
+	snapshots, err := ListSnapshots(runtime.GetBaseDir())
+	if err != nil {
+		return errors.Wrap(err, "failed to list the snapshots")
+	}
+	// never mirror an empty directory, which would empty the object storage
+	if len(snapshots) == 0 {
+		logger.Infof("no snapshot to upload")
+		return nil
+	}
+	remote, err := remoteSnapshots(runtime, a.KubeConf.Arg.Storage, "")
+	if err != nil {
+		return err
+	}
+	cmd := fmt.Sprintf("%s sync --update --dirs %s/ %s", storage.JuiceFsFile, SnapshotsDir(runtime.GetBaseDir()), remote.URL)
+	if _, err := storage.SudoCmdWithEnv(runtime, cmd, remote.SyncEnv("DST"), false, false); err != nil {
+		return errors.Wrap(errors.WithStack(err), "failed to upload the snapshots")
+	}
+	return pruneRemoteSnapshots(runtime, remote, a.Keep, a.MaxAge)
+}
+
+// pruneRemoteSnapshots removes the snapshots of the node in the object storage expired as by ExpiredSnapshots,
+// they are listed from their snapshot.json, downloaded alone to a temporary directory
+func pruneRemoteSnapshots(runtime connector.Runtime, remote *storage.ObjectStore, keep int, maxAge time.Duration) error {
+	tmp, err := os.MkdirTemp("", "olares-snapshots-")
+	if err != nil {
+		return errors.Wrap(err, "failed to create a temporary directory")
+	}
+	defer os.RemoveAll(tmp)
+	listed, empty := filepath.Join(tmp, "listed"), filepath.Join(tmp, "empty")
+	for _, dir := range []string{listed, empty} {
+		if err := os.Mkdir(dir, 0700); err != nil {
+			return errors.Wrapf(err, "failed to create %s", dir)
+		}
+	}
+
+	cmd := fmt.Sprintf("%s sync --dirs --include '*/' --include '%s' --exclude '*' %s %s/", storage.JuiceFsFile, snapshotFile, remote.URL, listed)
+	if _, err := storage.SudoCmdWithEnv(runtime, cmd, remote.SyncEnv("SRC"), false, false); err != nil {
+		return errors.Wrap(errors.WithStack(err), "failed to list the snapshots in the object storage")
+	}
+	snapshots, err := listSnapshots(listed)
+	if err != nil {
+		return errors.Wrap(err, "failed to list the snapshots in the object storage")
+	}
+	for _, s := range ExpiredSnapshots(snapshots, keep, maxAge, time.Now()) {
+		logger.Infof("removing snapshot %s from the object storage", s)
+		// the directory of the snapshot alone is emptied, by syncing an empty one to it
+		cmd := fmt.Sprintf("%s sync --delete-dst --dirs %s/ %s%s/", storage.JuiceFsFile, empty, remote.URL, s.Name)
+		if _, err := storage.SudoCmdWithEnv(runtime, cmd, remote.SyncEnv("DST"), false, false); err != nil {
+			return errors.Wrapf(errors.WithStack(err), "failed to remove snapshot %s from the object storage", s.Name)
+		}
+	}
+	return nil
+}
+
+// DownloadSnapshot gets the snapshot Name from the object storage of Olares, unless it is kept on the node
+type DownloadSnapshot struct {
+	common.KubeAction
+	Name string
+}
+
+func (a *DownloadSnapshot) Execute(runtime connector.Runtime) error {
+	dir := filepath.Join(SnapshotsDir(runtime.GetBaseDir()), a.Name)
+	if util.IsExist(filepath.Join(dir, snapshotFile)) {
+		return nil
+	}
+	remote, err := remoteSnapshots(runtime, a.KubeConf.Arg.Storage, a.Name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return errors.Wrapf(err, "failed to create %s", dir)
+	}
+	cmd := fmt.Sprintf("%s sync --dirs %s %s/", storage.JuiceFsFile, remote.URL, dir)
+	if _, err := storage.SudoCmdWithEnv(runtime, cmd, remote.SyncEnv("SRC"), false, false); err != nil {
+		return errors.Wrapf(errors.WithStack(err), "failed to download snapshot %s", a.Name)
+	}
+	return nil
+}
+
+// CheckSnapshot checks that the snapshot Name is kept on the node and is of the datastore of the cluster
+type CheckSnapshot struct {
+	common.KubeAction
+	Name string
+}
+
+func (a *CheckSnapshot) Execute(runtime connector.Runtime) error {
+	s, err := LoadSnapshot(runtime.GetBaseDir(), a.Name)
+	if err != nil {
+		return errors.Wrapf(err, "failed to load snapshot %s", a.Name)
+	}
+	if current := Detect(runtime.RemoteHost().GetName()); current != s.Type {
+		return errors.Errorf("snapshot %s is of a %s datastore, the cluster has a %q one", s.Name, s.Type, current)
+	}
+	if !util.IsExist(filepath.Join(s.Dir, s.File)) {
+		return errors.Errorf("the datastore of snapshot %s is not found at %s", s.Name, filepath.Join(s.Dir, s.File))
+	}
+	return nil
+}
+
+// StopCluster stops k3s or kubelet, and the etcd installed along with them
+type StopCluster struct {
+	common.KubeAction
+}
+
+func (a *StopCluster) Execute(runtime connector.Runtime) error {
+	cmd := "systemctl stop kubelet"
+	if a.KubeConf.Cluster.Kubernetes.Type == common.K3s {
+		cmd = "systemctl stop k3s"
+	}
+	if Detect(runtime.RemoteHost().GetName()) == TypeEtcd {
+		cmd += " && systemctl stop etcd"
+	}
+	if _, err := runtime.GetRunner().SudoCmd(cmd, false, false); err != nil {
+		return errors.Wrap(errors.WithStack(err), "failed to stop the cluster")
+	}
+	return nil
+}
+
+// RestoreSnapshot resets the datastore of the stopped cluster from the snapshot Name
+type RestoreSnapshot struct {
+	common.KubeAction
+	Name string
+}
+
+func (a *RestoreSnapshot) Execute(runtime connector.Runtime) error {
+	s, err := LoadSnapshot(runtime.GetBaseDir(), a.Name)
+	if err != nil {
+		return errors.Wrapf(err, "failed to load snapshot %s", a.Name)
+	}
+	logger.Infof("restoring the %s datastore from snapshot %s", s.Type, s.Name)
+	return Restore(runtime, s.Type, s.Dir, s.File)
+}
+
+// StartCluster starts the etcd installed along with the cluster, and k3s or kubelet,
+// the control plane of kubeadm is restarted for it not to keep the state of the former datastore
+type StartCluster struct {
+	common.KubeAction
+}
+
+func (a *StartCluster) Execute(runtime connector.Runtime) error {
+	var cmd string
+	if Detect(runtime.RemoteHost().GetName()) == TypeEtcd {
+		cmd = "systemctl start etcd && "
+	}
+	if a.KubeConf.Cluster.Kubernetes.Type == common.K3s {
+		cmd += "systemctl start k3s"
+	} else {
+		cmd += certs.RestartControlPlanePods + " && systemctl start kubelet"
+	}
+	if _, err := runtime.GetRunner().SudoCmd(cmd, false, false); err != nil {
+		return errors.Wrap(errors.WithStack(err), "failed to start the cluster")
+	}
+	return nil
+}
@@ -27,6 +27,7 @@ import (
 
 	kubekeyapiv1alpha2 "bytetrade.io/web3os/installer/apis/kubekey/v1alpha2"
 	"bytetrade.io/web3os/installer/pkg/common"
+	"bytetrade.io/web3os/installer/pkg/core/cache"
 	"bytetrade.io/web3os/installer/pkg/core/connector"
 	"bytetrade.io/web3os/installer/pkg/core/util"
 	"bytetrade.io/web3os/installer/pkg/utils/certs"
@@ -144,6 +145,7 @@ func (f *FetchCerts) Execute(runtime connector.Runtime) error {
 
 type GenerateCerts struct {
 	common.KubeAction
+	cache.Filler
 }
 
 func (g *GenerateCerts) Execute(runtime connector.Runtime) error {
@@ -220,6 +222,7 @@ func GenerateAltName(k *common.KubeConf, runtime *connector.Runtime) *cert.AltNa
 
 type FetchCertsForExternalEtcd struct {
 	common.KubeAction
+	cache.Filler
 }
 
 func (f *FetchCertsForExternalEtcd) Execute(runtime connector.Runtime) error {
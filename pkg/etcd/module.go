@@ -17,11 +17,13 @@
 package etcd
 
 import (
+	"fmt"
 	"path/filepath"
 
 	kubekeyapiv1alpha2 "bytetrade.io/web3os/installer/apis/kubekey/v1alpha2"
 	"bytetrade.io/web3os/installer/pkg/common"
 	"bytetrade.io/web3os/installer/pkg/core/action"
+	"bytetrade.io/web3os/installer/pkg/core/prepare"
 	"bytetrade.io/web3os/installer/pkg/core/task"
 	"bytetrade.io/web3os/installer/pkg/core/util"
 	"bytetrade.io/web3os/installer/pkg/etcd/templates"
@@ -291,6 +293,16 @@ func handleNewCluster(c *ConfigureModule) []task.Interface {
 
 func handleExistCluster(c *ConfigureModule) []task.Interface {
 
+	syncMemberList := &task.RemoteTask{
+		Name:     "SyncETCDMemberList",
+		Desc:     "Get the peers of the etcd cluster from its members",
+		Hosts:    c.Runtime.GetHostsByRole(common.ETCD),
+		Prepare:  new(NodeETCDExist),
+		Action:   new(SyncMemberList),
+		Parallel: false,
+		Retry:    3,
+	}
+
 	existETCDHealthCheck := &task.RemoteTask{
 		Name:     "ExistETCDHealthCheck",
 		Desc:     "Health check on exist etcd",
@@ -339,12 +351,13 @@ func handleExistCluster(c *ConfigureModule) []task.Interface {
 	}
 
 	checkMember := &task.RemoteTask{
-		Name:     "CheckETCDMember",
-		Desc:     "Check etcd member",
-		Hosts:    c.Runtime.GetHostsByRole(common.ETCD),
-		Prepare:  &NodeETCDExist{Not: true},
-		Action:   new(CheckMember),
-		Parallel: true,
+		Name:      "CheckETCDMember",
+		AlwaysRun: true,
+		Desc:      "Check etcd member",
+		Hosts:     c.Runtime.GetHostsByRole(common.ETCD),
+		Prepare:   &NodeETCDExist{Not: true},
+		Action:    new(CheckMember),
+		Parallel:  true,
 	}
 
 	allRefreshETCDConfig := &task.RemoteTask{
@@ -365,6 +378,7 @@ func handleExistCluster(c *ConfigureModule) []task.Interface {
 	}
 
 	tasks := []task.Interface{
+		syncMemberList,
 		existETCDHealthCheck,
 		generateETCDConfig,
 		joinMember,
@@ -377,6 +391,38 @@ func handleExistCluster(c *ConfigureModule) []task.Interface {
 	return tasks
 }
 
+// RemoveMemberModule removes the etcd member of a deleted master node from the cluster,
+// it runs on the local host, nothing is done if the node is not a member,
+// or if the local host is not one itself, e.g., a worker deleting itself, having no etcd to talk to
+type RemoveMemberModule struct {
+	common.KubeModule
+	NodeName string
+	Skip     bool
+}
+
+func (r *RemoveMemberModule) IsSkip() bool {
+	return r.Skip
+}
+
+func (r *RemoveMemberModule) Init() {
+	r.Name = "ETCDRemoveMemberModule"
+	r.Desc = "Remove the etcd member of a node"
+
+	removeMember := &task.LocalTask{
+		Name: "RemoveETCDMember",
+		Desc: "Remove etcd member",
+		Prepare: &prepare.FileExist{
+			FilePath: filepath.Join(common.ETCDCertDir, fmt.Sprintf("admin-%s.pem", r.Runtime.GetLocalHost().GetName())),
+		},
+		Action: &RemoveMember{NodeName: r.NodeName},
+		Retry:  3,
+	}
+
+	r.Tasks = []task.Interface{
+		removeMember,
+	}
+}
+
 type BackupModule struct {
 	common.KubeModule
 	Skip bool
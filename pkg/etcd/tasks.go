@@ -28,7 +28,9 @@ import (
 	kubekeyapiv1alpha2 "bytetrade.io/web3os/installer/apis/kubekey/v1alpha2"
 	"bytetrade.io/web3os/installer/pkg/common"
 	"bytetrade.io/web3os/installer/pkg/core/action"
+	"bytetrade.io/web3os/installer/pkg/core/cache"
 	"bytetrade.io/web3os/installer/pkg/core/connector"
+	"bytetrade.io/web3os/installer/pkg/core/logger"
 	"bytetrade.io/web3os/installer/pkg/core/util"
 	"bytetrade.io/web3os/installer/pkg/etcd/templates"
 	"bytetrade.io/web3os/installer/pkg/utils"
@@ -56,6 +58,7 @@ const (
 
 type GetStatus struct {
 	common.KubeAction
+	cache.Filler
 }
 
 func (g *GetStatus) Execute(runtime connector.Runtime) error {
@@ -163,6 +166,7 @@ func (g *InstallETCDBinary) Execute(runtime connector.Runtime) error {
 
 type GenerateAccessAddress struct {
 	common.KubeAction
+	cache.Filler
 }
 
 func (g *GenerateAccessAddress) Execute(runtime connector.Runtime) error {
@@ -214,6 +218,7 @@ func healthCheck(runtime connector.Runtime, cluster *EtcdCluster) error {
 
 type GenerateConfig struct {
 	common.KubeAction
+	cache.Filler
 }
 
 func (g *GenerateConfig) Execute(runtime connector.Runtime) error {
@@ -418,3 +423,140 @@ func (e *EnableBackupETCDService) Execute(runtime connector.Runtime) error {
 	}
 	return nil
 }
+
+// Member is a member of the etcd cluster as listed by etcdctl member list
+type Member struct {
+	ID         string
+	Name       string
+	PeerURL    string
+	ClientURLs []string
+}
+
+// ParseMemberList parses the output of the v2 etcdctl member list,
+// the members added but not started yet have no name
+func ParseMemberList(output string) []Member {
+	var members []Member
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		id, attrs, ok := strings.Cut(line, ":")
+		if !ok || id == "" {
+			continue
+		}
+		m := Member{ID: strings.TrimSuffix(id, "[unstarted]")}
+		for _, field := range strings.Fields(attrs) {
+			key, value, _ := strings.Cut(field, "=")
+			switch key {
+			case "name":
+				m.Name = value
+			case "peerURLs":
+				m.PeerURL = strings.Split(value, ",")[0]
+			case "clientURLs":
+				if value != "" {
+					m.ClientURLs = strings.Split(value, ",")
+				}
+			}
+		}
+		members = append(members, m)
+	}
+	return members
+}
+
+func listMembers(runtime connector.Runtime, endpoints string) ([]Member, error) {
+	host := runtime.RemoteHost()
+	listMemberCmd := fmt.Sprintf("export ETCDCTL_API=2;"+
+		"export ETCDCTL_CERT_FILE='/etc/ssl/etcd/ssl/admin-%s.pem';"+
+		"export ETCDCTL_KEY_FILE='/etc/ssl/etcd/ssl/admin-%s-key.pem';"+
+		"export ETCDCTL_CA_FILE='/etc/ssl/etcd/ssl/ca.pem';"+
+		"%s/etcdctl --no-sync --endpoints=%s member list", host.GetName(), host.GetName(), common.BinDir, endpoints)
+	output, err := runtime.GetRunner().SudoCmd(listMemberCmd, false, false)
+	if err != nil {
+		return nil, errors.Wrap(errors.WithStack(err), "list etcd member failed")
+	}
+	return ParseMemberList(output), nil
+}
+
+// SyncMemberList sets the peers of the etcd cluster from its actual members,
+// rather than from the etcd hosts of the runtime, which only know about the master joined,
+// so that a new member is configured with all the others
+type SyncMemberList struct {
+	common.KubeAction
+	cache.Filler
+}
+
+func (s *SyncMemberList) Execute(runtime connector.Runtime) error {
+	v, ok := s.PipelineCache.Get(common.ETCDCluster)
+	if !ok {
+		return errors.New("get etcd cluster status by pipeline cache failed")
+	}
+	cluster := v.(*EtcdCluster)
+
+	members, err := listMembers(runtime, fmt.Sprintf("https://%s:2379", runtime.RemoteHost().GetInternalAddress()))
+	if err != nil {
+		return err
+	}
+	var peerAddresses []string
+	for _, m := range members {
+		// a member added by a failed run, the new node gets added again
+		if m.Name == "" {
+			continue
+		}
+		peerAddresses = append(peerAddresses, fmt.Sprintf("%s=%s", m.Name, m.PeerURL))
+	}
+	if len(peerAddresses) == 0 {
+		return errors.New("the etcd cluster has no started member")
+	}
+	cluster.peerAddresses = peerAddresses
+	s.PipelineCache.Set(common.ETCDCluster, cluster)
+	return nil
+}
+
+// RemoveMember removes the etcd member of a node from the cluster,
+// it runs on the local host, which must be an etcd member too
+type RemoveMember struct {
+	common.KubeAction
+	NodeName string
+}
+
+func (r *RemoveMember) Execute(runtime connector.Runtime) error {
+	endpoint := fmt.Sprintf("https://%s:2379", runtime.RemoteHost().GetInternalAddress())
+	members, err := listMembers(runtime, endpoint)
+	if err != nil {
+		return err
+	}
+	etcdName := fmt.Sprintf("etcd-%s", r.NodeName)
+	var target *Member
+	for i := range members {
+		if members[i].Name == etcdName {
+			target = &members[i]
+		}
+	}
+	if target == nil {
+		logger.Infof("node %s is not an etcd member, skip", r.NodeName)
+		return nil
+	}
+	if len(members) == 1 {
+		return fmt.Errorf("node %s is the last etcd member, it can not be removed", r.NodeName)
+	}
+
+	// when removing the local member, talk to another one, as the local one stops once removed
+	if strings.EqualFold(r.NodeName, runtime.RemoteHost().GetName()) {
+		for _, m := range members {
+			if m.ID != target.ID && len(m.ClientURLs) > 0 {
+				endpoint = m.ClientURLs[0]
+				break
+			}
+		}
+	}
+	host := runtime.RemoteHost()
+	removeMemberCmd := fmt.Sprintf("export ETCDCTL_API=2;"+
+		"export ETCDCTL_CERT_FILE='/etc/ssl/etcd/ssl/admin-%s.pem';"+
+		"export ETCDCTL_KEY_FILE='/etc/ssl/etcd/ssl/admin-%s-key.pem';"+
+		"export ETCDCTL_CA_FILE='/etc/ssl/etcd/ssl/ca.pem';"+
+		"%s/etcdctl --no-sync --endpoints=%s member remove %s",
+		host.GetName(), host.GetName(), common.BinDir, endpoint, target.ID)
+	if _, err := runtime.GetRunner().SudoCmd(removeMemberCmd, true, false); err != nil {
+		return errors.Wrapf(errors.WithStack(err), "remove etcd member %s failed", etcdName)
+	}
+	logger.Infof("etcd member %s is removed", etcdName)
+	return nil
+}
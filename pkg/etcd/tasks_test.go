@@ -0,0 +1,21 @@
+package etcd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseMemberList(t *testing.T) {
+	output := "8e9e05c52164694d: name=etcd-node1 peerURLs=https://10.0.0.1:2380 clientURLs=https://10.0.0.1:2379 isLeader=true\r\n" +
+		"91bc3c398fb3c146: name=etcd-node2 peerURLs=https://10.0.0.2:2380 clientURLs=https://10.0.0.2:2379,https://127.0.0.1:2379 isLeader=false\r\n" +
+		"fd422379fda50e48[unstarted]: peerURLs=https://10.0.0.3:2380\r\n"
+
+	want := []Member{
+		{ID: "8e9e05c52164694d", Name: "etcd-node1", PeerURL: "https://10.0.0.1:2380", ClientURLs: []string{"https://10.0.0.1:2379"}},
+		{ID: "91bc3c398fb3c146", Name: "etcd-node2", PeerURL: "https://10.0.0.2:2380", ClientURLs: []string{"https://10.0.0.2:2379", "https://127.0.0.1:2379"}},
+		{ID: "fd422379fda50e48", PeerURL: "https://10.0.0.3:2380"},
+	}
+	if got := ParseMemberList(output); !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseMemberList() = %+v, want %+v", got, want)
+	}
+}
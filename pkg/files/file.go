@@ -120,6 +120,18 @@ type KubeBinary struct {
 	Provider            storage.Provider
 	Md5sum              string
 	CheckMd5Sum         bool
+	// Sha256sum is the expected SHA-256 of the file, it takes precedence over the built-in table
+	Sha256sum string
+	// Size is the expected size of the file, if known
+	Size int64
+	// MirrorUrls are tried in order after Url when a download attempt fails
+	MirrorUrls []string
+	// RateLimiter caps the bandwidth, it can be shared by concurrent downloads
+	RateLimiter grab.RateLimiter
+	// NoProgressBar is set when several files are downloaded at the same time
+	NoProgressBar bool
+	// Context aborts the download when canceled, defaults to context.Background
+	Context context.Context
 }
 
 func NewKubeBinary(name, arch, osType, osVersion, osPlatformFamily, version, prePath, downloadMirrors string) *KubeBinary {
@@ -503,6 +515,9 @@ func (b *KubeBinary) GetTarCmd() string {
 // }
 
 func (b *KubeBinary) GetSha256() string {
+	if b.Sha256sum != "" {
+		return b.Sha256sum
+	}
 	s := FileSha256[b.ID][b.Arch][b.Version]
 	return s
 }
@@ -552,38 +567,61 @@ func (b *KubeBinary) Download() error {
 		}
 	}()
 
+	parent := b.Context
+	if parent == nil {
+		parent = context.Background()
+	}
+	urls := append([]string{b.Url}, b.MirrorUrls...)
 	for i := 5; i > 0; i-- {
+		if err := parent.Err(); err != nil {
+			return err
+		}
+		// fail over to the next mirror after a failed attempt,
+		// the partial file left by the previous attempt is resumed from wherever it came from
+		url := urls[(5-i)%len(urls)]
 
 		client := grab.NewClient()
-		req, _ := grab.NewRequest(fmt.Sprintf("%s/%s", b.BaseDir, b.FileName), b.Url)
-		// req.RateLimiter = NewLimiter(50 * 1024) // todo
-		req.HTTPRequest = req.HTTPRequest.WithContext(context.Background())
-		ctx, cancel := context.WithTimeout(req.HTTPRequest.Context(), 10*time.Minute)
+		req, _ := grab.NewRequest(fmt.Sprintf("%s/%s", b.BaseDir, b.FileName), url)
+		if b.RateLimiter != nil {
+			req.RateLimiter = b.RateLimiter
+		}
+		if b.Size > 0 {
+			req.Size = b.Size
+		}
+		ctx, cancel := context.WithTimeout(parent, 10*time.Minute)
 		defer cancel()
 
 		req.HTTPRequest = req.HTTPRequest.WithContext(ctx)
 		resp := client.Do(req)
 
-		size := resp.Size()
-		bar := progressbar.DefaultBytes(size)
+		if b.NoProgressBar {
+			<-resp.Done
+		} else {
+			size := resp.Size()
+			bar := progressbar.DefaultBytes(size)
 
-		t := time.NewTicker(100 * time.Millisecond)
-		defer t.Stop()
+			t := time.NewTicker(100 * time.Millisecond)
+			defer t.Stop()
 
-	Loop:
-		for {
-			select {
-			case <-t.C:
-				bar.Set64(resp.BytesComplete())
-			case <-resp.Done:
-				bar.Clear()
-				break Loop
+		Loop:
+			for {
+				select {
+				case <-t.C:
+					bar.Set64(resp.BytesComplete())
+				case <-resp.Done:
+					bar.Clear()
+					break Loop
+				}
 			}
 		}
 
 		if err := resp.Err(); err != nil {
-			logger.Errorf("Download failed: %v", err)
-			if i == 1 {
+			logger.Errorf("Download from %s failed: %v", url, err)
+			if errors.Is(err, grab.ErrBadLength) {
+				// the local file is larger than the remote one, it cannot be resumed
+				_ = os.Remove(b.Path())
+			}
+			if i == 1 || parent.Err() != nil {
 				line <- []interface{}{"All download attempts failed", common.StateFail, float64(0)}
 				logger.Error("All download attempts failed")
 				return err
@@ -591,6 +629,9 @@ func (b *KubeBinary) Download() error {
 			time.Sleep(2 * time.Second)
 			continue
 		}
+		if resp.DidResume {
+			logger.Debugf("%s resumed from a partial download", b.FileName)
+		}
 
 		if err := b.Bzip2Cmd(); err != nil { // only for restic or other bzip2 compressed files
 			logger.Errorf("bzip2 decompression failed: %v", err)
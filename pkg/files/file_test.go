@@ -0,0 +1,66 @@
+package files
+
+import (
+	"bytes"
+	"crypto/md5"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"bytetrade.io/web3os/installer/pkg/core/logger"
+)
+
+func TestDownloadResumesFromMirror(t *testing.T) {
+	dir := t.TempDir()
+	logger.InitLog(dir, filepath.Join(dir, "test.log"), true)
+
+	content := bytes.Repeat([]byte("olares"), 64*1024)
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer broken.Close()
+	var ranged atomic.Bool
+	mirror := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Range") != "" {
+			ranged.Store(true)
+		}
+		http.ServeContent(w, r, "pkg", time.Time{}, bytes.NewReader(content))
+	}))
+	defer mirror.Close()
+
+	// a partial file left over by an interrupted run
+	if err := os.WriteFile(filepath.Join(dir, "pkg"), content[:len(content)/2], 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	b := &KubeBinary{
+		ID:            "pkg",
+		FileName:      "pkg",
+		BaseDir:       dir,
+		Url:           broken.URL + "/pkg",
+		MirrorUrls:    []string{mirror.URL + "/pkg"},
+		Size:          int64(len(content)),
+		CheckMd5Sum:   true,
+		Md5sum:        fmt.Sprintf("%x", md5.Sum(content)),
+		RateLimiter:   NewLimiter(16 * 1024 * 1024),
+		NoProgressBar: true,
+	}
+	if err := b.Download(); err != nil {
+		t.Fatal(err)
+	}
+	if !ranged.Load() {
+		t.Error("expected the partial file to be resumed with a range request")
+	}
+	got, err := os.ReadFile(b.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, content) {
+		t.Errorf("downloaded %d bytes, expected %d", len(got), len(content))
+	}
+}
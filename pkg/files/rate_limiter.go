@@ -1,23 +1,24 @@
 package files
 
 import (
-	"context"
-	"time"
+	"math"
 
 	"github.com/cavaliergopher/grab/v3"
+	"golang.org/x/time/rate"
 )
 
-type RateLimiter struct {
-	r, n int
-}
-
-func NewLimiter(r int) grab.RateLimiter {
-	return &RateLimiter{r: r}
-}
+// minBurst is the size of the read buffer of grab, the bucket must be able to hold one
+const minBurst = 32 * 1024
 
-func (c *RateLimiter) WaitN(ctx context.Context, n int) (err error) {
-	c.n += n
-	time.Sleep(
-		time.Duration(1.00 / float64(c.r) * float64(n) * float64(time.Second)))
-	return
+// NewLimiter returns a token bucket capping the bandwidth to r bytes per second,
+// it is safe to share between concurrent downloads to cap their total bandwidth
+func NewLimiter(r int64) grab.RateLimiter {
+	burst := r
+	if burst < minBurst {
+		burst = minBurst
+	}
+	if burst > math.MaxInt32 {
+		burst = math.MaxInt32
+	}
+	return rate.NewLimiter(rate.Limit(r), int(burst))
 }
@@ -0,0 +1,54 @@
+package history
+
+import (
+	"bytetrade.io/web3os/installer/pkg/common"
+	"bytetrade.io/web3os/installer/pkg/core/task"
+)
+
+// RecordInventoryModule records the nodes of the cluster and the versions of the components installed on them
+// in the local state store, for olares-cli history to show
+type RecordInventoryModule struct {
+	common.KubeModule
+}
+
+func (m *RecordInventoryModule) Init() {
+	m.Name = "RecordInventory"
+
+	recordComponentVersions := &task.RemoteTask{
+		Name:     "RecordComponentVersions",
+		Hosts:    m.Runtime.GetAllHosts(),
+		Action:   new(RecordComponentVersions),
+		Parallel: true,
+		Retry:    1,
+	}
+
+	recordNodes := &task.LocalTask{
+		Name:   "RecordNodes",
+		Action: new(RecordNodes),
+	}
+
+	m.Tasks = []task.Interface{
+		recordComponentVersions,
+		recordNodes,
+	}
+}
+
+// ForgetInventoryModule removes the nodes being uninstalled, or deleted from the cluster, from the inventory
+type ForgetInventoryModule struct {
+	common.KubeModule
+	// defaults to the hosts of the runtime
+	NodeNames []string
+}
+
+func (m *ForgetInventoryModule) Init() {
+	m.Name = "ForgetInventory"
+
+	forgetNodes := &task.LocalTask{
+		Name:   "ForgetNodes",
+		Action: &ForgetNodes{NodeNames: m.NodeNames},
+	}
+
+	m.Tasks = []task.Interface{
+		forgetNodes,
+	}
+}
@@ -0,0 +1,190 @@
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"bytetrade.io/web3os/installer/pkg/common"
+	cc "bytetrade.io/web3os/installer/pkg/core/common"
+	"bytetrade.io/web3os/installer/pkg/core/event"
+	"bytetrade.io/web3os/installer/pkg/core/storage"
+	"bytetrade.io/web3os/installer/pkg/core/util"
+	"bytetrade.io/web3os/installer/pkg/model"
+)
+
+const timeFormat = "2006-01-02 15:04:05"
+
+// OpenStore opens the state store under the base directory, or the one of the current installation if empty.
+// The schema is not migrated, reading the history must not disturb a pipeline running at the same time.
+func OpenStore(baseDir string) (storage.Provider, error) {
+	if baseDir == "" {
+		arg := &common.Argument{}
+		if err := arg.LoadReleaseInfo(); err != nil {
+			return nil, errors.Wrap(err, "failed to load olares release info")
+		}
+		baseDir = arg.BaseDir
+	}
+	if baseDir == "" {
+		home, err := util.Home()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get home dir")
+		}
+		baseDir = filepath.Join(home, cc.DefaultBaseDir)
+	}
+
+	if _, err := os.Stat(storage.SQLiteDataSource(baseDir)); err != nil {
+		if os.IsNotExist(err) {
+			return nil, errors.Errorf("no history recorded under %s", baseDir)
+		}
+		return nil, err
+	}
+	provider := storage.NewSQLiteProvider(baseDir)
+	if err := provider.Ping(); err != nil {
+		return nil, errors.Wrap(err, "failed to open the state store")
+	}
+	return provider, nil
+}
+
+// RunReport is a past run with the outcome of its tasks on every host
+type RunReport struct {
+	Run      model.PipelineRun      `json:"run"`
+	Results  []model.TaskCheckpoint `json:"results"`
+	Failures []model.PipelineEvent  `json:"failures,omitempty"`
+}
+
+// Inventory is what the installer knows is installed on the machines of the cluster
+type Inventory struct {
+	Nodes      []model.Node             `json:"nodes"`
+	Components []model.ComponentVersion `json:"components"`
+}
+
+func ListRuns(w io.Writer, provider storage.Provider, limit int, asJSON bool) error {
+	runs, err := provider.QueryPipelineRuns(limit)
+	if err != nil {
+		return err
+	}
+	if asJSON {
+		return printJSON(w, runs)
+	}
+
+	tw := tabwriter.NewWriter(w, 10, 4, 3, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "RUN ID\tPIPELINE\tCOMMAND\tVERSION\tSTATUS\tSTARTED\tDURATION")
+	for _, r := range runs {
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			shortID(r.ID), r.Name, r.Command, r.Version, r.Status, r.StartedAt.Local().Format(timeFormat), duration(r))
+	}
+	return tw.Flush()
+}
+
+// ShowRun prints the run whose ID is, or starts with, id
+func ShowRun(w io.Writer, provider storage.Provider, id string, asJSON bool) error {
+	run, err := provider.QueryPipelineRun(id)
+	if err != nil {
+		return err
+	}
+	if run == nil {
+		return errors.Errorf("run %s not found", id)
+	}
+	report := RunReport{Run: *run}
+	if report.Results, err = provider.QueryTaskCheckpoints(run.ID); err != nil {
+		return err
+	}
+	events, err := provider.QueryPipelineEvents(run.ID)
+	if err != nil {
+		return err
+	}
+	for _, e := range events {
+		if e.Status == event.StatusFailed && e.Host != "" {
+			report.Failures = append(report.Failures, e)
+		}
+	}
+	if asJSON {
+		return printJSON(w, report)
+	}
+
+	tw := tabwriter.NewWriter(w, 10, 4, 3, ' ', 0)
+	_, _ = fmt.Fprintf(tw, "Run:\t%s\n", run.ID)
+	_, _ = fmt.Fprintf(tw, "Pipeline:\t%s\n", run.Name)
+	_, _ = fmt.Fprintf(tw, "Command:\t%s\n", strings.TrimSpace("olares-cli "+run.Command+" "+run.Args))
+	_, _ = fmt.Fprintf(tw, "Version:\t%s\n", run.Version)
+	_, _ = fmt.Fprintf(tw, "Status:\t%s\n", run.Status)
+	_, _ = fmt.Fprintf(tw, "Started:\t%s\n", run.StartedAt.Local().Format(timeFormat))
+	if run.EndedAt != nil {
+		_, _ = fmt.Fprintf(tw, "Ended:\t%s (%s)\n", run.EndedAt.Local().Format(timeFormat), duration(*run))
+	}
+	if run.Error != "" {
+		_, _ = fmt.Fprintf(tw, "Error:\t%s\n", run.Error)
+	}
+
+	_, _ = fmt.Fprintln(tw)
+	_, _ = fmt.Fprintln(tw, "MODULE\tTASK\tHOST\tSTATUS\tUPDATED")
+	for _, c := range report.Results {
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", c.Module, c.Task, c.Host, c.Status, c.UpdatedAt.Local().Format(timeFormat))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if len(report.Failures) > 0 {
+		_, _ = fmt.Fprintln(w, "\nFailures:")
+		for _, e := range report.Failures {
+			_, _ = fmt.Fprintf(w, "  [%s] %s/%s on %s: %s\n", e.Time.Local().Format(timeFormat), e.Module, e.Task, e.Host, e.Error)
+		}
+	}
+	return nil
+}
+
+func ShowInventory(w io.Writer, provider storage.Provider, asJSON bool) error {
+	var inv Inventory
+	var err error
+	if inv.Nodes, err = provider.QueryNodes(); err != nil {
+		return err
+	}
+	if inv.Components, err = provider.QueryComponentVersions(); err != nil {
+		return err
+	}
+	if asJSON {
+		return printJSON(w, inv)
+	}
+
+	tw := tabwriter.NewWriter(w, 10, 4, 3, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "NODE\tADDRESS\tINTERNAL ADDRESS\tROLES\tARCH\tOS\tUPDATED")
+	for _, n := range inv.Nodes {
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			n.Name, n.Address, n.InternalAddress, n.Roles, n.Arch, n.Os, n.UpdatedAt.Local().Format(timeFormat))
+	}
+	_, _ = fmt.Fprintln(tw)
+	_, _ = fmt.Fprintln(tw, "NODE\tCOMPONENT\tVERSION\tUPDATED")
+	for _, c := range inv.Components {
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", c.Host, c.Component, c.Version, c.UpdatedAt.Local().Format(timeFormat))
+	}
+	return tw.Flush()
+}
+
+func printJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func shortID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
+
+func duration(r model.PipelineRun) string {
+	if r.EndedAt == nil {
+		return "-"
+	}
+	return util.ShortDur(r.EndedAt.Sub(r.StartedAt).Round(time.Second))
+}
@@ -0,0 +1,126 @@
+package history
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"bytetrade.io/web3os/installer/pkg/common"
+	"bytetrade.io/web3os/installer/pkg/core/connector"
+	"bytetrade.io/web3os/installer/pkg/core/journal"
+	"bytetrade.io/web3os/installer/pkg/core/logger"
+	"bytetrade.io/web3os/installer/pkg/model"
+)
+
+var (
+	semverPattern = regexp.MustCompile(`v?\d+\.\d+(\.\d+)?([-+][0-9A-Za-z.+-]+)?`)
+	minioPattern  = regexp.MustCompile(`RELEASE\.[0-9A-Za-z:.-]+`)
+)
+
+// components are probed for their version on every host, the ones not installed on a host are left out
+var components = []struct {
+	name    string
+	binary  string
+	cmd     string
+	pattern *regexp.Regexp
+}{
+	{name: "k3s", binary: "k3s", cmd: "k3s --version", pattern: semverPattern},
+	{name: "kubelet", binary: "kubelet", cmd: "kubelet --version", pattern: semverPattern},
+	{name: "containerd", binary: "containerd", cmd: "containerd --version", pattern: semverPattern},
+	{name: "juicefs", binary: "juicefs", cmd: "juicefs version", pattern: semverPattern},
+	{name: "redis", binary: "redis-server", cmd: "redis-server --version", pattern: semverPattern},
+	{name: "minio", binary: "minio", cmd: "minio --version", pattern: minioPattern},
+	{name: "velero", binary: "velero", cmd: "velero version --client-only", pattern: semverPattern},
+	{name: "olaresd", binary: "olaresd", cmd: "olaresd --version", pattern: semverPattern},
+}
+
+// RecordComponentVersions is best effort, failing to record the inventory does not fail the pipeline
+type RecordComponentVersions struct {
+	common.KubeAction
+}
+
+func (a *RecordComponentVersions) Execute(runtime connector.Runtime) error {
+	provider := runtime.GetStorage()
+	if provider == nil {
+		return nil
+	}
+	host := runtime.RemoteHost().GetName()
+	runID := journal.FromCache(a.PipelineCache).RunID()
+	for _, c := range components {
+		out, err := runtime.GetRunner().SudoCmd(fmt.Sprintf("command -v %s >/dev/null && %s 2>&1", c.binary, c.cmd), false, false)
+		if err != nil {
+			logger.Debugf("%s is not installed on %s", c.name, host)
+			continue
+		}
+		version := c.pattern.FindString(out)
+		if version == "" {
+			logger.Debugf("no version of %s found on %s in: %s", c.name, host, out)
+			continue
+		}
+		if err := provider.SaveComponentVersion(model.ComponentVersion{
+			Host:      host,
+			Component: c.name,
+			Version:   version,
+			RunID:     runID,
+			UpdatedAt: time.Now(),
+		}); err != nil {
+			logger.Warnf("failed to record the version of %s on %s: %v", c.name, host, err)
+		}
+	}
+	return nil
+}
+
+type RecordNodes struct {
+	common.KubeAction
+}
+
+func (a *RecordNodes) Execute(runtime connector.Runtime) error {
+	provider := runtime.GetStorage()
+	if provider == nil {
+		return nil
+	}
+	runID := journal.FromCache(a.PipelineCache).RunID()
+	now := time.Now()
+	for _, host := range runtime.GetAllHosts() {
+		if err := provider.SaveNode(model.Node{
+			Name:            host.GetName(),
+			Address:         host.GetAddress(),
+			InternalAddress: host.GetInternalAddress(),
+			Roles:           strings.Join(host.GetRoles(), ","),
+			Arch:            host.GetArch(),
+			Os:              host.GetOs(),
+			RunID:           runID,
+			CreatedAt:       now,
+			UpdatedAt:       now,
+		}); err != nil {
+			logger.Warnf("failed to record node %s: %v", host.GetName(), err)
+		}
+	}
+	return nil
+}
+
+type ForgetNodes struct {
+	common.KubeAction
+	// defaults to the hosts of the runtime
+	NodeNames []string
+}
+
+func (a *ForgetNodes) Execute(runtime connector.Runtime) error {
+	provider := runtime.GetStorage()
+	if provider == nil {
+		return nil
+	}
+	names := a.NodeNames
+	if len(names) == 0 {
+		for _, host := range runtime.GetAllHosts() {
+			names = append(names, host.GetName())
+		}
+	}
+	for _, name := range names {
+		if err := provider.DeleteNode(name); err != nil {
+			logger.Warnf("failed to remove node %s from the inventory: %v", name, err)
+		}
+	}
+	return nil
+}
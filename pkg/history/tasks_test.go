@@ -0,0 +1,49 @@
+package history
+
+import "testing"
+
+func TestComponentVersionPatterns(t *testing.T) {
+	// the output of the version commands of the components
+	outputs := map[string]struct {
+		output   string
+		expected string
+	}{
+		"k3s": {
+			output:   "k3s version v1.33.3+k3s1 (236cbf25)\ngo version go1.24.4\n",
+			expected: "v1.33.3+k3s1",
+		},
+		"kubelet": {
+			output:   "Kubernetes v1.22.10\n",
+			expected: "v1.22.10",
+		},
+		"containerd": {
+			output:   "containerd github.com/containerd/containerd v1.7.27 05044ec0a9a75232cad458027ca83437aae3f4da\n",
+			expected: "v1.7.27",
+		},
+		"juicefs": {
+			output:   "juicefs version 1.1.2+2024-02-04.8dbd89a\n",
+			expected: "1.1.2+2024-02-04.8dbd89a",
+		},
+		"redis": {
+			output:   "Redis server v=5.0.14 sha=00000000:0 malloc=jemalloc-5.1.0 bits=64 build=5e0ec3a1a2b5d4c5\n",
+			expected: "5.0.14",
+		},
+		"minio": {
+			output:   "minio version RELEASE.2023-05-04T21-44-30Z (commit-id=7e3fb9a6d8b6c2a3d2b4c1f1e7c0e7ac1f1d1e2f)\nRuntime: go1.19.8 linux/amd64\nLicense: GNU AGPLv3 <https://www.gnu.org/licenses/agpl-3.0.html>\n",
+			expected: "RELEASE.2023-05-04T21-44-30Z",
+		},
+		"velero": {
+			output:   "Client:\n\tVersion: v1.11.1\n\tGit commit: bdbe7eb242b0f64d5b04a7fea86d1edbb3a3587c\n",
+			expected: "v1.11.1",
+		},
+	}
+	for _, c := range components {
+		o, ok := outputs[c.name]
+		if !ok {
+			continue
+		}
+		if version := c.pattern.FindString(o.output); version != o.expected {
+			t.Errorf("%s: expected version %q, got %q", c.name, o.expected, version)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package images
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"bytetrade.io/web3os/installer/pkg/common"
+	"bytetrade.io/web3os/installer/pkg/core/connector"
+	"bytetrade.io/web3os/installer/pkg/core/logger"
+	"bytetrade.io/web3os/installer/pkg/manifest"
+	"github.com/containers/image/v5/docker"
+	"github.com/containers/image/v5/docker/archive"
+	"github.com/containers/image/v5/docker/reference"
+	"github.com/containers/image/v5/image"
+	imagemanifest "github.com/containers/image/v5/manifest"
+	"github.com/containers/image/v5/pkg/blobinfocache/none"
+	"github.com/containers/image/v5/types"
+	"github.com/pkg/errors"
+)
+
+// PushImages pushes the images of the manifest from their archives to a registry serving as the mirror of their registries,
+// rather than importing them into containerd, see MirrorPath for where they are pushed to
+type PushImages struct {
+	common.KubeAction
+	manifest.ManifestAction
+	// Registry is the address of the registry
+	Registry string
+	// CertDir holds the CA certificate of the registry, as ca.crt
+	CertDir string
+}
+
+func (a *PushImages) Execute(runtime connector.Runtime) error {
+	items, _ := a.Manifest.GetImageList()
+	sort.Slice(items, func(i, j int) bool { return items[i].ImageName < items[j].ImageName })
+
+	// the pushes stop when the run is interrupted
+	ctx := runtime.GetContext()
+	sys := &types.SystemContext{
+		DockerCertPath:          a.CertDir,
+		DockerRegistryUserAgent: defaultUserAgent,
+		// the layers are compressed as they are pushed, their digest is to be known to skip the ones already pushed
+		DockerRegistryPushPrecomputeDigests: true,
+	}
+	for index, item := range items {
+		var start = time.Now()
+		path, err := MirrorPath(item.ImageName)
+		if err != nil {
+			logger.Warnf("%s is not pushed, it is to be pulled from its registry: %v", item.ImageName, err)
+			continue
+		}
+		dst := a.Registry + "/" + path
+		if imagePushed(ctx, sys, dst) {
+			logger.Infof("%s already pushed", item.ImageName)
+			continue
+		}
+		if err := pushImageArchive(ctx, sys, item.FilePath(a.BaseDir), dst); err != nil {
+			return errors.Wrapf(err, "push image %s to %s failed", item.ImageName, a.Registry)
+		}
+		logger.Infof("(%d/%d) pushed image: %s, time: %s", index+1, len(items), item.ImageName, time.Since(start))
+	}
+	return nil
+}
+
+// MirrorPath returns the repository and the tag an image is pushed to in a registry mirroring that of the image,
+// a mirror being asked for the path of the image alone, e.g., beclab/bfl:v0.3.1 for docker.io/beclab/bfl:v0.3.1,
+// the images referenced by digest can't be mirrored as their manifest is rewritten when they are pushed
+func MirrorPath(image string) (string, error) {
+	named, err := reference.ParseNormalizedNamed(image)
+	if err != nil {
+		return "", err
+	}
+	if _, ok := named.(reference.Digested); ok {
+		return "", fmt.Errorf("%s is referenced by digest", image)
+	}
+	tagged := reference.TagNameOnly(named).(reference.Tagged)
+	return reference.Path(named) + ":" + tagged.Tag(), nil
+}
+
+func imagePushed(ctx context.Context, sys *types.SystemContext, dst string) bool {
+	ref, err := docker.ParseReference("//" + dst)
+	if err != nil {
+		return false
+	}
+	src, err := ref.NewImageSource(ctx, sys)
+	if err != nil {
+		return false
+	}
+	defer src.Close()
+	_, _, err = src.GetManifest(ctx, nil)
+	return err == nil
+}
+
+// pushImageArchive pushes the image of a docker archive, as written by docker save or ctr export, compressed or not,
+// its layers are read uncompressed from the archive and compressed with gzip as they are pushed
+func pushImageArchive(ctx context.Context, sys *types.SystemContext, file, dst string) error {
+	srcRef, err := archive.NewReference(file, nil)
+	if err != nil {
+		return err
+	}
+	dstRef, err := docker.ParseReference("//" + dst)
+	if err != nil {
+		return err
+	}
+	src, err := srcRef.NewImageSource(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dest, err := dstRef.NewImageDestination(ctx, sys)
+	if err != nil {
+		return err
+	}
+	defer dest.Close()
+
+	data, mimeType, err := src.GetManifest(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if mimeType != imagemanifest.DockerV2Schema2MediaType {
+		return fmt.Errorf("unexpected manifest type %s of %s", mimeType, file)
+	}
+	m, err := imagemanifest.Schema2FromManifest(data)
+	if err != nil {
+		return err
+	}
+
+	config, _, err := src.GetBlob(ctx, types.BlobInfo{Digest: m.ConfigDescriptor.Digest, Size: m.ConfigDescriptor.Size}, none.NoCache)
+	if err != nil {
+		return err
+	}
+	_, err = dest.PutBlob(ctx, config, types.BlobInfo{Digest: m.ConfigDescriptor.Digest, Size: m.ConfigDescriptor.Size}, none.NoCache, true)
+	config.Close()
+	if err != nil {
+		return errors.Wrap(err, "push config failed")
+	}
+
+	for i, layer := range m.LayersDescriptors {
+		info, err := pushCompressedLayer(ctx, src, dest, types.BlobInfo{Digest: layer.Digest, Size: layer.Size})
+		if err != nil {
+			return errors.Wrapf(err, "push layer %s failed", layer.Digest)
+		}
+		m.LayersDescriptors[i].Digest = info.Digest
+		m.LayersDescriptors[i].Size = info.Size
+	}
+
+	out, err := m.Serialize()
+	if err != nil {
+		return err
+	}
+	if err := dest.PutManifest(ctx, out, nil); err != nil {
+		return errors.Wrap(err, "push manifest failed")
+	}
+	return dest.Commit(ctx, image.UnparsedInstance(src, nil))
+}
+
+func pushCompressedLayer(ctx context.Context, src types.ImageSource, dest types.ImageDestination, layer types.BlobInfo) (types.BlobInfo, error) {
+	rc, _, err := src.GetBlob(ctx, layer, none.NoCache)
+	if err != nil {
+		return types.BlobInfo{}, err
+	}
+	pr, pw := io.Pipe()
+	go func() {
+		defer rc.Close()
+		gw := gzip.NewWriter(pw)
+		_, err := io.Copy(gw, rc)
+		if err == nil {
+			err = gw.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+	defer pr.Close()
+	return dest.PutBlob(ctx, pr, types.BlobInfo{Size: -1}, none.NoCache, false)
+}
@@ -0,0 +1,19 @@
+package images
+
+import "testing"
+
+func TestMirrorPath(t *testing.T) {
+	for image, want := range map[string]string{
+		"docker.io/beclab/bfl:v0.3.1": "beclab/bfl:v0.3.1",
+		"nginx":                       "library/nginx:latest",
+		"quay.io/x/y:1":               "x/y:1",
+	} {
+		got, err := MirrorPath(image)
+		if err != nil || got != want {
+			t.Errorf("MirrorPath(%s) = %s, %v, want %s", image, got, err, want)
+		}
+	}
+	if _, err := MirrorPath("beclab/bfl@sha256:" + "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"); err == nil {
+		t.Error("MirrorPath of an image referenced by digest, want an error")
+	}
+}
@@ -426,6 +426,22 @@ func (i *InitClusterModule) Init() {
 		addMasterTaint,
 		addWorkerLabel,
 	}
+
+	i.RollbackTasks = []task.Interface{
+		&task.RemoteTask{
+			Name:  "ResetK3sCluster",
+			Desc:  "Stop k3s and remove its configuration",
+			Hosts: i.Runtime.GetHostsByRole(common.Master),
+			// a cluster that existed before the run is kept
+			Prepare: &prepare.PrepareCollection{
+				new(common.OnlyFirstMaster),
+				new(ClusterCreated),
+			},
+			Action:   new(ResetK3sCluster),
+			Parallel: false,
+			Retry:    1,
+		},
+	}
 }
 
 type JoinNodesModule struct {
@@ -464,7 +480,7 @@ func (j *JoinNodesModule) Init() {
 		Hosts: j.Runtime.GetHostsByRole(common.K8s),
 		Prepare: &prepare.PrepareCollection{
 			&kubernetes.NodeInCluster{Not: true},
-			&UsePrivateRegstry{Not: false},
+			new(UseRegistryConfig),
 		},
 		Action:   new(GenerateK3sRegistryConfig),
 		Parallel: true,
@@ -634,3 +650,38 @@ func checkContainerExists(runtime connector.Runtime) bool {
 	}
 	return true
 }
+
+// UpgradeK3sModule replaces the k3s binaries of the local node with those of the manifest and starts k3s again
+type UpgradeK3sModule struct {
+	common.KubeModule
+	manifest.ManifestModule
+}
+
+func (m *UpgradeK3sModule) Init() {
+	m.Name = "UpgradeK3s"
+	m.Desc = "Upgrade k3s"
+
+	syncBinary := &task.LocalTask{
+		Name:    "SyncKubeBinary(k3s)",
+		Prepare: new(kubernetes.NodeNeedsUpgrade),
+		Action: &SyncKubeBinary{
+			ManifestAction: manifest.ManifestAction{
+				BaseDir:  m.BaseDir,
+				Manifest: m.Manifest,
+			},
+		},
+		Retry: 2,
+	}
+
+	start := &task.LocalTask{
+		Name:    "StartK3s",
+		Prepare: new(kubernetes.NodeNeedsUpgrade),
+		Action:  new(kubernetes.StartKubeService),
+		Retry:   3,
+	}
+
+	m.Tasks = []task.Interface{
+		syncBinary,
+		start,
+	}
+}
@@ -41,6 +41,17 @@ func (c *ClusterIsExist) PreCheck(_ connector.Runtime) (bool, error) {
 	}
 }
 
+// ClusterCreated checks that the cluster has been created by the run, by EnableK3sService,
+// rather than having existed before it
+type ClusterCreated struct {
+	common.KubePrepare
+}
+
+func (c *ClusterCreated) PreCheck(_ connector.Runtime) (bool, error) {
+	created, _ := c.PipelineCache.GetMustBool(common.ClusterCreated)
+	return created, nil
+}
+
 type UsePrivateRegstry struct {
 	common.KubePrepare
 	Not bool
@@ -50,6 +61,19 @@ func (c *UsePrivateRegstry) PreCheck(_ connector.Runtime) (bool, error) {
 	return c.KubeConf.Cluster.Registry.PrivateRegistry != "", nil
 }
 
+// UseRegistryConfig tells whether the node pulls the images from a private registry or from the local registry of the master
+type UseRegistryConfig struct {
+	common.KubePrepare
+}
+
+func (c *UseRegistryConfig) PreCheck(_ connector.Runtime) (bool, error) {
+	if c.KubeConf.Cluster.Registry.PrivateRegistry != "" {
+		return true, nil
+	}
+	localRegistry, _ := c.PipelineCache.GetMustString(common.LocalRegistry)
+	return localRegistry != "", nil
+}
+
 type CheckK3sUninstallScript struct {
 	common.KubePrepare
 }
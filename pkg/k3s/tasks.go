@@ -36,6 +36,7 @@ import (
 	kubekeyregistry "bytetrade.io/web3os/installer/pkg/bootstrap/registry"
 	"bytetrade.io/web3os/installer/pkg/common"
 	"bytetrade.io/web3os/installer/pkg/core/action"
+	"bytetrade.io/web3os/installer/pkg/core/cache"
 	"bytetrade.io/web3os/installer/pkg/core/connector"
 	"bytetrade.io/web3os/installer/pkg/core/logger"
 	"bytetrade.io/web3os/installer/pkg/core/util"
@@ -53,6 +54,7 @@ import (
 
 type GetClusterStatus struct {
 	common.KubeAction
+	cache.Filler
 }
 
 func (g *GetClusterStatus) Execute(runtime connector.Runtime) error {
@@ -291,7 +293,7 @@ func (g *GenerateK3sServiceEnv) Execute(runtime connector.Runtime) error {
 	case kubekeyapiv1alpha2.External:
 		externalEtcd.Endpoints = g.KubeConf.Cluster.Etcd.External.Endpoints
 
-		if len(g.KubeConf.Cluster.Etcd.External.CAFile) != 0 && len(g.KubeConf.Cluster.Etcd.External.CAFile) != 0 && len(g.KubeConf.Cluster.Etcd.External.CAFile) != 0 {
+		if len(g.KubeConf.Cluster.Etcd.External.CAFile) != 0 && len(g.KubeConf.Cluster.Etcd.External.CertFile) != 0 && len(g.KubeConf.Cluster.Etcd.External.KeyFile) != 0 {
 			externalEtcd.CAFile = fmt.Sprintf("/etc/ssl/etcd/ssl/%s", filepath.Base(g.KubeConf.Cluster.Etcd.External.CAFile))
 			externalEtcd.CertFile = fmt.Sprintf("/etc/ssl/etcd/ssl/%s", filepath.Base(g.KubeConf.Cluster.Etcd.External.CertFile))
 			externalEtcd.KeyFile = fmt.Sprintf("/etc/ssl/etcd/ssl/%s", filepath.Base(g.KubeConf.Cluster.Etcd.External.KeyFile))
@@ -346,6 +348,7 @@ type EnableK3sService struct {
 }
 
 func (e *EnableK3sService) Execute(runtime connector.Runtime) error {
+	e.PipelineCache.Set(common.ClusterCreated, true)
 	if _, err := runtime.GetRunner().SudoCmd("systemctl daemon-reload && systemctl enable --now k3s",
 		false, false); err != nil {
 		return errors.Wrap(errors.WithStack(err), "enable k3s failed")
@@ -481,6 +484,9 @@ func (a *AddWorkerLabel) Execute(runtime connector.Runtime) error {
 	return nil
 }
 
+// AgentLoadBalancerServer is the local load balancer of the k3s agent in front of the API servers of the masters
+const AgentLoadBalancerServer = "https://127.0.0.1:6444"
+
 type SyncKubeConfigToWorker struct {
 	common.KubeAction
 }
@@ -494,10 +500,10 @@ func (s *SyncKubeConfigToWorker) Execute(runtime connector.Runtime) error {
 			return errors.Wrap(errors.WithStack(err), "create .kube dir failed")
 		}
 
+		// the agent balances the API requests over all the masters, and keeps up with the masters added or deleted,
+		// so that the worker is not tied to the master it joined through
 		oldServer := "server: https://127.0.0.1:6443"
-		newServer := fmt.Sprintf("server: https://%s:%d",
-			s.KubeConf.Cluster.ControlPlaneEndpoint.Domain,
-			s.KubeConf.Cluster.ControlPlaneEndpoint.Port)
+		newServer := fmt.Sprintf("server: %s", AgentLoadBalancerServer)
 		newKubeConfig := strings.Replace(cluster.KubeConfig, oldServer, newServer, -1)
 
 		syncKubeConfigForRootCmd := fmt.Sprintf("echo '%s' > %s", newKubeConfig, "/root/.kube/config")
@@ -559,6 +565,36 @@ func (t *ExecKillAllScript) Execute(runtime connector.Runtime) error {
 	return nil
 }
 
+// ResetK3sCluster reverts InitClusterModule, the k3s service is stopped
+// and its configuration and data are removed, the binaries are kept,
+// it is only run if the cluster has been created by the run, see ClusterCreated
+type ResetK3sCluster struct {
+	common.KubeAction
+}
+
+func (t *ResetK3sCluster) Execute(runtime connector.Runtime) error {
+	if _, err := runtime.GetRunner().SudoCmd("systemctl disable --now k3s || true", false, false); err != nil {
+		return errors.Wrap(errors.WithStack(err), "stop k3s service failed")
+	}
+	killAll := path.Join(common.BinDir, "k3s-killall.sh")
+	exist, err := runtime.GetRunner().FileExist(killAll)
+	if err != nil {
+		return errors.Wrapf(errors.WithStack(err), "check %s failed", killAll)
+	}
+	if exist {
+		if _, err := runtime.GetRunner().SudoCmd(killAll, false, false); err != nil {
+			return errors.Wrap(errors.WithStack(err), "kill k3s processes failed")
+		}
+	}
+	removeCmd := fmt.Sprintf("rm -f %s %s && rm -rf /etc/rancher/k3s /var/lib/rancher/k3s && systemctl daemon-reload",
+		filepath.Join("/etc/systemd/system/", templates.K3sService.Name()),
+		filepath.Join("/etc/systemd/system/", templates.K3sServiceEnv.Name()))
+	if _, err := runtime.GetRunner().SudoCmd(removeCmd, false, false); err != nil {
+		return errors.Wrap(errors.WithStack(err), "remove k3s configuration failed")
+	}
+	return nil
+}
+
 type ExecUninstallScript struct {
 	common.KubeAction
 }
@@ -669,8 +705,18 @@ func (g *GenerateK3sRegistryConfig) Execute(runtime connector.Runtime) error {
 		registryConfigs[g.KubeConf.Cluster.Registry.PrivateRegistry] = registry.RegistryConfig{TLS: &registry.TLSConfig{InsecureSkipVerify: true}}
 	}
 
+	mirrors := map[string]registry.Mirror{"docker.io": dockerioMirror}
+	if localRegistry := g.localRegistry(runtime); localRegistry != "" {
+		// the local registry is tried first for the images of every registry, which are pulled from it when pushed there
+		endpoint := "https://" + localRegistry
+		dockerioMirror.Endpoints = append([]string{endpoint}, dockerioMirror.Endpoints...)
+		mirrors["docker.io"] = dockerioMirror
+		mirrors["*"] = registry.Mirror{Endpoints: []string{endpoint}}
+		registryConfigs[localRegistry] = registry.RegistryConfig{TLS: &registry.TLSConfig{CAFile: kubekeyregistry.LocalRegistryCAFile}}
+	}
+
 	k3sRegistries := registry.Registry{
-		Mirrors: map[string]registry.Mirror{"docker.io": dockerioMirror},
+		Mirrors: mirrors,
 		Configs: registryConfigs,
 	}
 
@@ -690,6 +736,18 @@ func (g *GenerateK3sRegistryConfig) Execute(runtime connector.Runtime) error {
 	return nil
 }
 
+// localRegistry returns the address of the local registry the node pulls the images from:
+// that of the master for a node joining the cluster, see registry.FetchLocalRegistryCA, or that of the node if it serves one
+func (g *GenerateK3sRegistryConfig) localRegistry(runtime connector.Runtime) string {
+	if addr, ok := g.PipelineCache.GetMustString(common.LocalRegistry); ok && addr != "" {
+		return addr
+	}
+	if exist, _ := runtime.GetRunner().FileExist(kubekeyregistry.LocalRegistryConfigFile); exist {
+		return kubekeyregistry.LocalRegistryAddress(runtime.RemoteHost().GetInternalAddress())
+	}
+	return ""
+}
+
 type UninstallK3s struct {
 	common.KubeAction
 }
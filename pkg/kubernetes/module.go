@@ -435,6 +435,71 @@ func (d *DeleteKubeNodeModule) Init() {
 	}
 }
 
+// RemoveWorkerNodeModule drains a worker node and deletes it from the cluster,
+// it runs with the kubeconfig of the local host, which is either the master or a worker,
+// a master other than the last one can be removed too if AllowMaster is set
+type RemoveWorkerNodeModule struct {
+	common.KubeModule
+	NodeName     string
+	DrainTimeout time.Duration
+	AllowMaster  bool
+}
+
+func (r *RemoveWorkerNodeModule) Init() {
+	r.Name = "RemoveWorkerNodeModule"
+	r.Desc = "Remove a worker node from the cluster"
+
+	check := &task.LocalTask{
+		Name:   "CheckWorkerNode",
+		Action: &CheckWorkerNode{NodeName: r.NodeName, AllowMaster: r.AllowMaster},
+	}
+
+	cordon := &task.LocalTask{
+		Name:   "CordonNode",
+		Action: &CordonNode{NodeName: r.NodeName},
+	}
+
+	drain := &task.LocalTask{
+		Name:   "DrainNode",
+		Desc:   "Node safely evict all pods",
+		Action: &DrainNode{Timeout: r.DrainTimeout},
+	}
+
+	deleteNode := &task.LocalTask{
+		Name:   "DeleteNode",
+		Desc:   "Delete the node using kubectl",
+		Action: new(KubectlDeleteNode),
+		Retry:  5,
+	}
+
+	r.Tasks = []task.Interface{
+		check,
+		cordon,
+		drain,
+		deleteNode,
+	}
+}
+
+type CordonNodeModule struct {
+	common.KubeModule
+	NodeName string
+	Uncordon bool
+}
+
+func (c *CordonNodeModule) Init() {
+	c.Name = "CordonNodeModule"
+	c.Desc = "Mark a node as unschedulable, or schedulable again"
+
+	cordon := &task.LocalTask{
+		Name:   "CordonNode",
+		Action: &CordonNode{NodeName: c.NodeName, Uncordon: c.Uncordon},
+	}
+
+	c.Tasks = []task.Interface{
+		cordon,
+	}
+}
+
 type SetUpgradePlanModule struct {
 	common.KubeModule
 	Step UpgradeStep
@@ -541,3 +606,147 @@ func (s *SecurityEnhancementModule) Init() {
 		nodesSecurityEnhancement,
 	}
 }
+
+// PrepareNodeUpgradeModule checks that the local node can be upgraded to the target version,
+// then upgrades the control plane or the kubelet configuration with kubeadm,
+// and evicts the pods of the node before its Kubernetes service is stopped for its binaries to be replaced
+type PrepareNodeUpgradeModule struct {
+	common.KubeModule
+	manifest.ManifestModule
+	NodeName      string
+	TargetVersion string
+	DrainTimeout  time.Duration
+}
+
+func (p *PrepareNodeUpgradeModule) Init() {
+	p.Name = "PrepareNodeUpgrade"
+	p.Desc = "Prepare the node for the upgrade of Kubernetes"
+
+	check := &task.LocalTask{
+		Name: "CheckNodeUpgrade",
+		Action: &CheckNodeUpgrade{
+			ManifestAction: manifest.ManifestAction{
+				BaseDir:  p.BaseDir,
+				Manifest: p.Manifest,
+			},
+			NodeName:      p.NodeName,
+			TargetVersion: p.TargetVersion,
+		},
+	}
+
+	cordon := &task.LocalTask{
+		Name:    "CordonNode",
+		Prepare: new(NodeNeedsUpgrade),
+		Action:  &CordonNode{NodeName: p.NodeName},
+	}
+
+	drain := &task.LocalTask{
+		Name: "DrainNode",
+		Desc: "Node safely evict all pods",
+		Prepare: &prepare.PrepareCollection{
+			new(NodeNeedsUpgrade),
+			new(MultiNodes),
+		},
+		Action: &DrainNode{NodeName: p.NodeName, Timeout: p.DrainTimeout},
+	}
+
+	stop := &task.LocalTask{
+		Name:    "StopKubeService",
+		Prepare: new(NodeNeedsUpgrade),
+		Action:  new(StopKubeService),
+	}
+
+	p.Tasks = []task.Interface{check}
+	if p.KubeConf.Cluster.Kubernetes.Type != common.K3s {
+		p.Tasks = append(p.Tasks,
+			&task.LocalTask{
+				Name:    "SyncKubeadm",
+				Prepare: new(NodeNeedsUpgrade),
+				Action: &SyncKubeBinary{
+					ManifestAction: manifest.ManifestAction{
+						BaseDir:  p.BaseDir,
+						Manifest: p.Manifest,
+					},
+					Binaries: []string{"kubeadm"},
+				},
+				Retry: 2,
+			},
+			&task.LocalTask{
+				Name:    "KubeadmUpgradeNode",
+				Prepare: new(NodeNeedsUpgrade),
+				Action:  &KubeadmUpgradeNode{NodeName: p.NodeName, TargetVersion: p.TargetVersion},
+				Retry:   3,
+				Delay:   30 * time.Second,
+			},
+		)
+	}
+	p.Tasks = append(p.Tasks, cordon, drain, stop)
+}
+
+// UpgradeKubeletModule replaces the binaries of kubeadm clusters but kubeadm's and starts kubelet again
+type UpgradeKubeletModule struct {
+	common.KubeModule
+	manifest.ManifestModule
+}
+
+func (u *UpgradeKubeletModule) Init() {
+	u.Name = "UpgradeKubelet"
+	u.Desc = "Upgrade kubelet"
+
+	syncBinary := &task.LocalTask{
+		Name:    "SyncKubeBinary",
+		Prepare: new(NodeNeedsUpgrade),
+		Action: &SyncKubeBinary{
+			ManifestAction: manifest.ManifestAction{
+				BaseDir:  u.BaseDir,
+				Manifest: u.Manifest,
+			},
+			Binaries: []string{"kubelet", "kubectl", "helm", "cni-plugins"},
+		},
+		Retry: 2,
+	}
+
+	start := &task.LocalTask{
+		Name:    "StartKubelet",
+		Prepare: new(NodeNeedsUpgrade),
+		Action:  new(StartKubeService),
+		Retry:   3,
+	}
+
+	u.Tasks = []task.Interface{
+		syncBinary,
+		start,
+	}
+}
+
+// FinishNodeUpgradeModule waits for the upgraded node to be Ready at the target version and makes it schedulable again
+type FinishNodeUpgradeModule struct {
+	common.KubeModule
+	NodeName      string
+	TargetVersion string
+}
+
+func (f *FinishNodeUpgradeModule) Init() {
+	f.Name = "FinishNodeUpgrade"
+	f.Desc = "Wait for the upgraded node to be ready"
+
+	wait := &task.LocalTask{
+		Name:    "WaitNodeUpgraded",
+		Prepare: new(NodeNeedsUpgrade),
+		Action:  &WaitNodeUpgraded{NodeName: f.NodeName, TargetVersion: f.TargetVersion},
+		Retry:   60,
+		Delay:   10 * time.Second,
+	}
+
+	uncordon := &task.LocalTask{
+		Name:    "UncordonNode",
+		Prepare: new(NodeNeedsUpgrade),
+		Action:  &CordonNode{NodeName: f.NodeName, Uncordon: true},
+		Retry:   3,
+	}
+
+	f.Tasks = []task.Interface{
+		wait,
+		uncordon,
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"fmt"
 
 	"bytetrade.io/web3os/installer/pkg/common"
+	"bytetrade.io/web3os/installer/pkg/core/cache"
 	"bytetrade.io/web3os/installer/pkg/core/connector"
 	"bytetrade.io/web3os/installer/pkg/core/logger"
 	"bytetrade.io/web3os/installer/pkg/core/util"
@@ -149,6 +150,7 @@ func (n *NotEqualDesiredVersion) PreCheck(runtime connector.Runtime) (bool, erro
 
 type GetKubeletVersion struct {
 	common.KubePrepare
+	cache.Filler
 	CommandDelete bool
 }
 
@@ -172,3 +174,30 @@ func (p *CheckKubeadmExist) PreCheck(runtime connector.Runtime) (bool, error) {
 	}
 	return false, nil
 }
+
+// NodeNeedsUpgrade checks whether CheckNodeUpgrade found the node needs to be upgraded,
+// it does if the check did not run on the host, the tasks of the upgrade being safe to run again
+type NodeNeedsUpgrade struct {
+	common.KubePrepare
+}
+
+func (p *NodeNeedsUpgrade) PreCheck(runtime connector.Runtime) (bool, error) {
+	needed, ok := p.PipelineCache.GetMustBool(common.CacheNodeUpgradeNeeded)
+	if !ok {
+		return true, nil
+	}
+	return needed, nil
+}
+
+// MultiNodes checks that the cluster has more than one node, there is nowhere to evict the pods to otherwise
+type MultiNodes struct {
+	common.KubePrepare
+}
+
+func (p *MultiNodes) PreCheck(runtime connector.Runtime) (bool, error) {
+	nodes, err := getNodeVersions(runtime)
+	if err != nil {
+		return false, err
+	}
+	return len(nodes) > 1, nil
+}
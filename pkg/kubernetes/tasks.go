@@ -20,10 +20,12 @@ import (
 	"bufio"
 	"context"
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 	"sort"
 	"strings"
 	"time"
@@ -45,6 +47,7 @@ import (
 	kubekeyv1alpha2 "bytetrade.io/web3os/installer/apis/kubekey/v1alpha2"
 	"bytetrade.io/web3os/installer/pkg/common"
 	"bytetrade.io/web3os/installer/pkg/core/action"
+	"bytetrade.io/web3os/installer/pkg/core/cache"
 	"bytetrade.io/web3os/installer/pkg/core/connector"
 	"bytetrade.io/web3os/installer/pkg/core/logger"
 	"bytetrade.io/web3os/installer/pkg/core/prepare"
@@ -109,6 +112,7 @@ func (g *GetKubeVersion) Execute() (string, string, error) {
 
 type GetClusterStatus struct {
 	common.KubeAction
+	cache.Filler
 }
 
 func (g *GetClusterStatus) Execute(runtime connector.Runtime) error {
@@ -154,6 +158,8 @@ func (g *GetClusterStatus) Execute(runtime connector.Runtime) error {
 type SyncKubeBinary struct {
 	common.KubeAction
 	manifest.ManifestAction
+	// Binaries are the ones to sync, all of them if empty
+	Binaries []string
 }
 
 func (i *SyncKubeBinary) Execute(runtime connector.Runtime) error {
@@ -161,7 +167,10 @@ func (i *SyncKubeBinary) Execute(runtime connector.Runtime) error {
 		return err
 	}
 
-	binaryList := []string{"kubeadm", "kubelet", "kubectl", "helm", "cni-plugins"}
+	binaryList := i.Binaries
+	if len(binaryList) == 0 {
+		binaryList = []string{"kubeadm", "kubelet", "kubectl", "helm", "cni-plugins"}
+	}
 	for _, name := range binaryList {
 		binary, err := i.Manifest.Get(name)
 		if err != nil {
@@ -312,7 +321,7 @@ func (g *GenerateKubeadmConfig) Execute(runtime connector.Runtime) error {
 		case kubekeyv1alpha2.External:
 			externalEtcd.Endpoints = g.KubeConf.Cluster.Etcd.External.Endpoints
 
-			if len(g.KubeConf.Cluster.Etcd.External.CAFile) != 0 && len(g.KubeConf.Cluster.Etcd.External.CAFile) != 0 && len(g.KubeConf.Cluster.Etcd.External.CAFile) != 0 {
+			if len(g.KubeConf.Cluster.Etcd.External.CAFile) != 0 && len(g.KubeConf.Cluster.Etcd.External.CertFile) != 0 && len(g.KubeConf.Cluster.Etcd.External.KeyFile) != 0 {
 				externalEtcd.CAFile = fmt.Sprintf("/etc/ssl/etcd/ssl/%s", filepath.Base(g.KubeConf.Cluster.Etcd.External.CAFile))
 				externalEtcd.CertFile = fmt.Sprintf("/etc/ssl/etcd/ssl/%s", filepath.Base(g.KubeConf.Cluster.Etcd.External.CertFile))
 				externalEtcd.KeyFile = fmt.Sprintf("/etc/ssl/etcd/ssl/%s", filepath.Base(g.KubeConf.Cluster.Etcd.External.KeyFile))
@@ -639,6 +648,7 @@ func (u *UmountKubelet) Execute(runtime connector.Runtime) error {
 
 type FindNode struct {
 	common.KubeAction
+	cache.Filler
 }
 
 func (f *FindNode) Execute(runtime connector.Runtime) error {
@@ -680,17 +690,84 @@ func (f *FindNode) Execute(runtime connector.Runtime) error {
 	return nil
 }
 
+// CheckWorkerNode makes sure that the node exists and is not a master,
+// or, if AllowMaster is set, not the last master,
+// before it is set as the node to drain and delete
+type CheckWorkerNode struct {
+	common.KubeAction
+	cache.Filler
+	NodeName    string
+	AllowMaster bool
+}
+
+func (c *CheckWorkerNode) Execute(runtime connector.Runtime) error {
+	output, err := runtime.GetRunner().SudoCmd(fmt.Sprintf("/usr/local/bin/kubectl get node %s -o json", c.NodeName), false, false)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get node %s", c.NodeName)
+	}
+	node := &corev1.Node{}
+	if err := json.Unmarshal([]byte(output), node); err != nil {
+		return errors.Wrapf(err, "failed to parse node %s", c.NodeName)
+	}
+	for _, role := range []string{"node-role.kubernetes.io/master", "node-role.kubernetes.io/control-plane"} {
+		if _, ok := node.Labels[role]; !ok {
+			continue
+		}
+		if !c.AllowMaster {
+			return fmt.Errorf("node %s is a master, only worker nodes can be deleted", c.NodeName)
+		}
+		masters, err := runtime.GetRunner().SudoCmd(fmt.Sprintf("/usr/local/bin/kubectl get node -l %s -o name", role), false, false)
+		if err != nil {
+			return errors.Wrap(err, "failed to get the master nodes")
+		}
+		if len(strings.Fields(masters)) <= 1 {
+			return fmt.Errorf("node %s is the last master, it can not be deleted", c.NodeName)
+		}
+		break
+	}
+	c.PipelineCache.Set("dstNode", c.NodeName)
+	return nil
+}
+
+type CordonNode struct {
+	common.KubeAction
+	NodeName string
+	Uncordon bool
+}
+
+func (c *CordonNode) Execute(runtime connector.Runtime) error {
+	verb := "cordon"
+	if c.Uncordon {
+		verb = "uncordon"
+	}
+	if _, err := runtime.GetRunner().SudoCmd(fmt.Sprintf("/usr/local/bin/kubectl %s %s", verb, c.NodeName), true, false); err != nil {
+		return errors.Wrapf(err, "%s the node failed", verb)
+	}
+	return nil
+}
+
 type DrainNode struct {
 	common.KubeAction
+	// NodeName defaults to the node set to the pipeline cache by the tasks checking it
+	NodeName string
+	// defaults to 2 minutes
+	Timeout time.Duration
 }
 
 func (d *DrainNode) Execute(runtime connector.Runtime) error {
-	nodeName, ok := d.PipelineCache.Get("dstNode")
-	if !ok {
-		return errors.New("get dstNode failed by pipeline cache")
+	var nodeName interface{} = d.NodeName
+	if d.NodeName == "" {
+		var ok bool
+		if nodeName, ok = d.PipelineCache.Get("dstNode"); !ok {
+			return errors.New("get dstNode failed by pipeline cache")
+		}
+	}
+	timeout := d.Timeout
+	if timeout <= 0 {
+		timeout = 2 * time.Minute
 	}
 	if _, err := runtime.GetRunner().SudoCmd(fmt.Sprintf(
-		"/usr/local/bin/kubectl drain %s --delete-emptydir-data --ignore-daemonsets --timeout=2m --force", nodeName),
+		"/usr/local/bin/kubectl drain %s --delete-emptydir-data --ignore-daemonsets --timeout=%s --force", nodeName, timeout),
 		true, false); err != nil {
 		return errors.Wrap(err, "drain the node failed")
 	}
@@ -714,8 +791,9 @@ func (k *KubectlDeleteCurrentWorkerNode) Execute(runtime connector.Runtime) erro
 		return nil
 	}
 	nodeName := runtime.GetSystemInfo().GetHostname()
+	// the node may have been deleted already by olares-cli node delete
 	if _, _, err := util.Exec(context.Background(), fmt.Sprintf(
-		"%s delete node %s", kubectl, nodeName),
+		"%s delete node %s --ignore-not-found", kubectl, nodeName),
 		true, false); err != nil {
 		if k.FailOnError {
 			return err
@@ -744,6 +822,7 @@ func (k *KubectlDeleteNode) Execute(runtime connector.Runtime) error {
 
 type SetUpgradePlan struct {
 	common.KubeAction
+	cache.Filler
 	Step UpgradeStep
 }
 
@@ -1010,6 +1089,7 @@ func SyncKubeConfigTask(runtime connector.Runtime, kubeAction common.KubeAction)
 
 type SetCurrentK8sVersion struct {
 	common.KubeAction
+	cache.Filler
 }
 
 func (s *SetCurrentK8sVersion) Execute(_ connector.Runtime) error {
@@ -1264,3 +1344,239 @@ func (n *NodesSecurityEnhancemenAction) Execute(runtime connector.Runtime) error
 
 	return nil
 }
+
+// NodeVersion is the Kubernetes version of a node, as reported by its kubelet
+type NodeVersion struct {
+	Name    string
+	Master  bool
+	Ready   bool
+	Version *versionutil.Version
+}
+
+func getNodeVersions(runtime connector.Runtime) ([]NodeVersion, error) {
+	output, err := runtime.GetRunner().SudoCmd("/usr/local/bin/kubectl get node -o json", false, false)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get the nodes")
+	}
+	nodeList := &corev1.NodeList{}
+	if err := json.Unmarshal([]byte(output), nodeList); err != nil {
+		return nil, errors.Wrap(err, "failed to parse the nodes")
+	}
+	var nodes []NodeVersion
+	for _, node := range nodeList.Items {
+		version, err := versionutil.ParseSemantic(node.Status.NodeInfo.KubeletVersion)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse the version of node %s", node.Name)
+		}
+		n := NodeVersion{Name: node.Name, Version: version}
+		for _, role := range []string{"node-role.kubernetes.io/master", "node-role.kubernetes.io/control-plane"} {
+			if _, ok := node.Labels[role]; ok {
+				n.Master = true
+			}
+		}
+		for _, c := range node.Status.Conditions {
+			if c.Type == corev1.NodeReady {
+				n.Ready = c.Status == corev1.ConditionTrue
+			}
+		}
+		nodes = append(nodes, n)
+	}
+	return nodes, nil
+}
+
+// CheckVersionSkew checks that a node can be upgraded to the target version given the versions of all the nodes:
+// the masters are upgraded before the workers, one minor version at a time,
+// the kubelets are not left more than two minor versions behind the control plane and no node is downgraded,
+// it returns false if the node is already at the target version
+func CheckVersionSkew(nodeName string, nodes []NodeVersion, target *versionutil.Version) (bool, error) {
+	var node *NodeVersion
+	var oldestMaster *versionutil.Version
+	for i, n := range nodes {
+		if n.Name == nodeName {
+			node = &nodes[i]
+		}
+		if n.Master && (oldestMaster == nil || n.Version.LessThan(oldestMaster)) {
+			oldestMaster = n.Version
+		}
+	}
+	if node == nil {
+		return false, errors.Errorf("node %s is not found in the cluster", nodeName)
+	}
+	if cmp, _ := node.Version.Compare(target.String()); cmp > 0 {
+		return false, errors.Errorf("node %s is at v%s, it can not be downgraded to v%s", nodeName, node.Version, target)
+	} else if cmp == 0 {
+		return false, nil
+	}
+	if target.Major() != node.Version.Major() {
+		return false, errors.Errorf("upgrading from v%s to v%s is not supported", node.Version, target)
+	}
+
+	if !node.Master {
+		for _, n := range nodes {
+			if n.Master && n.Version.LessThan(target) {
+				return false, errors.Errorf("master %s is at v%s, the masters are to be upgraded to v%s before the workers", n.Name, n.Version, target)
+			}
+		}
+		return true, nil
+	}
+
+	if oldestMaster != nil && target.Minor() > oldestMaster.Minor()+1 {
+		return false, errors.Errorf("the masters are at v%d.%d, upgrade them to v%d.%d first, one minor version at a time",
+			oldestMaster.Major(), oldestMaster.Minor(), oldestMaster.Major(), oldestMaster.Minor()+1)
+	}
+	for _, n := range nodes {
+		if !n.Master && target.Minor() > n.Version.Minor()+2 {
+			return false, errors.Errorf("worker %s is at v%s, more than two minor versions behind v%s, upgrade it first", n.Name, n.Version, target)
+		}
+	}
+	return true, nil
+}
+
+// kubeBinaryVersionPattern finds the version of a Kubernetes binary in its file name
+var kubeBinaryVersionPattern = regexp.MustCompile(`v?\d+\.\d+\.\d+`)
+
+// CheckNodeUpgrade checks that the local node can be upgraded to the target version with the binaries of the manifest,
+// see CheckVersionSkew, and sets to the pipeline cache whether it still needs to be
+type CheckNodeUpgrade struct {
+	common.KubeAction
+	cache.Filler
+	manifest.ManifestAction
+	NodeName      string
+	TargetVersion string
+}
+
+func (c *CheckNodeUpgrade) Execute(runtime connector.Runtime) error {
+	target, err := versionutil.ParseSemantic(c.TargetVersion)
+	if err != nil {
+		return errors.Wrapf(err, "invalid Kubernetes version %s", c.TargetVersion)
+	}
+
+	name := "kubelet"
+	if c.KubeConf.Cluster.Kubernetes.Type == common.K3s {
+		name = common.K3s
+	}
+	binary, err := c.Manifest.Get(name)
+	if err != nil {
+		return err
+	}
+	if v := kubeBinaryVersionPattern.FindString(binary.Filename); v == "" {
+		logger.Warnf("no version found in the file name %s, make sure it is of %s v%s", binary.Filename, name, target)
+	} else if cmp, err := target.Compare(v); err != nil || cmp != 0 {
+		return errors.Errorf("the installation package provides %s %s, not v%s", name, v, target)
+	}
+
+	nodes, err := getNodeVersions(runtime)
+	if err != nil {
+		return err
+	}
+	needed, err := CheckVersionSkew(c.NodeName, nodes, target)
+	if err != nil {
+		return err
+	}
+	if !needed {
+		logger.Infof("node %s is already at v%s", c.NodeName, target)
+	}
+	c.PipelineCache.Set(common.CacheNodeUpgradeNeeded, needed)
+	return nil
+}
+
+// KubeadmUpgradeNode upgrades the control plane with kubeadm if the local node is the first master upgraded,
+// or the local kubelet configuration otherwise, the kubeadm binary of the target version is to be installed
+type KubeadmUpgradeNode struct {
+	common.KubeAction
+	NodeName      string
+	TargetVersion string
+}
+
+func (k *KubeadmUpgradeNode) Execute(runtime connector.Runtime) error {
+	target, err := versionutil.ParseSemantic(k.TargetVersion)
+	if err != nil {
+		return errors.Wrapf(err, "invalid Kubernetes version %s", k.TargetVersion)
+	}
+	nodes, err := getNodeVersions(runtime)
+	if err != nil {
+		return err
+	}
+	apply := false
+	for _, n := range nodes {
+		if n.Name == k.NodeName {
+			apply = n.Master
+			break
+		}
+	}
+	for _, n := range nodes {
+		if n.Master && n.Name != k.NodeName && !n.Version.LessThan(target) {
+			apply = false
+		}
+	}
+
+	cmd := "/usr/local/bin/kubeadm upgrade node"
+	if apply {
+		cmd = fmt.Sprintf("timeout -k 600s 600s /usr/local/bin/kubeadm upgrade apply v%s -y "+
+			"--ignore-preflight-errors=all "+
+			"--etcd-upgrade=false "+
+			"--certificate-renewal=true", target)
+	}
+	if _, err := runtime.GetRunner().SudoCmd(cmd, true, false); err != nil {
+		return errors.Wrap(errors.WithStack(err), "upgrade node using kubeadm failed")
+	}
+	return nil
+}
+
+// StopKubeService stops k3s or kubelet on the local node, the containers keep running
+type StopKubeService struct {
+	common.KubeAction
+}
+
+func (s *StopKubeService) Execute(runtime connector.Runtime) error {
+	service := "kubelet"
+	if s.KubeConf.Cluster.Kubernetes.Type == common.K3s {
+		service = common.K3s
+	}
+	if _, err := runtime.GetRunner().SudoCmd("systemctl stop "+service, false, false); err != nil {
+		return errors.Wrapf(errors.WithStack(err), "stop %s failed", service)
+	}
+	return nil
+}
+
+type StartKubeService struct {
+	common.KubeAction
+}
+
+func (s *StartKubeService) Execute(runtime connector.Runtime) error {
+	service := "kubelet"
+	if s.KubeConf.Cluster.Kubernetes.Type == common.K3s {
+		service = common.K3s
+	}
+	if _, err := runtime.GetRunner().SudoCmd("systemctl daemon-reload && systemctl start "+service, false, false); err != nil {
+		return errors.Wrapf(errors.WithStack(err), "start %s failed", service)
+	}
+	return nil
+}
+
+// WaitNodeUpgraded waits for the node to be Ready at the target version
+type WaitNodeUpgraded struct {
+	common.KubeAction
+	NodeName      string
+	TargetVersion string
+}
+
+func (w *WaitNodeUpgraded) Execute(runtime connector.Runtime) error {
+	nodes, err := getNodeVersions(runtime)
+	if err != nil {
+		return err
+	}
+	for _, n := range nodes {
+		if n.Name != w.NodeName {
+			continue
+		}
+		if cmp, err := n.Version.Compare(w.TargetVersion); err != nil || cmp != 0 {
+			return errors.Errorf("node %s is still at v%s", n.Name, n.Version)
+		}
+		if !n.Ready {
+			return errors.Errorf("node %s is not ready yet", n.Name)
+		}
+		return nil
+	}
+	return errors.Errorf("node %s is not found in the cluster", w.NodeName)
+}
@@ -0,0 +1,90 @@
+package kubernetes
+
+import (
+	"strings"
+	"testing"
+
+	versionutil "k8s.io/apimachinery/pkg/util/version"
+)
+
+func TestCheckVersionSkew(t *testing.T) {
+	v := versionutil.MustParseSemantic
+	tests := []struct {
+		name    string
+		node    string
+		nodes   []NodeVersion
+		target  string
+		needed  bool
+		errPart string
+	}{
+		{
+			name:   "first master",
+			node:   "m1",
+			nodes:  []NodeVersion{{Name: "m1", Master: true, Version: v("v1.32.5")}, {Name: "w1", Version: v("v1.32.5")}},
+			target: "v1.33.3",
+			needed: true,
+		},
+		{
+			name:   "already upgraded",
+			node:   "m1",
+			nodes:  []NodeVersion{{Name: "m1", Master: true, Version: v("v1.33.3+k3s1")}},
+			target: "v1.33.3",
+		},
+		{
+			name:    "minor skipped",
+			node:    "m1",
+			nodes:   []NodeVersion{{Name: "m1", Master: true, Version: v("v1.31.0")}},
+			target:  "v1.33.3",
+			errPart: "one minor version at a time",
+		},
+		{
+			name:    "downgrade",
+			node:    "m1",
+			nodes:   []NodeVersion{{Name: "m1", Master: true, Version: v("v1.33.3")}},
+			target:  "v1.32.5",
+			errPart: "can not be downgraded",
+		},
+		{
+			name:    "worker before masters",
+			node:    "w1",
+			nodes:   []NodeVersion{{Name: "m1", Master: true, Version: v("v1.32.5")}, {Name: "w1", Version: v("v1.32.5")}},
+			target:  "v1.33.3",
+			errPart: "before the workers",
+		},
+		{
+			name:   "worker after masters",
+			node:   "w1",
+			nodes:  []NodeVersion{{Name: "m1", Master: true, Version: v("v1.33.3")}, {Name: "w1", Version: v("v1.32.5")}},
+			target: "v1.33.3",
+			needed: true,
+		},
+		{
+			name:    "worker left behind",
+			node:    "m1",
+			nodes:   []NodeVersion{{Name: "m1", Master: true, Version: v("v1.32.5")}, {Name: "w1", Version: v("v1.30.2")}},
+			target:  "v1.33.3",
+			errPart: "worker w1",
+		},
+		{
+			name:    "unknown node",
+			node:    "x",
+			nodes:   []NodeVersion{{Name: "m1", Master: true, Version: v("v1.32.5")}},
+			target:  "v1.33.3",
+			errPart: "not found",
+		},
+	}
+	for _, tt := range tests {
+		needed, err := CheckVersionSkew(tt.node, tt.nodes, v(tt.target))
+		if tt.errPart != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.errPart) {
+				t.Errorf("%s: error = %v, want it to mention %q", tt.name, err, tt.errPart)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+		} else if needed != tt.needed {
+			t.Errorf("%s: needed = %v, want %v", tt.name, needed, tt.needed)
+		}
+	}
+}
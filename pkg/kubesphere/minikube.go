@@ -13,6 +13,7 @@ import (
 
 	kubekeyapiv1alpha2 "bytetrade.io/web3os/installer/apis/kubekey/v1alpha2"
 	"bytetrade.io/web3os/installer/pkg/common"
+	"bytetrade.io/web3os/installer/pkg/core/cache"
 	"bytetrade.io/web3os/installer/pkg/core/connector"
 	"bytetrade.io/web3os/installer/pkg/core/logger"
 	"bytetrade.io/web3os/installer/pkg/core/task"
@@ -90,6 +91,7 @@ func (t *RetagMinikubeKubeImages) Execute(runtime connector.Runtime) error {
 
 type GetMiniKubeContainerdConfig struct {
 	common.KubeAction
+	cache.Filler
 }
 
 func (t *GetMiniKubeContainerdConfig) Execute(runtime connector.Runtime) error {
@@ -359,6 +361,7 @@ func (m *DownloadMinikubeBinaries) Init() {
 
 type GetMinikubeProfile struct {
 	common.KubeAction
+	cache.Filler
 }
 
 func (t *GetMinikubeProfile) Execute(runtime connector.Runtime) error {
@@ -456,6 +459,7 @@ func (t *InitMinikubeNs) Execute(runtime connector.Runtime) error {
 
 type CheckMacCommandExists struct {
 	common.KubeAction
+	cache.Filler
 }
 
 func (t *CheckMacCommandExists) Execute(runtime connector.Runtime) error {
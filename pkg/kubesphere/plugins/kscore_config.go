@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"bytetrade.io/web3os/installer/pkg/common"
+	"bytetrade.io/web3os/installer/pkg/core/cache"
 	cc "bytetrade.io/web3os/installer/pkg/core/common"
 	"bytetrade.io/web3os/installer/pkg/core/connector"
 	"bytetrade.io/web3os/installer/pkg/core/logger"
@@ -313,6 +314,7 @@ func (t *PacthKsCore) Execute(runtime connector.Runtime) error {
 
 type CheckKsCoreExist struct {
 	common.KubeAction
+	cache.Filler
 }
 
 func (t *CheckKsCoreExist) Execute(runtime connector.Runtime) error {
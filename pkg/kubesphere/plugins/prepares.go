@@ -22,6 +22,7 @@ import (
 	"strings"
 
 	"bytetrade.io/web3os/installer/pkg/common"
+	"bytetrade.io/web3os/installer/pkg/core/cache"
 	"bytetrade.io/web3os/installer/pkg/core/connector"
 	"bytetrade.io/web3os/installer/pkg/utils"
 	"github.com/pkg/errors"
@@ -83,6 +84,7 @@ func (p *CheckStorageClass) PreCheck(runtime connector.Runtime) (bool, error) {
 
 type GenerateRedisPassword struct {
 	common.KubePrepare
+	cache.Filler
 }
 
 func (p *GenerateRedisPassword) PreCheck(runtime connector.Runtime) (bool, error) {
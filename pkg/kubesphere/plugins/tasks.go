@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"bytetrade.io/web3os/installer/pkg/common"
+	"bytetrade.io/web3os/installer/pkg/core/cache"
 	cc "bytetrade.io/web3os/installer/pkg/core/common"
 	"bytetrade.io/web3os/installer/pkg/core/connector"
 	"bytetrade.io/web3os/installer/pkg/core/logger"
@@ -24,6 +25,7 @@ func (t *CopyEmbedFiles) Execute(runtime connector.Runtime) error {
 
 type CheckNodeState struct {
 	common.KubeAction
+	cache.Filler
 }
 
 func (t *CheckNodeState) Execute(runtime connector.Runtime) error {
@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"bytetrade.io/web3os/installer/pkg/common"
+	"bytetrade.io/web3os/installer/pkg/core/cache"
 	"bytetrade.io/web3os/installer/pkg/core/connector"
 	"bytetrade.io/web3os/installer/pkg/core/logger"
 	"bytetrade.io/web3os/installer/pkg/core/prepare"
@@ -17,6 +18,7 @@ import (
 
 type GenerateKubeSphereToken struct {
 	common.KubeAction
+	cache.Filler
 }
 
 func (t *GenerateKubeSphereToken) Execute(runtime connector.Runtime) error {
@@ -19,6 +19,7 @@ package kubesphere
 import (
 	kubekeyapiv1alpha2 "bytetrade.io/web3os/installer/apis/kubekey/v1alpha2"
 	"bytetrade.io/web3os/installer/pkg/common"
+	"bytetrade.io/web3os/installer/pkg/core/cache"
 	"bytetrade.io/web3os/installer/pkg/core/connector"
 	"bytetrade.io/web3os/installer/pkg/core/logger"
 	"bytetrade.io/web3os/installer/pkg/core/util"
@@ -347,6 +348,7 @@ func (a *Apply) Execute(runtime connector.Runtime) error {
 
 type GetKubeCommand struct {
 	common.KubeAction
+	cache.Filler
 }
 
 func (t *GetKubeCommand) Execute(runtime connector.Runtime) error {
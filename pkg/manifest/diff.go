@@ -0,0 +1,87 @@
+package manifest
+
+import (
+	"fmt"
+	"sort"
+)
+
+const (
+	ChangeAdded    = "added"
+	ChangeRemoved  = "removed"
+	ChangeModified = "modified"
+)
+
+// Change is the difference between two manifests for one file ID
+type Change struct {
+	FileID string   `json:"fileId"`
+	Kind   string   `json:"kind"`
+	Fields []string `json:"fields,omitempty"`
+}
+
+func (c Change) String() string {
+	switch c.Kind {
+	case ChangeAdded:
+		return "+ " + c.FileID
+	case ChangeRemoved:
+		return "- " + c.FileID
+	}
+	s := "~ " + c.FileID
+	for _, f := range c.Fields {
+		s += "\n    " + f
+	}
+	return s
+}
+
+// Diff compares two manifests by file ID, the changes are sorted by file ID
+func Diff(old, new InstallationManifest) []Change {
+	var changes []Change
+	for id, o := range old {
+		n, ok := new[id]
+		if !ok {
+			changes = append(changes, Change{FileID: id, Kind: ChangeRemoved})
+			continue
+		}
+		if fields := diffItem(o, n); len(fields) > 0 {
+			changes = append(changes, Change{FileID: id, Kind: ChangeModified, Fields: fields})
+		}
+	}
+	for id := range new {
+		if _, ok := old[id]; !ok {
+			changes = append(changes, Change{FileID: id, Kind: ChangeAdded})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].FileID < changes[j].FileID })
+	return changes
+}
+
+func diffItem(o, n *ManifestItem) []string {
+	var fields []string
+	field := func(name, ov, nv string) {
+		if ov != nv {
+			fields = append(fields, fmt.Sprintf("%s: %q -> %q", name, ov, nv))
+		}
+	}
+	field("filename", o.Filename, n.Filename)
+	field("path", o.Path, n.Path)
+	field("type", o.Type, n.Type)
+	field("imageName", o.ImageName, n.ImageName)
+
+	oa, na := o.Arches(), n.Arches()
+	all := make(map[string]fileUrl)
+	for arch, f := range oa {
+		all[arch] = f
+	}
+	for arch, f := range na {
+		all[arch] = f
+	}
+	for _, arch := range sortedArches(all) {
+		of, nf := oa[arch], na[arch]
+		field(arch+".url", of.Url, nf.Url)
+		field(arch+".md5", of.Checksum, nf.Checksum)
+		field(arch+".sha256", of.SHA256, nf.SHA256)
+		if of.Size != nf.Size {
+			fields = append(fields, fmt.Sprintf("%s.size: %d -> %d", arch, of.Size, nf.Size))
+		}
+	}
+	return fields
+}
@@ -0,0 +1,269 @@
+package manifest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"bytetrade.io/web3os/installer/pkg/common"
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+)
+
+// SchemaVersion is the latest version of the structured manifest format this installer understands
+const SchemaVersion = 1
+
+const (
+	// FormatLegacy is the comma separated format with one item per line and positional fields:
+	// filename,path,type,amd64 url,amd64 md5,arm64 url,arm64 md5,file id or image name
+	FormatLegacy = "csv"
+	FormatJSON   = "json"
+	FormatYAML   = "yaml"
+)
+
+const (
+	ArchAMD64 = "amd64"
+	ArchARM64 = "arm64"
+)
+
+// Document is a manifest file as read from disk, its items are kept in the order of the file
+type Document struct {
+	Format        string
+	SchemaVersion int
+	Items         []*ManifestItem
+	// Signed tells whether the manifest came with a detached signature, which has then been verified
+	Signed bool
+}
+
+// structuredManifest is the on-disk layout of the JSON and YAML formats
+type structuredManifest struct {
+	SchemaVersion int              `json:"schemaVersion"`
+	Items         []structuredItem `json:"items"`
+}
+
+type structuredItem struct {
+	FileID    string             `json:"fileId"`
+	Filename  string             `json:"filename"`
+	Path      string             `json:"path"`
+	Type      string             `json:"type"`
+	ImageName string             `json:"imageName,omitempty"`
+	Files     map[string]fileUrl `json:"files"`
+}
+
+// Load reads the manifest at path in any of the supported formats,
+// if a detached signature sits next to it, the manifest is rejected unless the signature is valid,
+// a signature is required if public keys are configured, see ConfiguredKeys, for removing it not to bypass its verification
+func Load(path string) (*Document, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read manifest")
+	}
+	doc, err := Parse(data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid manifest %s", path)
+	}
+
+	sig, err := os.ReadFile(SignaturePath(path))
+	switch {
+	case os.IsNotExist(err):
+		configured, err := ConfiguredKeys()
+		if err != nil {
+			return nil, err
+		}
+		if len(configured) > 0 {
+			return nil, fmt.Errorf("manifest %s is not signed, its signature %s is required as $%s is set",
+				path, SignaturePath(path), common.ENV_MANIFEST_PUBLIC_KEYS_FILE)
+		}
+		return doc, nil
+	case err != nil:
+		return nil, errors.Wrap(err, "unable to read manifest signature")
+	}
+	if err := VerifySignature(data, sig); err != nil {
+		return nil, errors.Wrapf(err, "manifest %s", path)
+	}
+	doc.Signed = true
+	return doc, nil
+}
+
+// Parse detects the format of the manifest and decodes it,
+// the items are only checked for what is needed to tell them apart, see Validate for the rest
+func Parse(data []byte) (*Document, error) {
+	switch detectFormat(data) {
+	case FormatLegacy:
+		return parseLegacy(data)
+	case FormatJSON:
+		return parseStructured(data, FormatJSON)
+	default:
+		return parseStructured(data, FormatYAML)
+	}
+}
+
+// detectFormat looks at the first line holding something: a legacy line has at least 8 comma separated fields,
+// a JSON document starts with a brace, anything else is taken as YAML
+func detectFormat(data []byte) string {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(line, "{"):
+			return FormatJSON
+		case strings.Count(line, ",") >= 7:
+			return FormatLegacy
+		default:
+			return FormatYAML
+		}
+	}
+	return FormatLegacy
+}
+
+func parseLegacy(data []byte) (*Document, error) {
+	doc := &Document{Format: FormatLegacy}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		item, err := ReadItem(line)
+		if err != nil {
+			return nil, errors.Wrapf(err, "line %d", lineNo)
+		}
+		doc.Items = append(doc.Items, item)
+	}
+	return doc, scanner.Err()
+}
+
+func parseStructured(data []byte, format string) (*Document, error) {
+	var s structuredManifest
+	if err := yaml.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	if s.SchemaVersion == 0 {
+		return nil, errors.New("schemaVersion is missing")
+	}
+	if s.SchemaVersion > SchemaVersion {
+		return nil, fmt.Errorf("schemaVersion %d is newer than the supported one (%d), please upgrade olares-cli", s.SchemaVersion, SchemaVersion)
+	}
+
+	doc := &Document{Format: format, SchemaVersion: s.SchemaVersion}
+	for _, si := range s.Items {
+		item := &ManifestItem{
+			Filename:  si.Filename,
+			Path:      si.Path,
+			Type:      si.Type,
+			ImageName: si.ImageName,
+			FileID:    si.FileID,
+		}
+		for arch, f := range si.Files {
+			item.setArchUrl(arch, f)
+		}
+		doc.Items = append(doc.Items, item)
+	}
+	return doc, nil
+}
+
+// Manifest indexes the items by file ID
+func (d *Document) Manifest() InstallationManifest {
+	m := New()
+	for _, item := range d.Items {
+		m[item.FileID] = item
+	}
+	return m
+}
+
+// Encode writes the items in the given format, the structured formats carry the latest schema version.
+// The legacy format can only hold the URL and MD5 of amd64 and arm64, an error is returned
+// rather than dropping anything else.
+func Encode(items []*ManifestItem, format string) ([]byte, error) {
+	switch format {
+	case FormatLegacy:
+		return encodeLegacy(items)
+	case FormatJSON, FormatYAML:
+	default:
+		return nil, fmt.Errorf("unknown manifest format %q, expected one of: %s, %s, %s", format, FormatLegacy, FormatJSON, FormatYAML)
+	}
+
+	s := structuredManifest{SchemaVersion: SchemaVersion, Items: make([]structuredItem, 0, len(items))}
+	for _, item := range items {
+		s.Items = append(s.Items, structuredItem{
+			FileID:    item.FileID,
+			Filename:  item.Filename,
+			Path:      item.Path,
+			Type:      item.Type,
+			ImageName: item.ImageName,
+			Files:     item.Arches(),
+		})
+	}
+	if format == FormatYAML {
+		return yaml.Marshal(s)
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+func encodeLegacy(items []*ManifestItem) ([]byte, error) {
+	var b bytes.Buffer
+	for _, item := range items {
+		for arch, f := range item.Arches() {
+			if arch != ArchAMD64 && arch != ArchARM64 {
+				return nil, fmt.Errorf("item %s has a %s file, which the %s format cannot hold", item.FileID, arch, FormatLegacy)
+			}
+			if f.SHA256 != "" || f.Size != 0 {
+				return nil, fmt.Errorf("item %s has a SHA-256 or a size, which the %s format cannot hold", item.FileID, FormatLegacy)
+			}
+		}
+		fmt.Fprintf(&b, "%s,%s,%s,%s,%s,%s,%s,%s\n", item.Filename, item.Path, item.Type,
+			item.URL.AMD64.Url, item.URL.AMD64.Checksum, item.URL.ARM64.Url, item.URL.ARM64.Checksum, item.FileID)
+	}
+	return b.Bytes(), nil
+}
+
+// Arches returns the files of the item by architecture, leaving out the architectures it has no file for
+func (item *ManifestItem) Arches() map[string]fileUrl {
+	arches := make(map[string]fileUrl)
+	if item.URL.AMD64 != (fileUrl{}) {
+		arches[ArchAMD64] = item.URL.AMD64
+	}
+	if item.URL.ARM64 != (fileUrl{}) {
+		arches[ArchARM64] = item.URL.ARM64
+	}
+	for arch, f := range item.URL.Extra {
+		arches[arch] = f
+	}
+	return arches
+}
+
+func (item *ManifestItem) setArchUrl(arch string, f fileUrl) {
+	switch arch {
+	case ArchAMD64:
+		item.URL.AMD64 = f
+	case ArchARM64:
+		item.URL.ARM64 = f
+	default:
+		if item.URL.Extra == nil {
+			item.URL.Extra = make(map[string]fileUrl)
+		}
+		item.URL.Extra[arch] = f
+	}
+}
+
+func sortedArches(arches map[string]fileUrl) []string {
+	keys := make([]string, 0, len(arches))
+	for arch := range arches {
+		keys = append(keys, arch)
+	}
+	sort.Strings(keys)
+	return keys
+}
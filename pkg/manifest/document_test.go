@@ -0,0 +1,129 @@
+package manifest
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"bytetrade.io/web3os/installer/pkg/common"
+)
+
+const legacyManifest = `# generated by the release builder
+
+k3s,pkg/components,components,abc,0123456789abcdef0123456789abcdef,arm64/abc,fedcba9876543210fedcba9876543210,k3s
+9f2a.tar.gz,images,images.mf,9f2a.tar.gz,00000000000000000000000000000000,arm64/9f2a.tar.gz,,docker.io/beclab/app:v1
+`
+
+func TestParseFormats(t *testing.T) {
+	legacy, err := Parse([]byte(legacyManifest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if legacy.Format != FormatLegacy || len(legacy.Items) != 2 {
+		t.Fatalf("expected 2 legacy items, got %d in %s", len(legacy.Items), legacy.Format)
+	}
+	if errs := Validate(legacy); len(errs) > 0 {
+		t.Fatalf("unexpected validation errors: %v", errs)
+	}
+	legacy.Items[0].URL.Extra = map[string]fileUrl{"riscv64": {Url: "riscv64/abc", SHA256: "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef", Size: 42}}
+
+	for _, format := range []string{FormatJSON, FormatYAML} {
+		data, err := Encode(legacy.Items, format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		doc, err := Parse(data)
+		if err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		if doc.Format != format || doc.SchemaVersion != SchemaVersion {
+			t.Errorf("%s: detected as %s with schema version %d", format, doc.Format, doc.SchemaVersion)
+		}
+		if !reflect.DeepEqual(doc.Items, legacy.Items) {
+			t.Errorf("%s: items changed on the round trip:\n%+v\n%+v", format, doc.Items[0], legacy.Items[0])
+		}
+		if changes := Diff(legacy.Manifest(), doc.Manifest()); len(changes) > 0 {
+			t.Errorf("%s: unexpected changes %v", format, changes)
+		}
+		if got := doc.Items[0].GetItemUrlForHost("riscv64").Size; got != 42 {
+			t.Errorf("%s: riscv64 size is %d", format, got)
+		}
+	}
+
+	if _, err := Encode(legacy.Items, FormatLegacy); err == nil {
+		t.Error("expected the riscv64 file to be refused by the legacy format")
+	}
+	if _, err := Parse([]byte("schemaVersion: 99\nitems: []\n")); err == nil {
+		t.Error("expected a newer schema version to be refused")
+	}
+}
+
+func TestSignature(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func(keys []ed25519.PublicKey) { trustedKeys = keys }(trustedKeys)
+	trustedKeys = []ed25519.PublicKey{pub}
+
+	path := filepath.Join(t.TempDir(), "installation.manifest")
+	if err := os.WriteFile(path, []byte(legacyManifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if doc, err := Load(path); err != nil || doc.Signed {
+		t.Fatalf("unsigned manifest: %v", err)
+	}
+
+	sig, err := Sign([]byte(legacyManifest), base64.StdEncoding.EncodeToString(priv))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(SignaturePath(path), sig, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if doc, err := Load(path); err != nil || !doc.Signed {
+		t.Fatalf("signed manifest: %v", err)
+	}
+
+	if err := os.WriteFile(path, []byte(legacyManifest+"extra,images,images.mf,a,00000000000000000000000000000000,b,,extra\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Fatal("expected a tampered manifest to be refused")
+	}
+}
+
+func TestConfiguredKeys(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	keys := filepath.Join(dir, "manifest.pub")
+	if err := os.WriteFile(keys, []byte("# release key\n"+base64.StdEncoding.EncodeToString(pub)+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(common.ENV_MANIFEST_PUBLIC_KEYS_FILE, keys)
+
+	path := filepath.Join(dir, "installation.manifest")
+	if err := os.WriteFile(path, []byte(legacyManifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Fatal("expected an unsigned manifest to be refused")
+	}
+
+	sig, err := Sign([]byte(legacyManifest), base64.StdEncoding.EncodeToString(priv))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(SignaturePath(path), sig, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if doc, err := Load(path); err != nil || !doc.Signed {
+		t.Fatalf("manifest signed with a configured key: %v", err)
+	}
+}
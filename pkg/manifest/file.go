@@ -1,14 +1,12 @@
 package manifest
 
 import (
-	"bufio"
-	"bytes"
 	"errors"
 	"fmt"
-	"os"
 	"path/filepath"
 	"strings"
 
+	"bytetrade.io/web3os/installer/pkg/common"
 	"bytetrade.io/web3os/installer/pkg/core/logger"
 )
 
@@ -41,30 +39,20 @@ func ReadItem(line string) (*ManifestItem, error) {
 	return item, nil
 }
 
+// ReadAll loads the manifest at path in any of the supported formats and indexes its items by file ID,
+// see Load
 func ReadAll(path string) (InstallationManifest, error) {
-	m := New()
-	if data, err := os.ReadFile(path); err != nil {
+	doc, err := Load(path)
+	if err != nil {
 		logger.Error("unable to read manifest, ", err)
 		return nil, err
-	} else {
-		scanner := bufio.NewScanner(bytes.NewReader(data))
-
-		for scanner.Scan() {
-			line := scanner.Text()
-			if line == "" && strings.HasPrefix(line, "#") {
-				continue
-			}
-
-			item, err := ReadItem(line)
-			if err != nil {
-				return nil, err
-			}
-
-			m[item.FileID] = item
-		}
+	}
+	if !doc.Signed {
+		logger.Warnf("manifest %s is not signed, its content cannot be authenticated, set $%s to require its signature",
+			path, common.ENV_MANIFEST_PUBLIC_KEYS_FILE)
 	}
 
-	return m, nil
+	return doc.Manifest(), nil
 }
 
 func New() InstallationManifest {
@@ -73,9 +61,14 @@ func New() InstallationManifest {
 
 func (item *ManifestItem) GetItemUrlForHost(osArch string) *fileUrl {
 	switch osArch {
-	case "arm64":
+	case ArchAMD64:
+		return &item.URL.AMD64
+	case ArchARM64:
 		return &item.URL.ARM64
 	}
+	if f, ok := item.URL.Extra[osArch]; ok {
+		return &f
+	}
 
 	return &item.URL.AMD64
 }
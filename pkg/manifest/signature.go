@@ -0,0 +1,117 @@
+package manifest
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	_ "embed"
+	"encoding/base64"
+	"fmt"
+	"os"
+
+	"bytetrade.io/web3os/installer/pkg/common"
+	"github.com/pkg/errors"
+)
+
+// SignatureSuffix is appended to the path of a manifest to get the one of its detached signature,
+// which holds the base64 encoded ed25519 signature of the manifest file as is
+const SignatureSuffix = ".sig"
+
+// embeddedPublicKey is that of the key the install wizards of the official releases are signed with,
+// by olares-cli release --signing-key-file, see keys/manifest.pub
+//
+//go:embed keys/manifest.pub
+var embeddedPublicKey []byte
+
+// trustedKeys are the keys a manifest signature is checked against, along with the configured ones, see ConfiguredKeys
+var trustedKeys = mustParsePublicKeys(embeddedPublicKey)
+
+func SignaturePath(manifestPath string) string {
+	return manifestPath + SignatureSuffix
+}
+
+// ConfiguredKeys reads the public keys of the file named by $MANIFEST_PUBLIC_KEYS_FILE, if set, one base64 encoded ed25519 key per line,
+// e.g., that of the key a release is signed with by olares-cli release --signing-key-file,
+// they are trusted along with the embedded one, and a manifest is then required to be signed
+func ConfiguredKeys() ([]ed25519.PublicKey, error) {
+	file := os.Getenv(common.ENV_MANIFEST_PUBLIC_KEYS_FILE)
+	if file == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read the manifest public keys")
+	}
+	keys, err := parsePublicKeys(data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid manifest public keys %s", file)
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no manifest public key found in %s", file)
+	}
+	return keys, nil
+}
+
+// VerifySignature checks the detached signature of the manifest data against the public key embedded in the installer
+// and the configured ones
+func VerifySignature(data, sig []byte) error {
+	configured, err := ConfiguredKeys()
+	if err != nil {
+		return err
+	}
+	return verifySignature(data, sig, append(configured, trustedKeys...))
+}
+
+func verifySignature(data, sig []byte, keys []ed25519.PublicKey) error {
+	raw, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(sig)))
+	if err != nil {
+		return errors.Wrap(err, "malformed signature")
+	}
+	if len(raw) != ed25519.SignatureSize {
+		return fmt.Errorf("malformed signature, expected %d bytes, got %d", ed25519.SignatureSize, len(raw))
+	}
+	for _, key := range keys {
+		if ed25519.Verify(key, data, raw) {
+			return nil
+		}
+	}
+	return errors.New("signature verification failed")
+}
+
+// Sign returns the detached signature of the manifest data, privateKey is a base64 encoded ed25519 private key
+func Sign(data []byte, privateKey string) ([]byte, error) {
+	raw, err := base64.StdEncoding.DecodeString(privateKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "malformed private key")
+	}
+	if len(raw) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("malformed private key, expected %d bytes, got %d", ed25519.PrivateKeySize, len(raw))
+	}
+	sig := ed25519.Sign(ed25519.PrivateKey(raw), data)
+	return []byte(base64.StdEncoding.EncodeToString(sig) + "\n"), nil
+}
+
+// parsePublicKeys reads one base64 encoded ed25519 public key per line, the empty lines and those starting with # are ignored,
+// so that a new key can be rolled out next to the old one
+func parsePublicKeys(data []byte) ([]ed25519.PublicKey, error) {
+	var keys []ed25519.PublicKey
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+		raw, err := base64.StdEncoding.DecodeString(string(line))
+		if err != nil || len(raw) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid public key %q", line)
+		}
+		keys = append(keys, ed25519.PublicKey(raw))
+	}
+	return keys, nil
+}
+
+func mustParsePublicKeys(data []byte) []ed25519.PublicKey {
+	keys, err := parsePublicKeys(data)
+	if err != nil {
+		panic(fmt.Sprintf("embedded manifest public keys: %v", err))
+	}
+	return keys
+}
@@ -1,13 +1,18 @@
 package manifest
 
 type fileUrl struct {
-	Url      string
-	Checksum string // md5 checksum
+	Url      string `json:"url"`
+	Checksum string `json:"md5,omitempty"` // md5 checksum
+	SHA256   string `json:"sha256,omitempty"`
+	Size     int64  `json:"size,omitempty"`
 }
 
 type itemUrl struct {
 	AMD64 fileUrl
 	ARM64 fileUrl
+	// Extra holds the files of the architectures other than amd64 and arm64,
+	// only the structured format can carry them
+	Extra map[string]fileUrl
 }
 
 type ManifestItem struct {
@@ -0,0 +1,73 @@
+package manifest
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	md5Pattern    = regexp.MustCompile(`^[0-9a-f]{32}$`)
+	sha256Pattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+)
+
+// Validate checks every item of the manifest and returns all the problems found rather than the first one
+func Validate(doc *Document) []error {
+	var errs []error
+	fail := func(item *ManifestItem, format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf("item %s: %s", item.FileID, fmt.Sprintf(format, args...)))
+	}
+
+	seen := make(map[string]bool)
+	for i, item := range doc.Items {
+		if item.FileID == "" {
+			errs = append(errs, fmt.Errorf("item #%d (%s): file ID is empty", i+1, item.Filename))
+			continue
+		}
+		if seen[item.FileID] {
+			fail(item, "duplicated file ID")
+		}
+		seen[item.FileID] = true
+
+		if item.Filename == "" {
+			fail(item, "filename is empty")
+		}
+		if item.Path == "" {
+			fail(item, "path is empty")
+		}
+		if item.Type == "" {
+			fail(item, "type is empty")
+		}
+		if strings.HasPrefix(item.Type, "images.") && item.ImageName == "" {
+			fail(item, "image name is empty")
+		}
+
+		arches := item.Arches()
+		if _, ok := arches[ArchAMD64]; !ok {
+			fail(item, "no %s file", ArchAMD64)
+		}
+		for _, arch := range sortedArches(arches) {
+			f := arches[arch]
+			// the legacy format leaves the arm64 checksum empty for the files that are not built for arm64
+			if doc.Format == FormatLegacy && arch == ArchARM64 && f.Checksum == "" {
+				continue
+			}
+			if f.Url == "" {
+				fail(item, "%s: url is empty", arch)
+			}
+			if f.Checksum == "" && f.SHA256 == "" {
+				fail(item, "%s: neither md5 nor sha256 is set", arch)
+			}
+			if f.Checksum != "" && !md5Pattern.MatchString(f.Checksum) {
+				fail(item, "%s: malformed md5 %q", arch, f.Checksum)
+			}
+			if f.SHA256 != "" && !sha256Pattern.MatchString(f.SHA256) {
+				fail(item, "%s: malformed sha256 %q", arch, f.SHA256)
+			}
+			if f.Size < 0 {
+				fail(item, "%s: negative size", arch)
+			}
+		}
+	}
+	return errs
+}
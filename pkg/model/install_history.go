@@ -0,0 +1,27 @@
+package model
+
+import "time"
+
+// ComponentVersion is the version of a component found installed on a host,
+// RunID being the run that recorded it
+type ComponentVersion struct {
+	Host      string    `json:"host" db:"host"`
+	Component string    `json:"component" db:"component"`
+	Version   string    `json:"version" db:"version"`
+	RunID     string    `json:"run_id" db:"run_id"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Node is a machine of the cluster as known to the installer,
+// Roles is a comma separated list
+type Node struct {
+	Name            string    `json:"name" db:"name"`
+	Address         string    `json:"address" db:"address"`
+	InternalAddress string    `json:"internal_address" db:"internal_address"`
+	Roles           string    `json:"roles" db:"roles"`
+	Arch            string    `json:"arch" db:"arch"`
+	Os              string    `json:"os" db:"os"`
+	RunID           string    `json:"run_id" db:"run_id"`
+	CreatedAt       time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at" db:"updated_at"`
+}
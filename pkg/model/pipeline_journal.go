@@ -0,0 +1,61 @@
+package model
+
+import "time"
+
+const (
+	PipelineRunRunning   = "running"
+	PipelineRunSucceeded = "succeeded"
+	PipelineRunFailed    = "failed"
+	// PipelineRunRolledBack is a failed run whose modules have been rolled back, it can not be resumed
+	PipelineRunRolledBack = "rolled_back"
+	// PipelineRunInterrupted is a run stopped by a signal, it can be resumed
+	PipelineRunInterrupted = "interrupted"
+)
+
+type PipelineRun struct {
+	ID        string     `json:"id" db:"id"`
+	Name      string     `json:"name" db:"name"`
+	Status    string     `json:"status" db:"status"`
+	Error     string     `json:"error" db:"error"`
+	StartedAt time.Time  `json:"started_at" db:"started_at"`
+	EndedAt   *time.Time `json:"ended_at,omitempty" db:"ended_at"`
+	// Command, Args and Version describe the olares-cli invocation that started the run
+	Command string `json:"command" db:"command"`
+	Args    string `json:"args" db:"args"`
+	Version string `json:"version" db:"version"`
+}
+
+type TaskCheckpoint struct {
+	RunID       string    `json:"run_id" db:"run_id"`
+	ModuleIndex int       `json:"module_index" db:"module_index"`
+	Module      string    `json:"module" db:"module"`
+	TaskIndex   int       `json:"task_index" db:"task_index"`
+	Task        string    `json:"task" db:"task"`
+	Host        string    `json:"host" db:"host"`
+	Status      string    `json:"status" db:"status"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// PipelineRunValue is a value a run saves to find it again when it is resumed
+type PipelineRunValue struct {
+	RunID     string    `json:"run_id" db:"run_id"`
+	Name      string    `json:"name" db:"name"`
+	Value     string    `json:"value" db:"value"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// PipelineEvent is a progress event of a pipeline run,
+// Percent is the progress of the whole run at the time of the event
+type PipelineEvent struct {
+	Type     string    `json:"type" db:"type"`
+	Time     time.Time `json:"time" db:"created_at"`
+	RunID    string    `json:"runId,omitempty" db:"run_id"`
+	Pipeline string    `json:"pipeline" db:"pipeline"`
+	Module   string    `json:"module,omitempty" db:"module"`
+	Task     string    `json:"task,omitempty" db:"task"`
+	Host     string    `json:"host,omitempty" db:"host"`
+	Status   string    `json:"status,omitempty" db:"status"`
+	Attempt  int       `json:"attempt,omitempty" db:"attempt"`
+	Percent  int       `json:"percent" db:"percent"`
+	Error    string    `json:"error,omitempty" db:"error"`
+}
@@ -2,10 +2,13 @@ package cluster
 
 import (
 	"bytetrade.io/web3os/installer/pkg/bootstrap/os"
+	"bytetrade.io/web3os/installer/pkg/bootstrap/registry"
 	"bytetrade.io/web3os/installer/pkg/common"
 	"bytetrade.io/web3os/installer/pkg/core/logger"
 	"bytetrade.io/web3os/installer/pkg/core/module"
 	"bytetrade.io/web3os/installer/pkg/core/pipeline"
+	"bytetrade.io/web3os/installer/pkg/etcd"
+	"bytetrade.io/web3os/installer/pkg/history"
 	"bytetrade.io/web3os/installer/pkg/k3s"
 	"bytetrade.io/web3os/installer/pkg/kubernetes"
 	"bytetrade.io/web3os/installer/pkg/manifest"
@@ -25,24 +28,50 @@ func AddNodePhase(runtime *common.KubeRuntime) *pipeline.Pipeline {
 	m = append(m,
 		&terminus.GetMasterInfoModule{},
 		&terminus.CheckPreparedModule{Force: true},
+		&registry.SyncLocalRegistryCAModule{},
 		&storage.InstallJuiceFsModule{
 			ManifestModule: manifest.ManifestModule{
 				Manifest: manifestMap,
 				BaseDir:  runtime.GetBaseDir(),
 			},
 		},
+	)
+
+	name := "Add Worker Node To The Cluster"
+	if runtime.Arg.JoinAsMaster {
+		name = "Add Master Node To The Cluster"
+		// the etcd member of the new master is to be running before its control plane joins,
+		// and it is set up on the OS configured
+		m = append(m,
+			&k3s.StatusModule{},
+			&os.ConfigureOSModule{},
+			&etcd.PreCheckModule{},
+			&etcd.CertsModule{},
+			&etcd.InstallETCDBinaryModule{
+				ManifestModule: manifest.ManifestModule{
+					Manifest: manifestMap,
+					BaseDir:  runtime.GetBaseDir(),
+				},
+			},
+			&etcd.ConfigureModule{},
+			&etcd.BackupModule{},
+		)
+	}
+
+	m = append(m,
 		&AddNodeModule{
 			ManifestModule: manifest.ManifestModule{
 				Manifest: manifestMap,
 				BaseDir:  runtime.GetBaseDir(),
 			},
+			OSConfigured: runtime.Arg.JoinAsMaster,
 		},
 	)
 
-	m = append(m, &terminus.SaveMasterHostConfigModule{}, &terminus.InstalledModule{})
+	m = append(m, &terminus.SaveMasterHostConfigModule{}, &terminus.InstalledModule{}, &history.RecordInventoryModule{})
 
 	return &pipeline.Pipeline{
-		Name:    "Add Worker Node To The Cluster",
+		Name:    name,
 		Modules: m,
 		Runtime: runtime,
 	}
@@ -51,6 +80,8 @@ func AddNodePhase(runtime *common.KubeRuntime) *pipeline.Pipeline {
 type AddNodeModule struct {
 	common.KubeModule
 	manifest.ManifestModule
+	// OSConfigured is set if the cluster status is got and the OS is configured by the previous modules
+	OSConfigured      bool
 	underlyingModules []module.TaskModule
 }
 
@@ -66,14 +97,18 @@ func (m *AddNodeModule) Init() {
 			&kubernetes.JoinNodesModule{},
 		}
 	} else {
-		m.underlyingModules = []module.TaskModule{
-			&k3s.StatusModule{},
-			&os.ConfigureOSModule{},
+		if !m.OSConfigured {
+			m.underlyingModules = []module.TaskModule{
+				&k3s.StatusModule{},
+				&os.ConfigureOSModule{},
+			}
+		}
+		m.underlyingModules = append(m.underlyingModules,
 			&k3s.InstallKubeBinariesModule{
 				ManifestModule: m.ManifestModule,
 			},
 			&k3s.JoinNodesModule{},
-		}
+		)
 	}
 	for _, underlyingModule := range m.underlyingModules {
 		underlyingModule.Default(m.Runtime, m.PipelineCache, m.ModuleCache)
@@ -0,0 +1,66 @@
+package cluster
+
+import (
+	"time"
+
+	kubekeyapiv1alpha2 "bytetrade.io/web3os/installer/apis/kubekey/v1alpha2"
+	"bytetrade.io/web3os/installer/pkg/bootstrap/os"
+	"bytetrade.io/web3os/installer/pkg/common"
+	"bytetrade.io/web3os/installer/pkg/core/module"
+	"bytetrade.io/web3os/installer/pkg/core/pipeline"
+	"bytetrade.io/web3os/installer/pkg/etcd"
+	"bytetrade.io/web3os/installer/pkg/history"
+	"bytetrade.io/web3os/installer/pkg/k3s"
+	"bytetrade.io/web3os/installer/pkg/kubernetes"
+	"bytetrade.io/web3os/installer/pkg/storage"
+	"bytetrade.io/web3os/installer/pkg/terminus"
+)
+
+// DeleteNodePhase drains a worker node, or an additional master of a k3s cluster, and deletes it from the cluster,
+// the etcd member of a master is removed from the etcd cluster,
+// if the node is the local host, its Kubernetes components, etcd and JuiceFS mount are removed as well,
+// so that it can be added again, to this cluster or to another one
+func DeleteNodePhase(runtime *common.KubeRuntime, nodeName string, drainTimeout time.Duration, local bool) *pipeline.Pipeline {
+	// masters can only be added to k3s clusters, see AddNodePhase
+	multiMaster := runtime.Cluster.Kubernetes.Type == common.K3s && runtime.Cluster.Etcd.Type == kubekeyapiv1alpha2.KubeKey
+	m := []module.Module{
+		&kubernetes.RemoveWorkerNodeModule{NodeName: nodeName, DrainTimeout: drainTimeout, AllowMaster: multiMaster},
+		&etcd.RemoveMemberModule{NodeName: nodeName, Skip: !multiMaster},
+	}
+
+	if local {
+		switch runtime.Cluster.Kubernetes.Type {
+		case common.K3s:
+			m = append(m, &k3s.DeleteClusterModule{}, &os.UninstallETCDModule{})
+		default:
+			m = append(m, &kubernetes.ResetClusterModule{}, &kubernetes.UmountKubeModule{})
+		}
+		m = append(m,
+			&storage.RemoveJuiceFSModule{},
+			&terminus.DeleteMasterHostConfigModule{},
+			&storage.DeletePhaseFlagModule{
+				PhaseFile: common.TerminusStateFileInstalled,
+				BaseDir:   runtime.GetBaseDir(),
+			},
+		)
+	}
+	m = append(m, &history.ForgetInventoryModule{NodeNames: []string{nodeName}})
+
+	return &pipeline.Pipeline{
+		Name:    "Delete Node From The Cluster",
+		Modules: m,
+		Runtime: runtime,
+	}
+}
+
+func CordonNodePhase(runtime *common.KubeRuntime, nodeName string, uncordon bool) *pipeline.Pipeline {
+	name := "Cordon Node"
+	if uncordon {
+		name = "Uncordon Node"
+	}
+	return &pipeline.Pipeline{
+		Name:    name,
+		Modules: []module.Module{&kubernetes.CordonNodeModule{NodeName: nodeName, Uncordon: uncordon}},
+		Runtime: runtime,
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bytetrade.io/web3os/installer/pkg/common"
 	"bytetrade.io/web3os/installer/pkg/core/module"
 	"bytetrade.io/web3os/installer/pkg/gpu"
+	"bytetrade.io/web3os/installer/pkg/history"
 	"bytetrade.io/web3os/installer/pkg/kubesphere/plugins"
 	"bytetrade.io/web3os/installer/pkg/manifest"
 	"bytetrade.io/web3os/installer/pkg/storage"
@@ -99,5 +100,6 @@ func (l *linuxInstallPhaseBuilder) build() []module.Module {
 		}).withBackup(l.runtime)...).
 		addModule(&terminus.InstalledModule{}).
 		addModule(&terminus.WriteReleaseFileModule{}).
+		addModule(&history.RecordInventoryModule{}).
 		addModule(&terminus.WelcomeModule{})
 }
@@ -0,0 +1,56 @@
+package cluster
+
+import (
+	"time"
+
+	"bytetrade.io/web3os/installer/pkg/common"
+	"bytetrade.io/web3os/installer/pkg/container"
+	"bytetrade.io/web3os/installer/pkg/core/logger"
+	"bytetrade.io/web3os/installer/pkg/core/module"
+	"bytetrade.io/web3os/installer/pkg/core/pipeline"
+	"bytetrade.io/web3os/installer/pkg/history"
+	"bytetrade.io/web3os/installer/pkg/k3s"
+	"bytetrade.io/web3os/installer/pkg/kubernetes"
+	"bytetrade.io/web3os/installer/pkg/manifest"
+)
+
+// UpgradeKubePhase upgrades k3s or kubeadm and kubelet, and containerd, of the local node in place to the target version,
+// with the binaries of the manifest: the node is cordoned and drained, its binaries are replaced
+// and it is made schedulable again once Ready at the target version,
+// the nodes are upgraded one after another, the masters first, nothing is done on a node already at the target version
+func UpgradeKubePhase(runtime *common.KubeRuntime, nodeName, targetVersion string, drainTimeout time.Duration) *pipeline.Pipeline {
+	manifestMap, err := manifest.ReadAll(runtime.Arg.Manifest)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	manifestModule := manifest.ManifestModule{
+		Manifest: manifestMap,
+		BaseDir:  runtime.GetBaseDir(),
+	}
+
+	m := []module.Module{
+		&kubernetes.PrepareNodeUpgradeModule{
+			ManifestModule: manifestModule,
+			NodeName:       nodeName,
+			TargetVersion:  targetVersion,
+			DrainTimeout:   drainTimeout,
+		},
+		&container.UpgradeContainerdModule{ManifestModule: manifestModule},
+	}
+	switch runtime.Cluster.Kubernetes.Type {
+	case common.K3s:
+		m = append(m, &k3s.UpgradeK3sModule{ManifestModule: manifestModule})
+	default:
+		m = append(m, &kubernetes.UpgradeKubeletModule{ManifestModule: manifestModule})
+	}
+	m = append(m,
+		&kubernetes.FinishNodeUpgradeModule{NodeName: nodeName, TargetVersion: targetVersion},
+		&history.RecordInventoryModule{},
+	)
+
+	return &pipeline.Pipeline{
+		Name:    "Upgrade Kubernetes",
+		Modules: m,
+		Runtime: runtime,
+	}
+}
@@ -0,0 +1,48 @@
+package download
+
+import (
+	"path"
+
+	"bytetrade.io/web3os/installer/pkg/bootstrap/download"
+	"bytetrade.io/web3os/installer/pkg/bootstrap/precheck"
+	"bytetrade.io/web3os/installer/pkg/common"
+	"bytetrade.io/web3os/installer/pkg/core/module"
+	"bytetrade.io/web3os/installer/pkg/core/pipeline"
+)
+
+func NewExportBundle(mainifest string, arches []string, target string, runtime *common.KubeRuntime) *pipeline.Pipeline {
+	m := []module.Module{
+		&precheck.GreetingsModule{},
+		&download.BundleExportModule{
+			Manifest:  mainifest,
+			BaseDir:   runtime.GetBaseDir(),
+			Arches:    arches,
+			Target:    target,
+			Mirrors:   runtime.Arg.DownloadMirrors,
+			Parallel:  runtime.Arg.DownloadParallel,
+			RateLimit: runtime.Arg.DownloadRateLimit,
+		},
+	}
+
+	return &pipeline.Pipeline{
+		Name:    "Export Olares Offline Bundle",
+		Modules: m,
+		Runtime: runtime,
+	}
+}
+
+// NewImportBundle unpacks the bundle into the base dir and checks the result
+// the same way as the download check, with no network access needed
+func NewImportBundle(bundle string, runtime *common.KubeRuntime) *pipeline.Pipeline {
+	m := []module.Module{
+		&precheck.GreetingsModule{},
+		&download.BundleImportModule{Bundle: bundle, BaseDir: runtime.GetBaseDir()},
+		&download.CheckDownloadModule{Manifest: path.Join(runtime.GetInstallerDir(), "installation.manifest"), BaseDir: runtime.GetBaseDir()},
+	}
+
+	return &pipeline.Pipeline{
+		Name:    "Import Olares Offline Bundle",
+		Modules: m,
+		Runtime: runtime,
+	}
+}
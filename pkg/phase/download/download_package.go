@@ -12,7 +12,13 @@ func NewDownloadPackage(mainifest string, runtime *common.KubeRuntime) *pipeline
 
 	m := []module.Module{
 		&precheck.GreetingsModule{},
-		&download.PackageDownloadModule{Manifest: mainifest, BaseDir: runtime.GetBaseDir(), DownloadCdnUrl: runtime.Arg.DownloadCdnUrl},
+		&download.PackageDownloadModule{
+			Manifest:  mainifest,
+			BaseDir:   runtime.GetBaseDir(),
+			Mirrors:   runtime.Arg.DownloadMirrors,
+			Parallel:  runtime.Arg.DownloadParallel,
+			RateLimit: runtime.Arg.DownloadRateLimit,
+		},
 	}
 
 	return &pipeline.Pipeline{
@@ -7,6 +7,7 @@ import (
 	"bytetrade.io/web3os/installer/pkg/bootstrap/os"
 	"bytetrade.io/web3os/installer/pkg/bootstrap/patch"
 	"bytetrade.io/web3os/installer/pkg/bootstrap/precheck"
+	"bytetrade.io/web3os/installer/pkg/bootstrap/registry"
 	"bytetrade.io/web3os/installer/pkg/common"
 	"bytetrade.io/web3os/installer/pkg/container"
 	"bytetrade.io/web3os/installer/pkg/core/module"
@@ -105,7 +106,15 @@ func (l *linuxPhaseBuilder) build() []module.Module {
 						Manifest: l.manifestMap,
 						BaseDir:  l.runtime.GetBaseDir(), // l.runtime.Arg.BaseDir,
 					},
+					Skip: l.runtime.Arg.LocalRegistry || l.runtime.Arg.SkipPullImages,
 				}, //
+				&registry.LocalRegistryModule{
+					ManifestModule: manifest.ManifestModule{
+						Manifest: l.manifestMap,
+						BaseDir:  l.runtime.GetBaseDir(),
+					},
+					Skip: !l.runtime.Arg.LocalRegistry,
+				},
 			}
 		}).withoutCloud(l.runtime)...).
 		addModule(terminusBoxModuleBuilder(func() []module.Module {
@@ -25,6 +25,9 @@ func AddNodePipeline(opts *options.AddNodeOptions) error {
 		return errors.Wrap(err, "failed to load master host config")
 	}
 	arg.SetMasterHostOverride(opts.MasterHostConfig)
+	if err := arg.SetJoinRole(opts.Role); err != nil {
+		return err
+	}
 	if err := arg.MasterHostConfig.Validate(); err != nil {
 		return fmt.Errorf("invalid master host config: %w", err)
 	}
@@ -38,6 +41,7 @@ func AddNodePipeline(opts *options.AddNodeOptions) error {
 	runtime.Arg.SetManifest(manifest)
 
 	var p = cluster.AddNodePhase(runtime)
+	p.Options = opts.Options
 	if err := p.Start(); err != nil {
 		return err
 	}
@@ -0,0 +1,98 @@
+package pipelines
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"bytetrade.io/web3os/installer/cmd/ctl/options"
+	"bytetrade.io/web3os/installer/pkg/certs"
+	"bytetrade.io/web3os/installer/pkg/core/logger"
+	"bytetrade.io/web3os/installer/pkg/core/module"
+	"bytetrade.io/web3os/installer/pkg/core/pipeline"
+	"github.com/pkg/errors"
+)
+
+// CertsReport is what olares-cli certs check prints as JSON
+type CertsReport struct {
+	WarnDays     int                `json:"warnDays"`
+	Expiring     int                `json:"expiring"`
+	Certificates []certs.CertExpiry `json:"certificates"`
+}
+
+// CertsCheckPipeline prints the expiry of the certificates of the node,
+// it fails if any of them is expired or expires within the days given by --certs-warn-days
+func CertsCheckPipeline() error {
+	found := certs.ScanCerts()
+	if len(found) == 0 {
+		return errors.New("no certificate found, is Olares installed on this node and the command run as root?")
+	}
+	report := CertsReport{
+		WarnDays:     certs.ExpiryWarnDays,
+		Expiring:     certs.CheckExpiry(found, time.Duration(certs.ExpiryWarnDays)*24*time.Hour, time.Now()),
+		Certificates: found,
+	}
+
+	if pipeline.DefaultOptions.Output == pipeline.OutputJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			return err
+		}
+	} else {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+		fmt.Fprintln(w, "COMPONENT\tCERTIFICATE\tAUTHORITY\tEXPIRES\tRESIDUAL TIME\tSTATUS")
+		for _, c := range found {
+			fmt.Fprintf(w, "%s\t%s\t%t\t%s\t%s\t%s\n", c.Component, c.Path, c.Authority, c.Expires(), c.Residual, c.Status)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+
+	if report.Expiring > 0 {
+		return errors.Errorf("%d certificate(s) expired or expiring within %d days, renew them with olares-cli certs renew", report.Expiring, report.WarnDays)
+	}
+	return nil
+}
+
+// CertsRenewPipeline renews the certificates of the control plane of the local master
+func CertsRenewPipeline(opts *options.CertsRenewOptions) error {
+	runtime, err := newNodeRuntime(opts.BaseDir, "certs-renew.log")
+	if err != nil {
+		return err
+	}
+	p := &pipeline.Pipeline{
+		Name:    "RenewCerts",
+		Modules: []module.Module{&certs.RenewLocalCertsModule{Components: opts.Components}},
+		Runtime: runtime,
+		Options: opts.Options,
+	}
+	if err := p.Start(); err != nil {
+		return errors.Wrap(err, "renew the certificates failed")
+	}
+	return nil
+}
+
+// CertsRotateCAPipeline replaces the certificate authorities of the cluster on the local master
+func CertsRotateCAPipeline(opts *options.CertsRotateCAOptions) error {
+	runtime, err := newNodeRuntime(opts.BaseDir, "certs-rotate-ca.log")
+	if err != nil {
+		return err
+	}
+	p := &pipeline.Pipeline{
+		Name:    "RotateCA",
+		Modules: []module.Module{&certs.RotateCAModule{}},
+		Runtime: runtime,
+		Options: opts.Options,
+	}
+	if err := p.Start(); err != nil {
+		return errors.Wrap(err, "rotate the certificate authorities failed")
+	}
+	if !p.DryRun {
+		logger.Infof("the certificate authorities are rotated, restart k3s or kubelet on the other nodes, or add the workers of a kubeadm cluster again, for them to trust the new ones")
+	}
+	return nil
+}
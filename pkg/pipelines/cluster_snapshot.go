@@ -0,0 +1,136 @@
+package pipelines
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	"bytetrade.io/web3os/installer/cmd/ctl/options"
+	"bytetrade.io/web3os/installer/pkg/common"
+	cc "bytetrade.io/web3os/installer/pkg/core/common"
+	"bytetrade.io/web3os/installer/pkg/core/logger"
+	"bytetrade.io/web3os/installer/pkg/core/module"
+	"bytetrade.io/web3os/installer/pkg/core/pipeline"
+	"bytetrade.io/web3os/installer/pkg/core/util"
+	"bytetrade.io/web3os/installer/pkg/datastore"
+	"github.com/pkg/errors"
+)
+
+// ClusterSnapshotSavePipeline takes a snapshot of the datastore of the cluster on the local master,
+// prunes the former ones and optionally uploads them to the object storage
+func ClusterSnapshotSavePipeline(opts *options.ClusterSnapshotOptions) error {
+	runtime, err := newNodeRuntime(opts.BaseDir, "cluster-snapshot.log")
+	if err != nil {
+		return err
+	}
+	if opts.Upload {
+		runtime.Arg.SetStorage(getStorageValueFromEnv())
+	}
+	p := &pipeline.Pipeline{
+		Name: "SaveClusterSnapshot",
+		Modules: []module.Module{
+			&datastore.SaveSnapshotModule{Keep: opts.Keep, MaxAge: opts.MaxAge, Upload: opts.Upload},
+		},
+		Runtime: runtime,
+		Options: opts.Options,
+	}
+	if err := p.Start(); err != nil {
+		return errors.Wrap(err, "save the snapshot of the cluster failed")
+	}
+	return nil
+}
+
+// ClusterSnapshotListPipeline prints the snapshots of the datastore kept on the node
+func ClusterSnapshotListPipeline(opts *options.ClusterSnapshotListOptions) error {
+	baseDir := opts.BaseDir
+	if baseDir == "" {
+		arg := &common.Argument{}
+		if err := arg.LoadReleaseInfo(); err != nil {
+			return errors.Wrap(err, "failed to load olares release info")
+		}
+		baseDir = arg.BaseDir
+	}
+	if baseDir == "" {
+		home, err := util.Home()
+		if err != nil {
+			return errors.Wrap(err, "failed to get home dir")
+		}
+		baseDir = filepath.Join(home, cc.DefaultBaseDir)
+	}
+	snapshots, err := datastore.ListSnapshots(baseDir)
+	if err != nil {
+		return errors.Wrap(err, "failed to list the snapshots")
+	}
+
+	if pipeline.DefaultOptions.Output == pipeline.OutputJSON {
+		if snapshots == nil {
+			snapshots = []*datastore.Snapshot{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(snapshots)
+	}
+	if len(snapshots) == 0 {
+		fmt.Printf("no snapshot found in %s\n", datastore.SnapshotsDir(baseDir))
+		return nil
+	}
+	now := time.Now()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "NAME\tNODE\tDATASTORE\tSIZE\tAGE\tFILE")
+	for _, s := range snapshots {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", s.Name, s.Node, s.Type, util.FormatBytes(s.Size), s.Age(now), filepath.Join(s.Dir, s.File))
+	}
+	return w.Flush()
+}
+
+// ClusterSnapshotRestorePipeline resets the datastore of the cluster on the local master from a snapshot
+func ClusterSnapshotRestorePipeline(name string, opts *options.ClusterSnapshotRestoreOptions) error {
+	runtime, err := newNodeRuntime(opts.BaseDir, "cluster-snapshot-restore.log")
+	if err != nil {
+		return err
+	}
+	if opts.Download {
+		runtime.Arg.SetStorage(getStorageValueFromEnv())
+	}
+	p := &pipeline.Pipeline{
+		Name: "RestoreClusterSnapshot",
+		Modules: []module.Module{
+			&datastore.RestoreSnapshotModule{SnapshotName: name, Download: opts.Download},
+		},
+		Runtime: runtime,
+		Options: opts.Options,
+	}
+	if err := p.Start(); err != nil {
+		return errors.Wrapf(err, "restore snapshot %s failed", name)
+	}
+	if !p.DryRun {
+		logger.Infof("the datastore of the cluster is restored from snapshot %s, the data written since is lost", name)
+	}
+	return nil
+}
+
+// ClusterSnapshotPrunePipeline removes the snapshots beyond the retention
+func ClusterSnapshotPrunePipeline(opts *options.ClusterSnapshotOptions) error {
+	runtime, err := newNodeRuntime(opts.BaseDir, "cluster-snapshot.log")
+	if err != nil {
+		return err
+	}
+	if opts.Upload {
+		runtime.Arg.SetStorage(getStorageValueFromEnv())
+	}
+	p := &pipeline.Pipeline{
+		Name: "PruneClusterSnapshots",
+		Modules: []module.Module{
+			&datastore.PruneSnapshotsModule{Keep: opts.Keep, MaxAge: opts.MaxAge, Upload: opts.Upload},
+		},
+		Runtime: runtime,
+		Options: opts.Options,
+	}
+	if err := p.Start(); err != nil {
+		return errors.Wrap(err, "prune the snapshots of the cluster failed")
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package pipelines
+
+import (
+	"fmt"
+	"path"
+
+	"bytetrade.io/web3os/installer/cmd/ctl/options"
+	"bytetrade.io/web3os/installer/pkg/bundle"
+	"bytetrade.io/web3os/installer/pkg/common"
+	"bytetrade.io/web3os/installer/pkg/core/logger"
+	"bytetrade.io/web3os/installer/pkg/phase/download"
+	"bytetrade.io/web3os/installer/pkg/utils"
+)
+
+func ExportOfflineBundle(opts *options.CliDownloadBundleOptions) error {
+	arg := common.NewArgument()
+	arg.SetBaseDir(opts.BaseDir)
+	arg.SetKubeVersion(opts.KubeType)
+	arg.SetOlaresVersion(opts.Version)
+	arg.SetDownloadCdnUrl(opts.DownloadCdnUrl)
+	rateLimit, err := parseRateLimit(opts.LimitRate)
+	if err != nil {
+		return err
+	}
+	arg.SetDownloadLimits(opts.Parallel, rateLimit)
+
+	runtime, err := common.NewKubeRuntime(common.AllInOne, *arg)
+	if err != nil {
+		return err
+	}
+
+	for _, u := range arg.DownloadMirrors {
+		if ok := utils.CheckUrl(u); !ok {
+			return fmt.Errorf("--download-cdn-url invalid: %s", u)
+		}
+	}
+
+	manifest := opts.Manifest
+	if manifest == "" {
+		manifest = path.Join(runtime.GetInstallerDir(), "installation.manifest")
+	}
+	arches := opts.Arch
+	if len(arches) == 0 {
+		arches = []string{arg.SystemInfo.GetOsArch()}
+	}
+	target := opts.Target
+	if target == "" {
+		target = fmt.Sprintf("olares-%s%s", arg.OlaresVersion, bundle.Extension)
+	}
+
+	p := download.NewExportBundle(manifest, arches, target, runtime)
+	if err := p.Start(); err != nil {
+		logger.Errorf("export offline bundle failed %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func ImportOfflineBundle(bundlePath string, opts *options.CliImportBundleOptions) error {
+	info, err := bundle.ReadInfo(bundlePath)
+	if err != nil {
+		return err
+	}
+
+	arg := common.NewArgument()
+	arg.SetBaseDir(opts.BaseDir)
+	arg.SetOlaresVersion(info.Version)
+	arg.SetOlaresVersion(opts.Version)
+
+	runtime, err := common.NewKubeRuntime(common.AllInOne, *arg)
+	if err != nil {
+		return err
+	}
+
+	p := download.NewImportBundle(bundlePath, runtime)
+	if err := p.Start(); err != nil {
+		logger.Errorf("import offline bundle failed %v", err)
+		return err
+	}
+
+	return nil
+}
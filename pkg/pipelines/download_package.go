@@ -9,6 +9,7 @@ import (
 	"bytetrade.io/web3os/installer/pkg/core/logger"
 	"bytetrade.io/web3os/installer/pkg/phase/download"
 	"bytetrade.io/web3os/installer/pkg/utils"
+	"k8s.io/apimachinery/pkg/api/resource"
 )
 
 func DownloadInstallationPackage(opts *options.CliDownloadOptions) error {
@@ -17,14 +18,21 @@ func DownloadInstallationPackage(opts *options.CliDownloadOptions) error {
 	arg.SetKubeVersion(opts.KubeType)
 	arg.SetOlaresVersion(opts.Version)
 	arg.SetDownloadCdnUrl(opts.DownloadCdnUrl)
+	rateLimit, err := parseRateLimit(opts.LimitRate)
+	if err != nil {
+		return err
+	}
+	arg.SetDownloadLimits(opts.Parallel, rateLimit)
 
 	runtime, err := common.NewKubeRuntime(common.AllInOne, *arg)
 	if err != nil {
 		return err
 	}
 
-	if ok := utils.CheckUrl(arg.DownloadCdnUrl); !ok {
-		return fmt.Errorf("--download-cdn-url invalid")
+	for _, u := range arg.DownloadMirrors {
+		if ok := utils.CheckUrl(u); !ok {
+			return fmt.Errorf("--download-cdn-url invalid: %s", u)
+		}
 	}
 
 	manifest := opts.Manifest
@@ -40,3 +48,15 @@ func DownloadInstallationPackage(opts *options.CliDownloadOptions) error {
 
 	return nil
 }
+
+// parseRateLimit reads a bandwidth in bytes per second, e.g., 512Ki or 10M, an empty one meaning unlimited
+func parseRateLimit(s string) (int64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	q, err := resource.ParseQuantity(s)
+	if err != nil || q.Sign() <= 0 {
+		return 0, fmt.Errorf("--limit-rate invalid: %s", s)
+	}
+	return q.Value(), nil
+}
@@ -22,8 +22,10 @@ func DownloadInstallationWizard(opts *options.CliDownloadWizardOptions) error {
 		return err
 	}
 
-	if ok := utils.CheckUrl(arg.DownloadCdnUrl); !ok {
-		return fmt.Errorf("--download-cdn-url invalid")
+	for _, u := range arg.DownloadMirrors {
+		if ok := utils.CheckUrl(u); !ok {
+			return fmt.Errorf("--download-cdn-url invalid: %s", u)
+		}
 	}
 
 	p := download.NewDownloadWizard(runtime)
@@ -66,6 +66,9 @@ func UpgradeGpuDrivers(opt *options.InstallGpuOptions) error {
 	if err := p.Start(); err != nil {
 		return err
 	}
+	if p.DryRun {
+		return nil
+	}
 
 	fmt.Println()
 	fmt.Println("The GPU driver has been upgraded, for it to work properly, the machine needs to be rebooted.")
@@ -47,10 +47,14 @@ func CliInstallTerminusPipeline(opts *options.CliTerminusInstallOptions) error {
 	runtime.Arg.SetManifest(manifest)
 
 	var p = cluster.InstallSystemPhase(runtime)
+	p.Options = opts.Options
 	logger.InfoInstallationProgress("Start to Install Olares ...")
 	if err := p.Start(); err != nil {
 		return err
 	}
+	if p.DryRun {
+		return nil
+	}
 
 	if !runtime.GetSystemInfo().IsWindows() {
 		if runtime.Arg.InCluster {
@@ -0,0 +1,164 @@
+package pipelines
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"bytetrade.io/web3os/installer/cmd/ctl/options"
+	"bytetrade.io/web3os/installer/pkg/common"
+	"bytetrade.io/web3os/installer/pkg/core/connector"
+	"bytetrade.io/web3os/installer/pkg/core/logger"
+	"bytetrade.io/web3os/installer/pkg/core/pipeline"
+	"bytetrade.io/web3os/installer/pkg/gpu"
+	"bytetrade.io/web3os/installer/pkg/phase"
+	"bytetrade.io/web3os/installer/pkg/phase/cluster"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func newNodeRuntime(baseDir, consoleLog string) (*common.KubeRuntime, error) {
+	arg := common.NewArgument()
+	if !arg.SystemInfo.IsLinux() {
+		return nil, errors.New("nodes can only be managed on a Linux node of the cluster")
+	}
+	arg.SetBaseDir(baseDir)
+	arg.SetKubeVersion(phase.GetKubeType())
+	if consoleLog != "" {
+		arg.SetConsoleLog(consoleLog, true)
+	}
+	runtime, err := common.NewKubeRuntime(common.AllInOne, *arg)
+	if err != nil {
+		return nil, fmt.Errorf("error creating runtime: %v", err)
+	}
+	return runtime, nil
+}
+
+func DeleteNodePipeline(nodeName string, opts *options.DeleteNodeOptions) error {
+	runtime, err := newNodeRuntime(opts.BaseDir, "deletenode.log")
+	if err != nil {
+		return err
+	}
+
+	local := strings.EqualFold(nodeName, runtime.GetSystemInfo().GetHostname())
+	p := cluster.DeleteNodePhase(runtime, nodeName, opts.DrainTimeout, local)
+	p.Options = opts.Options
+	if err := p.Start(); err != nil {
+		return err
+	}
+	if !local && !p.DryRun {
+		logger.Infof("node %s is deleted from the cluster, run \"olares-cli uninstall\" on it to clean it up", nodeName)
+	}
+	return nil
+}
+
+func CordonNodePipeline(nodeName string, uncordon bool, opts *options.NodeOptions) error {
+	runtime, err := newNodeRuntime(opts.BaseDir, "")
+	if err != nil {
+		return err
+	}
+	return cluster.CordonNodePhase(runtime, nodeName, uncordon).Start()
+}
+
+// NodeSummary is what olares-cli node list shows of a node
+type NodeSummary struct {
+	Name          string   `json:"name"`
+	Ready         bool     `json:"ready"`
+	Unschedulable bool     `json:"unschedulable"`
+	Roles         []string `json:"roles"`
+	Version       string   `json:"version"`
+	InternalIP    string   `json:"internalIP"`
+	GPUDriver     string   `json:"gpuDriver,omitempty"`
+	Cuda          string   `json:"cuda,omitempty"`
+}
+
+func (n NodeSummary) status() string {
+	s := "NotReady"
+	if n.Ready {
+		s = "Ready"
+	}
+	if n.Unschedulable {
+		s += ",SchedulingDisabled"
+	}
+	return s
+}
+
+func newNodeSummary(node *corev1.Node) NodeSummary {
+	n := NodeSummary{
+		Name:          node.Name,
+		Unschedulable: node.Spec.Unschedulable,
+		Version:       node.Status.NodeInfo.KubeletVersion,
+		GPUDriver:     node.Labels[gpu.GpuDriverLabel],
+		Cuda:          node.Labels[gpu.GpuCudaLabel],
+	}
+	for _, c := range node.Status.Conditions {
+		if c.Type == corev1.NodeReady {
+			n.Ready = c.Status == corev1.ConditionTrue
+		}
+	}
+	for label := range node.Labels {
+		if role, ok := strings.CutPrefix(label, "node-role.kubernetes.io/"); ok && role != "" {
+			n.Roles = append(n.Roles, role)
+		}
+	}
+	sort.Strings(n.Roles)
+	for _, addr := range node.Status.Addresses {
+		if addr.Type == corev1.NodeInternalIP {
+			n.InternalIP = addr.Address
+			break
+		}
+	}
+	return n
+}
+
+func ListNodesPipeline(opts *options.NodeOptions) error {
+	runtime, err := newNodeRuntime(opts.BaseDir, "")
+	if err != nil {
+		return err
+	}
+	runtime.SetRunner(
+		&connector.Runner{
+			Host: &connector.BaseHost{
+				Name: common.LocalHost,
+				Arch: runtime.GetSystemInfo().GetOsArch(),
+				Os:   runtime.GetSystemInfo().GetOsType(),
+			},
+		},
+	)
+
+	output, err := runtime.GetRunner().SudoCmd("/usr/local/bin/kubectl get node -o json", false, false)
+	if err != nil {
+		return errors.Wrap(err, "failed to get the nodes, is Kubernetes running?")
+	}
+	nodeList := &corev1.NodeList{}
+	if err := json.Unmarshal([]byte(output), nodeList); err != nil {
+		return errors.Wrap(err, "failed to parse the nodes")
+	}
+	nodes := make([]NodeSummary, 0, len(nodeList.Items))
+	for i := range nodeList.Items {
+		nodes = append(nodes, newNodeSummary(&nodeList.Items[i]))
+	}
+
+	if pipeline.DefaultOptions.Output == pipeline.OutputJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(nodes)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSTATUS\tROLES\tVERSION\tINTERNAL-IP\tGPU DRIVER\tCUDA")
+	for _, n := range nodes {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", n.Name, n.status(), orNone(strings.Join(n.Roles, ",")),
+			n.Version, orNone(n.InternalIP), orNone(n.GPUDriver), orNone(n.Cuda))
+	}
+	return w.Flush()
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "<none>"
+	}
+	return s
+}
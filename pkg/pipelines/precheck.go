@@ -1,6 +1,9 @@
 package pipelines
 
 import (
+	"io"
+	"os"
+
 	"bytetrade.io/web3os/installer/cmd/ctl/options"
 	"bytetrade.io/web3os/installer/pkg/bootstrap/precheck"
 	"bytetrade.io/web3os/installer/pkg/common"
@@ -15,18 +18,25 @@ func StartPreCheckPipeline(opt *options.PreCheckOptions) error {
 	arg.SetOlaresVersion(terminusVersion)
 	arg.SetBaseDir(opt.BaseDir)
 	arg.SetConsoleLog("precheck.log", true)
+	arg.SetSkipChecks(opt.SkipChecks)
 
 	runtime, err := common.NewKubeRuntime(common.AllInOne, *arg)
 	if err != nil {
 		return err
 	}
 
+	var report io.Writer
+	if pipeline.DefaultOptions.Output == pipeline.OutputJSON {
+		report = os.Stdout
+	}
+
 	p := &pipeline.Pipeline{
 		Name: "PreCheck",
 		Modules: []module.Module{
-			&precheck.RunPrechecksModule{},
+			&precheck.RunPrechecksModule{Report: report},
 		},
 		Runtime: runtime,
+		Options: pipeline.Options{JSONReport: true},
 	}
 	return p.Start()
 
@@ -11,6 +11,7 @@ import (
 	"bytetrade.io/web3os/installer/cmd/ctl/options"
 	bootstrapos "bytetrade.io/web3os/installer/pkg/bootstrap/os"
 	"bytetrade.io/web3os/installer/pkg/bootstrap/patch"
+	"bytetrade.io/web3os/installer/pkg/bootstrap/registry"
 	"bytetrade.io/web3os/installer/pkg/common"
 	"bytetrade.io/web3os/installer/pkg/container"
 	"bytetrade.io/web3os/installer/pkg/core/module"
@@ -33,10 +34,17 @@ func PrepareSystemPipeline(opts *options.CliPrepareSystemOptions, components []s
 	arg.SetMinikubeProfile(opts.MinikubeProfile)
 	arg.SetOlaresVersion(opts.Version)
 	arg.SetRegistryMirrors(opts.RegistryMirrors)
+	arg.SetSkipChecks(opts.SkipChecks)
+	arg.SetLocalRegistry(opts.LocalRegistry)
+	arg.SetSkipPullImages(opts.SkipImages)
 	arg.SetStorage(getStorageValueFromEnv())
 	arg.SetTokenMaxAge()
 	arg.SetReverseProxy()
 
+	if arg.LocalRegistry && (!arg.SystemInfo.IsLinux() || arg.Kubetype != common.K3s) {
+		return errors.New("the local registry is only supported by a k3s cluster on Linux")
+	}
+
 	runtime, err := common.NewKubeRuntime(common.AllInOne, *arg)
 	if err != nil {
 		return fmt.Errorf("error creating runtime: %w", err)
@@ -53,6 +61,7 @@ func PrepareSystemPipeline(opts *options.CliPrepareSystemOptions, components []s
 	// if no components specified, run all
 	if len(components) == 0 {
 		var p = system.PrepareSystemPhase(runtime)
+		p.Options = opts.Options
 		if err := p.Start(); err != nil {
 			return err
 		}
@@ -70,9 +79,18 @@ func PrepareSystemPipeline(opts *options.CliPrepareSystemOptions, components []s
 							Manifest: manifestMap,
 							BaseDir:  runtime.GetBaseDir(),
 						},
+						Skip: runtime.Arg.LocalRegistry || runtime.Arg.SkipPullImages,
+					},
+					&registry.LocalRegistryModule{
+						ManifestModule: manifest.ManifestModule{
+							Manifest: manifestMap,
+							BaseDir:  runtime.GetBaseDir(),
+						},
+						Skip: !runtime.Arg.LocalRegistry,
 					},
 				},
 				Runtime: runtime,
+				Options: opts.Options,
 			}
 			if err := p.Start(); err != nil {
 				return fmt.Errorf("error preparing images: %w", err)
@@ -89,6 +107,7 @@ func PrepareSystemPipeline(opts *options.CliPrepareSystemOptions, components []s
 					},
 				},
 				Runtime: runtime,
+				Options: opts.Options,
 			}
 			if err := p.Start(); err != nil {
 				return fmt.Errorf("error preparing os environment: %w", err)
@@ -107,6 +126,7 @@ func PrepareSystemPipeline(opts *options.CliPrepareSystemOptions, components []s
 					&bootstrapos.ConfigSystemModule{},
 				},
 				Runtime: runtime,
+				Options: opts.Options,
 			}
 			if err := p.Start(); err != nil {
 				return fmt.Errorf("error preparing os environment: %w", err)
@@ -123,6 +143,7 @@ func PrepareSystemPipeline(opts *options.CliPrepareSystemOptions, components []s
 					},
 				},
 				Runtime: runtime,
+				Options: opts.Options,
 			}
 			if err := p.Start(); err != nil {
 				return fmt.Errorf("error setting up container runtime: %w", err)
@@ -1,15 +1,20 @@
 package pipelines
 
 import (
-	"time"
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
 
+	"bytetrade.io/web3os/installer/cmd/ctl/options"
 	"bytetrade.io/web3os/installer/pkg/common"
+	"bytetrade.io/web3os/installer/pkg/core/logger"
 	"bytetrade.io/web3os/installer/pkg/core/module"
 	"bytetrade.io/web3os/installer/pkg/core/pipeline"
 	"bytetrade.io/web3os/installer/pkg/terminus"
 )
 
-func StartOlares() error {
+func StartOlares(opts *options.StartOptions) error {
 	arg := common.NewArgument()
 	arg.SetConsoleLog("start.log", true)
 	runtime, err := common.NewKubeRuntime(common.AllInOne, *arg)
@@ -17,18 +22,32 @@ func StartOlares() error {
 		return err
 	}
 
+	report := terminus.NewStageReport("StartOlares", "RestoreWorkloads", "WaitOlaresPods")
+	restore := &terminus.RestoreWorkloadsModule{StageTimeout: opts.StageTimeout}
+	waitPods := &terminus.WaitOlaresPodsModule{StageTimeout: opts.StageTimeout}
+	if !terminus.KubernetesInstalled() {
+		restore.Skip, waitPods.Skip = true, true
+		report.Skip("RestoreWorkloads", "Kubernetes is not installed")
+		report.Skip("WaitOlaresPods", "Kubernetes is not installed")
+	}
+
 	p := &pipeline.Pipeline{
 		Name: "StartOlares",
 		Modules: []module.Module{
 			&terminus.StartOlaresModule{},
+			restore,
+			waitPods,
 		},
-		Runtime: runtime,
+		Runtime:         runtime,
+		ModulePostHooks: []module.PostHookInterface{report.PostHook()},
+		// the stages are reported by printStageReport
+		Options: pipeline.Options{JSONReport: true},
 	}
 
-	return p.Start()
+	return printStageReport(report, p.Start())
 }
 
-func StopOlares(timeout, checkInterval time.Duration) error {
+func StopOlares(opts *options.StopOptions) error {
 	arg := common.NewArgument()
 	arg.SetConsoleLog("stop.log", true)
 	runtime, err := common.NewKubeRuntime(common.AllInOne, *arg)
@@ -36,16 +55,72 @@ func StopOlares(timeout, checkInterval time.Duration) error {
 		return err
 	}
 
+	report := terminus.NewStageReport("DrainWorkloads", "SyncJuiceFS", "StopOlares")
+	drain := &terminus.DrainWorkloadsModule{StageTimeout: opts.StageTimeout}
+	syncJuiceFS := &terminus.SyncJuiceFSModule{StageTimeout: opts.StageTimeout}
+	switch {
+	case opts.SkipDrain:
+		drain.Skip = true
+		report.Skip("DrainWorkloads", "--skip-drain")
+	case !terminus.KubernetesInstalled():
+		drain.Skip = true
+		report.Skip("DrainWorkloads", "Kubernetes is not installed")
+	case !terminus.KubeAPIServerReady():
+		// the cluster is already down, or broken, the services are stopped as they can be
+		logger.Warn("the API server is not ready, the workloads can not be scaled down before the services are stopped")
+		drain.Skip = true
+		report.Skip("DrainWorkloads", "the API server is not ready")
+	}
+	switch {
+	case opts.SkipDrain:
+		syncJuiceFS.Skip = true
+		report.Skip("SyncJuiceFS", "--skip-drain")
+	case !terminus.JuiceFSInstalled():
+		syncJuiceFS.Skip = true
+		report.Skip("SyncJuiceFS", "JuiceFS is not installed")
+	}
+
 	p := &pipeline.Pipeline{
 		Name: "StopOlares",
 		Modules: []module.Module{
+			drain,
+			syncJuiceFS,
 			&terminus.StopOlaresModule{
-				Timeout:       timeout,
-				CheckInterval: checkInterval,
+				Timeout:       opts.Timeout,
+				CheckInterval: opts.CheckInterval,
 			},
 		},
-		Runtime: runtime,
+		Runtime:         runtime,
+		ModulePostHooks: []module.PostHookInterface{report.PostHook()},
+		// the stages are reported by printStageReport
+		Options: pipeline.Options{JSONReport: true},
 	}
 
-	return p.Start()
+	return printStageReport(report, p.Start())
+}
+
+// printStageReport prints the result of each stage of stop or start, as JSON with --output json,
+// whether the pipeline has failed or not, and returns its error
+func printStageReport(report *terminus.StageReport, err error) error {
+	if pipeline.DefaultOptions.DryRun {
+		return err
+	}
+	if pipeline.DefaultOptions.Output == pipeline.OutputJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if perr := enc.Encode(report); perr != nil && err == nil {
+			err = perr
+		}
+		return err
+	}
+	fmt.Println()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "STAGE\tRESULT\tDURATION\tDETAIL")
+	for _, s := range report.Stages {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", s.Name, s.Result, orNone(s.Duration), s.Detail)
+	}
+	if perr := w.Flush(); perr != nil && err == nil {
+		err = perr
+	}
+	return err
 }
@@ -0,0 +1,42 @@
+package pipelines
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"bytetrade.io/web3os/installer/pkg/core/pipeline"
+	"bytetrade.io/web3os/installer/pkg/status"
+	"github.com/pkg/errors"
+)
+
+// StatusPipeline prints the health of Olares on the local node,
+// it fails if any of its components is degraded
+func StatusPipeline() error {
+	report := status.Collect(context.Background())
+
+	if pipeline.DefaultOptions.Output == pipeline.OutputJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			return err
+		}
+	} else {
+		fmt.Printf("Olares %s: %s\n\n", orNone(report.Version), report.Status)
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+		fmt.Fprintln(w, "CATEGORY\tNAME\tSTATUS\tDETAIL")
+		for _, c := range report.Checks {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", c.Category, c.Name, c.Status, c.Detail)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+
+	if degraded := report.Degraded(); len(degraded) > 0 {
+		return errors.Errorf("Olares is degraded, %d check(s) failed, first %s %s: %s", len(degraded), degraded[0].Category, degraded[0].Name, degraded[0].Detail)
+	}
+	return nil
+}
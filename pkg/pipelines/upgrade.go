@@ -3,40 +3,56 @@ package pipelines
 import (
 	"bytetrade.io/web3os/installer/pkg/upgrade"
 	"bytetrade.io/web3os/installer/pkg/utils"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
 
 	"bytetrade.io/web3os/installer/cmd/ctl/options"
 	"bytetrade.io/web3os/installer/pkg/common"
 	"bytetrade.io/web3os/installer/pkg/core/logger"
 	"bytetrade.io/web3os/installer/pkg/core/module"
 	"bytetrade.io/web3os/installer/pkg/core/pipeline"
+	"bytetrade.io/web3os/installer/pkg/history"
 	"bytetrade.io/web3os/installer/pkg/phase"
+	"bytetrade.io/web3os/installer/pkg/phase/cluster"
+	"github.com/Masterminds/semver/v3"
 	"github.com/pkg/errors"
 )
 
-func UpgradeOlaresPipeline(opts *options.UpgradeOptions) error {
+// upgradeVersions parses the current version of Olares and the target version to upgrade to
+func upgradeVersions(target string) (*semver.Version, *semver.Version, error) {
 	currentVersionString, err := phase.GetOlaresVersion()
 	if err != nil {
-		return errors.Wrap(err, "failed to get current Olares version")
+		return nil, nil, errors.Wrap(err, "failed to get current Olares version")
 	}
 	if currentVersionString == "" {
-		return errors.New("Olares is not installed, please install it first")
+		return nil, nil, errors.New("Olares is not installed, please install it first")
 	}
 	currentVersion, err := utils.ParseOlaresVersionString(currentVersionString)
 	if err != nil {
-		return fmt.Errorf("error parsing current Olares version: %v", err)
+		return nil, nil, fmt.Errorf("error parsing current Olares version: %v", err)
 	}
 
 	// validate the expected version is non-empty before the NewArgument() call
 	// as it will fall back to load the current olares release
-	if opts.Version == "" {
-		return errors.New("target version is required")
+	if target == "" {
+		return nil, nil, errors.New("target version is required")
 	}
-	targetVersion, err := utils.ParseOlaresVersionString(opts.Version)
+	targetVersion, err := utils.ParseOlaresVersionString(target)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error parsing target Olares version: %v", err)
+	}
+	return currentVersion, targetVersion, nil
+}
+
+func UpgradeOlaresPipeline(opts *options.UpgradeOptions) error {
+	currentVersion, targetVersion, err := upgradeVersions(opts.Version)
 	if err != nil {
-		return fmt.Errorf("error parsing target Olares version: %v", err)
+		return err
 	}
 
 	if !targetVersion.GreaterThan(currentVersion) {
@@ -55,6 +71,11 @@ func UpgradeOlaresPipeline(opts *options.UpgradeOptions) error {
 		return fmt.Errorf("error creating runtime: %v", err)
 	}
 
+	plan := upgrade.NewPlan(runtime.GetBaseDir(), runtime.GetSystemInfo().GetOsArch(), currentVersion, targetVersion)
+	if len(plan.Blockers) > 0 {
+		return errors.Errorf("cannot upgrade from %s to %s: %s, see olares-cli upgrade plan", currentVersion, targetVersion, strings.Join(plan.Blockers, "; "))
+	}
+
 	manifest := path.Join(runtime.GetInstallerDir(), "installation.manifest")
 	runtime.Arg.SetManifest(manifest)
 
@@ -63,16 +84,22 @@ func UpgradeOlaresPipeline(opts *options.UpgradeOptions) error {
 		TargetVersion:  targetVersion,
 	}
 
+	// a failed upgrade is rolled back by default, from the snapshot taken by the run only, see UpgradeModule
+	opts.Options.RollbackOnFailure = !opts.NoRollback
 	p := &pipeline.Pipeline{
 		Name:    "UpgradeOlares",
-		Modules: []module.Module{upgradeModule},
+		Modules: []module.Module{upgradeModule, &history.RecordInventoryModule{}},
 		Runtime: runtime,
+		Options: opts.Options,
 	}
 
 	logger.Infof("Starting Olares upgrade from %s to %s...", currentVersion, opts.Version)
 	if err := p.Start(); err != nil {
 		return errors.Wrap(err, "upgrade failed")
 	}
+	if p.DryRun {
+		return nil
+	}
 
 	logger.Info("Olares upgrade completed successfully!")
 	return nil
@@ -97,3 +124,145 @@ func UpgradePreCheckPipeline() error {
 	return p.Start()
 
 }
+
+// UpgradeRollbackPipeline restores the system from a snapshot taken before an upgrade, the latest one by default
+func UpgradeRollbackPipeline(opts *options.UpgradeRollbackOptions) error {
+	arg := common.NewArgument()
+	arg.SetBaseDir(opts.BaseDir)
+
+	var snapshot *upgrade.Snapshot
+	var err error
+	if opts.Snapshot != "" {
+		snapshot, err = upgrade.LoadSnapshot(opts.Snapshot)
+	} else {
+		snapshot, err = upgrade.LatestSnapshot(arg.BaseDir)
+	}
+	if err != nil {
+		return errors.Wrap(err, "failed to load the snapshot")
+	}
+	if snapshot == nil {
+		return errors.Errorf("no snapshot taken before an upgrade found in %s", upgrade.SnapshotsDir(arg.BaseDir))
+	}
+
+	// the installation package of the version upgraded from is used to restore the charts of app-service
+	arg.SetOlaresVersion(snapshot.CurrentVersion)
+	arg.SetConsoleLog("upgrade-rollback.log", true)
+	arg.SetKubeVersion(phase.GetKubeType())
+
+	runtime, err := common.NewKubeRuntime(common.AllInOne, *arg)
+	if err != nil {
+		return fmt.Errorf("error creating runtime: %v", err)
+	}
+
+	p := &pipeline.Pipeline{
+		Name:    "RollbackUpgrade",
+		Modules: []module.Module{&upgrade.RollbackModule{Snapshot: snapshot}, &history.RecordInventoryModule{}},
+		Runtime: runtime,
+		Options: opts.Options,
+	}
+
+	logger.Infof("Rolling back Olares from %s to %s with the snapshot %s...", snapshot.TargetVersion, snapshot.CurrentVersion, snapshot.Dir)
+	if err := p.Start(); err != nil {
+		return errors.Wrap(err, "rollback failed")
+	}
+	if p.DryRun {
+		return nil
+	}
+
+	logger.Infof("Olares has been rolled back to %s", snapshot.CurrentVersion)
+	if snapshot.Datastore != "" {
+		logger.Infof("the %s datastore of the cluster saved before the upgrade is at %s, it has not been restored", snapshot.DatastoreType, filepath.Join(snapshot.Dir, snapshot.Datastore))
+	}
+	return nil
+}
+
+// UpgradePlanPipeline prints what upgrading to the target version would do, and what prevents it,
+// it exits with an error if the upgrade is blocked
+func UpgradePlanPipeline(opts *options.UpgradePlanOptions) error {
+	currentVersion, targetVersion, err := upgradeVersions(opts.Version)
+	if err != nil {
+		return err
+	}
+	arg := common.NewArgument()
+	arg.SetBaseDir(opts.BaseDir)
+
+	plan := upgrade.NewPlan(arg.BaseDir, arg.SystemInfo.GetOsArch(), currentVersion, targetVersion)
+	if pipeline.DefaultOptions.Output == pipeline.OutputJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(plan); err != nil {
+			return err
+		}
+	} else if err := printUpgradePlan(plan); err != nil {
+		return err
+	}
+	if len(plan.Blockers) > 0 {
+		return errors.Errorf("the upgrade from %s to %s is blocked", currentVersion, targetVersion)
+	}
+	return nil
+}
+
+func printUpgradePlan(plan *upgrade.Plan) error {
+	fmt.Printf("Upgrade from %s to %s\n\n", plan.CurrentVersion, plan.TargetVersion)
+	fmt.Println("Tasks:")
+	for _, group := range []struct {
+		name  string
+		tasks []string
+	}{{"pre", plan.PreTasks}, {"core", plan.CoreTasks}, {"post", plan.PostTasks}} {
+		fmt.Printf("  %-5s %s\n", group.name, orNone(strings.Join(group.tasks, ", ")))
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "\nCOMPONENT\tCURRENT\tTARGET")
+	for _, d := range plan.Components {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", d.Name, orNone(d.Current), orNone(d.Target))
+	}
+	fmt.Fprintln(w, "\nCHART\tCURRENT\tTARGET")
+	for _, d := range plan.Charts {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", d.Name, orNone(d.Current), orNone(d.Target))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Printf("\nImages: %d added, %d changed, %d removed\n", plan.Images.Added, plan.Images.Changed, plan.Images.Removed)
+	fmt.Printf("To download: %d files, %s\n", plan.MissingFiles, utils.FormatBytes(plan.DownloadSize))
+	if len(plan.Blockers) == 0 {
+		fmt.Println("\nNo blocker found, the upgrade can be done")
+		return nil
+	}
+	fmt.Println("\nBlockers:")
+	for _, b := range plan.Blockers {
+		fmt.Printf("  - %s\n", b)
+	}
+	return nil
+}
+
+// UpgradeKubePipeline upgrades Kubernetes and containerd on the local node,
+// it is run on every node, the masters first, one node at a time
+func UpgradeKubePipeline(opts *options.UpgradeKubeOptions) error {
+	if opts.Version == "" {
+		return errors.New("target Kubernetes version is required")
+	}
+	runtime, err := newNodeRuntime(opts.BaseDir, "upgrade-kube.log")
+	if err != nil {
+		return err
+	}
+
+	manifest := opts.Manifest
+	if manifest == "" {
+		manifest = path.Join(runtime.GetInstallerDir(), "installation.manifest")
+	}
+	runtime.Arg.SetManifest(manifest)
+
+	nodeName := strings.ToLower(runtime.GetSystemInfo().GetHostname())
+	p := cluster.UpgradeKubePhase(runtime, nodeName, opts.Version, opts.DrainTimeout)
+	p.Options = opts.Options
+	if err := p.Start(); err != nil {
+		return errors.Wrap(err, "upgrade Kubernetes failed")
+	}
+	if !p.DryRun {
+		logger.Infof("node %s is at Kubernetes %s, run the command on the next node to upgrade", nodeName, opts.Version)
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package builder
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sourceDateEpochEnv is the variable of https://reproducible-builds.org/specs/source-date-epoch/
+const sourceDateEpochEnv = "SOURCE_DATE_EPOCH"
+
+// sourceDate is the time the release is built from, set to every entry of the package:
+// $SOURCE_DATE_EPOCH if set, otherwise the time of the last commit of the repo, for two builds of a same commit to be identical
+func sourceDate(repoRoot string) (time.Time, error) {
+	epoch := os.Getenv(sourceDateEpochEnv)
+	if epoch == "" {
+		out, err := exec.Command("git", "-C", repoRoot, "log", "-1", "--format=%ct").Output()
+		if err != nil {
+			return time.Time{}, fmt.Errorf("failed to get the time of the last commit, set $%s to build outside of a git repo: %v", sourceDateEpochEnv, err)
+		}
+		epoch = strings.TrimSpace(string(out))
+	}
+	seconds, err := strconv.ParseInt(epoch, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid source date epoch %q: %v", epoch, err)
+	}
+	return time.Unix(seconds, 0).UTC(), nil
+}
+
+// createReproducibleTar archives the regular files under src into the gzip compressed tarball dst,
+// sorted by name, with the modification time given, owned by root and with normalized permissions,
+// for the tarball to only depend on the content of the files
+func createReproducibleTar(src, dst string, modTime time.Time) error {
+	var files []string
+	err := filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type().IsRegular() {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	names := make(map[string]string, len(files))
+	for _, path := range files {
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		names[path] = filepath.ToSlash(rel)
+	}
+	sort.Slice(files, func(i, j int) bool { return names[files[i]] < names[files[j]] })
+
+	fw, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer fw.Close()
+	// the header of the gzip stream holds neither a name nor a time
+	gw := gzip.NewWriter(fw)
+	tw := tar.NewWriter(gw)
+	for _, path := range files {
+		if err := addReproducibleEntry(tw, path, names[path], modTime); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := gw.Close(); err != nil {
+		return err
+	}
+	return fw.Close()
+}
+
+func addReproducibleEntry(tw *tar.Writer, path, name string, modTime time.Time) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	var mode int64 = 0644
+	if info.Mode().Perm()&0111 != 0 {
+		mode = 0755
+	}
+	hdr := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Mode:     mode,
+		Size:     info.Size(),
+		ModTime:  modTime,
+		Uname:    "root",
+		Gname:    "root",
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	if _, err := io.Copy(tw, f); err != nil {
+		return err
+	}
+	return nil
+}
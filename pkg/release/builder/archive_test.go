@@ -0,0 +1,99 @@
+package builder
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCreateReproducibleTar(t *testing.T) {
+	src := t.TempDir()
+	for name, mode := range map[string]os.FileMode{"install.sh": 0700, "wizard/config/b.yaml": 0600, "a.txt": 0664} {
+		path := filepath.Join(src, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(name), mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+	date := time.Unix(1700000000, 0)
+	first := filepath.Join(t.TempDir(), "first.tar.gz")
+	if err := createReproducibleTar(src, first, date); err != nil {
+		t.Fatal(err)
+	}
+	// touching the files changes nothing
+	if err := os.Chtimes(filepath.Join(src, "a.txt"), time.Now(), time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	second := filepath.Join(t.TempDir(), "second.tar.gz")
+	if err := createReproducibleTar(src, second, date); err != nil {
+		t.Fatal(err)
+	}
+	a, _ := os.ReadFile(first)
+	b, _ := os.ReadFile(second)
+	if !bytes.Equal(a, b) {
+		t.Fatal("two archives of the same files differ")
+	}
+
+	gr, err := gzip.NewReader(bytes.NewReader(a))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gr)
+	var names []string
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, hdr.Name)
+		if !hdr.ModTime.Equal(date) || hdr.Uid != 0 || hdr.Uname != "root" {
+			t.Errorf("%s: mtime %s, owner %d %s, want %s and root", hdr.Name, hdr.ModTime, hdr.Uid, hdr.Uname, date)
+		}
+		wantMode := int64(0644)
+		if hdr.Name == "install.sh" {
+			wantMode = 0755
+		}
+		if hdr.Mode != wantMode {
+			t.Errorf("%s: mode %o, want %o", hdr.Name, hdr.Mode, wantMode)
+		}
+	}
+	if got := strings.Join(names, ","); got != "a.txt,install.sh,wizard/config/b.yaml" {
+		t.Errorf("entries %s, want them sorted", got)
+	}
+}
+
+func TestWriteSums(t *testing.T) {
+	dir := t.TempDir()
+	wizard := filepath.Join(dir, "install-wizard-v1.0.0.tar.gz")
+	sbom := filepath.Join(dir, "install-wizard-v1.0.0.spdx.json")
+	if err := os.WriteFile(wizard, []byte("wizard"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(sbom, []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	sums := filepath.Join(dir, "install-wizard-v1.0.0.sha256sums")
+	if err := writeSums(sums, wizard, sbom); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(sums)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "44136fa355b3678a1146ad16f7e8649e94fb4fc21fe77e8310c060f61caaff8a  install-wizard-v1.0.0.spdx.json\n" +
+		"67a7d5777fedda6f582329a7f1199a6fcafd40da7d6b29e29271d8d97b4233ee  install-wizard-v1.0.0.tar.gz\n"
+	if string(data) != want {
+		t.Errorf("sums:\n%s\nwant:\n%s", data, want)
+	}
+}
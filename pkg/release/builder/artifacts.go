@@ -0,0 +1,98 @@
+package builder
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"bytetrade.io/web3os/installer/pkg/manifest"
+	"bytetrade.io/web3os/installer/pkg/release/sbom"
+	"bytetrade.io/web3os/installer/version"
+)
+
+// Artifacts are the files of a release, next to each other in the root of the repo
+type Artifacts struct {
+	// Package is the install wizard tarball
+	Package string
+	// SBOM is the SPDX document of the images and binaries of the manifest
+	SBOM string
+	// Sums is the SHA-256 sums of the package and of the SBOM, in the format of sha256sum
+	Sums string
+	// Signature is the detached signature of Sums, only made with a signing key
+	Signature string
+}
+
+// writeSBOM writes the SPDX document of the images and the binaries of the manifest
+func (b *Builder) writeSBOM(manifestPath, dst string, created time.Time) error {
+	data, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return err
+	}
+	doc, err := manifest.Parse(data)
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %v", manifestPath, err)
+	}
+	name := strings.TrimSuffix(filepath.Base(dst), sbomSuffix)
+	out, err := sbom.Generate(name, b.version, data, doc.Items, b.options.CDNURL, "olares-cli-"+version.VERSION, created).Marshal()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(dst, out, 0644)
+}
+
+// sign returns the detached signature of data with the signing key, see manifest.Sign
+func (b *Builder) sign(data []byte) ([]byte, error) {
+	key, err := os.ReadFile(b.options.SigningKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signing key: %v", err)
+	}
+	return manifest.Sign(data, strings.TrimSpace(string(key)))
+}
+
+// signFile writes the detached signature of the file next to it
+func (b *Builder) signFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	sig, err := b.sign(data)
+	if err != nil {
+		return "", err
+	}
+	sigPath := manifest.SignaturePath(path)
+	return sigPath, os.WriteFile(sigPath, sig, 0644)
+}
+
+// writeSums writes the SHA-256 sums of the files in the format of sha256sum, sorted by name,
+// for them to be checked with sha256sum -c from the directory they are in
+func writeSums(dst string, files ...string) error {
+	lines := make([]string, 0, len(files))
+	for _, file := range files {
+		sum, err := sha256File(file)
+		if err != nil {
+			return err
+		}
+		lines = append(lines, fmt.Sprintf("%s  %s\n", sum, filepath.Base(file)))
+	}
+	sort.Slice(lines, func(i, j int) bool { return lines[i][66:] < lines[j][66:] })
+	return os.WriteFile(dst, []byte(strings.Join(lines, "")), 0644)
+}
+
+func sha256File(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
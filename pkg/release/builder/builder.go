@@ -7,41 +7,72 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 )
 
+const (
+	sbomSuffix = ".spdx.json"
+	sumsSuffix = ".sha256sums"
+)
+
+// Options are those of a release build
+type Options struct {
+	// CDNURL is where the checksums of the dependencies and of the images are downloaded from
+	CDNURL              string
+	IgnoreMissingImages bool
+	// AllowMissingChecksums writes the dependencies and the arm64 images whose checksum is not found with an empty one,
+	// rather than failing the build
+	AllowMissingChecksums bool
+	// SigningKeyFile holds the base64 encoded ed25519 private key signing the manifest and the sums, if set
+	SigningKeyFile string
+}
+
 type Builder struct {
 	olaresRepoRoot  string
 	distPath        string
 	version         string
+	options         Options
 	manifestManager *manifest.Manager
 	appManager      *app.Manager
 }
 
-func NewBuilder(olaresRepoRoot, version, cdnURL string, ignoreMissingImages bool) *Builder {
+func NewBuilder(olaresRepoRoot, version string, options Options) *Builder {
 	distPath := filepath.Join(olaresRepoRoot, ".dist/install-wizard")
 	return &Builder{
 		olaresRepoRoot:  olaresRepoRoot,
 		distPath:        distPath,
 		version:         version,
-		manifestManager: manifest.NewManager(olaresRepoRoot, cdnURL, ignoreMissingImages),
+		options:         options,
+		manifestManager: manifest.NewManager(olaresRepoRoot, options.CDNURL, options.IgnoreMissingImages, options.AllowMissingChecksums),
 		appManager:      app.NewManager(olaresRepoRoot, distPath),
 	}
 }
 
-func (b *Builder) Build() (string, error) {
+func (b *Builder) Build() (*Artifacts, error) {
+	// the time of the entries of the package, checked first not to fail after a long build
+	sourceDate, err := sourceDate(b.olaresRepoRoot)
+	if err != nil {
+		return nil, err
+	}
+	if b.options.SigningKeyFile != "" {
+		if _, err := b.sign(nil); err != nil {
+			return nil, err
+		}
+	}
+
 	// Clean previous build
 	if err := os.RemoveAll(filepath.Join(b.olaresRepoRoot, ".dist")); err != nil {
-		return "", fmt.Errorf("failed to clean previous dist directory: %v", err)
+		return nil, fmt.Errorf("failed to clean previous dist directory: %v", err)
 	}
 
 	// Package apps
 	if err := b.appManager.Package(); err != nil {
-		return "", fmt.Errorf("package apps failed: %v", err)
+		return nil, fmt.Errorf("package apps failed: %v", err)
 	}
 
 	// Build manifest
 	if err := b.manifestManager.Build(); err != nil {
-		return "", fmt.Errorf("manifest build failed: %v", err)
+		return nil, fmt.Errorf("manifest build failed: %v", err)
 	}
 
 	// Copy upgrade script, as the current build.sh does
@@ -49,29 +80,29 @@ func (b *Builder) Build() (string, error) {
 		filepath.Join(b.olaresRepoRoot, "scripts/upgrade.sh"),
 		filepath.Join(b.distPath, "upgrade.sh"),
 	); err != nil {
-		return "", fmt.Errorf("failed to copy upgrade script: %v", err)
+		return nil, fmt.Errorf("failed to copy upgrade script: %v", err)
 	}
 
 	// archive the install-wizard
-	return b.createFinalPackage()
+	return b.createFinalPackage(sourceDate)
 
 }
 
-func (b *Builder) createFinalPackage() (string, error) {
+func (b *Builder) createFinalPackage(sourceDate time.Time) (*Artifacts, error) {
 	if err := os.RemoveAll(filepath.Join(b.distPath, "images")); err != nil {
-		return "", err
+		return nil, err
 	}
 
 	manifestSrc := filepath.Join(b.olaresRepoRoot, ".manifest/installation.manifest")
 	manifestDst := filepath.Join(b.distPath, "installation.manifest")
 	if err := util.MoveFile(manifestSrc, manifestDst); err != nil {
-		return "", err
+		return nil, err
 	}
 
 	imagesSrc := filepath.Join(b.olaresRepoRoot, ".manifest")
 	imagesDst := filepath.Join(b.distPath, "images")
 	if err := util.MoveDirectory(imagesSrc, imagesDst); err != nil {
-		return "", err
+		return nil, err
 	}
 
 	versionStr := "v" + b.version
@@ -83,10 +114,38 @@ func (b *Builder) createFinalPackage() (string, error) {
 
 	for _, file := range files {
 		if err := util.ReplaceInFile(file, "#__VERSION__", b.version); err != nil {
-			return "", err
+			return nil, err
 		}
 	}
 
-	tarFile := filepath.Join(b.olaresRepoRoot, fmt.Sprintf("install-wizard-%s.tar.gz", versionStr))
-	return tarFile, util.Tar(b.distPath, tarFile, b.distPath)
+	// the installer checks the signature of the manifest against its public keys if it is shipped along
+	if b.options.SigningKeyFile != "" {
+		if _, err := b.signFile(manifestDst); err != nil {
+			return nil, fmt.Errorf("failed to sign the manifest: %v", err)
+		}
+	}
+
+	name := fmt.Sprintf("install-wizard-%s", versionStr)
+	a := &Artifacts{
+		Package: filepath.Join(b.olaresRepoRoot, name+".tar.gz"),
+		SBOM:    filepath.Join(b.olaresRepoRoot, name+sbomSuffix),
+		Sums:    filepath.Join(b.olaresRepoRoot, name+sumsSuffix),
+	}
+	if err := createReproducibleTar(b.distPath, a.Package, sourceDate); err != nil {
+		return nil, fmt.Errorf("failed to archive the install wizard: %v", err)
+	}
+	if err := b.writeSBOM(manifestDst, a.SBOM, sourceDate); err != nil {
+		return nil, fmt.Errorf("failed to write the SBOM: %v", err)
+	}
+	if err := writeSums(a.Sums, a.Package, a.SBOM); err != nil {
+		return nil, fmt.Errorf("failed to write the sums: %v", err)
+	}
+	if b.options.SigningKeyFile != "" {
+		sig, err := b.signFile(a.Sums)
+		if err != nil {
+			return nil, fmt.Errorf("failed to sign the sums: %v", err)
+		}
+		a.Signature = sig
+	}
+	return a, nil
 }
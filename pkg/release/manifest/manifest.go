@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"bytetrade.io/web3os/installer/pkg/core/util"
 	"crypto/md5"
+	"errors"
 	"fmt"
 	dockerref "github.com/containerd/containerd/reference/docker"
 	"io"
@@ -15,17 +16,24 @@ import (
 	"strings"
 )
 
+// ErrChecksumNotFound is returned when the checksum of an object is not found on the CDN,
+// i.e. the object has not been uploaded yet
+var ErrChecksumNotFound = errors.New("checksum not found")
+
 type Manager struct {
 	olaresRepoRoot      string
 	cdnURL              string
 	ignoreMissingImages bool
+	// allowMissingChecksums writes the items whose checksum is not found with an empty one, rather than failing
+	allowMissingChecksums bool
 }
 
-func NewManager(olaresRepoRoot, cdnURL string, ignoreMissingImages bool) *Manager {
+func NewManager(olaresRepoRoot, cdnURL string, ignoreMissingImages, allowMissingChecksums bool) *Manager {
 	return &Manager{
-		olaresRepoRoot:      olaresRepoRoot,
-		cdnURL:              cdnURL,
-		ignoreMissingImages: ignoreMissingImages,
+		olaresRepoRoot:        olaresRepoRoot,
+		cdnURL:                cdnURL,
+		ignoreMissingImages:   ignoreMissingImages,
+		allowMissingChecksums: allowMissingChecksums,
 	}
 }
 
@@ -147,7 +155,7 @@ func (m *Manager) downloadChecksum(name string) (string, error) {
 	// update: it seems that sometimes 404 is also returned
 	if resp.StatusCode != http.StatusOK {
 		if resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusNotFound {
-			return "", nil
+			return "", ErrChecksumNotFound
 		}
 		return "", fmt.Errorf("failed to download checksum, status code: %d", resp.StatusCode)
 	}
@@ -157,7 +165,24 @@ func (m *Manager) downloadChecksum(name string) (string, error) {
 		return "", fmt.Errorf("failed to read http body for checksum: %v", err)
 	}
 
-	return strings.Fields(string(body))[0], nil
+	fields := strings.Fields(string(body))
+	if len(fields) == 0 {
+		return "", ErrChecksumNotFound
+	}
+	return fields[0], nil
+}
+
+// checksumOrAllowed is the checksum of the object, or an empty one if it is not found and missing checksums are allowed
+func (m *Manager) checksumOrAllowed(name, item string) (string, error) {
+	checksum, err := m.downloadChecksum(name)
+	if errors.Is(err, ErrChecksumNotFound) {
+		if m.allowMissingChecksums {
+			fmt.Printf("warning: checksum of %s, object: %s, is not found, writing it empty as missing checksums are allowed\n", item, name)
+			return "", nil
+		}
+		return "", fmt.Errorf("checksum of %s, object: %s, is not found, has it been uploaded to the CDN?", item, name)
+	}
+	return checksum, err
 }
 
 func (m *Manager) processDependencies(depType, manifestFile string) error {
@@ -194,12 +219,12 @@ func (m *Manager) processDependencies(depType, manifestFile string) error {
 
 		fmt.Printf("downloading md5 checksum for dependency %s, object: %s\n", filename, name)
 
-		checksumAMD64, err := m.downloadChecksum(urlAMD64)
+		checksumAMD64, err := m.checksumOrAllowed(urlAMD64, filename)
 		if err != nil {
 			return err
 		}
 
-		checksumARM64, err := m.downloadChecksum(urlARM64)
+		checksumARM64, err := m.checksumOrAllowed(urlARM64, filename)
 		if err != nil {
 			return err
 		}
@@ -242,18 +267,18 @@ func (m *Manager) processImages(manifestFile string) error {
 		fmt.Printf("downloading checksum for image %s, object: %s\n", line, urlAMD64)
 
 		checksumAMD64, err := m.downloadChecksum(name)
+		if errors.Is(err, ErrChecksumNotFound) && m.ignoreMissingImages {
+			fmt.Printf("skipping image %s due to missing checksum\n", line)
+			continue
+		}
+		if errors.Is(err, ErrChecksumNotFound) {
+			return fmt.Errorf("checksum of image %s is not found, has it been uploaded to the CDN?", line)
+		}
 		if err != nil {
 			return fmt.Errorf("failed to download AMD64 checksum for %s: %v", line, err)
 		}
-		if checksumAMD64 == "" {
-			if m.ignoreMissingImages {
-				fmt.Printf("skipping image %s due to missing checksum\n", line)
-				continue
-			}
-			return fmt.Errorf("got empty checksum for image %s", line)
-		}
 
-		checksumARM64, err := m.downloadChecksum("arm64/" + name)
+		checksumARM64, err := m.checksumOrAllowed("arm64/"+name, "image "+line)
 		if err != nil {
 			return fmt.Errorf("failed to download ARM64 checksum for %s: %v", line, err)
 		}
@@ -0,0 +1,175 @@
+package sbom
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"bytetrade.io/web3os/installer/pkg/manifest"
+	dockerref "github.com/containerd/containerd/reference/docker"
+)
+
+// the SPDX version and license of the documents generated, see https://spdx.github.io/spdx-spec/v2.3/
+const (
+	SPDXVersion = "SPDX-2.3"
+	DataLicense = "CC0-1.0"
+	noAssertion = "NOASSERTION"
+	rootID      = "SPDXRef-Package-install-wizard"
+)
+
+// Document is an SPDX document in its JSON serialization
+type Document struct {
+	SPDXVersion       string         `json:"spdxVersion"`
+	DataLicense       string         `json:"dataLicense"`
+	SPDXID            string         `json:"SPDXID"`
+	Name              string         `json:"name"`
+	DocumentNamespace string         `json:"documentNamespace"`
+	CreationInfo      CreationInfo   `json:"creationInfo"`
+	Packages          []Package      `json:"packages"`
+	Relationships     []Relationship `json:"relationships"`
+}
+
+type CreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+type Package struct {
+	SPDXID                string        `json:"SPDXID"`
+	Name                  string        `json:"name"`
+	VersionInfo           string        `json:"versionInfo,omitempty"`
+	DownloadLocation      string        `json:"downloadLocation"`
+	FilesAnalyzed         bool          `json:"filesAnalyzed"`
+	PrimaryPackagePurpose string        `json:"primaryPackagePurpose,omitempty"`
+	Checksums             []Checksum    `json:"checksums,omitempty"`
+	ExternalRefs          []ExternalRef `json:"externalRefs,omitempty"`
+	Comment               string        `json:"comment,omitempty"`
+}
+
+type Checksum struct {
+	Algorithm     string `json:"algorithm"`
+	ChecksumValue string `json:"checksumValue"`
+}
+
+type ExternalRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+}
+
+type Relationship struct {
+	SPDXElementID      string `json:"spdxElementId"`
+	RelationshipType   string `json:"relationshipType"`
+	RelatedSPDXElement string `json:"relatedSpdxElement"`
+}
+
+// Generate describes the install wizard of the version as a package containing the images and the binaries
+// of the manifest, one package per architecture they are built for.
+// It only depends on its arguments, for the builds of a same release to produce a same document:
+// its namespace is derived from the manifest and created is the time the release is built from
+func Generate(name, version string, manifestData []byte, items []*manifest.ManifestItem, cdnURL, tool string, created time.Time) *Document {
+	sum := sha256.Sum256(manifestData)
+	doc := &Document{
+		SPDXVersion:       SPDXVersion,
+		DataLicense:       DataLicense,
+		SPDXID:            "SPDXRef-DOCUMENT",
+		Name:              name,
+		DocumentNamespace: fmt.Sprintf("https://olares.com/spdx/%s-%x", name, sum[:8]),
+		CreationInfo: CreationInfo{
+			Created:  created.UTC().Format(time.RFC3339),
+			Creators: []string{"Tool: " + tool},
+		},
+		Packages: []Package{{
+			SPDXID:                rootID,
+			Name:                  "install-wizard",
+			VersionInfo:           version,
+			DownloadLocation:      noAssertion,
+			PrimaryPackagePurpose: "INSTALL",
+		}},
+		Relationships: []Relationship{{
+			SPDXElementID:      "SPDXRef-DOCUMENT",
+			RelationshipType:   "DESCRIBES",
+			RelatedSPDXElement: rootID,
+		}},
+	}
+
+	for i, item := range items {
+		arches := item.Arches()
+		names := make([]string, 0, len(arches))
+		for arch := range arches {
+			names = append(names, arch)
+		}
+		sort.Strings(names)
+		for _, arch := range names {
+			file := arches[arch]
+			if file.Url == "" {
+				continue
+			}
+			pkg := itemPackage(item, arch, file.Url, cdnURL)
+			pkg.SPDXID = fmt.Sprintf("SPDXRef-Package-%d-%s", i, arch)
+			if file.SHA256 != "" {
+				pkg.Checksums = append(pkg.Checksums, Checksum{Algorithm: "SHA256", ChecksumValue: file.SHA256})
+			}
+			if file.Checksum != "" {
+				pkg.Checksums = append(pkg.Checksums, Checksum{Algorithm: "MD5", ChecksumValue: file.Checksum})
+			}
+			doc.Packages = append(doc.Packages, pkg)
+			doc.Relationships = append(doc.Relationships, Relationship{
+				SPDXElementID:      rootID,
+				RelationshipType:   "CONTAINS",
+				RelatedSPDXElement: pkg.SPDXID,
+			})
+		}
+	}
+	return doc
+}
+
+func itemPackage(item *manifest.ManifestItem, arch, url, cdnURL string) Package {
+	pkg := Package{
+		Name:                  item.Filename,
+		DownloadLocation:      strings.TrimSuffix(cdnURL, "/") + "/" + strings.TrimPrefix(url, "/"),
+		PrimaryPackagePurpose: "APPLICATION",
+		Comment:               fmt.Sprintf("%s for %s, type %s", item.FileID, arch, item.Type),
+	}
+	if item.ImageName == "" {
+		return pkg
+	}
+
+	pkg.Name = item.ImageName
+	pkg.PrimaryPackagePurpose = "CONTAINER"
+	pkg.Comment = fmt.Sprintf("image for %s", arch)
+	ref, err := dockerref.ParseDockerRef(item.ImageName)
+	if err != nil {
+		return pkg
+	}
+	purl := "pkg:docker/" + dockerref.Path(ref)
+	if tagged, ok := ref.(dockerref.Tagged); ok {
+		pkg.VersionInfo = tagged.Tag()
+		purl += "@" + tagged.Tag()
+	}
+	if digested, ok := ref.(dockerref.Digested); ok {
+		purl += "@" + digested.Digest().String()
+	}
+	purl += "?arch=" + arch
+	if domain := dockerref.Domain(ref); domain != "docker.io" {
+		purl += "&repository_url=" + domain
+	}
+	pkg.ExternalRefs = []ExternalRef{{
+		ReferenceCategory: "PACKAGE-MANAGER",
+		ReferenceType:     "purl",
+		ReferenceLocator:  purl,
+	}}
+	return pkg
+}
+
+// Marshal encodes the document as indented JSON
+func (d *Document) Marshal() ([]byte, error) {
+	data, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
@@ -0,0 +1,43 @@
+package sbom
+
+import (
+	"testing"
+	"time"
+
+	"bytetrade.io/web3os/installer/pkg/manifest"
+)
+
+const testManifest = `kubectl-v1.29.0,pkg/kubectl,components,2b1f,aaa1,arm64/2b1f,,kubectl
+9f3c.tar.gz,images,images.mf,9f3c.tar.gz,bbb2,arm64/9f3c.tar.gz,ccc3,beclab/bfl:v0.3.1
+`
+
+func TestGenerate(t *testing.T) {
+	doc, err := manifest.Parse([]byte(testManifest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	created := time.Unix(1700000000, 0)
+	d := Generate("install-wizard-v1.0.0", "1.0.0", []byte(testManifest), doc.Items, "https://cdn.example.com/", "olares-cli-test", created)
+	again := Generate("install-wizard-v1.0.0", "1.0.0", []byte(testManifest), doc.Items, "https://cdn.example.com/", "olares-cli-test", created)
+	if d.DocumentNamespace != again.DocumentNamespace || d.CreationInfo.Created != "2023-11-14T22:13:20Z" {
+		t.Errorf("namespace %s, created %s, want them to only depend on the arguments", d.DocumentNamespace, d.CreationInfo.Created)
+	}
+
+	// the wizard, then kubectl and bfl for both architectures
+	if len(d.Packages) != 5 || len(d.Relationships) != 5 {
+		t.Fatalf("%d packages, %d relationships, want 5 and 5", len(d.Packages), len(d.Relationships))
+	}
+	kubectl := d.Packages[1]
+	if kubectl.Name != "kubectl-v1.29.0" || kubectl.DownloadLocation != "https://cdn.example.com/2b1f" ||
+		len(kubectl.Checksums) != 1 || kubectl.Checksums[0].ChecksumValue != "aaa1" {
+		t.Errorf("kubectl amd64 = %+v", kubectl)
+	}
+	if arm := d.Packages[2]; len(arm.Checksums) != 0 {
+		t.Errorf("kubectl arm64 = %+v, want no checksum", arm)
+	}
+	bfl := d.Packages[4]
+	if bfl.Name != "beclab/bfl:v0.3.1" || bfl.VersionInfo != "v0.3.1" || bfl.PrimaryPackagePurpose != "CONTAINER" ||
+		len(bfl.ExternalRefs) != 1 || bfl.ExternalRefs[0].ReferenceLocator != "pkg:docker/beclab/bfl@v0.3.1?arch=arm64" {
+		t.Errorf("bfl arm64 = %+v", bfl)
+	}
+}
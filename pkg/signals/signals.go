@@ -2,8 +2,10 @@ package signals
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 )
 
@@ -36,3 +38,63 @@ func SetupSignalHandler(ctx context.Context, cancel context.CancelFunc) (stopCh
 
 	return stop
 }
+
+var (
+	gracefulOnce sync.Once
+	stopCtx      context.Context
+	killCtx      context.Context
+
+	hooksMu    sync.Mutex
+	hooks      = make(map[int]func())
+	nextHookID int
+)
+
+// GracefulShutdown registers for SIGTERM and SIGINT and returns two contexts shared by the whole program:
+// stop is canceled on the first signal, no new work should be started past that point,
+// kill is canceled on the second one, the work still running should then be aborted.
+// A third signal runs the force exit hooks and terminates the program with exit code 1.
+func GracefulShutdown() (stop, kill context.Context) {
+	gracefulOnce.Do(func() {
+		var cancelStop, cancelKill context.CancelFunc
+		stopCtx, cancelStop = context.WithCancel(context.Background())
+		killCtx, cancelKill = context.WithCancel(context.Background())
+
+		c := make(chan os.Signal, 3)
+		signal.Notify(c, shutdownSignals...)
+		go func() {
+			<-c
+			fmt.Fprintln(os.Stderr, "\nInterrupted, waiting for the running tasks to finish, press Ctrl-C again to abort them")
+			cancelStop()
+			<-c
+			fmt.Fprintln(os.Stderr, "\nAborting the running tasks, press Ctrl-C again to exit immediately")
+			cancelKill()
+			<-c
+			runForceExitHooks()
+			os.Exit(1)
+		}()
+	})
+	return stopCtx, killCtx
+}
+
+// OnForceExit registers fn to be run before the program is terminated by a third signal,
+// the returned function unregisters it
+func OnForceExit(fn func()) (remove func()) {
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+	id := nextHookID
+	nextHookID++
+	hooks[id] = fn
+	return func() {
+		hooksMu.Lock()
+		defer hooksMu.Unlock()
+		delete(hooks, id)
+	}
+}
+
+func runForceExitHooks() {
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+	for _, fn := range hooks {
+		fn()
+	}
+}
@@ -0,0 +1,294 @@
+package status
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"bytetrade.io/web3os/installer/pkg/certs"
+	"bytetrade.io/web3os/installer/pkg/common"
+	"bytetrade.io/web3os/installer/pkg/core/util"
+	daemontemplates "bytetrade.io/web3os/installer/pkg/daemon/templates"
+	"bytetrade.io/web3os/installer/pkg/storage"
+	storagetemplates "bytetrade.io/web3os/installer/pkg/storage/templates"
+	"bytetrade.io/web3os/installer/pkg/terminus"
+	"github.com/joho/godotenv"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// the statuses of a Check, and of the Report as the worst of them
+const (
+	OK       = "ok"
+	Warning  = "warning"
+	Degraded = "degraded"
+)
+
+// the categories of the checks
+const (
+	CategoryRelease      = "release"
+	CategoryPhase        = "phase"
+	CategoryService      = "service"
+	CategoryStorage      = "storage"
+	CategoryKubernetes   = "kubernetes"
+	CategoryNode         = "node"
+	CategoryPods         = "pods"
+	CategoryCertificates = "certificates"
+)
+
+const (
+	// kubeTimeout bounds every request to the API server, for the status not to hang on a cluster that is down
+	kubeTimeout = 10 * time.Second
+	// podErrorsShown is how many of the key pods that are not running are detailed
+	podErrorsShown = 3
+	mountsFile     = "/proc/mounts"
+	systemdUnitDir = "/etc/systemd/system"
+)
+
+// Check is the health of a component of Olares
+type Check struct {
+	Category string `json:"category"`
+	Name     string `json:"name"`
+	Status   string `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+}
+
+// Report is the health of Olares on the local node, Status is the worst of the checks
+type Report struct {
+	Version string  `json:"version,omitempty"`
+	BaseDir string  `json:"baseDir,omitempty"`
+	Status  string  `json:"status"`
+	Checks  []Check `json:"checks"`
+}
+
+func (r *Report) add(category, name, status, detail string) {
+	r.Checks = append(r.Checks, Check{Category: category, Name: name, Status: status, Detail: detail})
+	r.Status = worst(r.Status, status)
+}
+
+// Degraded lists the checks that are degraded
+func (r *Report) Degraded() []Check {
+	var degraded []Check
+	for _, c := range r.Checks {
+		if c.Status == Degraded {
+			degraded = append(degraded, c)
+		}
+	}
+	return degraded
+}
+
+func worst(a, b string) string {
+	rank := map[string]int{"": 0, OK: 1, Warning: 2, Degraded: 3}
+	if rank[b] > rank[a] {
+		return b
+	}
+	return a
+}
+
+// Collect checks the health of Olares on the local node, from its release file down to its pods,
+// it must run as root to check them all
+func Collect(ctx context.Context) *Report {
+	r := &Report{Status: OK}
+	r.checkRelease()
+	r.checkPhase()
+	services := r.checkServices(ctx)
+	if services[storagetemplates.JuicefsService.Name()] {
+		r.checkJuiceFSMount()
+	}
+	r.checkKubernetes(ctx)
+	r.checkCerts()
+	return r
+}
+
+func (r *Report) checkRelease() {
+	release, err := godotenv.Read(common.OlaresReleaseFile)
+	if err != nil {
+		r.add(CategoryRelease, common.OlaresReleaseFile, Degraded, fmt.Sprintf("Olares is not installed: %v", err))
+		return
+	}
+	r.Version, r.BaseDir = release[common.ENV_OLARES_VERSION], release[common.ENV_OLARES_BASE_DIR]
+	r.add(CategoryRelease, common.OlaresReleaseFile, OK, fmt.Sprintf("version %s, base dir %s", r.Version, r.BaseDir))
+}
+
+func (r *Report) checkPhase() {
+	if r.BaseDir == "" {
+		return
+	}
+	installed := filepath.Join(r.BaseDir, common.TerminusStateFileInstalled)
+	prepared := filepath.Join(r.BaseDir, common.TerminusStateFilePrepared)
+	switch {
+	case util.IsExist(installed):
+		r.add(CategoryPhase, "installed", OK, installed)
+	case util.IsExist(prepared):
+		r.add(CategoryPhase, "installed", Degraded, "the system is prepared but Olares is not installed")
+	default:
+		r.add(CategoryPhase, "installed", Degraded, fmt.Sprintf("neither %s nor %s found", prepared, installed))
+	}
+}
+
+// checkServices checks the systemd units of Olares installed on the node and returns which ones are
+func (r *Report) checkServices(ctx context.Context) map[string]bool {
+	installed := make(map[string]bool)
+	services := terminus.InstalledServices()
+	if olaresd := daemontemplates.TerminusdService.Name(); util.IsExist(path.Join(systemdUnitDir, olaresd)) {
+		services = append(services, olaresd)
+	}
+	if len(services) == 0 {
+		r.add(CategoryService, "systemd", Degraded, "no service of Olares found")
+		return installed
+	}
+	for _, service := range services {
+		installed[service] = true
+		// is-active prints the state of the unit, and exits non-zero unless it is active
+		out, _ := exec.CommandContext(ctx, "systemctl", "is-active", service).Output()
+		state := strings.TrimSpace(string(out))
+		if state == "active" {
+			r.add(CategoryService, service, OK, state)
+		} else {
+			r.add(CategoryService, service, Degraded, orUnknown(state))
+		}
+	}
+	return installed
+}
+
+func (r *Report) checkJuiceFSMount() {
+	mounts, err := os.ReadFile(mountsFile)
+	if err != nil {
+		r.add(CategoryStorage, storage.OlaresJuiceFSRootDir, Degraded, fmt.Sprintf("failed to read the mounts: %v", err))
+		return
+	}
+	if fsType, ok := findMount(string(mounts), storage.OlaresJuiceFSRootDir); ok {
+		r.add(CategoryStorage, storage.OlaresJuiceFSRootDir, OK, "mounted, "+fsType)
+	} else {
+		r.add(CategoryStorage, storage.OlaresJuiceFSRootDir, Degraded, "JuiceFS is not mounted")
+	}
+}
+
+// findMount finds the type of the file system mounted on dir in the content of /proc/mounts
+func findMount(mounts, dir string) (string, bool) {
+	for _, line := range strings.Split(mounts, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 3 && filepath.Clean(fields[1]) == filepath.Clean(dir) {
+			return fields[2], true
+		}
+	}
+	return "", false
+}
+
+func (r *Report) checkKubernetes(ctx context.Context) {
+	config, err := ctrl.GetConfig()
+	if err != nil {
+		r.add(CategoryKubernetes, "apiserver", Degraded, fmt.Sprintf("no kubeconfig: %v", err))
+		return
+	}
+	config.Timeout = kubeTimeout
+	client, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		r.add(CategoryKubernetes, "apiserver", Degraded, fmt.Sprintf("failed to create kube client: %v", err))
+		return
+	}
+	if _, err := client.Discovery().RESTClient().Get().AbsPath("/readyz").DoRaw(ctx); err != nil {
+		r.add(CategoryKubernetes, "apiserver", Degraded, fmt.Sprintf("%s is not ready: %v", config.Host, err))
+		return
+	}
+	r.add(CategoryKubernetes, "apiserver", OK, config.Host)
+
+	nodes, err := client.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		r.add(CategoryNode, "nodes", Degraded, fmt.Sprintf("failed to list the nodes: %v", err))
+	} else {
+		for _, node := range nodes.Items {
+			r.checkNode(&node)
+		}
+	}
+
+	pods, err := client.CoreV1().Pods(corev1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		r.add(CategoryPods, "key pods", Degraded, fmt.Sprintf("failed to list the pods: %v", err))
+		return
+	}
+	var keyPods int
+	var problems []string
+	for _, pod := range pods.Items {
+		if !terminus.IsKeyPod(&pod) {
+			continue
+		}
+		keyPods++
+		if err := terminus.CheckKeyPod(&pod); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	switch {
+	case keyPods == 0:
+		r.add(CategoryPods, "key pods", Degraded, "no pod of Olares found")
+	case len(problems) == 0:
+		r.add(CategoryPods, "key pods", OK, fmt.Sprintf("%d running", keyPods))
+	default:
+		detail := fmt.Sprintf("%d of %d not running: %s", len(problems), keyPods, strings.Join(first(problems, podErrorsShown), "; "))
+		r.add(CategoryPods, "key pods", Degraded, detail)
+	}
+}
+
+func (r *Report) checkNode(node *corev1.Node) {
+	version := node.Status.NodeInfo.KubeletVersion
+	for _, c := range node.Status.Conditions {
+		if c.Type != corev1.NodeReady {
+			continue
+		}
+		if c.Status == corev1.ConditionTrue {
+			r.add(CategoryNode, node.Name, OK, "Ready, "+version)
+		} else {
+			r.add(CategoryNode, node.Name, Degraded, fmt.Sprintf("NotReady, %s: %s", orUnknown(c.Reason), c.Message))
+		}
+		return
+	}
+	r.add(CategoryNode, node.Name, Degraded, "no Ready condition reported")
+}
+
+func (r *Report) checkCerts() {
+	found := certs.ScanCerts()
+	if len(found) == 0 {
+		return
+	}
+	certs.CheckExpiry(found, time.Duration(certs.ExpiryWarnDays)*24*time.Hour, time.Now())
+	var expired, expiring int
+	for _, c := range found {
+		switch c.Status {
+		case certs.ExpiryExpired:
+			expired++
+		case certs.ExpirySoon:
+			expiring++
+		}
+	}
+	// they are sorted by expiry, the first one expires first
+	next := fmt.Sprintf("%s of %s first on %s", found[0].Name, found[0].Component, found[0].Expires())
+	switch {
+	case expired > 0:
+		r.add(CategoryCertificates, "expiry", Degraded, fmt.Sprintf("%d of %d expired, %s", expired, len(found), next))
+	case expiring > 0:
+		r.add(CategoryCertificates, "expiry", Warning, fmt.Sprintf("%d of %d expire within %d days, %s", expiring, len(found), certs.ExpiryWarnDays, next))
+	default:
+		r.add(CategoryCertificates, "expiry", OK, fmt.Sprintf("%d valid, %s", len(found), next))
+	}
+}
+
+func first(s []string, n int) []string {
+	if len(s) > n {
+		return append(s[:n:n], fmt.Sprintf("and %d more", len(s)-n))
+	}
+	return s
+}
+
+func orUnknown(s string) string {
+	if s == "" {
+		return "unknown"
+	}
+	return s
+}
@@ -0,0 +1,36 @@
+package status
+
+import "testing"
+
+func TestReportStatus(t *testing.T) {
+	r := &Report{Status: OK}
+	r.add(CategoryService, "k3s.service", OK, "active")
+	if r.Status != OK {
+		t.Errorf("status = %s, want %s", r.Status, OK)
+	}
+	r.add(CategoryCertificates, "expiry", Warning, "expiring")
+	if r.Status != Warning {
+		t.Errorf("status = %s, want %s", r.Status, Warning)
+	}
+	r.add(CategoryService, "juicefs.service", Degraded, "failed")
+	r.add(CategoryNode, "olares", OK, "Ready")
+	if r.Status != Degraded {
+		t.Errorf("status = %s, want %s", r.Status, Degraded)
+	}
+	if d := r.Degraded(); len(d) != 1 || d[0].Name != "juicefs.service" {
+		t.Errorf("degraded checks = %+v, want juicefs.service", d)
+	}
+}
+
+func TestFindMount(t *testing.T) {
+	mounts := `/dev/sda1 / ext4 rw,relatime 0 0
+JuiceFS:rootfs /olares/rootfs fuse.juicefs rw,relatime,user_id=0,group_id=0 0 0
+tmpfs /run tmpfs rw,nosuid 0 0
+`
+	if fsType, ok := findMount(mounts, "/olares/rootfs/"); !ok || fsType != "fuse.juicefs" {
+		t.Errorf("findMount(/olares/rootfs) = %s, %t, want fuse.juicefs, true", fsType, ok)
+	}
+	if _, ok := findMount(mounts, "/olares"); ok {
+		t.Error("findMount(/olares): found, want not mounted")
+	}
+}
@@ -0,0 +1,80 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"bytetrade.io/web3os/installer/pkg/core/connector"
+	"bytetrade.io/web3os/installer/pkg/core/logger"
+	"github.com/pkg/errors"
+)
+
+// credentialsDir is where the credentials files are uploaded to on the hosts, only root has access to it
+const credentialsDir = "/etc/olares/credentials"
+
+// withCredentialsFile uploads content, holding credentials, to a file of the host of the runtime only readable by root,
+// for a command to read them from it rather than from its command line, which every user of the host sees,
+// fn is given the path of the file on the host, removed once it returns
+func withCredentialsFile(runtime connector.Runtime, content string, fn func(file string) error) error {
+	// os.CreateTemp creates the file with mode 0600, readable by the user running the installer only
+	f, err := os.CreateTemp("", "olares-credentials-")
+	if err != nil {
+		return errors.Wrap(err, "failed to create the credentials file")
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.WriteString(content); err != nil {
+		f.Close()
+		return errors.Wrap(err, "failed to write the credentials file")
+	}
+	if err := f.Close(); err != nil {
+		return errors.Wrap(err, "failed to write the credentials file")
+	}
+
+	runner := runtime.GetRunner()
+	if _, err := runner.SudoCmd(fmt.Sprintf("install -d -m 0700 -o root -g root %s", credentialsDir), false, false); err != nil {
+		return errors.Wrapf(err, "failed to create %s", credentialsDir)
+	}
+	file := filepath.Join(credentialsDir, filepath.Base(f.Name()))
+	defer func() {
+		if _, err := runner.SudoCmd(fmt.Sprintf("rm -f %s", file), false, false); err != nil {
+			logger.Warnf("failed to remove the credentials file %s: %v", file, err)
+		}
+	}()
+	if err := runner.SudoScp(f.Name(), file); err != nil {
+		return errors.Wrap(err, "failed to upload the credentials file")
+	}
+	if _, err := runner.SudoCmd(fmt.Sprintf("chown root:root %s && chmod 0600 %s", file, file), false, false); err != nil {
+		return errors.Wrap(err, "failed to restrict the access to the credentials file")
+	}
+	return fn(file)
+}
+
+// SudoCmdWithEnv runs cmd on the host of the runtime with the variables of env set in its own environment,
+// sourced from a credentials file, so that they are neither on its command line nor on that of sudo,
+// nor in the environment of the installer and of the other commands it runs
+func SudoCmdWithEnv(runtime connector.Runtime, cmd string, env map[string]string, printOutput, printLine bool) (string, error) {
+	var output string
+	err := withCredentialsFile(runtime, envFileContent(env), func(file string) error {
+		var err error
+		output, err = runtime.GetRunner().SudoCmd(fmt.Sprintf("set -a && . %s && set +a && %s", file, cmd), printOutput, printLine)
+		return err
+	})
+	return output, err
+}
+
+// envFileContent returns the variables of env as assignments for a shell to source, their values quoted
+func envFileContent(env map[string]string) string {
+	names := make([]string, 0, len(env))
+	for name := range env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&b, "%s='%s'\n", name, strings.ReplaceAll(env[name], "'", `'\''`))
+	}
+	return b.String()
+}
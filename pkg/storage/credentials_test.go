@@ -0,0 +1,11 @@
+package storage
+
+import "testing"
+
+func TestEnvFileContent(t *testing.T) {
+	got := envFileContent(map[string]string{"SECRET_KEY": `it's $ecret`, "ACCESS_KEY": "AKID"})
+	want := "ACCESS_KEY='AKID'\nSECRET_KEY='it'\\''s $ecret'\n"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
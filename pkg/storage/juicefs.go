@@ -10,6 +10,7 @@ import (
 	cc "bytetrade.io/web3os/installer/pkg/core/common"
 	corecommon "bytetrade.io/web3os/installer/pkg/core/common"
 	"bytetrade.io/web3os/installer/pkg/core/connector"
+	"bytetrade.io/web3os/installer/pkg/core/logger"
 	"bytetrade.io/web3os/installer/pkg/core/task"
 	"bytetrade.io/web3os/installer/pkg/manifest"
 	"bytetrade.io/web3os/installer/pkg/utils"
@@ -62,10 +63,11 @@ func (m *InstallJuiceFsModule) Init() {
 	}
 
 	checkJuiceFsState := &task.LocalTask{
-		Name:   "CheckJuiceFsState",
-		Action: new(CheckJuiceFsState),
-		Retry:  5,
-		Delay:  5 * time.Second,
+		Name:      "CheckJuiceFsState",
+		AlwaysRun: true,
+		Action:    new(CheckJuiceFsState),
+		Retry:     5,
+		Delay:     5 * time.Second,
 	}
 
 	m.Tasks = []task.Interface{
@@ -181,22 +183,20 @@ func (t *ConfigJuiceFsMetaDB) Execute(runtime connector.Runtime) error {
 	var localIp = systemInfo.GetLocalIp()
 	var redisAddress, _ = t.PipelineCache.GetMustString(common.CacheHostRedisAddress)
 	var redisPassword, _ = t.PipelineCache.GetMustString(common.CacheHostRedisPassword)
-	var redisService = fmt.Sprintf("redis://:%s@%s:6379/1", redisPassword, redisAddress)
 	if redisPassword == "" {
 		return fmt.Errorf("redis password not found")
 	}
 
-	storageFlags, err := getStorageFlags(t.KubeConf.Arg.Storage, localIp)
+	storageFlags, env, err := getStorageFlags(t.KubeConf.Arg.Storage, localIp)
 	if err != nil {
 		return err
 	}
-	var cmd = fmt.Sprintf("%s format %s", JuiceFsFile, redisService)
+	env["META_PASSWORD"] = redisPassword
+	var cmd = fmt.Sprintf("%s format redis://%s:6379/1", JuiceFsFile, redisAddress)
 	cmd = cmd + storageFlags
 
-	if _, err := runtime.GetRunner().SudoCmd(cmd, false, true); err != nil {
-		return err
-	}
-	return nil
+	_, err = SudoCmdWithEnv(runtime, cmd, env, false, true)
+	return err
 }
 
 type CheckJuiceFsState struct {
@@ -217,19 +217,22 @@ func (t *CheckJuiceFsState) Execute(runtime connector.Runtime) error {
 	return nil
 }
 
-func getStorageFlags(storage *common.Storage, localIp string) (string, error) {
+// getStorageFlags returns the flags of juicefs format for the object storage,
+// along with the credentials to be passed in the environment of juicefs
+func getStorageFlags(storage *common.Storage, localIp string) (string, map[string]string, error) {
 	var storageFlags string
+	var env map[string]string
 	var fsName string
 	var err error
 
 	switch storage.StorageType {
 	case common.ManagedMinIO:
-		storageFlags, err = getManagedMinIOAccessFlags(localIp)
+		storageFlags, env, err = getManagedMinIOAccessFlags(localIp)
 		if err != nil {
-			return "", err
+			return "", nil, err
 		}
 	default:
-		storageFlags = getExternalStorageAccessFlags(storage)
+		storageFlags, env = getExternalStorageAccessFlags(storage)
 	}
 
 	if storage.StorageVendor == "true" && storage.StorageClusterId != "" {
@@ -240,31 +243,33 @@ func getStorageFlags(storage *common.Storage, localIp string) (string, error) {
 
 	storageFlags = storageFlags + fmt.Sprintf(" %s --trash-days 0", fsName)
 
-	return storageFlags, nil
+	return storageFlags, env, nil
 }
 
-func getExternalStorageAccessFlags(storage *common.Storage) string {
+func getExternalStorageAccessFlags(storage *common.Storage) (string, map[string]string) {
 	var params = fmt.Sprintf(" --storage %s --bucket %s", storage.StorageType, storage.StorageBucket)
+	var env = make(map[string]string)
 	if storage.StorageVendor == "true" {
 		if storage.StorageToken != "" {
-			params = params + fmt.Sprintf(" --session-token %s", storage.StorageToken)
+			env["SESSION_TOKEN"] = storage.StorageToken
 		}
 	}
 	if storage.StorageAccessKey != "" {
-		params = params + fmt.Sprintf(" --access-key %s", storage.StorageAccessKey)
+		env["ACCESS_KEY"] = storage.StorageAccessKey
 	}
 	if storage.StorageSecretKey != "" {
-		params = params + fmt.Sprintf(" --secret-key %s", storage.StorageSecretKey)
+		env["SECRET_KEY"] = storage.StorageSecretKey
 	}
 
-	return params
+	return params, env
 }
 
-func getManagedMinIOAccessFlags(localIp string) (string, error) {
+func getManagedMinIOAccessFlags(localIp string) (string, map[string]string, error) {
 	minioPassword, err := getMinioPwdFromConfigFile()
 	if err != nil {
-		return "", errors.Wrap(err, "failed to get password of managed MinIO")
+		return "", nil, errors.Wrap(err, "failed to get password of managed MinIO")
 	}
-	return fmt.Sprintf(" --storage minio --bucket http://%s:9000/%s --access-key %s --secret-key %s",
-		localIp, cc.OlaresDir, MinioRootUser, minioPassword), nil
+	logger.RegisterSecret(minioPassword)
+	return fmt.Sprintf(" --storage minio --bucket http://%s:9000/%s", localIp, cc.OlaresDir),
+		map[string]string{"ACCESS_KEY": MinioRootUser, "SECRET_KEY": minioPassword}, nil
 }
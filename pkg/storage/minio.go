@@ -8,6 +8,7 @@ import (
 	"github.com/pkg/errors"
 
 	"bytetrade.io/web3os/installer/pkg/common"
+	"bytetrade.io/web3os/installer/pkg/core/cache"
 	cc "bytetrade.io/web3os/installer/pkg/core/common"
 	"bytetrade.io/web3os/installer/pkg/core/connector"
 	"bytetrade.io/web3os/installer/pkg/core/logger"
@@ -56,12 +57,14 @@ func (t *EnableMinio) Execute(runtime connector.Runtime) error {
 
 type GetOrSetMinIOPassword struct {
 	common.KubeAction
+	cache.Filler
 }
 
 func (t *GetOrSetMinIOPassword) Execute(runtime connector.Runtime) (err error) {
 	var minioPassword string
 	defer func() {
 		if err == nil {
+			logger.RegisterSecret(minioPassword)
 			t.PipelineCache.Set(common.CacheMinioPassword, minioPassword)
 		}
 	}()
@@ -74,7 +77,7 @@ func (t *GetOrSetMinIOPassword) Execute(runtime connector.Runtime) (err error) {
 		return
 	}
 	if minioPassword != "" {
-		logger.Debugf("using existing minio password: %s found in %s", minioPassword, MinioConfigFile)
+		logger.Debugf("using existing minio password found in %s", MinioConfigFile)
 		return
 	}
 	logger.Warnf("found MinIO config file %s but password is not set, generating a new one", MinioConfigFile)
@@ -82,6 +85,11 @@ func (t *GetOrSetMinIOPassword) Execute(runtime connector.Runtime) (err error) {
 	return
 }
 
+// ManagedMinioPassword reads the password of the root user of the MinIO managed by Olares on the local node
+func ManagedMinioPassword() (string, error) {
+	return getMinioPwdFromConfigFile()
+}
+
 func getMinioPwdFromConfigFile() (string, error) {
 	var cmd = fmt.Sprintf("cat %s 2>&1 |grep 'MINIO_ROOT_PASSWORD=' |cut -d'=' -f2 |tr -d '\n'", MinioConfigFile)
 	if res, _, err := util.Exec(context.Background(), cmd, false, false); err != nil {
@@ -229,12 +237,13 @@ func (m *InstallMinioModule) Init() {
 	}
 
 	checkMinioState := &task.RemoteTask{
-		Name:     "CheckMinioState",
-		Hosts:    m.Runtime.GetAllHosts(),
-		Action:   &CheckMinioState{},
-		Parallel: false,
-		Retry:    30,
-		Delay:    2 * time.Second,
+		Name:      "CheckMinioState",
+		AlwaysRun: true,
+		Hosts:     m.Runtime.GetAllHosts(),
+		Action:    &CheckMinioState{},
+		Parallel:  false,
+		Retry:     30,
+		Delay:     2 * time.Second,
 	}
 
 	m.Tasks = []task.Interface{
@@ -6,6 +6,7 @@ import (
 	"strings"
 	"time"
 
+	"bytetrade.io/web3os/installer/pkg/core/cache"
 	"bytetrade.io/web3os/installer/pkg/core/logger"
 
 	"bytetrade.io/web3os/installer/pkg/common"
@@ -64,6 +65,7 @@ func (t *EnableRedisService) Execute(runtime connector.Runtime) error {
 
 type GetOrSetRedisConfig struct {
 	common.KubeAction
+	cache.Filler
 }
 
 func (t *GetOrSetRedisConfig) Execute(runtime connector.Runtime) (err error) {
@@ -71,6 +73,7 @@ func (t *GetOrSetRedisConfig) Execute(runtime connector.Runtime) (err error) {
 	redisAddress := runtime.RemoteHost().GetInternalAddress()
 	defer func() {
 		if err == nil {
+			logger.RegisterSecret(redisPassword)
 			t.PipelineCache.Set(common.CacheHostRedisPassword, redisPassword)
 			t.PipelineCache.Set(common.CacheHostRedisAddress, redisAddress)
 		}
@@ -88,7 +91,7 @@ func (t *GetOrSetRedisConfig) Execute(runtime connector.Runtime) (err error) {
 		return
 	}
 	if redisPassword != "" {
-		logger.Debugf("using existing Redis password: %s found in %s", redisPassword, RedisConfigFile)
+		logger.Debugf("using existing Redis password found in %s", RedisConfigFile)
 		return
 	}
 	logger.Warnf("found Redis config file %s but password is not set, generating a new one", RedisConfigFile)
@@ -329,12 +332,13 @@ func (m *InstallRedisModule) Init() {
 	}
 
 	checkRedisServiceState := &task.RemoteTask{
-		Name:     "CheckState",
-		Hosts:    m.Runtime.GetAllHosts(),
-		Action:   new(CheckRedisServiceState),
-		Parallel: false,
-		Retry:    3,
-		Delay:    3 * time.Second,
+		Name:      "CheckState",
+		AlwaysRun: true,
+		Hosts:     m.Runtime.GetAllHosts(),
+		Action:    new(CheckRedisServiceState),
+		Parallel:  false,
+		Retry:     3,
+		Delay:     3 * time.Second,
 	}
 
 	m.Tasks = []task.Interface{
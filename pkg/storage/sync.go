@@ -0,0 +1,82 @@
+package storage
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"bytetrade.io/web3os/installer/pkg/common"
+	cc "bytetrade.io/web3os/installer/pkg/core/common"
+	"bytetrade.io/web3os/installer/pkg/core/logger"
+	"github.com/pkg/errors"
+)
+
+// ObjectStore is a directory of the object storage of Olares, as juicefs sync copies from or to it,
+// its credentials are kept out of its URL, not to be seen on the command line of juicefs sync, see SyncEnv
+type ObjectStore struct {
+	URL       string
+	AccessKey string
+	SecretKey string
+	Token     string
+}
+
+// NewObjectStore returns the directory dir of the object storage of Olares, under the prefix of the cluster
+// for the external storages, its URL ends with a slash, its credentials are registered as secrets for the logs to mask them
+func NewObjectStore(storage *common.Storage, localIp, dir string) (*ObjectStore, error) {
+	if storage.StorageType == common.ManagedMinIO {
+		password, err := ManagedMinioPassword()
+		if err != nil {
+			return nil, err
+		}
+		if password == "" {
+			return nil, errors.Errorf("no password of the managed MinIO found in %s", MinioConfigFile)
+		}
+		logger.RegisterSecret(password)
+		return &ObjectStore{
+			URL:       fmt.Sprintf("minio://%s/", path.Join(localIp+":9000", cc.OlaresDir, dir)),
+			AccessKey: MinioRootUser,
+			SecretKey: password,
+		}, nil
+	}
+	return externalObjectStore(storage, dir)
+}
+
+func externalObjectStore(storage *common.Storage, dir string) (*ObjectStore, error) {
+	switch storage.StorageType {
+	case common.S3, common.OSS, common.COS:
+	default:
+		return nil, errors.Errorf("unsupported storage type %q", storage.StorageType)
+	}
+	if storage.StorageBucket == "" {
+		return nil, errors.Errorf("no bucket of the %s storage is set", storage.StorageType)
+	}
+	// the bucket is the URL of its endpoint, juicefs sync takes its host and path after the type of the storage
+	bucket := storage.StorageBucket
+	if i := strings.Index(bucket, "://"); i >= 0 {
+		bucket = bucket[i+3:]
+	}
+	prefix := storage.StoragePrefix
+	if prefix == "" {
+		prefix = storage.StorageClusterId
+	}
+	logger.RegisterSecret(storage.StorageSecretKey, storage.StorageToken)
+	return &ObjectStore{
+		URL:       fmt.Sprintf("%s://%s/", storage.StorageType, path.Join(strings.TrimSuffix(bucket, "/"), prefix, dir)),
+		AccessKey: storage.StorageAccessKey,
+		SecretKey: storage.StorageSecretKey,
+		Token:     storage.StorageToken,
+	}, nil
+}
+
+// SyncEnv returns the variables juicefs sync reads the credentials of the store from,
+// prefixed with SRC for the store as the source of the copy, or with DST as its destination,
+// to be set with SudoCmdWithEnv
+func (s *ObjectStore) SyncEnv(prefix string) map[string]string {
+	env := make(map[string]string)
+	for name, value := range map[string]string{"ACCESS_KEY": s.AccessKey, "SECRET_KEY": s.SecretKey, "SESSION_TOKEN": s.Token} {
+		if value != "" {
+			env[prefix+"_"+name] = value
+		}
+	}
+	return env
+}
@@ -0,0 +1,51 @@
+package storage
+
+import (
+	"reflect"
+	"testing"
+
+	"bytetrade.io/web3os/installer/pkg/common"
+)
+
+func TestExternalObjectStore(t *testing.T) {
+	tests := []struct {
+		storage common.Storage
+		want    string
+		env     map[string]string
+	}{
+		{
+			storage: common.Storage{StorageType: common.S3, StorageBucket: "https://olares.s3.us-east-1.amazonaws.com", StoragePrefix: "cluster-1",
+				StorageAccessKey: "AKID", StorageSecretKey: "se/cr@t"},
+			want: "s3://olares.s3.us-east-1.amazonaws.com/cluster-1/snapshots/",
+			env:  map[string]string{"DST_ACCESS_KEY": "AKID", "DST_SECRET_KEY": "se/cr@t"},
+		},
+		{
+			storage: common.Storage{StorageType: common.OSS, StorageBucket: "https://olares.oss-cn-hangzhou.aliyuncs.com/", StorageClusterId: "c1",
+				StorageAccessKey: "AKID", StorageSecretKey: "secret", StorageToken: "to+ken"},
+			want: "oss://olares.oss-cn-hangzhou.aliyuncs.com/c1/snapshots/",
+			env:  map[string]string{"DST_ACCESS_KEY": "AKID", "DST_SECRET_KEY": "secret", "DST_SESSION_TOKEN": "to+ken"},
+		},
+		{
+			storage: common.Storage{StorageType: common.COS, StorageBucket: "olares-1250000000.cos.ap-beijing.myqcloud.com"},
+			want:    "cos://olares-1250000000.cos.ap-beijing.myqcloud.com/snapshots/",
+			env:     map[string]string{},
+		},
+	}
+	for _, tt := range tests {
+		got, err := externalObjectStore(&tt.storage, "snapshots")
+		if err != nil {
+			t.Errorf("%s: %v", tt.storage.StorageType, err)
+			continue
+		}
+		if got.URL != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.storage.StorageType, got.URL, tt.want)
+		}
+		if env := got.SyncEnv("DST"); !reflect.DeepEqual(env, tt.env) {
+			t.Errorf("%s: got env %v, want %v", tt.storage.StorageType, env, tt.env)
+		}
+	}
+
+	if _, err := externalObjectStore(&common.Storage{StorageType: "nfs", StorageBucket: "x"}, ""); err == nil {
+		t.Error("unsupported storage type: want an error")
+	}
+}
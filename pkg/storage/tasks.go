@@ -10,6 +10,7 @@ import (
 
 	kubekeyapiv1alpha2 "bytetrade.io/web3os/installer/apis/kubekey/v1alpha2"
 	"bytetrade.io/web3os/installer/pkg/common"
+	"bytetrade.io/web3os/installer/pkg/core/cache"
 	cc "bytetrade.io/web3os/installer/pkg/core/common"
 	"bytetrade.io/web3os/installer/pkg/core/connector"
 	"bytetrade.io/web3os/installer/pkg/core/logger"
@@ -42,6 +43,7 @@ func (t *MkStorageDir) Execute(runtime connector.Runtime) error {
 
 type DownloadStorageCli struct {
 	common.KubeAction
+	cache.Filler
 }
 
 func (t *DownloadStorageCli) Execute(runtime connector.Runtime) error {
@@ -174,9 +176,15 @@ func (t *UnMountOSS) Execute(runtime connector.Runtime) error {
 	ossName := fmt.Sprintf("oss://%s", s[0])
 	ossEndpoint := fmt.Sprintf("%s://%s.%s.%s", b, s[1], s[2], s[3])
 
-	var cmd = fmt.Sprintf("/usr/local/sbin/ossutil64 rm %s/%s/ --endpoint=%s --access-key-id=%s --access-key-secret=%s --sts-token=%s -r -f", ossName, storageClusterId, ossEndpoint, storageAccessKey, storageSecretKey, storageToken)
-
-	if _, err := runtime.GetRunner().SudoCmd(cmd, false, false); err != nil {
+	// the credentials are read from a configuration file of ossutil, not to be seen on its command line
+	config := fmt.Sprintf("[Credentials]\nlanguage=EN\nendpoint=%s\naccessKeyID=%s\naccessKeySecret=%s\nstsToken=%s\n",
+		ossEndpoint, storageAccessKey, storageSecretKey, storageToken)
+	err := withCredentialsFile(runtime, config, func(file string) error {
+		cmd := fmt.Sprintf("/usr/local/sbin/ossutil64 rm %s/%s/ --config-file=%s -r -f", ossName, storageClusterId, file)
+		_, err := runtime.GetRunner().SudoCmd(cmd, false, false)
+		return err
+	})
+	if err != nil {
 		logger.Errorf("failed to unmount oss bucket %s: %v", storageBucket, err)
 	}
 
@@ -211,9 +219,15 @@ func (t *UnMountCOS) Execute(runtime connector.Runtime) error {
 	}
 	cosName := fmt.Sprintf("cos://%s", s[0])
 	cosEndpoint := fmt.Sprintf("%s.%s.%s.%s", s[1], s[2], s[3], s[4])
-	var cmd = fmt.Sprintf("/usr/local/bin/cosutil rm %s/%s/ --endpoint %s --secret-id %s --secret-key %s --token %s --init-skip -r -f", cosName, storageClusterId, cosEndpoint, storageAccessKey, storageSecretKey, storageToken)
-
-	if _, err := runtime.GetRunner().SudoCmd(cmd, false, false); err != nil {
+	// the credentials are read from a configuration file of coscli, not to be seen on its command line
+	config := fmt.Sprintf("cos:\n  base:\n    secretid: %q\n    secretkey: %q\n    sessiontoken: %q\n    protocol: https\n",
+		storageAccessKey, storageSecretKey, storageToken)
+	err := withCredentialsFile(runtime, config, func(file string) error {
+		cmd := fmt.Sprintf("/usr/local/bin/cosutil rm %s/%s/ --endpoint %s --config-path %s --init-skip -r -f", cosName, storageClusterId, cosEndpoint, file)
+		_, err := runtime.GetRunner().SudoCmd(cmd, false, false)
+		return err
+	})
+	if err != nil {
 		logger.Errorf("failed to unmount cos bucket %s: %v", storageBucket, err)
 	}
 
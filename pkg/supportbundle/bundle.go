@@ -0,0 +1,208 @@
+package supportbundle
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"os"
+	"time"
+
+	"bytetrade.io/web3os/installer/pkg/core/connector"
+	"bytetrade.io/web3os/installer/pkg/core/logger"
+	"github.com/pkg/errors"
+)
+
+// A support bundle is a gzip compressed tarball of the logs and of the state collected on a node,
+// every entry is scrubbed of the secrets, and manifest.json, the last entry, lists them all
+const (
+	ManifestFile = "manifest.json"
+	EventsFile   = "cluster/events.json"
+)
+
+// Item is an entry of the bundle
+type Item struct {
+	Name string `json:"name"`
+	// Source is the file or the command the item is collected from
+	Source string `json:"source"`
+	Size   int64  `json:"size"`
+	// Truncated is set when only the end of the item is kept for the bundle not to exceed its maximum size,
+	// OriginalSize is then its size before
+	Truncated    bool  `json:"truncated,omitempty"`
+	OriginalSize int64 `json:"originalSize,omitempty"`
+}
+
+// CollectError is what failed to be collected
+type CollectError struct {
+	Source string `json:"source"`
+	Error  string `json:"error"`
+}
+
+// Manifest lists what the bundle holds and what failed to be collected
+type Manifest struct {
+	CLIVersion    string                `json:"cliVersion"`
+	OlaresVersion string                `json:"olaresVersion,omitempty"`
+	CreatedAt     time.Time             `json:"createdAt"`
+	Since         *time.Time            `json:"since,omitempty"`
+	Until         *time.Time            `json:"until,omitempty"`
+	MaxSize       int64                 `json:"maxSize,omitempty"`
+	Size          int64                 `json:"size"`
+	SystemInfo    *connector.SystemInfo `json:"systemInfo,omitempty"`
+	Items         []Item                `json:"items"`
+	// Skipped are the items left out as the bundle reached its maximum size
+	Skipped []Item         `json:"skipped,omitempty"`
+	Errors  []CollectError `json:"errors,omitempty"`
+}
+
+// Writer writes a support bundle, the size of its entries before compression is capped to the MaxSize of its manifest,
+// Close is to be called to write the manifest
+type Writer struct {
+	file     *os.File
+	gw       *gzip.Writer
+	tw       *tar.Writer
+	manifest Manifest
+}
+
+// Create creates the bundle at path, described by the manifest, whose items are filled in as they are added
+func Create(path string, manifest Manifest) (*Writer, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create %s", path)
+	}
+	gw := gzip.NewWriter(f)
+	return &Writer{file: f, gw: gw, tw: tar.NewWriter(gw), manifest: manifest}, nil
+}
+
+// Add adds what is read from r to the bundle under name, with the secrets masked,
+// only the lines keep returns true for are added, all of them if it is nil,
+// it is staged in a temporary file as its size is only known once scrubbed
+func (w *Writer) Add(name, source string, r io.Reader, modTime time.Time, keep func(line string) bool) error {
+	tmp, err := os.CreateTemp("", "olares-logs-scrubbed-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadString('\n')
+		if line != "" && (keep == nil || keep(line)) {
+			if _, werr := tmp.WriteString(logger.Scrub(line)); werr != nil {
+				return werr
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	size, err := tmp.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+
+	item := Item{Name: name, Source: source, Size: size}
+	offset := int64(0)
+	if max := w.manifest.MaxSize; max > 0 && w.manifest.Size+size > max {
+		remaining := max - w.manifest.Size
+		if remaining > 0 {
+			// the end of a log is the most recent, it is the part kept
+			if offset, err = lineStart(tmp, size-remaining); err != nil {
+				return err
+			}
+		}
+		// not even its last line fits
+		if remaining <= 0 || offset >= size {
+			w.manifest.Skipped = append(w.manifest.Skipped, item)
+			return nil
+		}
+		item.Truncated, item.OriginalSize, item.Size = true, size, size-offset
+	}
+	if _, err := tmp.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+
+	header := &tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    item.Size,
+		ModTime: modTime,
+	}
+	if err := w.tw.WriteHeader(header); err != nil {
+		return err
+	}
+	if _, err := io.CopyN(w.tw, tmp, item.Size); err != nil {
+		return err
+	}
+	w.manifest.Items = append(w.manifest.Items, item)
+	w.manifest.Size += item.Size
+	return nil
+}
+
+// lineStart finds the start of the first line from offset on, for a truncated item not to begin in the middle of a line
+func lineStart(f *os.File, offset int64) (int64, error) {
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return 0, err
+	}
+	line, err := bufio.NewReader(f).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return 0, err
+	}
+	return offset + int64(len(line)), nil
+}
+
+// AddBytes adds data to the bundle under name, see Add
+func (w *Writer) AddBytes(name, source string, data []byte) error {
+	return w.Add(name, source, bytes.NewReader(data), time.Now(), nil)
+}
+
+// AddJSON adds v encoded as JSON to the bundle under name, see Add
+func (w *Writer) AddJSON(name, source string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return w.AddBytes(name, source, append(data, '\n'))
+}
+
+// AddError records in the manifest what failed to be collected from source
+func (w *Writer) AddError(source string, err error) {
+	w.manifest.Errors = append(w.manifest.Errors, CollectError{Source: source, Error: logger.Scrub(err.Error())})
+}
+
+// Close writes the manifest and closes the bundle, the manifest is not subject to the maximum size
+func (w *Writer) Close() error {
+	data, err := json.MarshalIndent(w.manifest, "", "  ")
+	if err == nil {
+		data = []byte(logger.Scrub(string(data)))
+		if err = w.tw.WriteHeader(&tar.Header{Name: ManifestFile, Mode: 0644, Size: int64(len(data)), ModTime: time.Now()}); err == nil {
+			_, err = w.tw.Write(data)
+		}
+	}
+	for _, closer := range []io.Closer{w.tw, w.gw, w.file} {
+		if cerr := closer.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return errors.Wrap(err, "failed to write the bundle")
+}
+
+// SetSystemInfo sets the system info of the node the bundle is collected on
+func (w *Writer) SetSystemInfo(si *connector.SystemInfo) {
+	w.manifest.SystemInfo = si
+}
+
+// SetOlaresVersion sets the version of Olares installed on the node the bundle is collected on
+func (w *Writer) SetOlaresVersion(version string) {
+	w.manifest.OlaresVersion = version
+}
+
+// Manifest is the manifest of what has been added so far
+func (w *Writer) Manifest() Manifest {
+	return w.manifest
+}
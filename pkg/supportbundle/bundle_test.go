@@ -0,0 +1,113 @@
+package supportbundle
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"bytetrade.io/web3os/installer/pkg/core/logger"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestWriterAndInspect(t *testing.T) {
+	logger.RegisterSecret("s3cr3t-bundle-password")
+	path := filepath.Join(t.TempDir(), "bundle.tar.gz")
+	w, err := Create(path, Manifest{CLIVersion: "1.0.0", MaxSize: 64})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 3 lines of 20 bytes, the secret is masked
+	if err := w.AddBytes("redis.log", "journalctl -u redis", []byte("pass s3cr3t-bundle-password\nerror: connection lost\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.AddBytes("k3s.log", "journalctl -u k3s", []byte("line one of k3s 0001\nline two of k3s 0002\nerror: k3s fails 003\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.AddBytes("dmesg.log", "dmesg", []byte("left out\n")); err != nil {
+		t.Fatal(err)
+	}
+	events := corev1.EventList{Items: []corev1.Event{
+		{Type: corev1.EventTypeWarning, Reason: "BackOff", Count: 3, InvolvedObject: corev1.ObjectReference{Namespace: "os-system", Name: "redis"}},
+		{Type: corev1.EventTypeNormal, Reason: "Pulled"},
+	}}
+	w.manifest.MaxSize = 0
+	if err := w.AddJSON(EventsFile, "kubectl get events", &events); err != nil {
+		t.Fatal(err)
+	}
+	w.AddError("kubectl get nodes", errNotFound("s3cr3t-bundle-password"))
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	ins, err := Inspect(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := ins.Manifest
+	if len(m.Items) != 3 || len(m.Skipped) != 1 || m.Skipped[0].Name != "dmesg.log" {
+		t.Fatalf("items = %+v, skipped = %+v, want 3 items and dmesg.log skipped", m.Items, m.Skipped)
+	}
+	if m.Items[0].Truncated || strings.Contains(m.Items[0].Source, "s3cr3t") {
+		t.Errorf("item = %+v, want not truncated", m.Items[0])
+	}
+	if k3s := m.Items[1]; !k3s.Truncated || k3s.OriginalSize != 63 || k3s.Size != 21 {
+		t.Errorf("k3s.log = %+v, want its last line kept", k3s)
+	}
+	if len(m.Errors) != 1 || strings.Contains(m.Errors[0].Error, "s3cr3t") {
+		t.Errorf("errors = %+v, want the secret masked", m.Errors)
+	}
+	if len(ins.ErrorLines) != 2 || ins.ErrorLines[0].Name != "k3s.log" || ins.ErrorLines[0].Example != "error: k3s fails 003" {
+		t.Errorf("error lines = %+v, want one in k3s.log and redis.log", ins.ErrorLines)
+	}
+	if len(ins.WarningEvents) != 1 || ins.WarningEvents[0].Name != "BackOff" || ins.WarningEvents[0].Count != 3 {
+		t.Errorf("warning events = %+v, want 3 BackOff", ins.WarningEvents)
+	}
+}
+
+type errNotFound string
+
+func (e errNotFound) Error() string { return "not found with " + string(e) }
+
+func TestParseWindow(t *testing.T) {
+	now := time.Date(2024, 6, 10, 12, 0, 0, 0, time.Local)
+	w, err := ParseWindow("7d", "-3h", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !w.Since.Equal(now.AddDate(0, 0, -7)) || !w.Until.Equal(now.Add(-3*time.Hour)) {
+		t.Errorf("window = %+v, want 7 days to 3 hours ago", w)
+	}
+	w, err = ParseWindow("2024-06-10 08:00", "", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !w.Since.Equal(time.Date(2024, 6, 10, 8, 0, 0, 0, time.Local)) || !w.Until.IsZero() {
+		t.Errorf("window = %+v, want from 08:00 on", w)
+	}
+	if _, err := ParseWindow("1h", "2h", now); err == nil {
+		t.Error("since after until: no error")
+	}
+	if _, err := ParseWindow("yesterday", "", now); err == nil {
+		t.Error("since yesterday: no error")
+	}
+
+	w = Window{Since: now.Add(-time.Hour), Until: now}
+	for line, want := range map[string]bool{
+		now.Add(-30*time.Minute).UTC().Format(time.RFC3339Nano) + " stdout F started": true,
+		now.Add(-2*time.Hour).UTC().Format(time.RFC3339Nano) + " stdout F too old":    false,
+		"a line without time": true,
+	} {
+		if got := w.KeepLine(line); got != want {
+			t.Errorf("KeepLine(%q) = %t, want %t", line, got, want)
+		}
+	}
+	events := w.FilterEvents([]corev1.Event{
+		{Reason: "Recent", LastTimestamp: metav1.NewTime(now.Add(-time.Minute))},
+		{Reason: "Old", LastTimestamp: metav1.NewTime(now.Add(-2 * time.Hour))},
+	})
+	if len(events) != 1 || events[0].Reason != "Recent" {
+		t.Errorf("events = %+v, want the recent one", events)
+	}
+}
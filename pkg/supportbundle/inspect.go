@@ -0,0 +1,150 @@
+package supportbundle
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// errorLinePattern matches the lines of the logs reporting an error
+var errorLinePattern = regexp.MustCompile(`(?i)\b(error|fatal|panic|failed|oomkilled|crashloopbackoff)\b|level=(error|fatal)`)
+
+// findingsShown is how many of the items with the most errors, and of the most frequent warnings, are reported
+const findingsShown = 10
+
+// Inspection is what is found in a support bundle for offline analysis
+type Inspection struct {
+	Manifest Manifest `json:"manifest"`
+	// ErrorLines are the items with the most lines reporting an error, most first
+	ErrorLines []Count `json:"errorLines,omitempty"`
+	// WarningEvents are the reasons of the most frequent warning events of Kubernetes, most first
+	WarningEvents []Count `json:"warningEvents,omitempty"`
+}
+
+// Count is how many times something is found
+type Count struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+	// Example is the last one found
+	Example string `json:"example,omitempty"`
+}
+
+// Inspect reads a support bundle, its manifest and what its logs and events report
+func Inspect(path string) (*Inspection, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s is not a support bundle", path)
+	}
+	defer gr.Close()
+
+	ins := &Inspection{}
+	foundManifest := false
+	errorLines := make(map[string]*Count)
+	warnings := make(map[string]*Count)
+	tr := tar.NewReader(gr)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read %s", path)
+		}
+		switch {
+		case header.Name == ManifestFile:
+			if err := json.NewDecoder(tr).Decode(&ins.Manifest); err != nil {
+				return nil, errors.Wrapf(err, "failed to parse the manifest of %s", path)
+			}
+			foundManifest = true
+		case header.Name == EventsFile:
+			var events corev1.EventList
+			if err := json.NewDecoder(tr).Decode(&events); err != nil {
+				return nil, errors.Wrapf(err, "failed to parse %s of %s", EventsFile, path)
+			}
+			countWarnings(events.Items, warnings)
+		case strings.HasSuffix(header.Name, ".log") || strings.HasSuffix(header.Name, ".txt"):
+			if err := countErrorLines(header.Name, tr, errorLines); err != nil {
+				return nil, errors.Wrapf(err, "failed to read %s of %s", header.Name, path)
+			}
+		}
+	}
+	if !foundManifest {
+		return nil, errors.Errorf("no %s found in %s, was it collected by an older olares-cli?", ManifestFile, path)
+	}
+	ins.ErrorLines = mostFound(errorLines)
+	ins.WarningEvents = mostFound(warnings)
+	return ins, nil
+}
+
+func countErrorLines(name string, r io.Reader, counts map[string]*Count) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !errorLinePattern.MatchString(line) {
+			continue
+		}
+		c, ok := counts[name]
+		if !ok {
+			c = &Count{Name: name}
+			counts[name] = c
+		}
+		c.Count++
+		c.Example = strings.TrimSpace(line)
+	}
+	// a line too long to be scanned stops the count of the item, not the inspection
+	if err := scanner.Err(); err != nil && err != bufio.ErrTooLong {
+		return err
+	}
+	return nil
+}
+
+func countWarnings(events []corev1.Event, counts map[string]*Count) {
+	for _, e := range events {
+		if e.Type != corev1.EventTypeWarning {
+			continue
+		}
+		c, ok := counts[e.Reason]
+		if !ok {
+			c = &Count{Name: e.Reason}
+			counts[e.Reason] = c
+		}
+		n := int(e.Count)
+		if n == 0 {
+			n = 1
+		}
+		c.Count += n
+		c.Example = e.InvolvedObject.Namespace + "/" + e.InvolvedObject.Name + ": " + e.Message
+	}
+}
+
+func mostFound(counts map[string]*Count) []Count {
+	found := make([]Count, 0, len(counts))
+	for _, c := range counts {
+		found = append(found, *c)
+	}
+	sort.Slice(found, func(i, j int) bool {
+		if found[i].Count != found[j].Count {
+			return found[i].Count > found[j].Count
+		}
+		return found[i].Name < found[j].Name
+	})
+	if len(found) > findingsShown {
+		found = found[:findingsShown]
+	}
+	return found
+}
@@ -0,0 +1,113 @@
+package supportbundle
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// journalTimeForm is how journalctl takes the bounds of the window
+const journalTimeForm = "2006-01-02 15:04:05"
+
+var absoluteTimeForms = []string{time.RFC3339, journalTimeForm, "2006-01-02 15:04", "2006-01-02"}
+
+// Window is the time window of the logs to collect, a zero bound does not limit it
+type Window struct {
+	Since time.Time
+	Until time.Time
+}
+
+// ParseWindow parses the bounds of the window, each either a duration before now, such as 7d, 3h or 90m,
+// or a time, such as 2024-06-10 08:00 or in RFC 3339, in the local time zone unless given
+func ParseWindow(since, until string, now time.Time) (Window, error) {
+	var w Window
+	var err error
+	if w.Since, err = parseWindowTime(since, now); err != nil {
+		return w, errors.Wrap(err, "invalid --since")
+	}
+	if w.Until, err = parseWindowTime(until, now); err != nil {
+		return w, errors.Wrap(err, "invalid --until")
+	}
+	if !w.Since.IsZero() && !w.Until.IsZero() && !w.Since.Before(w.Until) {
+		return w, errors.Errorf("--since %s is not before --until %s", since, until)
+	}
+	return w, nil
+}
+
+func parseWindowTime(s string, now time.Time) (time.Time, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "-")
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		if n, err := strconv.ParseFloat(days, 64); err == nil {
+			return now.Add(-time.Duration(n * float64(24*time.Hour))), nil
+		}
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+	for _, form := range absoluteTimeForms {
+		if t, err := time.ParseInLocation(form, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, errors.Errorf("%q is neither a duration like 7d or 3h nor a time like 2024-06-10 08:00", s)
+}
+
+// Contains tells whether t is within the window
+func (w Window) Contains(t time.Time) bool {
+	return (w.Since.IsZero() || !t.Before(w.Since)) && (w.Until.IsZero() || !t.After(w.Until))
+}
+
+// ModifiedWithin tells whether a file last modified at modTime may hold lines within the window
+func (w Window) ModifiedWithin(modTime time.Time) bool {
+	return w.Since.IsZero() || !modTime.Before(w.Since)
+}
+
+// JournalArgs are the arguments of journalctl for the window
+func (w Window) JournalArgs() []string {
+	var args []string
+	if !w.Since.IsZero() {
+		args = append(args, "--since", w.Since.Local().Format(journalTimeForm))
+	}
+	if !w.Until.IsZero() {
+		args = append(args, "--until", w.Until.Local().Format(journalTimeForm))
+	}
+	return args
+}
+
+// KeepLine keeps the lines within the window of a log whose lines begin with their time in RFC 3339,
+// as the logs of the containers do, the others are kept as they cannot be placed
+func (w Window) KeepLine(line string) bool {
+	if w.Since.IsZero() && w.Until.IsZero() {
+		return true
+	}
+	ts, _, _ := strings.Cut(line, " ")
+	t, err := time.Parse(time.RFC3339Nano, ts)
+	if err != nil {
+		return true
+	}
+	return w.Contains(t)
+}
+
+// FilterEvents keeps the events of Kubernetes last seen within the window
+func (w Window) FilterEvents(events []corev1.Event) []corev1.Event {
+	kept := make([]corev1.Event, 0, len(events))
+	for _, e := range events {
+		t := e.LastTimestamp.Time
+		if t.IsZero() {
+			t = e.EventTime.Time
+		}
+		if t.IsZero() {
+			t = e.CreationTimestamp.Time
+		}
+		if w.Contains(t) {
+			kept = append(kept, e)
+		}
+	}
+	return kept
+}
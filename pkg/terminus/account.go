@@ -53,13 +53,14 @@ func (s *GetUserInfo) Execute(runtime connector.Runtime) error {
 	if err != nil {
 		return err
 	}
-	logger.Infof("using password: %s", s.KubeConf.Arg.User.Password)
+	// the password is only shown to the user once Olares is running, see Welcome
+	logger.RegisterSecret(s.KubeConf.Arg.User.Password, s.KubeConf.Arg.User.EncryptedPassword)
 
 	return nil
 }
 
 func (s *GetUserInfo) getDomainName() (string, error) {
-	domainName := strings.TrimSpace(os.Getenv("TERMINUS_OS_DOMAINNAME"))
+	domainName := strings.TrimSpace(os.Getenv(common.ENV_TERMINUS_OS_DOMAINNAME))
 	if len(domainName) > 0 {
 		if !utils.IsValidDomain(domainName) {
 			return "", errors.New(fmt.Sprintf("invalid domain name \"%s\" set in env, please reset", domainName))
@@ -90,7 +91,7 @@ LOOP:
 }
 
 func (s *GetUserInfo) getUserName() (string, error) {
-	userName := os.Getenv("TERMINUS_OS_USERNAME")
+	userName := os.Getenv(common.ENV_TERMINUS_OS_USERNAME)
 	if strings.Contains(userName, "@") {
 		userName = strings.Split(userName, "@")[0]
 	}
@@ -124,7 +125,7 @@ LOOP:
 }
 
 func (s *GetUserInfo) getUserEmail() (string, error) {
-	userEmail := strings.TrimSpace(os.Getenv("TERMINUS_OS_EMAIL"))
+	userEmail := strings.TrimSpace(os.Getenv(common.ENV_TERMINUS_OS_EMAIL))
 	if len(userEmail) == 0 {
 		return s.KubeConf.Arg.User.UserName + "@" + s.KubeConf.Arg.User.DomainName, nil
 	}
@@ -135,7 +136,7 @@ func (s *GetUserInfo) getUserEmail() (string, error) {
 }
 
 func (s *GetUserInfo) getUserPassword() (string, string, error) {
-	userPassword := strings.TrimSpace(os.Getenv("TERMINUS_OS_PASSWORD"))
+	userPassword := strings.TrimSpace(os.Getenv(common.ENV_TERMINUS_OS_PASSWORD))
 	if len(userPassword) != 32 && len(userPassword) != 0 {
 		return "", "", fmt.Errorf("invalid password \"%s\" set in env: length should be equal 32, please reset", userPassword)
 
@@ -1,6 +1,7 @@
 package terminus
 
 import (
+	"bytetrade.io/web3os/installer/pkg/core/cache"
 	"bytetrade.io/web3os/installer/pkg/core/logger"
 	"bytetrade.io/web3os/installer/pkg/storage"
 	"context"
@@ -69,6 +70,7 @@ func (i *InstallAppsModule) Init() {
 
 type PrepareAppValues struct {
 	common.KubeAction
+	cache.Filler
 }
 
 func (u *PrepareAppValues) Execute(runtime connector.Runtime) error {
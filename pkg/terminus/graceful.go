@@ -0,0 +1,493 @@
+package terminus
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"bytetrade.io/web3os/installer/pkg/common"
+	"bytetrade.io/web3os/installer/pkg/core/connector"
+	"bytetrade.io/web3os/installer/pkg/core/logger"
+	"github.com/pkg/errors"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+const (
+	// ReplicasBeforeStopAnnotation keeps the replicas of a workload scaled down by olares-cli stop,
+	// for olares-cli start to scale it up again
+	ReplicasBeforeStopAnnotation = "bytetrade.io/replicas-before-stop"
+	// CordonedByStopAnnotation marks the node cordoned by olares-cli stop,
+	// for olares-cli start not to uncordon a node cordoned by the administrator
+	CordonedByStopAnnotation = "bytetrade.io/cordoned-by-stop"
+
+	// the tiers of the workloads, those of the users are stopped before the system ones they depend on
+	TierUser   = "user"
+	TierSystem = "system"
+
+	// stageCheckInterval is the interval of the checks waiting for a stage to complete
+	stageCheckInterval = 5 * time.Second
+	// kubeReadyTimeout bounds the check of the API server before the workloads are drained
+	kubeReadyTimeout = 10 * time.Second
+	// pendingShown is how many of the pods or of the processes a stage waits for are detailed
+	pendingShown = 3
+)
+
+// WorkloadTier tells the tier of the workloads of the namespace, if they are of Olares, see IsKeyPod
+func WorkloadTier(namespace string) (string, bool) {
+	switch {
+	case strings.HasPrefix(namespace, "user-space"):
+		return TierUser, true
+	case strings.HasPrefix(namespace, "user-system"), namespace == "os-system":
+		return TierSystem, true
+	}
+	return "", false
+}
+
+// KubernetesInstalled tells whether k3s or kubelet is installed on the node
+func KubernetesInstalled() bool {
+	return serviceExists(k3sServiceName) || serviceExists(kubeletServiceName)
+}
+
+// KubeAPIServerReady tells whether the API server is up, for the workloads to be drained through it
+func KubeAPIServerReady() bool {
+	ctx, cancel := context.WithTimeout(context.Background(), kubeReadyTimeout)
+	defer cancel()
+	return exec.CommandContext(ctx, "/usr/local/bin/kubectl", "get", "--raw=/readyz").Run() == nil
+}
+
+// StageRetries is how many times a check is retried for its stage to complete within timeout
+func StageRetries(timeout time.Duration) int {
+	if n := int(timeout / stageCheckInterval); n > 1 {
+		return n
+	}
+	return 1
+}
+
+func newKubeClient() (kubernetes.Interface, error) {
+	kubeConfig, err := ctrl.GetConfig()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load kubeconfig")
+	}
+	kubeClient, err := kubernetes.NewForConfig(kubeConfig)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create kube client")
+	}
+	return kubeClient, nil
+}
+
+// CordonNodeForStop marks the node unschedulable, for the workloads scaled down not to be scheduled again
+type CordonNodeForStop struct {
+	common.KubeAction
+	Node string
+}
+
+func (c *CordonNodeForStop) Execute(runtime connector.Runtime) error {
+	kubeClient, err := newKubeClient()
+	if err != nil {
+		return err
+	}
+	ctx := runtime.GetContext()
+	node, err := kubeClient.CoreV1().Nodes().Get(ctx, c.Node, metav1.GetOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to get node %s", c.Node)
+	}
+	if node.Spec.Unschedulable {
+		logger.Infof("node %s is already cordoned", c.Node)
+		return nil
+	}
+	patch := fmt.Sprintf(`{"metadata":{"annotations":{%q:"true"}},"spec":{"unschedulable":true}}`, CordonedByStopAnnotation)
+	if _, err := kubeClient.CoreV1().Nodes().Patch(ctx, c.Node, types.MergePatchType, []byte(patch), metav1.PatchOptions{}); err != nil {
+		return errors.Wrapf(err, "failed to cordon node %s", c.Node)
+	}
+	return nil
+}
+
+// UncordonNodeAfterStop marks the node cordoned by olares-cli stop schedulable again
+type UncordonNodeAfterStop struct {
+	common.KubeAction
+	Node string
+}
+
+func (u *UncordonNodeAfterStop) Execute(runtime connector.Runtime) error {
+	kubeClient, err := newKubeClient()
+	if err != nil {
+		return err
+	}
+	ctx := runtime.GetContext()
+	node, err := kubeClient.CoreV1().Nodes().Get(ctx, u.Node, metav1.GetOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to get node %s", u.Node)
+	}
+	if _, ok := node.Annotations[CordonedByStopAnnotation]; !ok {
+		if node.Spec.Unschedulable {
+			logger.Warnf("node %s has not been cordoned by olares-cli stop, leaving it cordoned", u.Node)
+		}
+		return nil
+	}
+	patch := fmt.Sprintf(`{"metadata":{"annotations":{%q:null}},"spec":{"unschedulable":false}}`, CordonedByStopAnnotation)
+	if _, err := kubeClient.CoreV1().Nodes().Patch(ctx, u.Node, types.MergePatchType, []byte(patch), metav1.PatchOptions{}); err != nil {
+		return errors.Wrapf(err, "failed to uncordon node %s", u.Node)
+	}
+	return nil
+}
+
+// workload is a Deployment or a StatefulSet, scaled down and up the same way
+type workload struct {
+	kind        string
+	namespace   string
+	name        string
+	replicas    int32
+	annotations map[string]string
+}
+
+func (w workload) String() string {
+	return fmt.Sprintf("%s %s/%s", w.kind, w.namespace, w.name)
+}
+
+// drainsCluster tells whether stopping the node stops the workloads of the whole cluster, and starting it starts them:
+// when it is the only node, or the last control plane node ready, the other nodes not running Olares without it,
+// otherwise the pods of the node are evicted, to be scheduled on the other nodes
+func drainsCluster(nodes []corev1.Node, self string) bool {
+	if len(nodes) <= 1 {
+		return true
+	}
+	for _, node := range nodes {
+		if node.Name == self && !isControlPlane(&node) {
+			return false
+		}
+	}
+	for _, node := range nodes {
+		if node.Name != self && isControlPlane(&node) && nodeReady(&node) {
+			return false
+		}
+	}
+	return true
+}
+
+func isControlPlane(node *corev1.Node) bool {
+	for _, role := range []string{"node-role.kubernetes.io/master", "node-role.kubernetes.io/control-plane"} {
+		if _, ok := node.Labels[role]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func nodeReady(node *corev1.Node) bool {
+	for _, c := range node.Status.Conditions {
+		if c.Type == corev1.NodeReady {
+			return c.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+func nodeDrainsCluster(ctx context.Context, kubeClient kubernetes.Interface, node string) (bool, error) {
+	nodes, err := kubeClient.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return false, errors.Wrap(err, "failed to list nodes")
+	}
+	return drainsCluster(nodes.Items, node), nil
+}
+
+// evictWorkloadPods evicts the pods of the Deployments and of the StatefulSets of the tier running on the node,
+// their disruption budgets are respected, the eviction being retried while they forbid it
+func evictWorkloadPods(ctx context.Context, kubeClient kubernetes.Interface, tier, node string) error {
+	pods, err := kubeClient.CoreV1().Pods(corev1.NamespaceAll).List(ctx, metav1.ListOptions{FieldSelector: "spec.nodeName=" + node})
+	if err != nil {
+		return errors.Wrap(err, "failed to list pods")
+	}
+	var evicted int
+	for _, pod := range pods.Items {
+		if t, ok := WorkloadTier(pod.Namespace); !ok || t != tier || !ownedByWorkload(&pod) || pod.DeletionTimestamp != nil {
+			continue
+		}
+		eviction := &policyv1.Eviction{ObjectMeta: metav1.ObjectMeta{Namespace: pod.Namespace, Name: pod.Name}}
+		if err := kubeClient.CoreV1().Pods(pod.Namespace).EvictV1(ctx, eviction); err != nil && !apierrors.IsNotFound(err) {
+			return errors.Wrapf(err, "failed to evict pod %s/%s", pod.Namespace, pod.Name)
+		}
+		evicted++
+	}
+	logger.Infof("evicted %d pods of the %s workloads from node %s", evicted, tier, node)
+	return nil
+}
+
+func listWorkloads(ctx context.Context, kubeClient kubernetes.Interface, tier string) ([]workload, error) {
+	var workloads []workload
+	add := func(kind string, meta metav1.ObjectMeta, replicas *int32) {
+		if t, ok := WorkloadTier(meta.Namespace); !ok || t != tier {
+			return
+		}
+		w := workload{kind: kind, namespace: meta.Namespace, name: meta.Name, replicas: 1, annotations: meta.Annotations}
+		if replicas != nil {
+			w.replicas = *replicas
+		}
+		workloads = append(workloads, w)
+	}
+	deployments, err := kubeClient.AppsV1().Deployments(corev1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list deployments")
+	}
+	for _, d := range deployments.Items {
+		add("Deployment", d.ObjectMeta, d.Spec.Replicas)
+	}
+	statefulSets, err := kubeClient.AppsV1().StatefulSets(corev1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list statefulsets")
+	}
+	for _, s := range statefulSets.Items {
+		add("StatefulSet", s.ObjectMeta, s.Spec.Replicas)
+	}
+	return workloads, nil
+}
+
+func patchWorkload(ctx context.Context, kubeClient kubernetes.Interface, w workload, patch string) error {
+	var err error
+	switch w.kind {
+	case "Deployment":
+		_, err = kubeClient.AppsV1().Deployments(w.namespace).Patch(ctx, w.name, types.MergePatchType, []byte(patch), metav1.PatchOptions{})
+	default:
+		_, err = kubeClient.AppsV1().StatefulSets(w.namespace).Patch(ctx, w.name, types.MergePatchType, []byte(patch), metav1.PatchOptions{})
+	}
+	return errors.Wrapf(err, "failed to scale %s", w)
+}
+
+// ScaleDownWorkloads scales the Deployments and the StatefulSets of the tier down to zero,
+// for their pods to shut down cleanly, and keeps their replicas in an annotation,
+// unless the other nodes keep running Olares, see drainsCluster, only the pods of the node are then evicted
+type ScaleDownWorkloads struct {
+	common.KubeAction
+	Tier string
+	Node string
+}
+
+func (s *ScaleDownWorkloads) Execute(runtime connector.Runtime) error {
+	kubeClient, err := newKubeClient()
+	if err != nil {
+		return err
+	}
+	ctx := runtime.GetContext()
+	clusterWide, err := nodeDrainsCluster(ctx, kubeClient, s.Node)
+	if err != nil {
+		return err
+	}
+	if !clusterWide {
+		return evictWorkloadPods(ctx, kubeClient, s.Tier, s.Node)
+	}
+	workloads, err := listWorkloads(ctx, kubeClient, s.Tier)
+	if err != nil {
+		return err
+	}
+	var scaled int
+	for _, w := range workloads {
+		if w.replicas == 0 {
+			continue
+		}
+		// the replicas kept by a previous stop that has been interrupted are those to scale up again
+		replicas := strconv.Itoa(int(w.replicas))
+		if kept, ok := w.annotations[ReplicasBeforeStopAnnotation]; ok {
+			replicas = kept
+		}
+		patch := fmt.Sprintf(`{"metadata":{"annotations":{%q:%q}},"spec":{"replicas":0}}`, ReplicasBeforeStopAnnotation, replicas)
+		if err := patchWorkload(ctx, kubeClient, w, patch); err != nil {
+			return err
+		}
+		logger.Debugf("scaled down %s from %s replicas", w, replicas)
+		scaled++
+	}
+	logger.Infof("scaled down %d %s workloads", scaled, s.Tier)
+	return nil
+}
+
+// ScaleUpWorkloads scales the Deployments and the StatefulSets of the tier scaled down by olares-cli stop
+// up again to their replicas before, on the node starting the workloads of the whole cluster, see drainsCluster,
+// the pods evicted from the other nodes are scheduled on them again once they are uncordoned
+type ScaleUpWorkloads struct {
+	common.KubeAction
+	Tier string
+	Node string
+}
+
+func (s *ScaleUpWorkloads) Execute(runtime connector.Runtime) error {
+	kubeClient, err := newKubeClient()
+	if err != nil {
+		return err
+	}
+	ctx := runtime.GetContext()
+	clusterWide, err := nodeDrainsCluster(ctx, kubeClient, s.Node)
+	if err != nil {
+		return err
+	}
+	if !clusterWide {
+		logger.Infof("the %s workloads are scaled up by the control plane node, not by node %s", s.Tier, s.Node)
+		return nil
+	}
+	workloads, err := listWorkloads(ctx, kubeClient, s.Tier)
+	if err != nil {
+		return err
+	}
+	var scaled int
+	for _, w := range workloads {
+		kept, ok := w.annotations[ReplicasBeforeStopAnnotation]
+		if !ok {
+			continue
+		}
+		replicas, err := strconv.Atoi(kept)
+		if err != nil {
+			return errors.Wrapf(err, "invalid annotation %s=%q of %s", ReplicasBeforeStopAnnotation, kept, w)
+		}
+		patch := fmt.Sprintf(`{"metadata":{"annotations":{%q:null}},"spec":{"replicas":%d}}`, ReplicasBeforeStopAnnotation, replicas)
+		if err := patchWorkload(ctx, kubeClient, w, patch); err != nil {
+			return err
+		}
+		scaled++
+	}
+	logger.Infof("scaled up %d %s workloads", scaled, s.Tier)
+	return nil
+}
+
+// WaitWorkloadPodsTerminated waits for the pods of the Deployments and of the StatefulSets of the tier
+// on the node to have shut down
+type WaitWorkloadPodsTerminated struct {
+	common.KubeAction
+	Tier string
+	Node string
+}
+
+func (w *WaitWorkloadPodsTerminated) Execute(runtime connector.Runtime) error {
+	kubeClient, err := newKubeClient()
+	if err != nil {
+		return err
+	}
+	pods, err := kubeClient.CoreV1().Pods(corev1.NamespaceAll).List(runtime.GetContext(), metav1.ListOptions{})
+	if err != nil {
+		return errors.Wrap(err, "failed to list pods")
+	}
+	var pending []string
+	for _, pod := range pods.Items {
+		if w.Node != "" && pod.Spec.NodeName != w.Node {
+			continue
+		}
+		if tier, ok := WorkloadTier(pod.Namespace); ok && tier == w.Tier && ownedByWorkload(&pod) {
+			pending = append(pending, pod.Namespace+"/"+pod.Name)
+		}
+	}
+	if len(pending) > 0 {
+		return errors.Errorf("%d pods of the %s workloads are still shutting down: %s", len(pending), w.Tier, strings.Join(firstN(pending, pendingShown), ", "))
+	}
+	return nil
+}
+
+// ownedByWorkload tells whether the pod is one of a Deployment or of a StatefulSet,
+// those of the DaemonSets and of the Jobs are not scaled down
+func ownedByWorkload(pod *corev1.Pod) bool {
+	owner := metav1.GetControllerOf(pod)
+	if owner == nil {
+		return false
+	}
+	switch owner.Kind {
+	case "ReplicaSet":
+		return owner.APIVersion == appsv1.SchemeGroupVersion.String()
+	case "StatefulSet":
+		return true
+	}
+	return false
+}
+
+// WaitVolumesDetached waits for the volumes attached to the node to have been detached
+type WaitVolumesDetached struct {
+	common.KubeAction
+	Node string
+}
+
+func (w *WaitVolumesDetached) Execute(runtime connector.Runtime) error {
+	kubeClient, err := newKubeClient()
+	if err != nil {
+		return err
+	}
+	attachments, err := kubeClient.StorageV1().VolumeAttachments().List(runtime.GetContext(), metav1.ListOptions{})
+	if err != nil {
+		return errors.Wrap(err, "failed to list volume attachments")
+	}
+	var attached []string
+	for _, a := range attachments.Items {
+		if a.Spec.NodeName == w.Node && a.Status.Attached {
+			attached = append(attached, a.Name)
+		}
+	}
+	if len(attached) > 0 {
+		return errors.Errorf("%d volumes are still attached to node %s: %s", len(attached), w.Node, strings.Join(firstN(attached, pendingShown), ", "))
+	}
+	return nil
+}
+
+// SyncJuiceFS flushes the writes to JuiceFS and waits for the processes having files open on it to exit,
+// for it not to be unmounted under open writers
+type SyncJuiceFS struct {
+	common.KubeAction
+	MountPoint string
+}
+
+func (s *SyncJuiceFS) Execute(runtime connector.Runtime) error {
+	if _, err := runtime.GetRunner().SudoCmd(fmt.Sprintf("sync -f %s", s.MountPoint), false, false); err != nil {
+		return errors.Wrapf(err, "failed to sync %s", s.MountPoint)
+	}
+	open, err := processesWithFilesUnder("/proc", s.MountPoint)
+	if err != nil {
+		return err
+	}
+	if len(open) > 0 {
+		return errors.Errorf("%d processes still have files open on %s: %s", len(open), s.MountPoint, strings.Join(firstN(open, pendingShown), ", "))
+	}
+	return nil
+}
+
+// processesWithFilesUnder lists the processes having files open under dir, as "<command> (<pid>)",
+// found through the file descriptors in procDir, the processes gone meanwhile are ignored
+func processesWithFilesUnder(procDir, dir string) ([]string, error) {
+	entries, err := os.ReadDir(procDir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read %s", procDir)
+	}
+	prefix := filepath.Clean(dir) + string(filepath.Separator)
+	var found []string
+	for _, e := range entries {
+		if _, err := strconv.Atoi(e.Name()); err != nil {
+			continue
+		}
+		fdDir := filepath.Join(procDir, e.Name(), "fd")
+		fds, err := os.ReadDir(fdDir)
+		if err != nil {
+			continue
+		}
+		for _, fd := range fds {
+			target, err := os.Readlink(filepath.Join(fdDir, fd.Name()))
+			if err != nil || !strings.HasPrefix(target, prefix) {
+				continue
+			}
+			comm, _ := os.ReadFile(filepath.Join(procDir, e.Name(), "comm"))
+			found = append(found, fmt.Sprintf("%s (%s)", strings.TrimSpace(string(comm)), e.Name()))
+			break
+		}
+	}
+	return found, nil
+}
+
+func firstN(s []string, n int) []string {
+	if len(s) > n {
+		return append(s[:n:n], fmt.Sprintf("and %d more", len(s)-n))
+	}
+	return s
+}
@@ -0,0 +1,86 @@
+package terminus
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestWorkloadTier(t *testing.T) {
+	for namespace, want := range map[string]string{
+		"user-space-alice":  TierUser,
+		"user-system-alice": TierSystem,
+		"os-system":         TierSystem,
+		"kube-system":       "",
+	} {
+		if tier, _ := WorkloadTier(namespace); tier != want {
+			t.Errorf("WorkloadTier(%s) = %q, want %q", namespace, tier, want)
+		}
+	}
+}
+
+func TestProcessesWithFilesUnder(t *testing.T) {
+	proc := t.TempDir()
+	process := func(pid, comm string, targets ...string) {
+		fd := filepath.Join(proc, pid, "fd")
+		if err := os.MkdirAll(fd, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(proc, pid, "comm"), []byte(comm+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		for i, target := range targets {
+			if err := os.Symlink(target, filepath.Join(fd, string(rune('0'+i)))); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	process("100", "postgres", "/dev/null", "/olares/rootfs/userspace/pg/wal")
+	process("200", "bash", "/olares/rootfs-other/file")
+	process("300", "juicefs", "/dev/fuse")
+	if err := os.MkdirAll(filepath.Join(proc, "sys"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	found, err := processesWithFilesUnder(proc, "/olares/rootfs/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 1 || found[0] != "postgres (100)" {
+		t.Errorf("found %v, want postgres (100)", found)
+	}
+}
+
+func TestDrainsCluster(t *testing.T) {
+	node := func(name string, master, ready bool) corev1.Node {
+		n := corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{}}}
+		if master {
+			n.Labels["node-role.kubernetes.io/master"] = "true"
+		}
+		status := corev1.ConditionFalse
+		if ready {
+			status = corev1.ConditionTrue
+		}
+		n.Status.Conditions = []corev1.NodeCondition{{Type: corev1.NodeReady, Status: status}}
+		return n
+	}
+	for _, c := range []struct {
+		name  string
+		nodes []corev1.Node
+		self  string
+		want  bool
+	}{
+		{"single node", []corev1.Node{node("m1", true, true)}, "m1", true},
+		{"worker", []corev1.Node{node("m1", true, true), node("w1", false, true)}, "w1", false},
+		{"master with workers", []corev1.Node{node("m1", true, true), node("w1", false, true)}, "m1", true},
+		{"master with another ready master", []corev1.Node{node("m1", true, true), node("m2", true, true)}, "m1", false},
+		{"last master ready", []corev1.Node{node("m1", true, true), node("m2", true, false)}, "m1", true},
+	} {
+		if got := drainsCluster(c.nodes, c.self); got != c.want {
+			t.Errorf("%s: drainsCluster = %v, want %v", c.name, got, c.want)
+		}
+	}
+}
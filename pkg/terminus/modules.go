@@ -8,6 +8,7 @@ import (
 
 	bootstrapos "bytetrade.io/web3os/installer/pkg/bootstrap/os"
 	"bytetrade.io/web3os/installer/pkg/bootstrap/precheck"
+	"bytetrade.io/web3os/installer/pkg/certs"
 	"bytetrade.io/web3os/installer/pkg/common"
 	"bytetrade.io/web3os/installer/pkg/container"
 	containertemplates "bytetrade.io/web3os/installer/pkg/container/templates"
@@ -235,6 +236,26 @@ func serviceExists(serviceName string) bool {
 	return util.IsExist(path.Join(systemdUnitDir, serviceName))
 }
 
+// InstalledServices lists the systemd units of the components of Olares installed on the node,
+// those StopOlaresModule stops, in the order it stops them
+func InstalledServices() []string {
+	var services []string
+	for _, service := range []string{
+		k3sServiceName,
+		kubeletServiceName,
+		containerdServiceName,
+		etcdServiceName,
+		juiceFSServiceName,
+		redisServiceName,
+		minIOServiceName,
+	} {
+		if serviceExists(service) {
+			services = append(services, service)
+		}
+	}
+	return services
+}
+
 type StopOlaresModule struct {
 	common.KubeModule
 	Timeout       time.Duration
@@ -355,8 +376,129 @@ func (m *StartOlaresModule) Init() {
 		logger.Info("found no components of Olares, please install Olares first")
 		logger.Info("exiting ...")
 		os.Exit(0)
-	} else if k3sServiceExists || kubeletServiceExists {
+	} else if !k3sServiceExists && !kubeletServiceExists {
+		logger.Warn("kubernetes service can not be found, it seems that Olares has not been installed yet")
+		logger.Warn("will try to start any other base components that we can find")
+	}
+}
+
+// DrainWorkloadsModule shuts the workloads of Olares down through the API server before its services are stopped,
+// those of the users first and then the system ones they depend on, each within StageTimeout,
+// the node is cordoned and, unless it is the only one or the last control plane node, only its pods are evicted
+type DrainWorkloadsModule struct {
+	common.KubeModule
+	StageTimeout time.Duration
+}
+
+func (m *DrainWorkloadsModule) Init() {
+	m.Name = "DrainWorkloads"
+	m.Desc = "Scale down the workloads of the users and of the system"
+
+	node := m.Runtime.GetLocalHost().GetName()
+	retries := StageRetries(m.StageTimeout)
+	m.Tasks = []task.Interface{
+		&task.LocalTask{
+			Name:   "CordonNode",
+			Action: &CordonNodeForStop{Node: node},
+			Retry:  3,
+		},
+	}
+	for _, tier := range []struct{ name, title string }{{TierUser, "User"}, {TierSystem, "System"}} {
+		m.Tasks = append(m.Tasks,
+			&task.LocalTask{
+				Name:   fmt.Sprintf("ScaleDown%sWorkloads", tier.title),
+				Action: &ScaleDownWorkloads{Tier: tier.name, Node: node},
+				Retry:  3,
+			},
+			&task.LocalTask{
+				Name:   fmt.Sprintf("Wait%sPodsTerminated", tier.title),
+				Action: &WaitWorkloadPodsTerminated{Tier: tier.name, Node: node},
+				Delay:  stageCheckInterval,
+				Retry:  retries,
+			},
+		)
+	}
+	m.Tasks = append(m.Tasks, &task.LocalTask{
+		Name:   "WaitVolumesDetached",
+		Action: &WaitVolumesDetached{Node: node},
+		Delay:  stageCheckInterval,
+		Retry:  retries,
+	})
+}
+
+// SyncJuiceFSModule flushes JuiceFS and waits for its open files to be closed, within StageTimeout,
+// before it is stopped along with the other services
+type SyncJuiceFSModule struct {
+	common.KubeModule
+	StageTimeout time.Duration
+}
+
+func (m *SyncJuiceFSModule) Init() {
+	m.Name = "SyncJuiceFS"
+	m.Desc = "Sync JuiceFS and wait for its files to be closed"
+
+	m.Tasks = []task.Interface{
+		&task.LocalTask{
+			Name:   "SyncJuiceFS",
+			Action: &SyncJuiceFS{MountPoint: storage.OlaresJuiceFSRootDir},
+			Delay:  stageCheckInterval,
+			Retry:  StageRetries(m.StageTimeout),
+		},
+	}
+}
+
+// JuiceFSInstalled tells whether JuiceFS is installed on the node
+func JuiceFSInstalled() bool {
+	return serviceExists(juiceFSServiceName)
+}
+
+// RestoreWorkloadsModule scales the workloads drained by DrainWorkloadsModule up again once the API server is ready,
+// the system ones first, within StageTimeout
+type RestoreWorkloadsModule struct {
+	common.KubeModule
+	StageTimeout time.Duration
+}
+
+func (m *RestoreWorkloadsModule) Init() {
+	m.Name = "RestoreWorkloads"
+	m.Desc = "Scale up the workloads of the system and of the users"
+
+	node := m.Runtime.GetLocalHost().GetName()
+	m.Tasks = []task.Interface{
+		&task.LocalTask{
+			Name:      "WaitKubeAPIServer",
+			AlwaysRun: true,
+			Action:    new(certs.WaitKubeAPIServer),
+			Delay:     stageCheckInterval,
+			Retry:     StageRetries(m.StageTimeout),
+		},
+		&task.LocalTask{
+			Name:   "UncordonNode",
+			Action: &UncordonNodeAfterStop{Node: node},
+			Retry:  3,
+		},
+	}
+	for _, tier := range []struct{ name, title string }{{TierSystem, "System"}, {TierUser, "User"}} {
 		m.Tasks = append(m.Tasks, &task.LocalTask{
+			Name:   fmt.Sprintf("ScaleUp%sWorkloads", tier.title),
+			Action: &ScaleUpWorkloads{Tier: tier.name, Node: node},
+			Retry:  3,
+		})
+	}
+}
+
+// WaitOlaresPodsModule waits for the key pods of Olares to be running again, within StageTimeout
+type WaitOlaresPodsModule struct {
+	common.KubeModule
+	StageTimeout time.Duration
+}
+
+func (m *WaitOlaresPodsModule) Init() {
+	m.Name = "WaitOlaresPods"
+	m.Desc = "Wait for the pods of Olares to be running"
+
+	m.Tasks = []task.Interface{
+		&task.LocalTask{
 
 			// when starting an already stopped Olares, which is the normal use case
 			// it's very likely for this program to outrun kubelet
@@ -369,16 +511,13 @@ func (m *StartOlaresModule) Init() {
 			Prepare: &prepare.InitialDelay{
 				Duration: 30 * time.Second,
 			},
-			Name:   "EnsurePodsUpAndRunningAgain",
-			Action: &CheckKeyPodsRunning{Node: m.Runtime.GetLocalHost().GetName()},
-			Delay:  10 * time.Second,
-			Retry:  60,
-		})
-	} else {
-		logger.Warn("kubernetes service can not be found, it seems that Olares has not been installed yet")
-		logger.Warn("will try to start any other base components that we can find")
+			Name:      "EnsurePodsUpAndRunningAgain",
+			AlwaysRun: true,
+			Action:    &CheckKeyPodsRunning{Node: m.Runtime.GetLocalHost().GetName()},
+			Delay:     10 * time.Second,
+			Retry:     max(int(m.StageTimeout/(10*time.Second)), 1),
+		},
 	}
-
 }
 
 type ChangeIPModule struct {
@@ -496,9 +635,10 @@ func (m *ChangeIPModule) addStorageTasks() {
 				Retry:  3,
 			},
 			&task.LocalTask{
-				Name:   "CheckRedisState",
-				Action: new(storage.CheckRedisServiceState),
-				Retry:  20,
+				Name:      "CheckRedisState",
+				AlwaysRun: true,
+				Action:    new(storage.CheckRedisServiceState),
+				Retry:     20,
 			},
 		)
 	}
@@ -518,9 +658,10 @@ func (m *ChangeIPModule) addStorageTasks() {
 				Action: new(storage.EnableMinio),
 			},
 			&task.LocalTask{
-				Name:   "CheckMinioState",
-				Action: new(storage.CheckMinioState),
-				Retry:  20,
+				Name:      "CheckMinioState",
+				AlwaysRun: true,
+				Action:    new(storage.CheckMinioState),
+				Retry:     20,
 			},
 			&task.LocalTask{
 				Name:   "ConfigJuiceFSMetaDB",
@@ -536,9 +677,10 @@ func (m *ChangeIPModule) addStorageTasks() {
 		},
 
 		&task.LocalTask{
-			Name:   "CheckJuiceFsState",
-			Action: new(storage.CheckJuiceFsState),
-			Retry:  20,
+			Name:      "CheckJuiceFsState",
+			AlwaysRun: true,
+			Action:    new(storage.CheckJuiceFsState),
+			Retry:     20,
 		},
 	)
 }
@@ -697,17 +839,19 @@ func (m *ChangeIPModule) addRestartTasks() {
 	}
 	m.Tasks = append(m.Tasks,
 		&task.LocalTask{
-			Name:   "WaitForKubeAPIServerUp",
-			Action: new(precheck.GetKubernetesNodesStatus),
-			Retry:  20,
+			Name:      "WaitForKubeAPIServerUp",
+			AlwaysRun: true,
+			Action:    new(precheck.GetKubernetesNodesStatus),
+			Retry:     20,
 		})
 	m.Tasks = append(m.Tasks, restartPodsTasks...)
 
 	m.Tasks = append(m.Tasks, &task.LocalTask{
-		Name:   "EnsurePodsUpAndRunningAgain",
-		Action: &CheckKeyPodsRunning{Node: m.Runtime.GetLocalHost().GetName()},
-		Delay:  10 * time.Second,
-		Retry:  60,
+		Name:      "EnsurePodsUpAndRunningAgain",
+		AlwaysRun: true,
+		Action:    &CheckKeyPodsRunning{Node: m.Runtime.GetLocalHost().GetName()},
+		Delay:     10 * time.Second,
+		Retry:     60,
 	})
 }
 
@@ -720,8 +864,9 @@ func (m *ChangeHostIPModule) Init() {
 
 	m.Tasks = append(m.Tasks,
 		&task.LocalTask{
-			Name:   "CheckOlaresStateInHost",
-			Action: new(CheckTerminusStateInHost),
+			Name:      "CheckOlaresStateInHost",
+			AlwaysRun: true,
+			Action:    new(CheckTerminusStateInHost),
 		},
 		&task.LocalTask{
 			Name:   "GetNATGatewayIP",
@@ -748,8 +893,9 @@ func (m *GetMasterInfoModule) Init() {
 			Hosts:  m.Runtime.GetHostsByRole(common.Master),
 		},
 		&task.LocalTask{
-			Name:   "AddNodePrecheck",
-			Action: new(AddNodePrecheck),
+			Name:      "AddNodePrecheck",
+			AlwaysRun: true,
+			Action:    new(AddNodePrecheck),
 		},
 	)
 }
@@ -766,3 +912,16 @@ func (m *SaveMasterHostConfigModule) Init() {
 			Action: new(SaveMasterHostConfig),
 		})
 }
+
+type DeleteMasterHostConfigModule struct {
+	common.KubeModule
+}
+
+func (m *DeleteMasterHostConfigModule) Init() {
+	m.Name = "DeleteMasterHostConfig"
+	m.Tasks = append(m.Tasks,
+		&task.LocalTask{
+			Name:   "DeleteMasterHostConfig",
+			Action: new(DeleteMasterHostConfig),
+		})
+}
@@ -18,6 +18,7 @@ import (
 	configmaptemplates "bytetrade.io/web3os/installer/pkg/terminus/templates"
 	"bytetrade.io/web3os/installer/pkg/utils"
 	"github.com/pkg/errors"
+	"helm.sh/helm/v3/pkg/storage/driver"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	ctrl "sigs.k8s.io/controller-runtime"
 )
@@ -87,6 +88,47 @@ func (t *InstallOsSystem) Execute(runtime connector.Runtime) error {
 	return nil
 }
 
+// UninstallOsSystem reverts InstallOsSystem
+type UninstallOsSystem struct {
+	common.KubeAction
+}
+
+func (t *UninstallOsSystem) Execute(runtime connector.Runtime) error {
+	config, err := ctrl.GetConfig()
+	if err != nil {
+		return err
+	}
+	actionConfig, _, err := utils.InitConfig(config, common.NamespaceOsSystem)
+	if err != nil {
+		return err
+	}
+	if err := utils.UninstallCharts(actionConfig, common.ChartNameSystem); err != nil && !errors.Is(err, driver.ErrReleaseNotFound) {
+		return errors.Wrapf(errors.WithStack(err), "uninstall chart %s failed", common.ChartNameSystem)
+	}
+	return nil
+}
+
+// DeleteOsSystemConfigMaps reverts CreateBackupConfigMap and CreateReverseProxyConfigMap
+type DeleteOsSystemConfigMaps struct {
+	common.KubeAction
+}
+
+func (t *DeleteOsSystemConfigMaps) Execute(runtime connector.Runtime) error {
+	var kubectl, _ = util.GetCommand(common.CommandKubectl)
+	for _, configMapFile := range []string{
+		path.Join(runtime.GetInstallerDir(), "deploy", configmaptemplates.BackupConfigMap.Name()),
+		path.Join(runtime.GetInstallerDir(), "deploy", configmaptemplates.ReverseProxyConfigMap.Name()),
+	} {
+		if !util.IsExist(configMapFile) {
+			continue
+		}
+		if _, err := runtime.GetRunner().SudoCmd(fmt.Sprintf("%s delete --ignore-not-found -f %s", kubectl, configMapFile), false, true); err != nil {
+			return errors.Wrapf(errors.WithStack(err), "delete configmap %s failed", configMapFile)
+		}
+	}
+	return nil
+}
+
 type CreateBackupConfigMap struct {
 	common.KubeAction
 }
@@ -242,7 +284,8 @@ func (m *InstallOsSystemModule) Init() {
 	}
 
 	checkSystemService := &task.LocalTask{
-		Name: "CheckSystemServiceStatus",
+		Name:      "CheckSystemServiceStatus",
+		AlwaysRun: true,
 		Action: &CheckPodsRunning{
 			labels: map[string][]string{
 				"os-system": {"tier=app-service"},
@@ -266,6 +309,19 @@ func (m *InstallOsSystemModule) Init() {
 		checkSystemService,
 		patchOs,
 	}
+
+	m.RollbackTasks = []task.Interface{
+		&task.LocalTask{
+			Name:   "DeleteOsSystemConfigMaps",
+			Action: &DeleteOsSystemConfigMaps{},
+			Retry:  1,
+		},
+		&task.LocalTask{
+			Name:   "UninstallOsSystem",
+			Action: &UninstallOsSystem{},
+			Retry:  1,
+		},
+	}
 }
 
 func getGpuType(gpuEnable, gpuShare bool) (gpuType string) {
@@ -0,0 +1,96 @@
+package terminus
+
+import (
+	"time"
+
+	"bytetrade.io/web3os/installer/pkg/core/module"
+	"bytetrade.io/web3os/installer/pkg/core/util"
+)
+
+// the results of a Stage
+const (
+	StageDone    = "done"
+	StageFailed  = "failed"
+	StageSkipped = "skipped"
+	StageNotRun  = "not run"
+)
+
+// Stage is a stage of olares-cli stop or start, one of its modules
+type Stage struct {
+	Name     string `json:"name"`
+	Result   string `json:"result"`
+	Duration string `json:"duration,omitempty"`
+	Detail   string `json:"detail,omitempty"`
+}
+
+// StageReport is the result of the stages of olares-cli stop or start, in their order,
+// filled in by the post hook of their modules
+type StageReport struct {
+	Stages []Stage `json:"stages"`
+}
+
+// NewStageReport reports the stages named, none of them run yet
+func NewStageReport(names ...string) *StageReport {
+	r := &StageReport{}
+	for _, name := range names {
+		r.Stages = append(r.Stages, Stage{Name: name, Result: StageNotRun})
+	}
+	return r
+}
+
+// Skip reports the stage skipped, for the reason given
+func (r *StageReport) Skip(name, reason string) {
+	if s := r.stage(name); s != nil {
+		s.Result, s.Detail = StageSkipped, reason
+	}
+}
+
+// Failed tells whether a stage has failed
+func (r *StageReport) Failed() bool {
+	for _, s := range r.Stages {
+		if s.Result == StageFailed {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *StageReport) stage(name string) *Stage {
+	for i := range r.Stages {
+		if r.Stages[i].Name == name {
+			return &r.Stages[i]
+		}
+	}
+	return nil
+}
+
+// PostHook records the result of the module of a stage
+func (r *StageReport) PostHook() module.PostHookInterface {
+	return &stageHook{report: r}
+}
+
+type stageHook struct {
+	module.PostHook
+	report *StageReport
+}
+
+func (h *stageHook) Try() error {
+	s := h.report.stage(h.Module.GetName())
+	if s == nil {
+		return nil
+	}
+	end := h.Result.EndTime
+	if end.IsZero() {
+		end = time.Now()
+	}
+	s.Duration = util.ShortDur(end.Sub(h.Result.StartTime).Round(time.Second))
+	if h.Result.IsFailed() {
+		s.Result = StageFailed
+		if h.Result.CombineResult != nil {
+			s.Detail = h.Result.CombineResult.Error()
+		}
+	} else {
+		s.Result = StageDone
+	}
+	return nil
+}
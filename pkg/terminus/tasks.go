@@ -17,6 +17,7 @@ import (
 	"sync"
 	"time"
 
+	"bytetrade.io/web3os/installer/pkg/core/cache"
 	"bytetrade.io/web3os/installer/pkg/storage"
 	corev1 "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
@@ -31,6 +32,7 @@ import (
 	"bytetrade.io/web3os/installer/pkg/core/action"
 	"bytetrade.io/web3os/installer/pkg/terminus/templates"
 
+	kubekeyapiv1alpha2 "bytetrade.io/web3os/installer/apis/kubekey/v1alpha2"
 	"bytetrade.io/web3os/installer/pkg/common"
 	cc "bytetrade.io/web3os/installer/pkg/core/common"
 	"bytetrade.io/web3os/installer/pkg/core/connector"
@@ -91,23 +93,36 @@ func (t *CheckKeyPodsRunning) Execute(runtime connector.Runtime) error {
 			logger.Debugf("skipping pod %s that's not on node %s", pod.Name, t.Node)
 			continue
 		}
-		if strings.HasPrefix(pod.Namespace, "user-space") ||
-			strings.HasPrefix(pod.Namespace, "user-system") ||
-			pod.Namespace == "os-system" {
-			if pod.Status.Phase != corev1.PodRunning {
-				return fmt.Errorf("pod %s/%s is not running", pod.Namespace, pod.Name)
-			}
-			if len(pod.Status.ContainerStatuses) == 0 {
-				return fmt.Errorf("pod %s/%s has no container statuses yet", pod.Namespace, pod.Name)
-			}
-			for _, cStatus := range pod.Status.ContainerStatuses {
-				if cStatus.State.Terminated != nil && cStatus.State.Terminated.ExitCode != 0 {
-					return fmt.Errorf("container %s in pod %s/%s is terminated", cStatus.Name, pod.Namespace, pod.Name)
-				}
-				if cStatus.State.Running == nil {
-					return fmt.Errorf("container %s in pod %s/%s is not running", cStatus.Name, pod.Namespace, pod.Name)
-				}
-			}
+		if err := CheckKeyPod(&pod); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IsKeyPod tells whether the pod is one of the system or of the users, which Olares needs to be running
+func IsKeyPod(pod *corev1.Pod) bool {
+	_, ok := WorkloadTier(pod.Namespace)
+	return ok
+}
+
+// CheckKeyPod returns why a key pod is not running, it is nil for the other pods
+func CheckKeyPod(pod *corev1.Pod) error {
+	if !IsKeyPod(pod) {
+		return nil
+	}
+	if pod.Status.Phase != corev1.PodRunning {
+		return fmt.Errorf("pod %s/%s is not running", pod.Namespace, pod.Name)
+	}
+	if len(pod.Status.ContainerStatuses) == 0 {
+		return fmt.Errorf("pod %s/%s has no container statuses yet", pod.Namespace, pod.Name)
+	}
+	for _, cStatus := range pod.Status.ContainerStatuses {
+		if cStatus.State.Terminated != nil && cStatus.State.Terminated.ExitCode != 0 {
+			return fmt.Errorf("container %s in pod %s/%s is terminated", cStatus.Name, pod.Namespace, pod.Name)
+		}
+		if cStatus.State.Running == nil {
+			return fmt.Errorf("container %s in pod %s/%s is not running", cStatus.Name, pod.Namespace, pod.Name)
 		}
 	}
 	return nil
@@ -259,6 +274,7 @@ func (t *RemoveReleaseFile) Execute(runtime connector.Runtime) error {
 
 type CheckPrepared struct {
 	common.KubeAction
+	cache.Filler
 	Force bool
 }
 
@@ -276,6 +292,7 @@ func (t *CheckPrepared) Execute(runtime connector.Runtime) error {
 
 type CheckInstalled struct {
 	common.KubeAction
+	cache.Filler
 	Force bool
 }
 
@@ -445,6 +462,7 @@ func (a *DeleteAllPods) Execute(runtime connector.Runtime) error {
 
 type DeletePodsUsingHostIP struct {
 	common.KubeAction
+	cache.Filler
 }
 
 func (a *DeletePodsUsingHostIP) Execute(runtime connector.Runtime) error {
@@ -836,6 +854,7 @@ LOOP:
 
 type GetMasterInfo struct {
 	common.KubeAction
+	cache.Filler
 	Print bool
 }
 
@@ -846,7 +865,9 @@ type MasterInfo struct {
 	KubernetesType      string
 	OlaresVersion       string
 	MasterNodeName      string
-	AllNodes            []string
+	// MasterNodes are all the control plane nodes of the cluster
+	MasterNodes []string
+	AllNodes    []string
 }
 
 func (t *GetMasterInfo) Execute(runtime connector.Runtime) (err error) {
@@ -895,7 +916,11 @@ func (t *GetMasterInfo) Execute(runtime connector.Runtime) (err error) {
 	for _, node := range nodeList.Items {
 		masterInfo.AllNodes = append(masterInfo.AllNodes, node.Name)
 		if _, ok := node.Labels["node-role.kubernetes.io/master"]; ok {
-			masterInfo.MasterNodeName = node.Name
+			masterInfo.MasterNodes = append(masterInfo.MasterNodes, node.Name)
+			// with several masters, the one we are connected to is the one to name
+			if masterInfo.MasterNodeName == "" || nodeHasAddress(&node, runtime.RemoteHost().GetInternalAddress()) {
+				masterInfo.MasterNodeName = node.Name
+			}
 			if strings.Contains(node.Status.NodeInfo.KubeletVersion, common.K3s) {
 				masterInfo.KubernetesType = common.K3s
 			} else {
@@ -921,6 +946,15 @@ func (t *GetMasterInfo) Execute(runtime connector.Runtime) (err error) {
 
 }
 
+func nodeHasAddress(node *corev1.Node, address string) bool {
+	for _, addr := range node.Status.Addresses {
+		if addr.Address == address {
+			return true
+		}
+	}
+	return false
+}
+
 type AddNodePrecheck struct {
 	common.KubeAction
 }
@@ -951,6 +985,14 @@ func (a *AddNodePrecheck) Execute(runtime connector.Runtime) error {
 	if !masterInfo.OlaresInstalled {
 		errs = append(errs, errors.New("[Olares] the master node has not installed Olares"))
 	}
+	if a.KubeConf.Arg.JoinAsMaster {
+		if masterInfo.KubernetesInstalled && masterInfo.KubernetesType != common.K3s {
+			errs = append(errs, errors.New("[Role] additional master nodes are only supported for k3s clusters"))
+		}
+		if a.KubeConf.Cluster.Etcd.Type != kubekeyapiv1alpha2.KubeKey {
+			errs = append(errs, fmt.Errorf("[Role] additional master nodes need an etcd datastore managed by Olares, got %s", a.KubeConf.Cluster.Etcd.Type))
+		}
+	}
 	for _, node := range masterInfo.AllNodes {
 		if strings.EqualFold(node, runtime.GetSystemInfo().GetHostname()) {
 			errs = append(errs, fmt.Errorf("[NodeName] the node name: \"%s\" has already been occupied by another node", node))
@@ -973,3 +1015,17 @@ func (a *SaveMasterHostConfig) Execute(runtime connector.Runtime) error {
 	}
 	return os.WriteFile(filepath.Join(runtime.GetBaseDir(), common.MasterHostConfigFile), content, 0644)
 }
+
+// DeleteMasterHostConfig forgets the master saved by SaveMasterHostConfig,
+// once the node has left the cluster
+type DeleteMasterHostConfig struct {
+	common.KubeAction
+}
+
+func (a *DeleteMasterHostConfig) Execute(runtime connector.Runtime) error {
+	err := os.Remove(filepath.Join(runtime.GetBaseDir(), common.MasterHostConfigFile))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
@@ -65,7 +65,8 @@ func (t *WelcomeMessage) Execute(runtime connector.Runtime) error {
 	logger.Info("with the following credentials:")
 	fmt.Println()
 	logger.Infof("Username: %s", t.KubeConf.Arg.User.UserName)
-	logger.Infof("Password: %s", t.KubeConf.Arg.User.Password)
+	// printed past the logger, which masks it, to keep it out of the log files
+	fmt.Printf("Password: %s\n", t.KubeConf.Arg.User.Password)
 	fmt.Printf("\n------------------------------------------------\n\n\n\n\n")
 
 	return nil
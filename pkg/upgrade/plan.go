@@ -0,0 +1,212 @@
+package upgrade
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+
+	"bytetrade.io/web3os/installer/pkg/manifest"
+	"github.com/Masterminds/semver/v3"
+	"helm.sh/helm/v3/pkg/chartutil"
+)
+
+// Plan is what upgrading from the current version to the target one would do, as computed by NewPlan
+type Plan struct {
+	CurrentVersion string   `json:"currentVersion"`
+	TargetVersion  string   `json:"targetVersion"`
+	PreTasks       []string `json:"preTasks"`
+	CoreTasks      []string `json:"coreTasks"`
+	PostTasks      []string `json:"postTasks"`
+	// Components and Charts only list what differs between the two versions
+	Components []VersionDelta `json:"components"`
+	Charts     []VersionDelta `json:"charts"`
+	Images     ImageDelta     `json:"images"`
+	// DownloadSize is the size of the files of the target version still to be downloaded,
+	// the files whose size the manifest does not tell are only counted in MissingFiles
+	DownloadSize int64 `json:"downloadSize"`
+	MissingFiles int   `json:"missingFiles"`
+	// Blockers are the reasons why the upgrade cannot be done, it can if there is none
+	Blockers []string `json:"blockers"`
+}
+
+// VersionDelta is the version of a component or chart in the current and the target versions,
+// either is empty if the component or chart is not part of it
+type VersionDelta struct {
+	Name    string `json:"name"`
+	Current string `json:"current"`
+	Target  string `json:"target"`
+}
+
+type ImageDelta struct {
+	Added   int `json:"added"`
+	Changed int `json:"changed"`
+	Removed int `json:"removed"`
+}
+
+// plannedComponents are the manifest items whose version is worth showing in a plan,
+// the version is found in their file name
+var plannedComponents = []string{"k3s", "kubeadm", "kubelet", "containerd", "etcd", "helm", "juicefs", "redis", "minio", "velero", "olaresd"}
+
+var fileVersionPattern = regexp.MustCompile(`v?\d+\.\d+(\.\d+)?([-+][0-9A-Za-z.+]+)?`)
+
+// NewPlan computes the plan of the upgrade from the installation packages of both versions under baseDir,
+// the installation package of the current version may be gone, in which case the deltas are less accurate,
+// that of the target version is required and its absence is reported as a blocker
+func NewPlan(baseDir, arch string, current, target *semver.Version) *Plan {
+	p := &Plan{
+		CurrentVersion: current.String(),
+		TargetVersion:  target.String(),
+		PreTasks:       taskNames(preTasks, current, target),
+		CoreTasks:      taskNames(coreTasks, current, target),
+		PostTasks:      taskNames(postTasks, current, target),
+		Components:     []VersionDelta{},
+		Charts:         []VersionDelta{},
+		Blockers:       []string{},
+	}
+
+	if !target.GreaterThan(current) {
+		p.Blockers = append(p.Blockers, fmt.Sprintf("the target version %s is not newer than the current version %s", target, current))
+	} else if skipped := skippedMinor(current, target); skipped != "" {
+		p.Blockers = append(p.Blockers, fmt.Sprintf("upgrading from %s to %s skips %s, upgrade to it first", current, target, skipped))
+	}
+	if len(p.CoreTasks) == 0 {
+		p.Blockers = append(p.Blockers, fmt.Sprintf("no upgrade path from %s to %s, the current version must be at least %s", current, target, minimumVersion))
+	}
+
+	currentDir, targetDir := installerDir(baseDir, current), installerDir(baseDir, target)
+	targetManifest, err := readManifest(targetDir)
+	if err != nil {
+		p.Blockers = append(p.Blockers, fmt.Sprintf("the installation package of %s is not found in %s, download it first with olares-cli download wizard --version %s", target, targetDir, target))
+		return p
+	}
+	// the installation package of older versions may have been removed
+	currentManifest, err := readManifest(currentDir)
+	if err != nil {
+		currentManifest = manifest.New()
+	}
+
+	p.Components = componentDeltas(currentManifest, targetManifest)
+	p.Charts = chartDeltas(currentDir, targetDir)
+	p.Images = imageDelta(currentManifest, targetManifest)
+	for _, item := range targetManifest {
+		if _, err := os.Stat(item.FilePath(baseDir)); err == nil {
+			continue
+		}
+		p.MissingFiles++
+		p.DownloadSize += item.GetItemUrlForHost(arch).Size
+	}
+	return p
+}
+
+func installerDir(baseDir string, version *semver.Version) string {
+	return filepath.Join(baseDir, "versions", "v"+version.String())
+}
+
+func readManifest(installerDir string) (manifest.InstallationManifest, error) {
+	doc, err := manifest.Load(filepath.Join(installerDir, "installation.manifest"))
+	if err != nil {
+		return nil, err
+	}
+	return doc.Manifest(), nil
+}
+
+func taskNames(tasks []*upgradeTask, current, target *semver.Version) []string {
+	names := []string{}
+	for _, t := range tasks {
+		if t.Match(current, target) {
+			names = append(names, t.Task.GetName())
+		}
+	}
+	return names
+}
+
+// skippedMinor returns the first minor version skipped by the upgrade, if any,
+// minor versions are to be upgraded to one after another
+func skippedMinor(current, target *semver.Version) string {
+	switch {
+	case target.Major() == current.Major() && target.Minor() > current.Minor()+1:
+		return fmt.Sprintf("%d.%d", current.Major(), current.Minor()+1)
+	case target.Major() > current.Major()+1:
+		return fmt.Sprintf("%d.x", current.Major()+1)
+	}
+	return ""
+}
+
+func componentDeltas(current, target manifest.InstallationManifest) []VersionDelta {
+	deltas := []VersionDelta{}
+	for _, name := range plannedComponents {
+		d := VersionDelta{Name: name, Current: fileVersion(current[name]), Target: fileVersion(target[name])}
+		if d.Current != d.Target {
+			deltas = append(deltas, d)
+		}
+	}
+	return deltas
+}
+
+func fileVersion(item *manifest.ManifestItem) string {
+	if item == nil {
+		return ""
+	}
+	if v := fileVersionPattern.FindString(item.Filename); v != "" {
+		return v
+	}
+	return item.Filename
+}
+
+// chartDeltas compares the versions of the charts the upgrade installs,
+// the system charts and those of the launcher and the apps of every user
+func chartDeltas(currentDir, targetDir string) []VersionDelta {
+	charts := []string{"system", "settings", "launcher"}
+	apps := make(map[string]bool)
+	for _, dir := range []string{currentDir, targetDir} {
+		entries, _ := os.ReadDir(filepath.Join(dir, "wizard", "config", "apps"))
+		for _, e := range entries {
+			if e.IsDir() && !apps[e.Name()] {
+				apps[e.Name()] = true
+				charts = append(charts, filepath.Join("apps", e.Name()))
+			}
+		}
+	}
+	sort.Strings(charts[3:])
+
+	deltas := []VersionDelta{}
+	for _, chart := range charts {
+		d := VersionDelta{Name: chart, Current: chartVersion(currentDir, chart), Target: chartVersion(targetDir, chart)}
+		if d.Current != d.Target {
+			deltas = append(deltas, d)
+		}
+	}
+	return deltas
+}
+
+func chartVersion(installerDir, chart string) string {
+	metadata, err := chartutil.LoadChartfile(filepath.Join(installerDir, "wizard", "config", chart, chartutil.ChartfileName))
+	if err != nil {
+		return ""
+	}
+	return metadata.Version
+}
+
+func imageDelta(current, target manifest.InstallationManifest) ImageDelta {
+	var d ImageDelta
+	for _, c := range manifest.Diff(current, target) {
+		item := target[c.FileID]
+		if c.Kind == manifest.ChangeRemoved {
+			item = current[c.FileID]
+		}
+		if item.ImageName == "" {
+			continue
+		}
+		switch c.Kind {
+		case manifest.ChangeAdded:
+			d.Added++
+		case manifest.ChangeRemoved:
+			d.Removed++
+		default:
+			d.Changed++
+		}
+	}
+	return d
+}
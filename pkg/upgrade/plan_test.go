@@ -0,0 +1,56 @@
+package upgrade
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+func TestPlanBlockers(t *testing.T) {
+	tests := []struct {
+		current, target string
+		blocked         []string
+	}{
+		{current: "1.12.0", target: "1.13.0"},
+		{current: "1.12.0", target: "1.12.0", blocked: []string{"not newer"}},
+		{current: "1.12.0", target: "1.14.0", blocked: []string{"skips 1.13"}},
+		{current: "1.11.5", target: "1.12.0", blocked: []string{"no upgrade path"}},
+	}
+	for _, tt := range tests {
+		baseDir := t.TempDir()
+		target := semver.MustParse(tt.target)
+		dir := installerDir(baseDir, target)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "installation.manifest"), []byte("k3s-v1.33.3,pkg/components,components,k3s,md5,arm64/k3s,md5,k3s\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		p := NewPlan(baseDir, "amd64", semver.MustParse(tt.current), target)
+		if len(p.Blockers) != len(tt.blocked) {
+			t.Errorf("%s -> %s: blockers = %v, want %v", tt.current, tt.target, p.Blockers, tt.blocked)
+			continue
+		}
+		for i, b := range tt.blocked {
+			if !strings.Contains(p.Blockers[i], b) {
+				t.Errorf("%s -> %s: blocker %q does not mention %q", tt.current, tt.target, p.Blockers[i], b)
+			}
+		}
+		// the installation package of the current version is not there, but for the same version
+		want := []VersionDelta{{Name: "k3s", Target: "v1.33.3"}}
+		if tt.current == tt.target {
+			want = []VersionDelta{}
+		}
+		if !reflect.DeepEqual(p.Components, want) {
+			t.Errorf("%s -> %s: components = %+v, want %+v", tt.current, tt.target, p.Components, want)
+		}
+		if p.MissingFiles != 1 {
+			t.Errorf("%s -> %s: missing files = %d, want 1", tt.current, tt.target, p.MissingFiles)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package upgrade
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"bytetrade.io/web3os/installer/pkg/common"
+	"bytetrade.io/web3os/installer/pkg/core/connector"
+	"bytetrade.io/web3os/installer/pkg/core/logger"
+	"bytetrade.io/web3os/installer/pkg/core/task"
+	"bytetrade.io/web3os/installer/pkg/core/util"
+	"bytetrade.io/web3os/installer/pkg/daemon"
+	"bytetrade.io/web3os/installer/pkg/terminus"
+	"github.com/pkg/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// RollbackModule brings the system back to the version it was upgraded from, using the snapshot taken before the upgrade,
+// the runtime must be that of the version of before the upgrade, for its installation package to be used
+type RollbackModule struct {
+	common.KubeModule
+	Snapshot *Snapshot
+}
+
+func (m *RollbackModule) Init() {
+	m.Name = "RollbackUpgrade"
+	m.Desc = "Roll back the upgrade"
+
+	restoreReleaseFile := &task.LocalTask{
+		Name:   "RestoreReleaseFile",
+		Action: &RestoreReleaseFile{Snapshot: m.Snapshot},
+	}
+
+	rollbackReleases := &task.LocalTask{
+		Name:   "RollbackHelmReleases",
+		Action: &RollbackHelmReleases{Snapshot: m.Snapshot},
+		Retry:  3,
+		Delay:  15 * time.Second,
+	}
+
+	// the charts of the launcher and of the apps installed for the new users
+	restoreAppServiceCharts := &task.LocalTask{
+		Name:    "RestoreChartsInAppService",
+		Prepare: new(InstallerDirExists),
+		Action:  new(terminus.CopyAppServiceHelmFiles),
+		Retry:   5,
+	}
+
+	restoreOlaresd := &task.LocalTask{
+		Name:    "RestoreOlaresdBinary",
+		Prepare: &SnapshotHasOlaresd{Snapshot: m.Snapshot},
+		Action:  &RestoreOlaresdBinary{Snapshot: m.Snapshot},
+	}
+
+	updateOlaresdEnv := &task.LocalTask{
+		Name:    "UpdateOlaresdEnv",
+		Prepare: &SnapshotHasOlaresd{Snapshot: m.Snapshot},
+		Action:  new(daemon.UpdateOlaresdServiceEnv),
+	}
+
+	restartOlaresd := &task.LocalTask{
+		Name:    "RestartOlaresd",
+		Prepare: &SnapshotHasOlaresd{Snapshot: m.Snapshot},
+		Action: &terminus.SystemctlCommand{
+			Command:   "restart",
+			UnitNames: []string{"olaresd"},
+		},
+	}
+
+	m.Tasks = []task.Interface{
+		restoreReleaseFile,
+		rollbackReleases,
+		restoreAppServiceCharts,
+		restoreOlaresd,
+		updateOlaresdEnv,
+		restartOlaresd,
+		&task.LocalTask{
+			Name:      "EnsurePodsUpAndRunningAgain",
+			AlwaysRun: true,
+			Action:    new(terminus.CheckKeyPodsRunning),
+			Delay:     15 * time.Second,
+			Retry:     60,
+		},
+	}
+}
+
+// InstallerDirExists checks that the installation package of the version of the runtime is still there
+type InstallerDirExists struct {
+	common.KubePrepare
+}
+
+func (p *InstallerDirExists) PreCheck(runtime connector.Runtime) (bool, error) {
+	if !util.IsExist(runtime.GetInstallerDir()) {
+		logger.Warnf("the installation package %s is not found, the charts of app-service are not restored", runtime.GetInstallerDir())
+		return false, nil
+	}
+	return true, nil
+}
+
+type SnapshotHasOlaresd struct {
+	common.KubePrepare
+	Snapshot *Snapshot
+}
+
+func (p *SnapshotHasOlaresd) PreCheck(runtime connector.Runtime) (bool, error) {
+	return p.Snapshot.Olaresd != "", nil
+}
+
+type RestoreReleaseFile struct {
+	common.KubeAction
+	Snapshot *Snapshot
+}
+
+func (a *RestoreReleaseFile) Execute(runtime connector.Runtime) error {
+	return a.Snapshot.RestoreReleaseFile()
+}
+
+type RollbackHelmReleases struct {
+	common.KubeAction
+	Snapshot *Snapshot
+}
+
+func (a *RollbackHelmReleases) Execute(runtime connector.Runtime) error {
+	config, err := ctrl.GetConfig()
+	if err != nil {
+		return errors.Wrap(err, "failed to get rest config")
+	}
+	return a.Snapshot.RollbackReleases(config)
+}
+
+type RestoreOlaresdBinary struct {
+	common.KubeAction
+	Snapshot *Snapshot
+}
+
+func (a *RestoreOlaresdBinary) Execute(runtime connector.Runtime) error {
+	// olaresd is still running, its binary can't be written to but can be replaced by another file
+	tmp := olaresdBinary + ".restore"
+	restoreCmd := fmt.Sprintf("cp -f %s %s && chmod +x %s && mv -f %s %s",
+		filepath.Join(a.Snapshot.Dir, a.Snapshot.Olaresd), tmp, tmp, tmp, olaresdBinary)
+	if _, err := runtime.GetRunner().SudoCmd(restoreCmd, false, false); err != nil {
+		return errors.Wrap(errors.WithStack(err), "failed to restore the olaresd binary")
+	}
+	return nil
+}
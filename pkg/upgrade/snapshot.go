@@ -0,0 +1,352 @@
+package upgrade
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"bytetrade.io/web3os/installer/pkg/common"
+	"bytetrade.io/web3os/installer/pkg/core/cache"
+	"bytetrade.io/web3os/installer/pkg/core/connector"
+	"bytetrade.io/web3os/installer/pkg/core/journal"
+	"bytetrade.io/web3os/installer/pkg/core/logger"
+	"bytetrade.io/web3os/installer/pkg/core/util"
+	"bytetrade.io/web3os/installer/pkg/daemon"
+	"bytetrade.io/web3os/installer/pkg/datastore"
+	"bytetrade.io/web3os/installer/pkg/utils"
+	"github.com/joho/godotenv"
+	"github.com/pkg/errors"
+	"helm.sh/helm/v3/pkg/action"
+	"k8s.io/client-go/rest"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+const (
+	snapshotFile        = "snapshot.json"
+	snapshotReleaseFile = "release"
+	snapshotOlaresdFile = "olaresd"
+	// snapshotsToKeep is the number of snapshots kept, the older ones are removed when a new one is taken
+	snapshotsToKeep = 3
+
+	k3sSnapshotName = "pre-upgrade"
+	// journalSnapshot is the value of the journal the directory of the snapshot taken by the run is saved as
+	journalSnapshot = "upgradeSnapshot"
+	olaresdBinary   = "/usr/local/bin/olaresd"
+)
+
+// Snapshot is what is saved before an upgrade so that the system can be restored if the upgrade fails,
+// it is kept as snapshot.json in its directory along with the saved files
+type Snapshot struct {
+	// Dir is the directory of the snapshot, it is not saved
+	Dir            string    `json:"-"`
+	CurrentVersion string    `json:"currentVersion"`
+	TargetVersion  string    `json:"targetVersion"`
+	CreatedAt      time.Time `json:"createdAt"`
+	// Datastore is the file, relative to Dir, the cluster datastore has been saved to,
+	// it is empty if the datastore could not be saved, DatastoreType is one of the types of the datastore package
+	Datastore     string `json:"datastore,omitempty"`
+	DatastoreType string `json:"datastoreType,omitempty"`
+	// Olaresd is the file, relative to Dir, the olaresd binary of the current version has been saved to,
+	// it is empty if it was not found
+	Olaresd  string        `json:"olaresd,omitempty"`
+	Releases []HelmRelease `json:"releases"`
+}
+
+// HelmRelease is a deployed helm release at the time of the snapshot
+type HelmRelease struct {
+	Name      string                 `json:"name"`
+	Namespace string                 `json:"namespace"`
+	Revision  int                    `json:"revision"`
+	Chart     string                 `json:"chart"`
+	Version   string                 `json:"version"`
+	Values    map[string]interface{} `json:"values,omitempty"`
+}
+
+// SnapshotsDir is where the snapshots taken before the upgrades are kept, one directory each
+func SnapshotsDir(baseDir string) string {
+	return filepath.Join(baseDir, "upgrade", "snapshots")
+}
+
+// LatestSnapshot loads the most recent snapshot, it returns nil if there is none
+func LatestSnapshot(baseDir string) (*Snapshot, error) {
+	dirs, err := snapshotDirs(baseDir)
+	if err != nil {
+		return nil, err
+	}
+	for i := len(dirs) - 1; i >= 0; i-- {
+		// the snapshots interrupted while being taken have no snapshot.json
+		if s, err := LoadSnapshot(dirs[i]); err == nil {
+			return s, nil
+		}
+	}
+	return nil, nil
+}
+
+func LoadSnapshot(dir string) (*Snapshot, error) {
+	data, err := os.ReadFile(filepath.Join(dir, snapshotFile))
+	if err != nil {
+		return nil, err
+	}
+	s := &Snapshot{Dir: dir}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse %s", filepath.Join(dir, snapshotFile))
+	}
+	return s, nil
+}
+
+// snapshotDirs lists the directories of the snapshots, oldest first as they are named after their creation time
+func snapshotDirs(baseDir string) ([]string, error) {
+	entries, err := os.ReadDir(SnapshotsDir(baseDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var dirs []string
+	for _, e := range entries {
+		if e.IsDir() {
+			dirs = append(dirs, filepath.Join(SnapshotsDir(baseDir), e.Name()))
+		}
+	}
+	sort.Strings(dirs)
+	return dirs, nil
+}
+
+func (s *Snapshot) save() error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(s.Dir, snapshotFile), data, 0600)
+}
+
+// RestoreReleaseFile puts back the release file, and so the version of Olares, of before the upgrade
+func (s *Snapshot) RestoreReleaseFile() error {
+	if err := util.CopyFile(filepath.Join(s.Dir, snapshotReleaseFile), common.OlaresReleaseFile); err != nil {
+		return errors.Wrap(err, "failed to restore the release file")
+	}
+	return nil
+}
+
+// RollbackReleases rolls the helm releases back to their revision of the snapshot,
+// those left untouched since are not rolled back, nor those installed since
+func (s *Snapshot) RollbackReleases(config *rest.Config) error {
+	var errs []string
+	for _, r := range s.Releases {
+		actionConfig, _, err := utils.InitConfig(config, r.Namespace)
+		if err != nil {
+			return err
+		}
+		current, err := action.NewGet(actionConfig).Run(r.Name)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s/%s: %v", r.Namespace, r.Name, err))
+			continue
+		}
+		if current.Version == r.Revision {
+			continue
+		}
+		logger.Infof("rolling back release %s/%s from revision %d to %d", r.Namespace, r.Name, current.Version, r.Revision)
+		rollback := action.NewRollback(actionConfig)
+		rollback.Version = r.Revision
+		if err := rollback.Run(r.Name); err != nil {
+			errs = append(errs, fmt.Sprintf("%s/%s: %v", r.Namespace, r.Name, err))
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Errorf("failed to roll back releases: %v", errs)
+	}
+	return nil
+}
+
+// SaveUpgradeSnapshot saves the cluster datastore, the release file, the helm releases and olaresd before the upgrade,
+// see Snapshot, the snapshot is set to the pipeline cache for RestoreUpgradeSnapshot
+type SaveUpgradeSnapshot struct {
+	common.KubeAction
+}
+
+func (a *SaveUpgradeSnapshot) Execute(runtime connector.Runtime) error {
+	release, err := godotenv.Read(common.OlaresReleaseFile)
+	if err != nil {
+		return errors.Wrap(err, "failed to read the release file")
+	}
+	s := &Snapshot{
+		CurrentVersion: release[common.ENV_OLARES_VERSION],
+		TargetVersion:  a.KubeConf.Arg.OlaresVersion,
+		CreatedAt:      time.Now(),
+	}
+	s.Dir = filepath.Join(SnapshotsDir(runtime.GetBaseDir()),
+		fmt.Sprintf("%s-%s-%s", s.CreatedAt.Format("20060102150405"), s.CurrentVersion, s.TargetVersion))
+	// the chart values and the datastore hold secrets
+	if err := os.MkdirAll(s.Dir, 0700); err != nil {
+		return errors.Wrapf(err, "failed to create %s", s.Dir)
+	}
+
+	if err := util.CopyFile(common.OlaresReleaseFile, filepath.Join(s.Dir, snapshotReleaseFile)); err != nil {
+		return errors.Wrap(err, "failed to save the release file")
+	}
+
+	config, err := ctrl.GetConfig()
+	if err != nil {
+		return errors.Wrap(err, "failed to get rest config")
+	}
+	if s.Releases, err = listReleases(config); err != nil {
+		return err
+	}
+
+	s.Datastore, s.DatastoreType, err = datastore.Save(runtime, s.Dir, k3sSnapshotName)
+	if err != nil {
+		return err
+	}
+	if s.Datastore == "" {
+		logger.Warnf("no datastore of the cluster found to save, the snapshot does not include it")
+	}
+
+	if s.Olaresd, err = saveOlaresd(runtime, s); err != nil {
+		return err
+	}
+	if s.Olaresd == "" {
+		logger.Warnf("no olaresd binary of %s found to save, the snapshot does not include it", s.CurrentVersion)
+	}
+
+	if err := s.save(); err != nil {
+		return errors.Wrap(err, "failed to save the snapshot")
+	}
+	logger.Infof("snapshot of the system before the upgrade saved to %s", s.Dir)
+	a.PipelineCache.Set(common.CacheUpgradeSnapshot, s)
+	journal.FromCache(a.PipelineCache).Set(journalSnapshot, s.Dir)
+
+	pruneSnapshots(runtime.GetBaseDir())
+	return nil
+}
+
+func listReleases(config *rest.Config) ([]HelmRelease, error) {
+	actionConfig, _, err := utils.InitConfig(config, "")
+	if err != nil {
+		return nil, err
+	}
+	list := action.NewList(actionConfig)
+	list.AllNamespaces = true
+	list.Deployed = true
+	list.SetStateMask()
+	deployed, err := list.Run()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list the helm releases")
+	}
+	releases := make([]HelmRelease, 0, len(deployed))
+	for _, r := range deployed {
+		hr := HelmRelease{Name: r.Name, Namespace: r.Namespace, Revision: r.Version, Values: r.Config}
+		if r.Chart != nil && r.Chart.Metadata != nil {
+			hr.Chart, hr.Version = r.Chart.Metadata.Name, r.Chart.Metadata.Version
+		}
+		releases = append(releases, hr)
+	}
+	return releases, nil
+}
+
+// saveOlaresd saves the olaresd binary of the current version,
+// which is the installed one unless it has already been replaced for the upgrade, see daemon.BackupOlaresdBinary
+func saveOlaresd(runtime connector.Runtime, s *Snapshot) (string, error) {
+	src := daemon.OlaresdBackupPath(runtime.GetBaseDir(), s.CurrentVersion)
+	if !util.IsExist(src) {
+		installed, err := daemon.InstalledOlaresdVersion()
+		if err != nil || installed != s.CurrentVersion || !util.IsExist(olaresdBinary) {
+			return "", nil
+		}
+		src = olaresdBinary
+	}
+	if err := util.CopyFile(src, filepath.Join(s.Dir, snapshotOlaresdFile)); err != nil {
+		return "", errors.Wrap(err, "failed to save the olaresd binary")
+	}
+	return snapshotOlaresdFile, nil
+}
+
+func pruneSnapshots(baseDir string) {
+	dirs, err := snapshotDirs(baseDir)
+	if err != nil || len(dirs) <= snapshotsToKeep {
+		return
+	}
+	for _, dir := range dirs[:len(dirs)-snapshotsToKeep] {
+		if err := os.RemoveAll(dir); err != nil {
+			logger.Warnf("failed to remove the old snapshot %s: %v", dir, err)
+		}
+	}
+}
+
+// upgradeSnapshot returns the snapshot taken before the upgrade by this run, or by the run being resumed as recorded in its journal,
+// it returns nil if there is none, a snapshot left by another run is never restored
+func upgradeSnapshot(pipelineCache *cache.Cache) (*Snapshot, error) {
+	if v, ok := pipelineCache.Get(common.CacheUpgradeSnapshot); ok {
+		return v.(*Snapshot), nil
+	}
+	dir, ok := journal.FromCache(pipelineCache).Value(journalSnapshot)
+	if !ok {
+		return nil, nil
+	}
+	s, err := LoadSnapshot(dir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load the snapshot %s taken by the run", dir)
+	}
+	pipelineCache.Set(common.CacheUpgradeSnapshot, s)
+	return s, nil
+}
+
+// SnapshotHasDatastore checks that the snapshot taken before the upgrade holds the datastore of the cluster
+type SnapshotHasDatastore struct {
+	common.KubePrepare
+}
+
+func (p *SnapshotHasDatastore) PreCheck(runtime connector.Runtime) (bool, error) {
+	s, err := upgradeSnapshot(p.PipelineCache)
+	if err != nil {
+		return false, err
+	}
+	return s != nil && s.Datastore != "", nil
+}
+
+// RestoreUpgradeDatastore resets the datastore of the stopped cluster from the snapshot taken before the upgrade
+type RestoreUpgradeDatastore struct {
+	common.KubeAction
+}
+
+func (a *RestoreUpgradeDatastore) Execute(runtime connector.Runtime) error {
+	s, err := upgradeSnapshot(a.PipelineCache)
+	if err != nil {
+		return err
+	}
+	if s == nil || s.Datastore == "" {
+		return errors.New("no datastore saved before the upgrade by this run to restore")
+	}
+	logger.Infof("restoring the %s datastore of the cluster saved before the upgrade at %s", s.DatastoreType, filepath.Join(s.Dir, s.Datastore))
+	return datastore.Restore(runtime, s.DatastoreType, s.Dir, s.Datastore)
+}
+
+// RestoreUpgradeSnapshot rolls back what the upgrade changed from the snapshot taken before it:
+// the release file and the helm releases, the datastore is restored by RestoreUpgradeDatastore
+type RestoreUpgradeSnapshot struct {
+	common.KubeAction
+}
+
+func (a *RestoreUpgradeSnapshot) Execute(runtime connector.Runtime) error {
+	s, err := upgradeSnapshot(a.PipelineCache)
+	if err != nil {
+		return err
+	}
+	if s == nil {
+		logger.Infof("no snapshot taken before the upgrade to %s, nothing to restore", a.KubeConf.Arg.OlaresVersion)
+		return nil
+	}
+
+	logger.Infof("restoring the snapshot of %s taken before the upgrade", s.Dir)
+	if err := s.RestoreReleaseFile(); err != nil {
+		return err
+	}
+	config, err := ctrl.GetConfig()
+	if err != nil {
+		return errors.Wrap(err, "failed to get rest config")
+	}
+	return s.RollbackReleases(config)
+}
@@ -2,6 +2,7 @@ package upgrade
 
 import (
 	"bytetrade.io/web3os/installer/pkg/common"
+	"bytetrade.io/web3os/installer/pkg/core/cache"
 	"bytetrade.io/web3os/installer/pkg/core/connector"
 	"bytetrade.io/web3os/installer/pkg/core/logger"
 	"bytetrade.io/web3os/installer/pkg/utils"
@@ -20,6 +21,7 @@ import (
 
 type PrepareUserInfoForUpgrade struct {
 	common.KubeAction
+	cache.Filler
 }
 
 func (p *PrepareUserInfoForUpgrade) Execute(runtime connector.Runtime) error {
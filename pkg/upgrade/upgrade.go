@@ -4,9 +4,11 @@ import (
 	"time"
 
 	"bytetrade.io/web3os/installer/pkg/bootstrap/precheck"
+	"bytetrade.io/web3os/installer/pkg/certs"
 
 	"bytetrade.io/web3os/installer/pkg/common"
 	"bytetrade.io/web3os/installer/pkg/core/task"
+	"bytetrade.io/web3os/installer/pkg/datastore"
 	"bytetrade.io/web3os/installer/pkg/terminus"
 	"github.com/Masterminds/semver/v3"
 )
@@ -18,7 +20,16 @@ type UpgradeModule struct {
 }
 
 var (
-	preTasks []*upgradeTask
+	preTasks = []*upgradeTask{
+		{
+			Task: &task.LocalTask{
+				Name:   "SaveUpgradeSnapshot",
+				Action: new(SaveUpgradeSnapshot),
+			},
+			Current: anyVersion,
+			Target:  anyVersion,
+		},
+	}
 
 	coreTasks = []*upgradeTask{
 		{
@@ -90,10 +101,11 @@ var (
 		},
 		{
 			Task: &task.LocalTask{
-				Name:   "EnsurePodsUpAndRunningAgain",
-				Action: new(terminus.CheckKeyPodsRunning),
-				Delay:  15 * time.Second,
-				Retry:  60,
+				Name:      "EnsurePodsUpAndRunningAgain",
+				AlwaysRun: true,
+				Action:    new(terminus.CheckKeyPodsRunning),
+				Delay:     15 * time.Second,
+				Retry:     60,
 			},
 			Current: atLeasVersion112,
 			Target:  atLeasVersion112,
@@ -110,6 +122,57 @@ func (m *UpgradeModule) Init() {
 	tasks := m.calculateUpgradeTasks()
 
 	m.Tasks = tasks
+
+	// the snapshot restored is the one taken by SaveUpgradeSnapshot,
+	// the datastore first, the cluster being stopped meanwhile, then the release file and the helm releases,
+	// which are still rolled back if the datastore can't be restored, e.g., that of an etcd of several members
+	m.RollbackTasks = []task.Interface{
+		&task.LocalTask{
+			Name:    "StopOlaresd",
+			Prepare: new(SnapshotHasDatastore),
+			Action: &terminus.SystemctlCommand{
+				Command:   "stop",
+				UnitNames: []string{"olaresd"},
+			},
+		},
+		&task.LocalTask{
+			Name:    "StopCluster",
+			Prepare: new(SnapshotHasDatastore),
+			Action:  new(datastore.StopCluster),
+		},
+		&task.LocalTask{
+			Name:    "RestoreUpgradeDatastore",
+			Prepare: new(SnapshotHasDatastore),
+			Action:  new(RestoreUpgradeDatastore),
+		},
+		&task.LocalTask{
+			Name:    "StartCluster",
+			Prepare: new(SnapshotHasDatastore),
+			Action:  new(datastore.StartCluster),
+		},
+		&task.LocalTask{
+			Name:      "WaitKubeAPIServer",
+			AlwaysRun: true,
+			Prepare:   new(SnapshotHasDatastore),
+			Action:    new(certs.WaitKubeAPIServer),
+			Retry:     30,
+			Delay:     10 * time.Second,
+		},
+		&task.LocalTask{
+			Name:    "StartOlaresd",
+			Prepare: new(SnapshotHasDatastore),
+			Action: &terminus.SystemctlCommand{
+				Command:   "start",
+				UnitNames: []string{"olaresd"},
+			},
+		},
+		&task.LocalTask{
+			Name:   "RestoreUpgradeSnapshot",
+			Action: new(RestoreUpgradeSnapshot),
+			Retry:  3,
+			Delay:  15 * time.Second,
+		},
+	}
 }
 
 func (m *UpgradeModule) calculateUpgradeTasks() []task.Interface {
@@ -158,9 +221,11 @@ func (m *PrecheckModule) Init() {
 	checkers := []precheck.Checker{
 		new(precheck.MasterNodeReadyCheck),
 		new(precheck.RootPartitionAvailableSpaceCheck),
+		&precheck.CertsExpiryCheck{WarnDays: certs.ExpiryWarnDays},
 	}
 	runPreChecks := &task.LocalTask{
-		Name: "UpgradePrecheck",
+		Name:      "UpgradePrecheck",
+		AlwaysRun: true,
 		Action: &precheck.RunChecks{
 			Checkers: checkers,
 		},
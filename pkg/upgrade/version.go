@@ -48,7 +48,10 @@ func (m *explicitVersionMatcher) Match(version *semver.Version) bool {
 
 // todo: do we need to check at least 1.12 in cli?
 var anyVersion versionMatcher = &explicitVersionMatcher{}
-var atLeasVersion112 versionMatcher = &explicitVersionMatcher{min: semver.New(1, 12, 0, "1", "")}
+var atLeasVersion112 versionMatcher = &explicitVersionMatcher{min: minimumVersion}
+
+// minimumVersion is the oldest version that can be upgraded from
+var minimumVersion = semver.New(1, 12, 0, "1", "")
 
 type upgradeTask struct {
 	Task    task.Interface
@@ -12,6 +12,7 @@ import (
 
 	kubekeyapiv1alpha2 "bytetrade.io/web3os/installer/apis/kubekey/v1alpha2"
 	"bytetrade.io/web3os/installer/pkg/common"
+	"bytetrade.io/web3os/installer/pkg/core/cache"
 	cc "bytetrade.io/web3os/installer/pkg/core/common"
 	"bytetrade.io/web3os/installer/pkg/core/connector"
 	"bytetrade.io/web3os/installer/pkg/core/logger"
@@ -32,6 +33,7 @@ const (
 
 type DownloadAppxPackage struct {
 	common.KubeAction
+	cache.Filler
 }
 
 func (d *DownloadAppxPackage) Execute(runtime connector.Runtime) error {
@@ -90,6 +92,7 @@ func (i *InstallAppxPackage) Execute(runtime connector.Runtime) error {
 
 type DownloadWSLInstallPackage struct {
 	common.KubeAction
+	cache.Filler
 }
 
 func (d *DownloadWSLInstallPackage) Execute(runtime connector.Runtime) error {
@@ -597,6 +600,7 @@ func (r *RemovePortProxy) Execute(runtime connector.Runtime) error {
 
 type GetDiskPartition struct {
 	common.KubeAction
+	cache.Filler
 }
 
 func (g *GetDiskPartition) Execute(runtime connector.Runtime) error {